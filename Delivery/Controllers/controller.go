@@ -1,9 +1,18 @@
 package controllers
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
-	"task_manager/usecases"
+	"strconv"
+	"strings"
 	"task_manager/domain"
+	"task_manager/infrastructure"
+	"task_manager/usecases"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,198 +20,1367 @@ import (
 
 // UserDTO is a data transfer object for user information.
 type UserDTO struct {
-    ID       string `json:"id"`
-    Username string `json:"username"`
-    Email    string `json:"email"`
-    Password string `json:"password,omitempty"`
-    Role     string `json:"role"`
+	ID            string `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	Password      string `json:"password,omitempty"`
+	Role          string `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	Deactivated   bool   `json:"deactivated"`
+	ActiveOrgID   string `json:"active_org_id,omitempty"`
 }
 
 // todomainUser converts a UserDTO to a domain.User.
 func todomainUser(dto *UserDTO) *domain.User {
-    return &domain.User{
-        ID:       dto.ID,
-        Username: dto.Username,
-        Email:    dto.Email,
-        Password: dto.Password,
-        Role:     dto.Role,
-    }
+	return &domain.User{
+		ID:       dto.ID,
+		Username: dto.Username,
+		Email:    dto.Email,
+		Password: dto.Password,
+		Role:     dto.Role,
+	}
 }
 
 // toUserDTO converts a domain.User to a UserDTO.
 func toUserDTO(user *domain.User) *UserDTO {
-    return &UserDTO{
-        ID:       user.ID,
-        Username: user.Username,
-        Email:    user.Email,
-        Role:     user.Role,
-    }
+	return &UserDTO{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Role:          user.Role,
+		EmailVerified: user.EmailVerified,
+		Deactivated:   user.Deactivated,
+		ActiveOrgID:   user.ActiveOrgID,
+	}
+}
+
+// UpdateProfileRequest is the body of PATCH /me. Omitted fields are left
+// unchanged.
+type UpdateProfileRequest struct {
+	Username *string `json:"username"`
+	Email    *string `json:"email"`
+}
+
+// ChangePasswordRequest is the body of POST /me/password.
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
 }
 
 // TaskDTO is a data transfer object for task information.
 type TaskDTO struct {
-    ID          string    `json:"id"`
-    Title       string    `json:"title"`
-    Description string    `json:"description"`
-    DueDate     time.Time `json:"due_date"`
-    Status      string    `json:"status"`
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	DueDate     time.Time `json:"due_date"`
+	Status      string    `json:"status"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	AssigneeID  string    `json:"assignee_id,omitempty"`
+	OrgID       string    `json:"org_id,omitempty"`
+	ProjectID   string    `json:"project_id,omitempty"`
+	Version     int64     `json:"version"`
+	// DeletedAt and DeletedBy are only set on tasks in the trash.
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy string     `json:"deleted_by,omitempty"`
 }
 
 // todomainTask converts a TaskDTO to a domain.Task.
 func todomainTask(dto *TaskDTO) *domain.Task {
-    return &domain.Task{
-        ID:          dto.ID,
-        Title:       dto.Title,
-        Description: dto.Description,
-        DueDate:     dto.DueDate,
-        Status:      dto.Status,
-    }
+	return &domain.Task{
+		ID:          dto.ID,
+		Title:       dto.Title,
+		Description: dto.Description,
+		DueDate:     dto.DueDate,
+		Status:      dto.Status,
+		AssigneeID:  dto.AssigneeID,
+		ProjectID:   dto.ProjectID,
+	}
 }
 
 // toTaskDTO converts a domain.Task to a TaskDTO.
 func toTaskDTO(task *domain.Task) *TaskDTO {
-    return &TaskDTO{
-        ID:          task.ID,
-        Title:       task.Title,
-        Description: task.Description,
-        DueDate:     task.DueDate,
-        Status:      task.Status,
-    }
+	dto := &TaskDTO{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		DueDate:     task.DueDate,
+		Status:      task.Status,
+		CreatedBy:   task.CreatedBy,
+		AssigneeID:  task.AssigneeID,
+		OrgID:       task.OrgID,
+		ProjectID:   task.ProjectID,
+		Version:     task.Version,
+		DeletedBy:   task.DeletedBy,
+	}
+	if !task.DeletedAt.IsZero() {
+		deletedAt := task.DeletedAt
+		dto.DeletedAt = &deletedAt
+	}
+	return dto
+}
+
+// principal returns the authenticated caller stored by
+// infrastructure.AuthMiddleware. It is only nil on routes without the
+// middleware.
+func principal(c *gin.Context) *domain.Principal {
+	p, _ := domain.PrincipalFromContext(c.Request.Context())
+	return p
 }
 
 // UserController handles user-related HTTP requests.
 type UserController struct {
-    userUsecase *usecases.UserUsecase
+	userUsecase          *usecases.UserUsecase
+	passwordResetUsecase *usecases.PasswordResetUsecase
 }
 
 // NewUserController creates a new UserController.
-func NewUserController(userUsecase *usecases.UserUsecase) *UserController {
-    return &UserController{userUsecase: userUsecase}
+func NewUserController(userUsecase *usecases.UserUsecase, passwordResetUsecase *usecases.PasswordResetUsecase) *UserController {
+	return &UserController{userUsecase: userUsecase, passwordResetUsecase: passwordResetUsecase}
 }
 
 // RegisterUser handles user registration requests.
 func (ctrl *UserController) RegisterUser(c *gin.Context) {
-    var req UserDTO
-    if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
-        return
-    }
-    role, err := ctrl.userUsecase.RegisterUser(c.Request.Context(), req.Username, req.Email, req.Password)
-    if err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-        return
-    }
-    c.JSON(http.StatusOK, gin.H{"message": "User registered successfully", "role": role})
+	var req UserDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	user, err := ctrl.userUsecase.RegisterUser(c.Request.Context(), req.Username, req.Email, req.Password)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User registered successfully", "role": user.Role, "user": toUserDTO(user)})
 }
 
 // LoginUser handles user login requests.
 func (ctrl *UserController) LoginUser(c *gin.Context) {
-    var req UserDTO
-    if err := c.ShouldBindJSON(&req); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
-        return
-    }
-    usernameOrEmail := req.Email
-    if usernameOrEmail == "" {
-        usernameOrEmail = req.Username
-    }
-    token, role, err := ctrl.userUsecase.LoginUser(c.Request.Context(), usernameOrEmail, req.Password)
-    if err != nil {
-        c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-        return
-    }
-    c.JSON(http.StatusOK, gin.H{"message": "User logged in successfully", "token": token, "role": role})
+	var req UserDTO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	usernameOrEmail := req.Email
+	if usernameOrEmail == "" {
+		usernameOrEmail = req.Username
+	}
+	tokens, user, err := ctrl.userUsecase.LoginUser(c.Request.Context(), usernameOrEmail, req.Password)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "User logged in successfully",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"role":          user.Role,
+		"user":          toUserDTO(user),
+	})
+}
+
+// RefreshTokenRequest is the body of POST /token/refresh and POST /logout.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken exchanges a refresh token for a new access/refresh token pair.
+func (ctrl *UserController) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		infrastructure.WriteError(c, domain.NewValidationError("refresh_token", domain.ValidationRequired, "refresh_token is required"))
+		return
+	}
+	tokens, err := ctrl.userUsecase.RefreshTokens(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": tokens.AccessToken, "refresh_token": tokens.RefreshToken})
+}
+
+// SwitchOrganization makes the organization in the path the caller's active
+// one and returns a token pair scoped to it.
+func (ctrl *UserController) SwitchOrganization(c *gin.Context) {
+	tokens, err := ctrl.userUsecase.SwitchOrganization(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"token": tokens.AccessToken, "refresh_token": tokens.RefreshToken, "org_id": c.Param("id")})
+}
+
+// Logout revokes the access token used for the request and, if a
+// refresh_token is supplied, every refresh token issued alongside it.
+func (ctrl *UserController) Logout(c *gin.Context) {
+	var req RefreshTokenRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			infrastructure.WriteError(c, payloadError(err))
+			return
+		}
+	}
+	err := ctrl.userUsecase.Logout(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// ForgotPassword emails a password reset link. It answers the same way
+// whether or not the email belongs to an account.
+func (ctrl *UserController) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" {
+		infrastructure.WriteError(c, domain.NewValidationError("email", domain.ValidationRequired, "email is required"))
+		return
+	}
+	if err := ctrl.passwordResetUsecase.RequestPasswordReset(c.Request.Context(), req.Email); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "If an account exists for that email, a reset link has been sent"})
+}
+
+// ResetPassword sets a new password using the token from a reset email.
+func (ctrl *UserController) ResetPassword(c *gin.Context) {
+	var req struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	if err := ctrl.passwordResetUsecase.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset"})
+}
+
+// VerifyEmail confirms an email address using the token from a verification
+// email.
+func (ctrl *UserController) VerifyEmail(c *gin.Context) {
+	if err := ctrl.userUsecase.VerifyEmail(c.Request.Context(), c.Query("token")); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email address verified"})
+}
+
+// ResendVerification emails a new verification link. Like ForgotPassword, it
+// answers the same way whether or not the email belongs to an account.
+func (ctrl *UserController) ResendVerification(c *gin.Context) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" {
+		infrastructure.WriteError(c, domain.NewValidationError("email", domain.ValidationRequired, "email is required"))
+		return
+	}
+	if err := ctrl.userUsecase.ResendVerificationEmail(c.Request.Context(), req.Email); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "If an unverified account exists for that email, a verification link has been sent"})
+}
+
+// MarkEmailVerified lets an admin verify a user's email address.
+func (ctrl *UserController) MarkEmailVerified(c *gin.Context) {
+	if err := ctrl.userUsecase.MarkEmailVerified(c.Request.Context(), c.Param("id")); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email address verified"})
+}
+
+// GetMe returns the profile of the authenticated user.
+func (ctrl *UserController) GetMe(c *gin.Context) {
+	user, err := ctrl.userUsecase.GetProfile(c.Request.Context(), principal(c).UserID)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toUserDTO(user))
+}
+
+// UpdateMe changes the username and/or email of the authenticated user.
+func (ctrl *UserController) UpdateMe(c *gin.Context) {
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	user, err := ctrl.userUsecase.UpdateProfile(c.Request.Context(), principal(c).UserID, req.Username, req.Email)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toUserDTO(user))
+}
+
+// ChangePassword sets a new password for the authenticated user and returns a
+// fresh token pair; every other token of the user stops working.
+func (ctrl *UserController) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	tokens, err := ctrl.userUsecase.ChangePassword(c.Request.Context(), principal(c).UserID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Password changed",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+	})
 }
 
 // PromoteUser promotes a user to admin.
 func (ctrl *UserController) PromoteUser(c *gin.Context) {
-    var req struct {
-        Identifier string `json:"identifier"`
-    }
-    if err := c.ShouldBindJSON(&req); err != nil || req.Identifier == "" {
-        c.JSON(http.StatusBadRequest, gin.H{"error": "Username or email is required"})
-        return
-    }
-    err := ctrl.userUsecase.PromoteUserToAdmin(c.Request.Context(), req.Identifier)
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
-    c.JSON(http.StatusOK, gin.H{"message": "User promoted to admin"})
+	var req struct {
+		Identifier string `json:"identifier"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Identifier == "" {
+		infrastructure.WriteError(c, domain.NewValidationError("identifier", domain.ValidationRequired, "Username or email is required"))
+		return
+	}
+	if err := ctrl.userUsecase.PromoteUserToAdmin(c.Request.Context(), req.Identifier); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User promoted to admin"})
+}
+
+// AdminController handles the admin-only user management requests.
+type AdminController struct {
+	userAdminUsecase *usecases.UserAdminUsecase
+}
+
+// NewAdminController creates a new AdminController.
+func NewAdminController(userAdminUsecase *usecases.UserAdminUsecase) *AdminController {
+	return &AdminController{userAdminUsecase: userAdminUsecase}
+}
+
+// UserListDTO is the response envelope for a page of users.
+type UserListDTO struct {
+	Users      []UserDTO `json:"users"`
+	NextCursor string    `json:"next_cursor"`
+	Total      int64     `json:"total"`
+}
+
+// ListUsers returns a page of users. Supported query parameters are search
+// (part of a username or email), role, limit and cursor.
+func (ctrl *AdminController) ListUsers(c *gin.Context) {
+	query := domain.UserQuery{
+		Search: c.Query("search"),
+		Role:   c.Query("role"),
+		Cursor: c.Query("cursor"),
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			infrastructure.WriteError(c, domain.NewValidationError("limit", domain.ValidationInvalid, "limit must be an integer"))
+			return
+		}
+		query.Limit = limit
+	}
+	page, err := ctrl.userAdminUsecase.ListUsers(c.Request.Context(), query)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	dtos := make([]UserDTO, 0, len(page.Users))
+	for _, u := range page.Users {
+		dtos = append(dtos, *toUserDTO(&u))
+	}
+	c.JSON(http.StatusOK, UserListDTO{Users: dtos, NextCursor: page.NextCursor, Total: page.Total})
+}
+
+// PromoteUser makes the user with the given ID an admin.
+func (ctrl *AdminController) PromoteUser(c *gin.Context) {
+	if err := ctrl.userAdminUsecase.PromoteUser(c.Request.Context(), c.Param("id")); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User promoted to admin"})
+}
+
+// DemoteUser turns an admin back into a regular user.
+func (ctrl *AdminController) DemoteUser(c *gin.Context) {
+	if err := ctrl.userAdminUsecase.DemoteUser(c.Request.Context(), c.Param("id")); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User demoted to user"})
+}
+
+// SetRoleRequest is the body of PUT /users/:id/role.
+type SetRoleRequest struct {
+	Role string `json:"role"`
+}
+
+// SetUserRole gives a user a built-in or custom role.
+func (ctrl *AdminController) SetUserRole(c *gin.Context) {
+	var req SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Role == "" {
+		infrastructure.WriteError(c, domain.NewValidationError("role", domain.ValidationRequired, "Role is required"))
+		return
+	}
+	if err := ctrl.userAdminUsecase.SetUserRole(c.Request.Context(), c.Param("id"), req.Role); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User role updated", "role": req.Role})
+}
+
+// DeactivateUser blocks a user from logging in.
+func (ctrl *AdminController) DeactivateUser(c *gin.Context) {
+	if err := ctrl.userAdminUsecase.DeactivateUser(c.Request.Context(), c.Param("id")); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User deactivated"})
+}
+
+// ReactivateUser lets a deactivated user log in again.
+func (ctrl *AdminController) ReactivateUser(c *gin.Context) {
+	if err := ctrl.userAdminUsecase.ReactivateUser(c.Request.Context(), c.Param("id")); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User reactivated"})
+}
+
+// DeleteUser deletes a user. The tasks query parameter chooses what happens
+// to the tasks the user created: reassign (to the calling admin, the
+// default) or delete.
+func (ctrl *AdminController) DeleteUser(c *gin.Context) {
+	err := ctrl.userAdminUsecase.DeleteUser(c.Request.Context(), c.Param("id"), c.Query("tasks"))
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// RoleDTO is a data transfer object for role definitions.
+type RoleDTO struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	BuiltIn     bool     `json:"built_in"`
+}
+
+func toRoleDTO(role *domain.Role) *RoleDTO {
+	return &RoleDTO{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+		BuiltIn:     role.BuiltIn,
+	}
+}
+
+// RoleController handles the role management requests.
+type RoleController struct {
+	roleUsecase *usecases.RoleUsecase
+}
+
+// NewRoleController creates a new RoleController.
+func NewRoleController(roleUsecase *usecases.RoleUsecase) *RoleController {
+	return &RoleController{roleUsecase: roleUsecase}
+}
+
+// ListRoles returns the built-in and custom roles.
+func (ctrl *RoleController) ListRoles(c *gin.Context) {
+	roles, err := ctrl.roleUsecase.ListRoles(c.Request.Context())
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	dtos := make([]RoleDTO, 0, len(roles))
+	for _, r := range roles {
+		dtos = append(dtos, *toRoleDTO(&r))
+	}
+	c.JSON(http.StatusOK, gin.H{"roles": dtos, "permissions": domain.AllPermissions})
+}
+
+// GetRole returns a single role by name.
+func (ctrl *RoleController) GetRole(c *gin.Context) {
+	role, err := ctrl.roleUsecase.GetRole(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toRoleDTO(role))
+}
+
+// CreateRole defines a custom role.
+func (ctrl *RoleController) CreateRole(c *gin.Context) {
+	var dto RoleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	role := &domain.Role{Name: dto.Name, Description: dto.Description, Permissions: dto.Permissions}
+	if err := ctrl.roleUsecase.CreateRole(c.Request.Context(), role); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toRoleDTO(role))
+}
+
+// UpdateRole replaces the description and permissions of a custom role.
+func (ctrl *RoleController) UpdateRole(c *gin.Context) {
+	var dto RoleDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	role := &domain.Role{Name: c.Param("name"), Description: dto.Description, Permissions: dto.Permissions}
+	if err := ctrl.roleUsecase.UpdateRole(c.Request.Context(), role); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toRoleDTO(role))
+}
+
+// DeleteRole removes a custom role that no user holds.
+func (ctrl *RoleController) DeleteRole(c *gin.Context) {
+	if err := ctrl.roleUsecase.DeleteRole(c.Request.Context(), c.Param("name")); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role deleted"})
+}
+
+// OrganizationDTO is a data transfer object for an organization and the
+// caller's role in it.
+type OrganizationDTO struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+	Role      string    `json:"role,omitempty"`
+}
+
+func toOrganizationDTO(org *domain.Organization, role string) *OrganizationDTO {
+	return &OrganizationDTO{
+		ID:        org.ID,
+		Name:      org.Name,
+		CreatedBy: org.CreatedBy,
+		CreatedAt: org.CreatedAt,
+		Role:      role,
+	}
+}
+
+// MembershipDTO is a data transfer object for an organization member.
+type MembershipDTO struct {
+	OrgID     string    `json:"org_id"`
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toMembershipDTO(membership *domain.Membership) *MembershipDTO {
+	return &MembershipDTO{
+		OrgID:     membership.OrgID,
+		UserID:    membership.UserID,
+		Role:      membership.Role,
+		CreatedAt: membership.CreatedAt,
+	}
+}
+
+// AddMemberRequest is the body of POST /orgs/:id/members.
+type AddMemberRequest struct {
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// OrganizationController handles organization and membership requests.
+type OrganizationController struct {
+	orgUsecase *usecases.OrganizationUsecase
+}
+
+// NewOrganizationController creates a new OrganizationController.
+func NewOrganizationController(orgUsecase *usecases.OrganizationUsecase) *OrganizationController {
+	return &OrganizationController{orgUsecase: orgUsecase}
+}
+
+// ListOrganizations returns the organizations the caller belongs to.
+func (ctrl *OrganizationController) ListOrganizations(c *gin.Context) {
+	orgs, err := ctrl.orgUsecase.ListOrganizations(c.Request.Context())
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	dtos := make([]OrganizationDTO, 0, len(orgs))
+	for _, o := range orgs {
+		dtos = append(dtos, *toOrganizationDTO(&o.Organization, o.Role))
+	}
+	c.JSON(http.StatusOK, gin.H{"organizations": dtos})
+}
+
+// CreateOrganization creates an organization owned by the caller.
+func (ctrl *OrganizationController) CreateOrganization(c *gin.Context) {
+	var dto OrganizationDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	org, err := ctrl.orgUsecase.CreateOrganization(c.Request.Context(), dto.Name)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toOrganizationDTO(org, domain.OrgRoleOwner))
+}
+
+// GetOrganization returns an organization the caller belongs to.
+func (ctrl *OrganizationController) GetOrganization(c *gin.Context) {
+	org, err := ctrl.orgUsecase.GetOrganization(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toOrganizationDTO(&org.Organization, org.Role))
+}
+
+// ListMembers returns the members of an organization.
+func (ctrl *OrganizationController) ListMembers(c *gin.Context) {
+	members, err := ctrl.orgUsecase.ListMembers(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	dtos := make([]MembershipDTO, 0, len(members))
+	for _, m := range members {
+		dtos = append(dtos, *toMembershipDTO(&m))
+	}
+	c.JSON(http.StatusOK, gin.H{"members": dtos})
+}
+
+// AddMember adds a user to an organization.
+func (ctrl *OrganizationController) AddMember(c *gin.Context) {
+	var req AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	if req.Role == "" {
+		req.Role = domain.OrgRoleMember
+	}
+	membership, err := ctrl.orgUsecase.AddMember(c.Request.Context(), c.Param("id"), req.UserID, req.Role)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toMembershipDTO(membership))
+}
+
+// UpdateMemberRole changes a member's organization role.
+func (ctrl *OrganizationController) UpdateMemberRole(c *gin.Context) {
+	var req SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	if err := ctrl.orgUsecase.UpdateMemberRole(c.Request.Context(), c.Param("id"), c.Param("userId"), req.Role); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member role updated"})
+}
+
+// RemoveMember removes a user from an organization.
+func (ctrl *OrganizationController) RemoveMember(c *gin.Context) {
+	if err := ctrl.orgUsecase.RemoveMember(c.Request.Context(), c.Param("id"), c.Param("userId")); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// ProjectDTO is a data transfer object for project information.
+type ProjectDTO struct {
+	ID          string    `json:"id"`
+	OrgID       string    `json:"org_id,omitempty"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	Archived    bool      `json:"archived"`
+}
+
+func toProjectDTO(project *domain.Project) *ProjectDTO {
+	return &ProjectDTO{
+		ID:          project.ID,
+		OrgID:       project.OrgID,
+		Name:        project.Name,
+		Description: project.Description,
+		CreatedBy:   project.CreatedBy,
+		CreatedAt:   project.CreatedAt,
+		Archived:    project.Archived,
+	}
+}
+
+// ProjectMemberDTO is a data transfer object for a project member.
+type ProjectMemberDTO struct {
+	ProjectID string    `json:"project_id"`
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toProjectMemberDTO(member *domain.ProjectMember) *ProjectMemberDTO {
+	return &ProjectMemberDTO{
+		ProjectID: member.ProjectID,
+		UserID:    member.UserID,
+		Role:      member.Role,
+		CreatedAt: member.CreatedAt,
+	}
+}
+
+// ProjectController handles project and project membership requests.
+type ProjectController struct {
+	projectUsecase *usecases.ProjectUsecase
+	taskUsecase    *usecases.TaskUsecase
+}
+
+// NewProjectController creates a new ProjectController.
+func NewProjectController(projectUsecase *usecases.ProjectUsecase, taskUsecase *usecases.TaskUsecase) *ProjectController {
+	return &ProjectController{projectUsecase: projectUsecase, taskUsecase: taskUsecase}
+}
+
+// ListProjects returns the projects the caller can see. Archived projects
+// are included with include_archived=true.
+func (ctrl *ProjectController) ListProjects(c *gin.Context) {
+	includeArchived, _ := strconv.ParseBool(c.Query("include_archived"))
+	projects, err := ctrl.projectUsecase.ListProjects(c.Request.Context(), includeArchived)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	dtos := make([]ProjectDTO, 0, len(projects))
+	for _, p := range projects {
+		dtos = append(dtos, *toProjectDTO(&p))
+	}
+	c.JSON(http.StatusOK, gin.H{"projects": dtos})
+}
+
+// CreateProject creates a project managed by the caller.
+func (ctrl *ProjectController) CreateProject(c *gin.Context) {
+	var dto ProjectDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	project := &domain.Project{ID: dto.ID, Name: dto.Name, Description: dto.Description}
+	if err := ctrl.projectUsecase.CreateProject(c.Request.Context(), project); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.Header("Location", "/projects/"+project.ID)
+	c.JSON(http.StatusCreated, toProjectDTO(project))
+}
+
+// GetProject returns a project by ID.
+func (ctrl *ProjectController) GetProject(c *gin.Context) {
+	project, err := ctrl.projectUsecase.GetProject(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toProjectDTO(project))
+}
+
+// UpdateProject changes a project's name and description.
+func (ctrl *ProjectController) UpdateProject(c *gin.Context) {
+	var dto ProjectDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	project := &domain.Project{ID: c.Param("id"), Name: dto.Name, Description: dto.Description}
+	if err := ctrl.projectUsecase.UpdateProject(c.Request.Context(), project); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toProjectDTO(project))
+}
+
+// ArchiveProject archives a project.
+func (ctrl *ProjectController) ArchiveProject(c *gin.Context) {
+	ctrl.setArchived(c, true)
+}
+
+// UnarchiveProject restores an archived project.
+func (ctrl *ProjectController) UnarchiveProject(c *gin.Context) {
+	ctrl.setArchived(c, false)
+}
+
+func (ctrl *ProjectController) setArchived(c *gin.Context, archived bool) {
+	project, err := ctrl.projectUsecase.SetProjectArchived(c.Request.Context(), c.Param("id"), archived)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toProjectDTO(project))
+}
+
+// DeleteProject removes a project without tasks.
+func (ctrl *ProjectController) DeleteProject(c *gin.Context) {
+	if err := ctrl.projectUsecase.DeleteProject(c.Request.Context(), c.Param("id")); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Project deleted"})
+}
+
+// GetProjectTasks lists the tasks of a project. It takes the same query
+// parameters as GET /tasks.
+func (ctrl *ProjectController) GetProjectTasks(c *gin.Context) {
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	page, err := ctrl.taskUsecase.ListProjectTasks(c.Request.Context(), c.Param("id"), query)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	dtos := make([]TaskDTO, 0, len(page.Tasks))
+	for _, t := range page.Tasks {
+		dtos = append(dtos, *toTaskDTO(&t))
+	}
+	c.JSON(http.StatusOK, TaskListDTO{Tasks: dtos, NextCursor: page.NextCursor, Total: page.Total})
+}
+
+// ListMembers returns the members of a project.
+func (ctrl *ProjectController) ListMembers(c *gin.Context) {
+	members, err := ctrl.projectUsecase.ListMembers(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	dtos := make([]ProjectMemberDTO, 0, len(members))
+	for _, m := range members {
+		dtos = append(dtos, *toProjectMemberDTO(&m))
+	}
+	c.JSON(http.StatusOK, gin.H{"members": dtos})
+}
+
+// AddMember adds a user to a project.
+func (ctrl *ProjectController) AddMember(c *gin.Context) {
+	var req AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	if req.Role == "" {
+		req.Role = domain.ProjectRoleEditor
+	}
+	member, err := ctrl.projectUsecase.AddMember(c.Request.Context(), c.Param("id"), req.UserID, req.Role)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, toProjectMemberDTO(member))
+}
+
+// UpdateMemberRole changes a member's project role.
+func (ctrl *ProjectController) UpdateMemberRole(c *gin.Context) {
+	var req SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	if err := ctrl.projectUsecase.UpdateMemberRole(c.Request.Context(), c.Param("id"), c.Param("userId"), req.Role); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member role updated"})
+}
+
+// RemoveMember removes a user from a project.
+func (ctrl *ProjectController) RemoveMember(c *gin.Context) {
+	if err := ctrl.projectUsecase.RemoveMember(c.Request.Context(), c.Param("id"), c.Param("userId")); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
 }
 
 // TaskController handles task-related HTTP requests.
 type TaskController struct {
-    taskUsecase *usecases.TaskUsecase
+	taskUsecase *usecases.TaskUsecase
 }
 
 // NewTaskController creates a new TaskController.
 func NewTaskController(taskUsecase *usecases.TaskUsecase) *TaskController {
-    return &TaskController{taskUsecase: taskUsecase}
+	return &TaskController{taskUsecase: taskUsecase}
+}
+
+// TaskListDTO is the response envelope for a page of tasks.
+type TaskListDTO struct {
+	Tasks      []TaskDTO `json:"tasks"`
+	NextCursor string    `json:"next_cursor"`
+	Total      int64     `json:"total"`
 }
 
-// GetTasks returns all tasks.
+// GetTasks returns a page of tasks. Supported query parameters are status,
+// due_after, due_before (RFC 3339), title_prefix, sort (id, title, due_date,
+// status), order (asc or desc), limit and cursor.
 func (ctrl *TaskController) GetTasks(c *gin.Context) {
-    tasks, err := ctrl.taskUsecase.GetAllTasks(c.Request.Context())
-    if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
-    var dtos []TaskDTO
-    for _, t := range tasks {
-        dtos = append(dtos, *toTaskDTO(&t))
-    }
-    c.JSON(http.StatusOK, dtos)
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	page, err := ctrl.taskUsecase.GetAllTasks(c.Request.Context(), query)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	dtos := make([]TaskDTO, 0, len(page.Tasks))
+	for _, t := range page.Tasks {
+		dtos = append(dtos, *toTaskDTO(&t))
+	}
+	c.JSON(http.StatusOK, TaskListDTO{Tasks: dtos, NextCursor: page.NextCursor, Total: page.Total})
+}
+
+// parseTaskQuery reads the listing parameters of GET /tasks.
+func parseTaskQuery(c *gin.Context) (domain.TaskQuery, error) {
+	query := domain.TaskQuery{
+		Status:      c.Query("status"),
+		TitlePrefix: c.Query("title_prefix"),
+		SortBy:      c.Query("sort"),
+		Cursor:      c.Query("cursor"),
+		ProjectID:   c.Query("project_id"),
+	}
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		query.SortDesc = true
+	default:
+		return query, domain.NewValidationError("order", domain.ValidationInvalid, "order must be asc or desc")
+	}
+	if v := c.Query("include_archived"); v != "" {
+		includeArchived, err := strconv.ParseBool(v)
+		if err != nil {
+			return query, domain.NewValidationError("include_archived", domain.ValidationInvalid, "include_archived must be true or false")
+		}
+		query.IncludeArchived = includeArchived
+	}
+	if v := c.Query("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return query, domain.NewValidationError("limit", domain.ValidationInvalid, "limit must be an integer")
+		}
+		query.Limit = limit
+	}
+	if v := c.Query("due_after"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return query, domain.NewValidationError("due_after", domain.ValidationInvalid, "due_after must be an RFC 3339 timestamp")
+		}
+		query.DueAfter = t
+	}
+	if v := c.Query("due_before"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return query, domain.NewValidationError("due_before", domain.ValidationInvalid, "due_before must be an RFC 3339 timestamp")
+		}
+		query.DueBefore = t
+	}
+	return query, nil
 }
 
 // GetTask returns a task by ID.
 func (ctrl *TaskController) GetTask(c *gin.Context) {
-    id := c.Param("id")
-    task, err := ctrl.taskUsecase.GetTaskByID(c.Request.Context(), id)
-    if err != nil {
-        c.JSON(http.StatusNotFound, gin.H{"message": "task not found"})
-        return
-    }
-    c.JSON(http.StatusOK, toTaskDTO(task))
+	id := c.Param("id")
+	task, err := ctrl.taskUsecase.GetTaskByID(c.Request.Context(), id)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	setTaskETag(c, task)
+	c.JSON(http.StatusOK, toTaskDTO(task))
+}
+
+// setTaskETag sets the ETag header to the task's version.
+func setTaskETag(c *gin.Context, task *domain.Task) {
+	c.Header("ETag", `"`+strconv.FormatInt(task.Version, 10)+`"`)
+}
+
+var errInvalidIfMatch = domain.NewValidationError("If-Match", domain.ValidationInvalid, `If-Match must be a single ETag such as "3"`)
+
+// ifMatchVersion returns the task version named by the If-Match header, or
+// zero when the header is missing or "*". A weak ETag (W/"3") names the
+// same version, since proxies may weaken the ETags they pass on.
+func ifMatchVersion(c *gin.Context) (int64, error) {
+	v := strings.TrimSpace(c.GetHeader("If-Match"))
+	if v == "" || v == "*" {
+		return 0, nil
+	}
+	v = strings.TrimPrefix(v, "W/")
+	if len(v) < 2 || v[0] != '"' || v[len(v)-1] != '"' {
+		return 0, errInvalidIfMatch
+	}
+	version, err := strconv.ParseInt(v[1:len(v)-1], 10, 64)
+	if err != nil || version < 0 {
+		return 0, errInvalidIfMatch
+	}
+	return version, nil
 }
 
-// AddTask adds a new task.
+// AddTask adds a new task. The ID is assigned by the server and returned in
+// the response body and the Location header.
 func (ctrl *TaskController) AddTask(c *gin.Context) {
-    var dto TaskDTO
-    if err := c.ShouldBindJSON(&dto); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-        return
-    }
-    task := todomainTask(&dto)
-    if err := ctrl.taskUsecase.Create(c.Request.Context(), task); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
-    c.JSON(http.StatusCreated, gin.H{"message": "Task created"})
-}
-
-// UpdateTask updates an existing task.
+	var dto TaskDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	if dto.ID != "" {
+		infrastructure.WriteError(c, domain.NewValidationError("id", domain.ValidationReadOnly, "id must not be provided when creating a task"))
+		return
+	}
+	task := todomainTask(&dto)
+	if err := ctrl.taskUsecase.Create(c.Request.Context(), task); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.Header("Location", "/tasks/"+task.ID)
+	setTaskETag(c, task)
+	c.JSON(http.StatusCreated, toTaskDTO(task))
+}
+
+// UpdateTask replaces the task identified by the :id path parameter and
+// returns it. With an If-Match header the update only happens if the task is
+// still at that version.
 func (ctrl *TaskController) UpdateTask(c *gin.Context) {
-    var dto TaskDTO
-    if err := c.ShouldBindJSON(&dto); err != nil {
-        c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-        return
-    }
-    task := todomainTask(&dto)
-    if err := ctrl.taskUsecase.UpdateTask(c.Request.Context(), task); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-        return
-    }
-    c.JSON(http.StatusOK, gin.H{"message": "Task updated"})
-}
-
-// RemoveTask deletes a task by ID.
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	var dto TaskDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	task := todomainTask(&dto)
+	task.ID = c.Param("id")
+	task.Version = version
+	if err := ctrl.taskUsecase.UpdateTask(c.Request.Context(), task); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	setTaskETag(c, task)
+	c.JSON(http.StatusOK, toTaskDTO(task))
+}
+
+// taskPatchDocument is the JSON document PATCH requests are applied to.
+// id, created_by, org_id and version are read-only.
+type taskPatchDocument struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	DueDate     time.Time `json:"due_date"`
+	Status      string    `json:"status"`
+	CreatedBy   string    `json:"created_by"`
+	AssigneeID  string    `json:"assignee_id,omitempty"`
+	OrgID       string    `json:"org_id"`
+	ProjectID   string    `json:"project_id,omitempty"`
+	Version     int64     `json:"version"`
+}
+
+func toTaskPatchDocument(task *domain.Task) *taskPatchDocument {
+	return &taskPatchDocument{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		DueDate:     task.DueDate,
+		Status:      task.Status,
+		CreatedBy:   task.CreatedBy,
+		AssigneeID:  task.AssigneeID,
+		OrgID:       task.OrgID,
+		ProjectID:   task.ProjectID,
+		Version:     task.Version,
+	}
+}
+
+// diff returns the changes from before to doc as a domain.TaskPatch. Removed
+// fields come back empty and are then rejected or cleared by the usecase.
+func (doc *taskPatchDocument) diff(before *taskPatchDocument) (domain.TaskPatch, error) {
+	var patch domain.TaskPatch
+	var errs domain.ValidationErrors
+	if doc.ID != before.ID {
+		errs.Add("id", domain.ValidationReadOnly, "id cannot be changed")
+	}
+	if doc.CreatedBy != before.CreatedBy {
+		errs.Add("created_by", domain.ValidationReadOnly, "created_by cannot be changed")
+	}
+	if doc.OrgID != before.OrgID {
+		errs.Add("org_id", domain.ValidationReadOnly, "org_id cannot be changed")
+	}
+	if doc.Version != before.Version {
+		errs.Add("version", domain.ValidationReadOnly, "version cannot be changed")
+	}
+	if err := errs.Err(); err != nil {
+		return patch, err
+	}
+	if doc.Title != before.Title {
+		patch.Title = &doc.Title
+	}
+	if doc.Description != before.Description {
+		patch.Description = &doc.Description
+	}
+	if !doc.DueDate.Equal(before.DueDate) {
+		patch.DueDate = &doc.DueDate
+	}
+	if doc.Status != before.Status {
+		patch.Status = &doc.Status
+	}
+	if doc.AssigneeID != before.AssigneeID {
+		patch.AssigneeID = &doc.AssigneeID
+	}
+	if doc.ProjectID != before.ProjectID {
+		patch.ProjectID = &doc.ProjectID
+	}
+	return patch, nil
+}
+
+// PatchTask partially updates the task identified by the :id path
+// parameter. The body is a JSON Merge Patch (RFC 7396) or, with the
+// application/json-patch+json content type, a JSON Patch (RFC 6902) applied
+// to the task as GetTask returns it. It honours If-Match like UpdateTask.
+func (ctrl *TaskController) PatchTask(c *gin.Context) {
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	var apply func(doc, patch []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	switch mediaType {
+	case "application/json-patch+json":
+		apply = infrastructure.ApplyJSONPatch
+	case "application/merge-patch+json", "application/json", "":
+		apply = infrastructure.ApplyMergePatch
+	default:
+		infrastructure.WriteProblem(c, infrastructure.NewProblem(http.StatusUnsupportedMediaType, "unsupported_media_type", "content type must be application/merge-patch+json or application/json-patch+json"))
+		return
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+
+	id := c.Param("id")
+	current, err := ctrl.taskUsecase.GetTaskByID(c.Request.Context(), id)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	if version != 0 && version != current.Version {
+		infrastructure.WriteError(c, domain.ErrVersionConflict)
+		return
+	}
+	before := toTaskPatchDocument(current)
+	doc, err := json.Marshal(before)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	patched, err := apply(doc, body)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	var after taskPatchDocument
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&after); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	patch, err := after.diff(before)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+
+	task, err := ctrl.taskUsecase.PatchTask(c.Request.Context(), id, current.Version, patch)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	setTaskETag(c, task)
+	c.JSON(http.StatusOK, toTaskDTO(task))
+}
+
+// TransitionRequest is the body of POST /tasks/:id/transition.
+type TransitionRequest struct {
+	Status string `json:"status"`
+}
+
+// TransitionTask moves the task identified by the :id path parameter to
+// another status of the workflow.
+func (ctrl *TaskController) TransitionTask(c *gin.Context) {
+	var req TransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		infrastructure.WriteError(c, payloadError(err))
+		return
+	}
+	task, err := ctrl.taskUsecase.TransitionTask(c.Request.Context(), c.Param("id"), req.Status)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	setTaskETag(c, task)
+	c.JSON(http.StatusOK, toTaskDTO(task))
+}
+
+// TaskHistoryEntryDTO is one recorded change of a task.
+type TaskHistoryEntryDTO struct {
+	ID        string          `json:"id"`
+	TaskID    string          `json:"task_id"`
+	Action    string          `json:"action"`
+	ActorID   string          `json:"actor_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Changes   []TaskChangeDTO `json:"changes"`
+}
+
+// TaskChangeDTO is the before and after value of one task field.
+type TaskChangeDTO struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+func toTaskHistoryEntryDTO(entry *domain.TaskHistoryEntry) *TaskHistoryEntryDTO {
+	dto := &TaskHistoryEntryDTO{
+		ID:        entry.ID,
+		TaskID:    entry.TaskID,
+		Action:    entry.Action,
+		ActorID:   entry.ActorID,
+		CreatedAt: entry.CreatedAt,
+		Changes:   make([]TaskChangeDTO, 0, len(entry.Changes)),
+	}
+	for _, c := range entry.Changes {
+		dto.Changes = append(dto.Changes, TaskChangeDTO{Field: c.Field, Before: c.Before, After: c.After})
+	}
+	return dto
+}
+
+// GetTaskHistory returns every recorded change of a task, oldest first.
+func (ctrl *TaskController) GetTaskHistory(c *gin.Context) {
+	entries, err := ctrl.taskUsecase.GetTaskHistory(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	dtos := make([]TaskHistoryEntryDTO, 0, len(entries))
+	for i := range entries {
+		dtos = append(dtos, *toTaskHistoryEntryDTO(&entries[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"history": dtos})
+}
+
+// WorkflowDTO describes the task status workflow.
+type WorkflowDTO struct {
+	States      []string        `json:"states"`
+	Transitions []TransitionDTO `json:"transitions"`
+}
+
+// TransitionDTO is one allowed status change. An empty roles list means any
+// role may perform it.
+type TransitionDTO struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Roles []string `json:"roles"`
+}
+
+// GetWorkflow returns the task status workflow.
+func (ctrl *TaskController) GetWorkflow(c *gin.Context) {
+	workflow := ctrl.taskUsecase.Workflow()
+	dto := WorkflowDTO{States: workflow.States, Transitions: make([]TransitionDTO, 0, len(workflow.Transitions))}
+	for _, t := range workflow.Transitions {
+		roles := t.Roles
+		if roles == nil {
+			roles = []string{}
+		}
+		dto.Transitions = append(dto.Transitions, TransitionDTO{From: t.From, To: t.To, Roles: roles})
+	}
+	c.JSON(http.StatusOK, dto)
+}
+
+// RemoveTask moves a task to the trash. It honours If-Match like UpdateTask.
 func (ctrl *TaskController) RemoveTask(c *gin.Context) {
-    id := c.Param("id")
-    if err := ctrl.taskUsecase.DeleteTask(c.Request.Context(), id); err != nil {
-        c.JSON(http.StatusNotFound, gin.H{"message": "task not found"})
-        return
-    }
-    c.JSON(http.StatusOK, gin.H{"message": "Task removed"})
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	id := c.Param("id")
+	if err := ctrl.taskUsecase.DeleteTask(c.Request.Context(), id, version); err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Task removed"})
 }
 
+// GetTrash returns a page of the tasks in the trash. It takes the same query
+// parameters as GetTasks.
+func (ctrl *TaskController) GetTrash(c *gin.Context) {
+	query, err := parseTaskQuery(c)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	page, err := ctrl.taskUsecase.ListDeletedTasks(c.Request.Context(), query)
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	dtos := make([]TaskDTO, 0, len(page.Tasks))
+	for _, t := range page.Tasks {
+		dtos = append(dtos, *toTaskDTO(&t))
+	}
+	c.JSON(http.StatusOK, TaskListDTO{Tasks: dtos, NextCursor: page.NextCursor, Total: page.Total})
+}
+
+// RestoreTask takes the task identified by the :id path parameter out of
+// the trash.
+func (ctrl *TaskController) RestoreTask(c *gin.Context) {
+	task, err := ctrl.taskUsecase.RestoreTask(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		infrastructure.WriteError(c, err)
+		return
+	}
+	setTaskETag(c, task)
+	c.JSON(http.StatusOK, toTaskDTO(task))
+}
+
+// errInvalidPayload is returned for request bodies that cannot be decoded.
+var errInvalidPayload = domain.NewError(domain.ErrValidation, "invalid_payload", "Invalid request payload")
+
+// payloadError describes a request body that could not be decoded, naming
+// the offending field when the decoder reports one.
+func payloadError(err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return domain.NewValidationError(typeErr.Field, domain.ValidationInvalid, typeErr.Field+" has the wrong type")
+	}
+	return fmt.Errorf("%w: %v", errInvalidPayload, err)
+}
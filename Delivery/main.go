@@ -2,12 +2,16 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	controllers "task_manager/delivery/controllers"
 	routers "task_manager/delivery/routers"
+	domain "task_manager/domain"
 	infrastructure "task_manager/infrastructure"
 	repositories "task_manager/repositories"
 	usecases "task_manager/usecases"
@@ -20,41 +24,326 @@ import (
 func main() {
 	_ = godotenv.Load()
 
-	uri := os.Getenv("MONGODB_URI")
-	if uri == "" {
-		uri = "mongodb://localhost:27017"
+	// "migrate" applies the storage migrations and exits, for deployments
+	// that run them as a separate step with AUTO_MIGRATE=false.
+	migrateOnly := false
+	if len(os.Args) > 1 {
+		if os.Args[1] != "migrate" {
+			log.Fatalf("Unknown command %q; the only command is migrate", os.Args[1])
+		}
+		migrateOnly = true
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	autoMigrate := true
+	if v := os.Getenv("AUTO_MIGRATE"); v != "" && !migrateOnly {
+		var err error
+		if autoMigrate, err = strconv.ParseBool(v); err != nil {
+			log.Fatalf("Invalid AUTO_MIGRATE %q: %v", v, err)
+		}
+	}
+
+	store, err := newStorage(autoMigrate)
 	if err != nil {
-		log.Fatalf("Failed to connect to MongoDB: %v", err)
+		log.Fatalf("Failed to set up storage: %v", err)
 	}
-	if err := client.Ping(ctx, nil); err != nil {
-		log.Fatalf("Failed to ping MongoDB: %v", err)
+	if migrateOnly {
+		log.Print("Migrations are up to date")
+		return
 	}
-
-	// Repositories (pass only client)
-	userRepo := repositories.NewUserRepository(client)
-	taskRepo := repositories.NewTaskRepository(client)
+	userRepo := store.users
+	taskRepo := store.tasks
+	taskHistoryRepo := store.taskHistory
+	tokenRepo := store.tokens
+	passwordResetRepo := store.passwordResets
+	roleRepo := store.roles
+	orgRepo := store.organizations
+	projectRepo := store.projects
 
 	// Services
 	passwordService := infrastructure.NewPasswordService()
-	 jwtSecret := []byte(os.Getenv("JWT_SECRET"))
-   if len(jwtSecret) == 0 {
-       log.Fatal("JWT_SECRET is not set in environment")
-   }
+	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
+	if len(jwtSecret) == 0 {
+		log.Fatal("JWT_SECRET is not set in environment")
+	}
 	jwtService := infrastructure.NewJWTService(string(jwtSecret))
+	mailSender, err := newMailSender()
+	if err != nil {
+		log.Fatalf("Failed to configure mail sender: %v", err)
+	}
+	passwordResetURL := os.Getenv("PASSWORD_RESET_URL")
+	if passwordResetURL == "" {
+		passwordResetURL = "http://localhost:8080/password/reset"
+	}
+	verifyEmailURL := os.Getenv("VERIFY_EMAIL_URL")
+	if verifyEmailURL == "" {
+		verifyEmailURL = "http://localhost:8080/verify"
+	}
+	requireEmailVerification := false
+	if v := os.Getenv("REQUIRE_EMAIL_VERIFICATION"); v != "" {
+		if requireEmailVerification, err = strconv.ParseBool(v); err != nil {
+			log.Fatalf("Invalid REQUIRE_EMAIL_VERIFICATION %q: %v", v, err)
+		}
+	}
+
+	workflow := domain.DefaultWorkflow()
+	if path := os.Getenv("WORKFLOW_FILE"); path != "" {
+		if workflow, err = infrastructure.LoadWorkflowFile(path); err != nil {
+			log.Fatalf("Failed to load workflow from %s: %v", path, err)
+		}
+	}
 
 	// Usecases
-	userUsecase := usecases.NewUserUsecase(userRepo, passwordService, jwtService, 5*time.Second)
-	taskUsecase := usecases.NewTaskUsecase(taskRepo, 5*time.Second)
+	userUsecase := usecases.NewUserUsecase(userRepo, tokenRepo, orgRepo, passwordResetRepo, passwordService, jwtService, mailSender, verifyEmailURL, requireEmailVerification, 5*time.Second)
+	taskUsecase := usecases.NewTaskUsecase(taskRepo, projectRepo, taskHistoryRepo, workflow, 5*time.Second)
+	userAdminUsecase := usecases.NewUserAdminUsecase(userRepo, taskRepo, taskHistoryRepo, tokenRepo, roleRepo, orgRepo, projectRepo, 5*time.Second)
+	roleUsecase := usecases.NewRoleUsecase(roleRepo, userRepo, 5*time.Second)
+	orgUsecase := usecases.NewOrganizationUsecase(orgRepo, userRepo, tokenRepo, 5*time.Second)
+	projectUsecase := usecases.NewProjectUsecase(projectRepo, taskRepo, orgRepo, userRepo, 5*time.Second)
+	passwordResetUsecase := usecases.NewPasswordResetUsecase(userRepo, passwordResetRepo, tokenRepo, passwordService, mailSender, passwordResetURL, 5*time.Second)
+
+	trashRetention, err := durationFromEnv("TRASH_RETENTION", 30*24*time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	purgeInterval, err := durationFromEnv("TRASH_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go purgeTrash(context.Background(), taskUsecase, trashRetention, purgeInterval)
+	tokenPurgeInterval, err := durationFromEnv("TOKEN_PURGE_INTERVAL", time.Hour)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go purgeExpiredTokens(context.Background(), userUsecase, tokenPurgeInterval)
 
 	// Controllers
-	userController := controllers.NewUserController(userUsecase)
+	userController := controllers.NewUserController(userUsecase, passwordResetUsecase)
+	adminController := controllers.NewAdminController(userAdminUsecase)
+	roleController := controllers.NewRoleController(roleUsecase)
+	orgController := controllers.NewOrganizationController(orgUsecase)
+	projectController := controllers.NewProjectController(projectUsecase, taskUsecase)
 	taskController := controllers.NewTaskController(taskUsecase)
 
 	// Router
-	router := routers.SetupRouter(userController, taskController, jwtSecret)
+	authMiddleware := infrastructure.AuthMiddleware(jwtSecret, tokenRepo, userRepo, roleRepo)
+	router := routers.SetupRouter(userController, adminController, roleController, orgController, projectController, taskController, authMiddleware)
 	router.Run()
-}
\ No newline at end of file
+}
+
+// storage holds one repository per aggregate, all backed by the same store.
+type storage struct {
+	users          domain.IUserRepository
+	tasks          domain.ITaskRepository
+	taskHistory    domain.ITaskHistoryRepository
+	tokens         domain.ITokenRepository
+	passwordResets domain.IPasswordResetRepository
+	roles          domain.IRoleRepository
+	organizations  domain.IOrganizationRepository
+	projects       domain.IProjectRepository
+}
+
+// newStorage builds the repositories selected by STORAGE: "memory" keeps
+// everything in process and loses it on exit, "sqlite" stores it in the
+// SQLITE_PATH file, "postgres" in the DATABASE_URL database, and "mongo",
+// the default, connects to MONGODB_URI. Other values are refused. With
+// migrate set it first applies the pending migrations of the store.
+func newStorage(migrate bool) (*storage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	switch os.Getenv("STORAGE") {
+	case "memory":
+		return &storage{
+			users:          repositories.NewMemoryUserRepository(),
+			tasks:          repositories.NewMemoryTaskRepository(),
+			taskHistory:    repositories.NewMemoryTaskHistoryRepository(),
+			tokens:         repositories.NewMemoryTokenRepository(),
+			passwordResets: repositories.NewMemoryPasswordResetRepository(),
+			roles:          repositories.NewMemoryRoleRepository(),
+			organizations:  repositories.NewMemoryOrganizationRepository(),
+			projects:       repositories.NewMemoryProjectRepository(),
+		}, nil
+	case repositories.DialectSQLite:
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = "task_manager.db"
+		}
+		return newSQLStorage(ctx, repositories.DialectSQLite, path, migrate)
+	case repositories.DialectPostgres:
+		url := os.Getenv("DATABASE_URL")
+		if url == "" {
+			return nil, fmt.Errorf("DATABASE_URL is not set")
+		}
+		return newSQLStorage(ctx, repositories.DialectPostgres, url, migrate)
+	case "", "mongo":
+		return newMongoStorage(ctx, migrate)
+	default:
+		return nil, fmt.Errorf("unknown STORAGE %q; use memory, sqlite, postgres or mongo", os.Getenv("STORAGE"))
+	}
+}
+
+// newMongoStorage connects to MONGODB_URI, applying the pending migrations
+// if migrate is set, and builds the MongoDB repositories on top of it.
+func newMongoStorage(ctx context.Context, migrate bool) (*storage, error) {
+	uri := os.Getenv("MONGODB_URI")
+	if uri == "" {
+		uri = "mongodb://localhost:27017"
+	}
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, fmt.Errorf("connect to MongoDB: %w", err)
+	}
+	if err := client.Ping(ctx, nil); err != nil {
+		return nil, fmt.Errorf("ping MongoDB: %w", err)
+	}
+	if migrate {
+		// Index builds on large collections can outlast the connect timeout.
+		applied, err := repositories.MigrateMongo(context.Background(), client)
+		logMigrations(applied)
+		if err != nil {
+			return nil, fmt.Errorf("migrate MongoDB: %w", err)
+		}
+	} else if err := refusePendingMigrations(repositories.PendingMongoMigrations(ctx, client)); err != nil {
+		return nil, err
+	}
+	return &storage{
+		users:          repositories.NewUserRepository(client),
+		tasks:          repositories.NewTaskRepository(client),
+		taskHistory:    repositories.NewTaskHistoryRepository(client),
+		tokens:         repositories.NewTokenRepository(client),
+		passwordResets: repositories.NewPasswordResetRepository(client),
+		roles:          repositories.NewRoleRepository(client),
+		organizations:  repositories.NewOrganizationRepository(client),
+		projects:       repositories.NewProjectRepository(client),
+	}, nil
+}
+
+// newSQLStorage opens the SQL database, applying its pending migrations if
+// migrate is set, and builds the SQL repositories on top of it.
+func newSQLStorage(ctx context.Context, dialect, dsn string, migrate bool) (*storage, error) {
+	db, err := repositories.OpenSQLDatabase(ctx, dialect, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open %s database: %w", dialect, err)
+	}
+	if migrate {
+		applied, err := db.Migrate(context.Background())
+		logMigrations(applied)
+		if err != nil {
+			return nil, fmt.Errorf("migrate %s database: %w", dialect, err)
+		}
+	} else if err := refusePendingMigrations(db.PendingMigrations(ctx)); err != nil {
+		return nil, err
+	}
+	return &storage{
+		users:          repositories.NewSQLUserRepository(db),
+		tasks:          repositories.NewSQLTaskRepository(db),
+		taskHistory:    repositories.NewSQLTaskHistoryRepository(db),
+		tokens:         repositories.NewSQLTokenRepository(db),
+		passwordResets: repositories.NewSQLPasswordResetRepository(db),
+		roles:          repositories.NewSQLRoleRepository(db),
+		organizations:  repositories.NewSQLOrganizationRepository(db),
+		projects:       repositories.NewSQLProjectRepository(db),
+	}, nil
+}
+
+// refusePendingMigrations returns an error if any migration is pending.
+// Unique emails and usernames and the single first admin rest on the
+// indexes they create, so the server must not run without them.
+func refusePendingMigrations(pending []string, err error) error {
+	if err != nil {
+		return fmt.Errorf("check migrations: %w", err)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("migrations %s are pending; run the migrate command or start with AUTO_MIGRATE=true", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+func logMigrations(applied []string) {
+	for _, name := range applied {
+		log.Printf("Applied migration %s", name)
+	}
+}
+
+// durationFromEnv parses the environment variable name as a duration such as
+// "720h", falling back to def when it is unset.
+func durationFromEnv(name string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive duration such as 720h", name, v)
+	}
+	return d, nil
+}
+
+// purgeTrash permanently removes the tasks that have been in the trash for
+// longer than retention, once right away and then every interval, until ctx
+// is done.
+func purgeTrash(ctx context.Context, taskUsecase *usecases.TaskUsecase, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := taskUsecase.PurgeDeletedTasks(ctx, retention)
+		if err != nil {
+			log.Printf("Failed to purge the trash: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d task(s) from the trash", purged)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeExpiredTokens removes expired refresh tokens and revoked access
+// tokens, once right away and then every interval, until ctx is done.
+func purgeExpiredTokens(ctx context.Context, userUsecase *usecases.UserUsecase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := userUsecase.PurgeExpiredTokens(ctx)
+		if err != nil {
+			log.Printf("Failed to purge expired tokens: %v", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d expired token(s)", purged)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newMailSender builds the mail sender selected by MAIL_DRIVER: "smtp"
+// delivers through SMTP_HOST/SMTP_PORT, anything else ("log", the default)
+// writes messages to MAIL_LOG_FILE or stdout.
+func newMailSender() (domain.IMailSender, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@task-manager.local"
+	}
+	if os.Getenv("MAIL_DRIVER") == "smtp" {
+		port := 587
+		if v := os.Getenv("SMTP_PORT"); v != "" {
+			p, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid SMTP_PORT: %w", err)
+			}
+			port = p
+		}
+		return infrastructure.NewSMTPMailSender(os.Getenv("SMTP_HOST"), port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from), nil
+	}
+	if path := os.Getenv("MAIL_LOG_FILE"); path != "" {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		return infrastructure.NewLogMailSender(f, from), nil
+	}
+	return infrastructure.NewLogMailSender(os.Stdout, from), nil
+}
@@ -1,29 +1,103 @@
 package routers
 
 import (
+	"net/http"
 	"task_manager/delivery/controllers"
+	"task_manager/domain"
 	"task_manager/infrastructure"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(userController *controllers.UserController, taskController *controllers.TaskController, jwtSecret []byte) *gin.Engine {
+func SetupRouter(userController *controllers.UserController, adminController *controllers.AdminController, roleController *controllers.RoleController, orgController *controllers.OrganizationController, projectController *controllers.ProjectController, taskController *controllers.TaskController, authMiddleware gin.HandlerFunc) *gin.Engine {
 	router := gin.Default()
+	router.NoRoute(func(c *gin.Context) {
+		infrastructure.WriteProblem(c, infrastructure.NewProblem(http.StatusNotFound, "route_not_found", "no such endpoint"))
+	})
 
-	taskGroup := router.Group("/tasks", infrastructure.AuthMiddleware(jwtSecret))
+	taskGroup := router.Group("/tasks", authMiddleware)
 	{
-		taskGroup.GET("", taskController.GetTasks)
-		taskGroup.GET(":id", taskController.GetTask)
-		taskGroup.DELETE(":id", infrastructure.AdminOnly(), taskController.RemoveTask)
-		taskGroup.PUT(":id", infrastructure.AdminOnly(), taskController.UpdateTask)
-		taskGroup.POST("", infrastructure.AdminOnly(), taskController.AddTask)
+		taskGroup.GET("", infrastructure.RequirePermission(domain.PermissionTasksRead), taskController.GetTasks)
+		taskGroup.GET("trash", infrastructure.RequirePermission(domain.PermissionTasksRead), taskController.GetTrash)
+		taskGroup.GET(":id", infrastructure.RequirePermission(domain.PermissionTasksRead), taskController.GetTask)
+		taskGroup.GET(":id/history", infrastructure.RequirePermission(domain.PermissionTasksRead), taskController.GetTaskHistory)
+		taskGroup.DELETE(":id", infrastructure.RequirePermission(domain.PermissionTasksDelete), taskController.RemoveTask)
+		taskGroup.PUT(":id", infrastructure.RequirePermission(domain.PermissionTasksUpdate), taskController.UpdateTask)
+		taskGroup.PATCH(":id", infrastructure.RequirePermission(domain.PermissionTasksUpdate), taskController.PatchTask)
+		taskGroup.POST(":id/transition", infrastructure.RequirePermission(domain.PermissionTasksUpdate), taskController.TransitionTask)
+		taskGroup.POST(":id/restore", infrastructure.RequirePermission(domain.PermissionTasksUpdate), taskController.RestoreTask)
+		taskGroup.POST("", infrastructure.RequirePermission(domain.PermissionTasksCreate), taskController.AddTask)
 	}
 
+	router.GET("/workflow", authMiddleware, taskController.GetWorkflow)
+
 	router.POST("/register", userController.RegisterUser)
 	router.POST("/login", userController.LoginUser)
+	router.POST("/token/refresh", userController.RefreshToken)
+	router.POST("/logout", authMiddleware, userController.Logout)
+	router.GET("/me", authMiddleware, userController.GetMe)
+	router.PATCH("/me", authMiddleware, userController.UpdateMe)
+	router.POST("/me/password", authMiddleware, userController.ChangePassword)
+	router.POST("/password/forgot", userController.ForgotPassword)
+	router.POST("/password/reset", userController.ResetPassword)
+	router.GET("/verify", userController.VerifyEmail)
+	router.POST("/verify/resend", userController.ResendVerification)
 
 	// Protected route for promoting users
-	router.POST("/promote", infrastructure.AuthMiddleware(jwtSecret), infrastructure.AdminOnly(), userController.PromoteUser)
+	router.POST("/promote", authMiddleware, infrastructure.RequirePermission(domain.PermissionUsersPromote), userController.PromoteUser)
+
+	// User management
+	userGroup := router.Group("/users", authMiddleware)
+	{
+		userGroup.GET("", infrastructure.RequirePermission(domain.PermissionUsersRead), adminController.ListUsers)
+		userGroup.DELETE(":id", infrastructure.RequirePermission(domain.PermissionUsersManage), adminController.DeleteUser)
+		userGroup.POST(":id/promote", infrastructure.RequirePermission(domain.PermissionUsersPromote), adminController.PromoteUser)
+		userGroup.POST(":id/demote", infrastructure.RequirePermission(domain.PermissionUsersPromote), adminController.DemoteUser)
+		userGroup.PUT(":id/role", infrastructure.RequirePermission(domain.PermissionUsersPromote), adminController.SetUserRole)
+		userGroup.POST(":id/deactivate", infrastructure.RequirePermission(domain.PermissionUsersManage), adminController.DeactivateUser)
+		userGroup.POST(":id/reactivate", infrastructure.RequirePermission(domain.PermissionUsersManage), adminController.ReactivateUser)
+		userGroup.POST(":id/verify", infrastructure.RequirePermission(domain.PermissionUsersManage), userController.MarkEmailVerified)
+	}
+
+	// Role management
+	roleGroup := router.Group("/roles", authMiddleware, infrastructure.RequirePermission(domain.PermissionRolesManage))
+	{
+		roleGroup.GET("", roleController.ListRoles)
+		roleGroup.POST("", roleController.CreateRole)
+		roleGroup.GET(":name", roleController.GetRole)
+		roleGroup.PUT(":name", roleController.UpdateRole)
+		roleGroup.DELETE(":name", roleController.DeleteRole)
+	}
+
+	// Organizations
+	orgGroup := router.Group("/orgs", authMiddleware)
+	{
+		orgGroup.GET("", orgController.ListOrganizations)
+		orgGroup.POST("", infrastructure.RequirePermission(domain.PermissionOrgsCreate), orgController.CreateOrganization)
+		orgGroup.GET(":id", orgController.GetOrganization)
+		orgGroup.POST(":id/switch", userController.SwitchOrganization)
+		orgGroup.GET(":id/members", orgController.ListMembers)
+		orgGroup.POST(":id/members", orgController.AddMember)
+		orgGroup.PUT(":id/members/:userId", orgController.UpdateMemberRole)
+		orgGroup.DELETE(":id/members/:userId", orgController.RemoveMember)
+	}
+
+	// Projects
+	projectGroup := router.Group("/projects", authMiddleware)
+	{
+		projectGroup.GET("", infrastructure.RequirePermission(domain.PermissionTasksRead), projectController.ListProjects)
+		projectGroup.POST("", infrastructure.RequirePermission(domain.PermissionTasksCreate), projectController.CreateProject)
+		projectGroup.GET(":id", infrastructure.RequirePermission(domain.PermissionTasksRead), projectController.GetProject)
+		projectGroup.PUT(":id", infrastructure.RequirePermission(domain.PermissionTasksUpdate), projectController.UpdateProject)
+		projectGroup.DELETE(":id", infrastructure.RequirePermission(domain.PermissionTasksDelete), projectController.DeleteProject)
+		projectGroup.POST(":id/archive", infrastructure.RequirePermission(domain.PermissionTasksUpdate), projectController.ArchiveProject)
+		projectGroup.POST(":id/unarchive", infrastructure.RequirePermission(domain.PermissionTasksUpdate), projectController.UnarchiveProject)
+		projectGroup.GET(":id/tasks", infrastructure.RequirePermission(domain.PermissionTasksRead), projectController.GetProjectTasks)
+		projectGroup.GET(":id/members", infrastructure.RequirePermission(domain.PermissionTasksRead), projectController.ListMembers)
+		projectGroup.POST(":id/members", infrastructure.RequirePermission(domain.PermissionTasksUpdate), projectController.AddMember)
+		projectGroup.PUT(":id/members/:userId", infrastructure.RequirePermission(domain.PermissionTasksUpdate), projectController.UpdateMemberRole)
+		projectGroup.DELETE(":id/members/:userId", projectController.RemoveMember)
+	}
 
 	return router
-}
\ No newline at end of file
+}
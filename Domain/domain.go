@@ -2,41 +2,978 @@ package domain
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
+// Error categories. Every error the usecases return on purpose matches one
+// of them with errors.Is, which is how the delivery layer picks a status
+// code; anything else is an internal error.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUnauthorized = errors.New("unauthorized")
+)
+
+// Error is an error of one of the categories above with its own message.
+// Code is a stable, machine-readable name for it such as "task_not_found";
+// clients rely on codes, so they never change once published.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+}
+
+// NewError returns an error of category kind, e.g.
+// NewError(ErrConflict, "username_taken", "username already taken").
+func NewError(kind error, code, message string) error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Codes of a ValidationError, saying what is wrong with the field.
+const (
+	ValidationRequired = "required"
+	ValidationInvalid  = "invalid"
+	ValidationTooShort = "too_short"
+	ValidationTooLong  = "too_long"
+	ValidationReadOnly = "read_only"
+)
+
+// ValidationError reports invalid input. Field names the offending input
+// field, or is empty when the error is not about a single field. Code is
+// one of the Validation* codes.
+type ValidationError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// NewValidationError returns a *ValidationError, which matches
+// ErrValidation.
+func NewValidationError(field, code, message string) error {
+	return &ValidationError{Field: field, Code: code, Message: message}
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
+
+// ValidationErrors reports every invalid field of one input at once. It
+// matches ErrValidation, and errors.As finds its first *ValidationError.
+type ValidationErrors []*ValidationError
+
+// Add records that field is invalid.
+func (e *ValidationErrors) Add(field, code, message string) {
+	*e = append(*e, &ValidationError{Field: field, Code: code, Message: message})
+}
+
+// Err returns e, or nil if no field is invalid.
+func (e ValidationErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
 type User struct {
-	ID       string 
-	Username string 
+	ID            string
+	Username      string
+	Email         string
+	Password      string
+	Role          string
+	EmailVerified bool
+	Deactivated   bool
+	// ActiveOrgID is the organization the user's access tokens are scoped
+	// to. Empty means DefaultOrganizationID.
+	ActiveOrgID string
+}
+
+// Built-in roles. Admins can define further roles, see Role.
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Permissions a role can grant. The *_all task permissions extend the
+// matching action to tasks the caller neither created nor is assigned to.
+const (
+	PermissionTasksCreate    = "tasks:create"
+	PermissionTasksRead      = "tasks:read"
+	PermissionTasksUpdate    = "tasks:update"
+	PermissionTasksDelete    = "tasks:delete"
+	PermissionTasksReadAll   = "tasks:read_all"
+	PermissionTasksManageAll = "tasks:manage_all"
+	PermissionUsersRead      = "users:read"
+	PermissionUsersPromote   = "users:promote"
+	PermissionUsersManage    = "users:manage"
+	PermissionRolesManage    = "roles:manage"
+	PermissionOrgsCreate     = "orgs:create"
+)
+
+// AllPermissions lists every permission a role may grant.
+var AllPermissions = []string{
+	PermissionTasksCreate,
+	PermissionTasksRead,
+	PermissionTasksUpdate,
+	PermissionTasksDelete,
+	PermissionTasksReadAll,
+	PermissionTasksManageAll,
+	PermissionUsersRead,
+	PermissionUsersPromote,
+	PermissionUsersManage,
+	PermissionRolesManage,
+	PermissionOrgsCreate,
+}
+
+// Role maps a role name to the permissions it grants. Built-in roles are
+// defined in code and cannot be changed; custom roles are stored by an
+// IRoleRepository.
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+	BuiltIn     bool
+}
+
+// HasPermission reports whether the role grants permission.
+func (r *Role) HasPermission(permission string) bool {
+	return r != nil && containsString(r.Permissions, permission)
+}
+
+// BuiltInRole returns the built-in role called name, if there is one.
+func BuiltInRole(name string) (*Role, bool) {
+	switch name {
+	case RoleUser:
+		return &Role{
+			Name:        RoleUser,
+			Description: "Manages their own tasks",
+			Permissions: []string{PermissionTasksCreate, PermissionTasksRead, PermissionTasksUpdate, PermissionTasksDelete},
+			BuiltIn:     true,
+		}, true
+	case RoleAdmin:
+		return &Role{
+			Name:        RoleAdmin,
+			Description: "Full access",
+			Permissions: append([]string(nil), AllPermissions...),
+			BuiltIn:     true,
+		}, true
+	}
+	return nil, false
+}
+
+// ErrRoleNotFound is returned when a role name matches neither a built-in
+// nor a custom role.
+var ErrRoleNotFound = NewError(ErrNotFound, "role_not_found", "role not found")
+
+// ErrRoleExists is returned when creating a role whose name is taken.
+var ErrRoleExists = NewError(ErrConflict, "role_exists", "role already exists")
+
+// ErrRoleInUse is returned when deleting a custom role still held by users.
+var ErrRoleInUse = NewError(ErrConflict, "role_in_use", "role is still assigned to users")
+
+// ErrBuiltInRole is returned when changing or deleting a built-in role.
+var ErrBuiltInRole = NewError(ErrConflict, "built_in_role", "built-in roles cannot be changed")
+
+// ErrPermissionDenied is returned (wrapped) when the caller lacks a
+// permission the action requires, including granting a permission they do
+// not hold themselves.
+var ErrPermissionDenied = NewError(ErrForbidden, "permission_denied", "permission denied")
+
+// ErrUserNotFound is returned when a user ID does not match any account.
+var ErrUserNotFound = NewError(ErrNotFound, "user_not_found", "user not found")
+
+// ErrEmailTaken is returned when an email address already belongs to
+// another account.
+var ErrEmailTaken = NewError(ErrConflict, "email_taken", "email already registered")
+
+// ErrUsernameTaken is returned when a username already belongs to another
+// account.
+var ErrUsernameTaken = NewError(ErrConflict, "username_taken", "username already taken")
+
+// ErrFirstAdminExists is returned when adding a first admin while the
+// account that bootstrapped the store still exists.
+var ErrFirstAdminExists = NewError(ErrConflict, "first_admin_exists", "first admin already exists")
+
+// ErrLastAdmin is returned when demoting, deactivating or deleting a user
+// would leave no active admin.
+var ErrLastAdmin = NewError(ErrConflict, "last_admin", "cannot remove the last active admin")
+
+// ErrUserDeactivated is returned by login and token refresh for accounts an
+// admin has deactivated.
+var ErrUserDeactivated = NewError(ErrForbidden, "user_deactivated", "account has been deactivated")
+
+// ErrEmailNotVerified is returned by login when email verification is
+// required and the account has not confirmed its address yet.
+var ErrEmailNotVerified = NewError(ErrForbidden, "email_not_verified", "email address has not been verified")
+
+// DefaultOrganizationID is the organization every user belongs to as a
+// member. It holds the tasks created before organizations existed.
+const DefaultOrganizationID = "default"
+
+// Roles a user can have within an organization. Owners and admins see and
+// manage every task of the organization; members only their own and those
+// assigned to them.
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// Organization groups users and isolates their tasks from other
+// organizations.
+type Organization struct {
+	ID        string
+	Name      string
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// OrganizationMembership is an organization together with the caller's role
+// in it.
+type OrganizationMembership struct {
+	Organization
+	Role string
+}
+
+// Membership records that a user belongs to an organization with an
+// org-level role.
+type Membership struct {
+	OrgID     string
+	UserID    string
+	Role      string
+	CreatedAt time.Time
+}
+
+// CanManage reports whether the membership lets its user manage the
+// organization's members and every task in it.
+func (m *Membership) CanManage() bool {
+	return m != nil && (m.Role == OrgRoleOwner || m.Role == OrgRoleAdmin)
+}
+
+// ErrOrganizationNotFound is returned for unknown organizations and for
+// organizations the caller is not a member of.
+var ErrOrganizationNotFound = NewError(ErrNotFound, "organization_not_found", "organization not found")
+
+// ErrAlreadyMember is returned when adding a user to an organization they
+// already belong to.
+var ErrAlreadyMember = NewError(ErrConflict, "already_member", "user is already a member of the organization")
+
+// ErrLastOwner is returned when demoting or removing the only owner of an
+// organization.
+var ErrLastOwner = NewError(ErrConflict, "last_owner", "cannot remove the last owner of the organization")
+
+// Roles a user can have within a project. Managers change the project and
+// its members, editors create and change its tasks, and viewers only see
+// them.
+const (
+	ProjectRoleManager = "manager"
+	ProjectRoleEditor  = "editor"
+	ProjectRoleViewer  = "viewer"
+)
+
+// Project groups tasks of an organization. Archived projects keep their
+// tasks but hide them from default task listings.
+type Project struct {
+	ID          string
+	OrgID       string
+	Name        string
+	Description string
+	CreatedBy   string
+	CreatedAt   time.Time
+	Archived    bool
+}
+
+// ProjectMember records that a user belongs to a project with a
+// project-level role.
+type ProjectMember struct {
+	ProjectID string
+	UserID    string
+	Role      string
+	CreatedAt time.Time
+}
+
+// CanEdit reports whether the member may create and change the project's
+// tasks.
+func (m *ProjectMember) CanEdit() bool {
+	return m != nil && (m.Role == ProjectRoleManager || m.Role == ProjectRoleEditor)
+}
+
+// CanManage reports whether the member may change the project and its
+// members.
+func (m *ProjectMember) CanManage() bool {
+	return m != nil && m.Role == ProjectRoleManager
+}
+
+// ProjectQuery selects the projects of an organization. MemberID restricts
+// the listing to projects the given user is a member of.
+type ProjectQuery struct {
+	OrgID           string
+	MemberID        string
+	IncludeArchived bool
+}
+
+// ErrProjectNotFound is returned for unknown projects, projects of other
+// organizations and projects the caller cannot see.
+var ErrProjectNotFound = NewError(ErrNotFound, "project_not_found", "project not found")
+
+// ErrProjectArchived is returned when adding tasks to an archived project.
+var ErrProjectArchived = NewError(ErrConflict, "project_archived", "project is archived")
+
+// ErrProjectNotEmpty is returned when deleting a project that still has
+// tasks.
+var ErrProjectNotEmpty = NewError(ErrConflict, "project_not_empty", "project still has tasks")
+
+// ErrAlreadyProjectMember is returned when adding a user to a project they
+// already belong to.
+var ErrAlreadyProjectMember = NewError(ErrConflict, "already_project_member", "user is already a member of the project")
+
+// Principal is the authenticated caller of a request. AuthMiddleware builds
+// it once from a validated access token and stores it in the request context,
+// where usecases read it with PrincipalFromContext.
+type Principal struct {
+	UserID   string
+	Username string
 	Email    string
-	Password string 
-	Role     string 
+	Role     string
+	// Permissions are those granted by Role when the request was
+	// authenticated.
+	Permissions []string
+	Scopes      []string
+	// OrgID is the organization the token is scoped to and OrgRole the
+	// principal's role in it.
+	OrgID     string
+	OrgRole   string
+	TokenID   string // jti of the access token
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// IsAdmin reports whether the principal has the admin role.
+func (p *Principal) IsAdmin() bool {
+	return p != nil && p.Role == RoleAdmin
+}
+
+// HasPermission reports whether the principal's role grants permission.
+func (p *Principal) HasPermission(permission string) bool {
+	return p != nil && containsString(p.Permissions, permission)
+}
+
+// CanManageOrg reports whether the principal is an owner or admin of its
+// active organization.
+func (p *Principal) CanManageOrg() bool {
+	return p != nil && (p.OrgRole == OrgRoleOwner || p.OrgRole == OrgRoleAdmin)
+}
+
+// HasScope reports whether the principal's token was granted scope.
+func (p *Principal) HasScope(scope string) bool {
+	return p != nil && containsString(p.Scopes, scope)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type principalContextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying p.
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, p)
+}
+
+// PrincipalFromContext returns the principal stored by WithPrincipal, if any.
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalContextKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// RefreshToken is a long-lived credential that can be exchanged once for a
+// new access/refresh token pair. Only the SHA-256 hash of the token is stored.
+// Tokens obtained by rotating one another share a FamilyID, so a replayed
+// token can take down the whole chain.
+type RefreshToken struct {
+	ID        string
+	UserID    string
+	FamilyID  string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    time.Time // zero until the token has been exchanged
+	Revoked   bool
+}
+
+// PasswordReset is a single-use, expiring request to choose a new password.
+// As with refresh tokens, only the hash of the emailed token is stored.
+type PasswordReset struct {
+	ID        string
+	UserID    string
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    time.Time // zero until the reset has been completed
+}
+
+// Mail is a plain-text email message.
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// TokenPair is the set of credentials handed out at login and on refresh.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
 }
 
 type Task struct {
-	ID          string    
-	Title       string    
-	Description string    
-	DueDate     time.Time 
-	Status      string    
+	ID          string
+	Title       string
+	Description string
+	DueDate     time.Time
+	Status      string
+	CreatedBy   string // ID of the user who created the task
+	AssigneeID  string // ID of the user the task is assigned to, if any
+	OrgID       string // ID of the organization the task belongs to
+	ProjectID   string // ID of the project the task belongs to, if any
+	// Version starts at 1 and grows with every write, so clients can detect
+	// changes made since they read the task.
+	Version int64
+	// DeletedAt is set while the task is in the trash; it is zero for live
+	// tasks.
+	DeletedAt time.Time
+	DeletedBy string // ID of the user who moved the task to the trash
+}
+
+// TaskPatch is a partial update of a task. Nil fields are left unchanged;
+// an empty AssigneeID or ProjectID clears the field.
+type TaskPatch struct {
+	Title       *string
+	Description *string
+	DueDate     *time.Time
+	Status      *string
+	AssigneeID  *string
+	ProjectID   *string
+}
+
+// IsEmpty reports whether the patch changes nothing.
+func (p *TaskPatch) IsEmpty() bool {
+	return p.Title == nil && p.Description == nil && p.DueDate == nil && p.Status == nil && p.AssigneeID == nil && p.ProjectID == nil
+}
+
+// Apply copies the fields set in the patch onto task.
+func (p *TaskPatch) Apply(task *Task) {
+	if p.Title != nil {
+		task.Title = *p.Title
+	}
+	if p.Description != nil {
+		task.Description = *p.Description
+	}
+	if p.DueDate != nil {
+		task.DueDate = *p.DueDate
+	}
+	if p.Status != nil {
+		task.Status = *p.Status
+	}
+	if p.AssigneeID != nil {
+		task.AssigneeID = *p.AssigneeID
+	}
+	if p.ProjectID != nil {
+		task.ProjectID = *p.ProjectID
+	}
+}
+
+// ErrTaskNotFound is returned for unknown tasks, tasks of other
+// organizations and tasks the caller cannot see.
+var ErrTaskNotFound = NewError(ErrNotFound, "task_not_found", "task not found")
+
+// ErrVersionConflict is returned when a task changed since the caller read
+// it, i.e. its version is no longer the one the caller expected.
+var ErrVersionConflict = NewError(ErrConflict, "version_conflict", "task was modified since it was read")
+
+// Task statuses of the default workflow.
+const (
+	TaskStatusPending    = "pending"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusCompleted  = "completed"
+	TaskStatusCancelled  = "cancelled"
+)
+
+// Workflow defines the statuses a task can have and the transitions between
+// them. Tasks are created in any of the States; after that their status only
+// changes along Transitions.
+type Workflow struct {
+	States      []string
+	Transitions []Transition
+}
+
+// Transition allows moving a task from one status to another. Roles lists
+// the user roles allowed to perform it; an empty list allows every role.
+type Transition struct {
+	From  string
+	To    string
+	Roles []string
+}
+
+// AllowsRole reports whether users with role may perform the transition.
+func (t *Transition) AllowsRole(role string) bool {
+	return len(t.Roles) == 0 || containsString(t.Roles, role)
+}
+
+// DefaultWorkflow returns the workflow used when none is configured. Work
+// can be started, paused and finished, finished work reopened, and only
+// admins can revive a cancelled task.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		States: []string{TaskStatusPending, TaskStatusInProgress, TaskStatusCompleted, TaskStatusCancelled},
+		Transitions: []Transition{
+			{From: TaskStatusPending, To: TaskStatusInProgress},
+			{From: TaskStatusPending, To: TaskStatusCompleted},
+			{From: TaskStatusPending, To: TaskStatusCancelled},
+			{From: TaskStatusInProgress, To: TaskStatusPending},
+			{From: TaskStatusInProgress, To: TaskStatusCompleted},
+			{From: TaskStatusInProgress, To: TaskStatusCancelled},
+			{From: TaskStatusCompleted, To: TaskStatusInProgress},
+			{From: TaskStatusCancelled, To: TaskStatusPending, Roles: []string{RoleAdmin}},
+		},
+	}
+}
+
+// Validate checks that the workflow has states, that they are unique and
+// that every transition connects two of them.
+func (w *Workflow) Validate() error {
+	if len(w.States) == 0 {
+		return errors.New("workflow must define at least one state")
+	}
+	seen := make(map[string]bool, len(w.States))
+	for _, s := range w.States {
+		if s == "" {
+			return errors.New("workflow states must not be empty")
+		}
+		if seen[s] {
+			return fmt.Errorf("workflow state %q is defined twice", s)
+		}
+		seen[s] = true
+	}
+	for _, t := range w.Transitions {
+		if !seen[t.From] || !seen[t.To] {
+			return fmt.Errorf("workflow transition %q -> %q uses an unknown state", t.From, t.To)
+		}
+		if t.From == t.To {
+			return fmt.Errorf("workflow transition %q -> %q does not change the state", t.From, t.To)
+		}
+	}
+	return nil
+}
+
+// HasState reports whether status is one of the workflow's states.
+func (w *Workflow) HasState(status string) bool {
+	return containsString(w.States, status)
+}
+
+// NextStates returns the statuses users with role may move a task in status
+// from to, in the order the transitions are defined.
+func (w *Workflow) NextStates(from, role string) []string {
+	next := []string{}
+	for i := range w.Transitions {
+		t := &w.Transitions[i]
+		if t.From == from && t.AllowsRole(role) {
+			next = append(next, t.To)
+		}
+	}
+	return next
+}
+
+// CheckTransition reports whether users with role may move a task from one
+// status to another. Keeping the status is always allowed, and so is leaving
+// a status the workflow no longer knows. Transitions the workflow does not
+// define fail with a *TransitionError; defined ones the role may not
+// perform fail with ErrPermissionDenied.
+func (w *Workflow) CheckTransition(from, to, role string) error {
+	if from == to || !w.HasState(from) {
+		return nil
+	}
+	for i := range w.Transitions {
+		t := &w.Transitions[i]
+		if t.From != from || t.To != to {
+			continue
+		}
+		if !t.AllowsRole(role) {
+			return fmt.Errorf("%w: role %q cannot move a task from %q to %q", ErrPermissionDenied, role, from, to)
+		}
+		return nil
+	}
+	return &TransitionError{From: from, To: to, Allowed: w.NextStates(from, role)}
+}
+
+// ErrInvalidStatus is returned (wrapped) when a task status is not one of
+// the workflow's states.
+var ErrInvalidStatus = NewError(ErrValidation, "invalid_status", "invalid status")
+
+// ErrInvalidTransition is returned (wrapped in a *TransitionError) when a
+// status change is not allowed by the workflow.
+var ErrInvalidTransition = NewError(ErrConflict, "invalid_transition", "invalid status transition")
+
+// TransitionError describes a status change the workflow does not allow
+// and lists the statuses the task could move to instead.
+type TransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s: cannot move a task from %q to %q", ErrInvalidTransition, e.From, e.To)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrInvalidTransition
+}
+
+// Fields a task listing can be sorted by.
+const (
+	TaskSortByID      = "id"
+	TaskSortByTitle   = "title"
+	TaskSortByDueDate = "due_date"
+	TaskSortByStatus  = "status"
+)
+
+// ErrInvalidTaskQuery is returned (wrapped) when a task listing request has
+// malformed filters, sorting, limits or cursor.
+var ErrInvalidTaskQuery = NewError(ErrValidation, "invalid_task_query", "invalid task query")
+
+// TaskQuery filters, sorts and paginates a task listing. Zero values mean
+// "no filter". Cursor is the opaque NextCursor of a previous page.
+// OrgID selects the organization to list and is always set; ProjectID
+// narrows it down to one project. VisibleTo
+// restricts the listing to tasks created by or assigned to the given user ID,
+// or belonging to one of VisibleProjectIDs. ExcludeProjectIDs hides the tasks
+// of those projects.
+type TaskQuery struct {
+	OrgID             string
+	ProjectID         string
+	VisibleTo         string
+	VisibleProjectIDs []string
+	ExcludeProjectIDs []string
+	// IncludeArchived keeps the tasks of archived projects in the listing.
+	IncludeArchived bool
+	// Deleted lists the tasks in the trash instead of the live ones.
+	Deleted     bool
+	Status      string
+	DueAfter    time.Time
+	DueBefore   time.Time
+	TitlePrefix string
+	SortBy      string
+	SortDesc    bool
+	Limit       int
+	Cursor      string
+}
+
+// TaskPage is a single page of a task listing. Total counts every task
+// matching the query filters, not just the ones on this page.
+type TaskPage struct {
+	Tasks      []Task
+	NextCursor string
+	Total      int64
+}
+
+// Actions recorded in a task's history.
+const (
+	TaskActionCreated      = "created"
+	TaskActionUpdated      = "updated"
+	TaskActionTransitioned = "transitioned"
+	TaskActionDeleted      = "deleted"
+	TaskActionRestored     = "restored"
+)
+
+// TaskChange is the value of one task field before and after a change.
+// Values are rendered as strings, due dates in RFC 3339; an empty string
+// means the field was not set.
+type TaskChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// TaskHistoryEntry is an immutable record of one change made to a task.
+type TaskHistoryEntry struct {
+	ID        string
+	TaskID    string
+	OrgID     string
+	Action    string
+	ActorID   string // ID of the user who made the change
+	CreatedAt time.Time
+	Changes   []TaskChange
 }
 
+// DiffTasks lists the fields that differ between two versions of a task in
+// a fixed order. A nil before stands for a task that did not exist yet, a
+// nil after for one that was deleted.
+func DiffTasks(before, after *Task) []TaskChange {
+	var changes []TaskChange
+	add := func(field string, value func(*Task) string) {
+		var b, a string
+		if before != nil {
+			b = value(before)
+		}
+		if after != nil {
+			a = value(after)
+		}
+		if b != a {
+			changes = append(changes, TaskChange{Field: field, Before: b, After: a})
+		}
+	}
+	add("title", func(t *Task) string { return t.Title })
+	add("description", func(t *Task) string { return t.Description })
+	add("due_date", func(t *Task) string {
+		if t.DueDate.IsZero() {
+			return ""
+		}
+		return t.DueDate.UTC().Format(time.RFC3339)
+	})
+	add("status", func(t *Task) string { return t.Status })
+	add("created_by", func(t *Task) string { return t.CreatedBy })
+	add("assignee_id", func(t *Task) string { return t.AssigneeID })
+	add("project_id", func(t *Task) string { return t.ProjectID })
+	add("deleted_at", func(t *Task) string {
+		if t.DeletedAt.IsZero() {
+			return ""
+		}
+		return t.DeletedAt.UTC().Format(time.RFC3339)
+	})
+	return changes
+}
+
+// ErrInvalidUserQuery is returned (wrapped) when a user listing request has
+// a malformed role, limit or cursor.
+var ErrInvalidUserQuery = NewError(ErrValidation, "invalid_user_query", "invalid user query")
+
+// UserQuery filters and paginates the admin user listing. Search matches a
+// case-insensitive substring of the username or email. Cursor is the opaque
+// NextCursor of a previous page.
+type UserQuery struct {
+	Search string
+	Role   string
+	Limit  int
+	Cursor string
+}
+
+// UserPage is a single page of a user listing. Total counts every user
+// matching the query filters, not just the ones on this page.
+type UserPage struct {
+	Users      []User
+	NextCursor string
+	Total      int64
+}
+
+// ITaskRepository stores tasks. Every lookup and change of a single task is
+// scoped to an organization: tasks of other organizations, and tasks in the
+// trash unless stated otherwise, are reported as ErrTaskNotFound. Every
+// write increments the task's version. The bulk operations below are used
+// when deleting a user and span every organization.
 type ITaskRepository interface {
+	// AddTask stores a new task at version 1.
 	AddTask(ctx context.Context, task *Task) error
-	GetAllTasks(ctx context.Context) ([]Task, error)
-	GetTaskByID(ctx context.Context, id string) (*Task, error)
+	GetAllTasks(ctx context.Context, query TaskQuery) (*TaskPage, error)
+	GetTaskByID(ctx context.Context, orgID, id string) (*Task, error)
+	// UpdateTask replaces the task with task.ID in task.OrgID if its stored
+	// version is still task.Version, and fails with ErrVersionConflict
+	// otherwise. On success task.Version is set to the new version.
 	UpdateTask(ctx context.Context, task *Task) error
-	DeleteTask(ctx context.Context, id string) error
+	// PatchTask changes only the fields set in patch, under the same version
+	// check as UpdateTask.
+	PatchTask(ctx context.Context, orgID, id string, version int64, patch TaskPatch) error
+	// DeleteTask moves the task with task.ID in task.OrgID to the trash,
+	// recording task.DeletedAt and task.DeletedBy. Like UpdateTask it fails
+	// with ErrVersionConflict unless the stored version is task.Version.
+	DeleteTask(ctx context.Context, task *Task) error
+	// RestoreTask takes a task out of the trash and returns it as it was
+	// stored there, DeletedAt and DeletedBy included.
+	RestoreTask(ctx context.Context, orgID, id string) (*Task, error)
+	// PurgeDeletedTasks permanently removes the tasks moved to the trash
+	// before deletedBefore and returns how many were removed.
+	PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// ReassignTasks hands every task created by fromUserID in one of the
+	// organizations orgIDs over to toUserID.
+	ReassignTasks(ctx context.Context, fromUserID, toUserID string, orgIDs []string) error
+	// DeleteTasksByCreator moves every task created by userID in one of the
+	// organizations orgIDs to the trash, recording deletedAt and deletedBy.
+	// It returns the tasks it moved, ordered by ID, as they are now stored.
+	DeleteTasksByCreator(ctx context.Context, userID string, orgIDs []string, deletedBy string, deletedAt time.Time) ([]Task, error)
+	// UnassignTasks clears the assignee of every task assigned to userID.
+	UnassignTasks(ctx context.Context, userID string) error
+}
+
+// ITaskHistoryRepository stores task history entries. Entries are only
+// ever added, never changed or removed.
+type ITaskHistoryRepository interface {
+	AddEntry(ctx context.Context, entry *TaskHistoryEntry) error
+	// ListEntries returns the history of a task, oldest entry first.
+	ListEntries(ctx context.Context, orgID, taskID string) ([]TaskHistoryEntry, error)
 }
 
+// IUserRepository stores user accounts. Lookups and updates of unknown
+// users, including PromoteUserToAdmin, return ErrUserNotFound. Usernames and
+// emails are unique: adding or updating a user to one that another user
+// already has fails with ErrUsernameTaken or ErrEmailTaken. Likewise,
+// UpdateUserRole and SetUserDeactivated never leave the store without an
+// active admin when it had one: of concurrent calls that together would, at
+// least one fails with ErrLastAdmin and changes nothing.
 type IUserRepository interface {
 	AddUser(ctx context.Context, user *User) error
+	// AddFirstAdmin adds the account that bootstraps an empty store, like
+	// AddUser. Only one such account exists at a time, so of several
+	// concurrent calls at most one succeeds; the others, and any call made
+	// while that account exists, fail with ErrFirstAdminExists.
+	AddFirstAdmin(ctx context.Context, user *User) error
 	GetUserByEmail(ctx context.Context, email string) (*User, error)
 	GetUserByUsername(ctx context.Context, username string) (*User, error)
 	IsUsersCollectionEmpty(ctx context.Context) (bool, error)
-	UserExistsByEmail(ctx context.Context, email string) (bool, error)
-	UserExistsByUsername(ctx context.Context, username string) (bool, error)
+	GetUserByID(ctx context.Context, id string) (*User, error)
+	UpdatePassword(ctx context.Context, id, hashedPassword string) error
+	MarkEmailVerified(ctx context.Context, id string) error
+	// UpdateProfile saves the username, email and email verification state
+	// of an existing user. Password and role are left untouched.
+	UpdateProfile(ctx context.Context, user *User) error
 	PromoteUserToAdmin(ctx context.Context, identifier string) error
+	ListUsers(ctx context.Context, query UserQuery) (*UserPage, error)
+	UpdateUserRole(ctx context.Context, id, role string) error
+	SetUserDeactivated(ctx context.Context, id string, deactivated bool) error
+	DeleteUser(ctx context.Context, id string) error
+	// CountActiveAdmins counts admins that have not been deactivated.
+	CountActiveAdmins(ctx context.Context) (int64, error)
+	// SetActiveOrganization stores the organization the user's next tokens
+	// are scoped to.
+	SetActiveOrganization(ctx context.Context, id, orgID string) error
+}
+
+// IOrganizationRepository stores organizations and their memberships. The
+// default organization and its implicit memberships are not stored. Lookups
+// of unknown organizations or memberships return ErrOrganizationNotFound,
+// and AddMember returns ErrAlreadyMember for existing members.
+type IOrganizationRepository interface {
+	AddOrganization(ctx context.Context, org *Organization) error
+	GetOrganization(ctx context.Context, id string) (*Organization, error)
+	// ListOrganizationsForUser returns the organizations userID is a
+	// member of.
+	ListOrganizationsForUser(ctx context.Context, userID string) ([]Organization, error)
+	AddMember(ctx context.Context, membership *Membership) error
+	GetMembership(ctx context.Context, orgID, userID string) (*Membership, error)
+	ListMembers(ctx context.Context, orgID string) ([]Membership, error)
+	UpdateMemberRole(ctx context.Context, orgID, userID, role string) error
+	RemoveMember(ctx context.Context, orgID, userID string) error
+	// RemoveUserMemberships removes userID from every organization.
+	RemoveUserMemberships(ctx context.Context, userID string) error
+	CountMembersWithRole(ctx context.Context, orgID, role string) (int64, error)
+}
+
+// IProjectRepository stores projects and their members. Lookups of unknown
+// projects or memberships return ErrProjectNotFound, and AddProjectMember
+// returns ErrAlreadyProjectMember for existing members.
+type IProjectRepository interface {
+	AddProject(ctx context.Context, project *Project) error
+	GetProject(ctx context.Context, orgID, id string) (*Project, error)
+	ListProjects(ctx context.Context, query ProjectQuery) ([]Project, error)
+	// UpdateProject saves the name, description and archived state of the
+	// project with project.ID in project.OrgID.
+	UpdateProject(ctx context.Context, project *Project) error
+	// DeleteProject removes a project together with its memberships.
+	DeleteProject(ctx context.Context, orgID, id string) error
+	AddProjectMember(ctx context.Context, member *ProjectMember) error
+	GetProjectMember(ctx context.Context, projectID, userID string) (*ProjectMember, error)
+	ListProjectMembers(ctx context.Context, projectID string) ([]ProjectMember, error)
+	UpdateProjectMemberRole(ctx context.Context, projectID, userID, role string) error
+	RemoveProjectMember(ctx context.Context, projectID, userID string) error
+	// RemoveUserProjectMemberships removes userID from every project.
+	RemoveUserProjectMemberships(ctx context.Context, userID string) error
+}
+
+// IRoleRepository stores custom roles; built-in roles are not stored. Get,
+// update and delete return ErrRoleNotFound for unknown names, and AddRole
+// returns ErrRoleExists for a taken one.
+type IRoleRepository interface {
+	AddRole(ctx context.Context, role *Role) error
+	GetRole(ctx context.Context, name string) (*Role, error)
+	ListRoles(ctx context.Context) ([]Role, error)
+	UpdateRole(ctx context.Context, role *Role) error
+	DeleteRole(ctx context.Context, name string) error
+}
+
+// ITokenRepository stores refresh tokens and revoked access tokens.
+// GetRefreshTokenByHash returns ErrNotFound for unknown tokens.
+type ITokenRepository interface {
+	AddRefreshToken(ctx context.Context, token *RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*RefreshToken, error)
+	// MarkRefreshTokenUsed atomically flags an unused, unrevoked token as
+	// exchanged and reports whether this call was the one that did so.
+	MarkRefreshTokenUsed(ctx context.Context, id string) (bool, error)
+	RevokeTokenFamily(ctx context.Context, familyID string) error
+	RevokeUserRefreshTokens(ctx context.Context, userID string) error
+	// RevokeAccessToken blacklists an access token by its jti until it
+	// would have expired anyway.
+	RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	// RevokeUserAccessTokens invalidates every access token of the user
+	// issued before issuedBefore.
+	RevokeUserAccessTokens(ctx context.Context, userID string, issuedBefore time.Time) error
+	// IsAccessTokenRevoked reports whether the access token with the given
+	// jti, issued to userID at issuedAt, was revoked by either method above.
+	IsAccessTokenRevoked(ctx context.Context, tokenID, userID string, issuedAt time.Time) (bool, error)
+	// PurgeExpiredTokens removes the refresh tokens and revoked access
+	// tokens that expired before now and returns how many were removed.
+	PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error)
+}
+
+// IPasswordResetRepository stores password reset requests.
+// GetPasswordResetByHash returns ErrNotFound for unknown tokens.
+type IPasswordResetRepository interface {
+	AddPasswordReset(ctx context.Context, reset *PasswordReset) error
+	GetPasswordResetByHash(ctx context.Context, hash string) (*PasswordReset, error)
+	// MarkPasswordResetUsed atomically flags an unused reset as completed
+	// and reports whether this call was the one that did so.
+	MarkPasswordResetUsed(ctx context.Context, id string) (bool, error)
+	// DeleteUserPasswordResets removes every reset of the user, so links
+	// still sitting in their inbox stop working.
+	DeleteUserPasswordResets(ctx context.Context, userID string) error
+}
+
+type IMailSender interface {
+	Send(ctx context.Context, mail *Mail) error
 }
 
 type IPasswordService interface {
@@ -45,5 +982,14 @@ type IPasswordService interface {
 }
 
 type IJWTService interface {
-	GenerateToken(user *User) (string, error)
-}
\ No newline at end of file
+	// GenerateToken signs an access token for user, scoped to the
+	// organization of membership; nil means the default organization.
+	GenerateToken(user *User, membership *Membership) (string, error)
+	// GenerateEmailVerificationToken signs a token proving that whoever holds
+	// it received mail at user.Email.
+	GenerateEmailVerificationToken(user *User) (string, error)
+	// ParseEmailVerificationToken checks a token from
+	// GenerateEmailVerificationToken and returns the user ID and email it was
+	// issued for.
+	ParseEmailVerificationToken(token string) (userID, email string, err error)
+}
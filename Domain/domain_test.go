@@ -1,6 +1,8 @@
 package domain
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -64,4 +66,118 @@ func TestUser_RoleValidation(t *testing.T) {
 
 	user.Role = "admin"
 	assert.Contains(t, validRoles, user.Role)
-} 
\ No newline at end of file
+}
+
+func TestPrincipal_Context(t *testing.T) {
+	_, ok := PrincipalFromContext(context.Background())
+	assert.False(t, ok)
+
+	principal := &Principal{UserID: "user123", Role: RoleAdmin, Scopes: []string{"tasks:read"}}
+	got, ok := PrincipalFromContext(WithPrincipal(context.Background(), principal))
+	assert.True(t, ok)
+	assert.Same(t, principal, got)
+	assert.True(t, got.IsAdmin())
+	assert.True(t, got.HasScope("tasks:read"))
+	assert.False(t, got.HasScope("tasks:write"))
+
+	var none *Principal
+	assert.False(t, none.IsAdmin())
+	assert.False(t, none.HasScope("tasks:read"))
+}
+
+func TestWorkflow_DefaultTransitions(t *testing.T) {
+	workflow := DefaultWorkflow()
+	assert.NoError(t, workflow.Validate())
+	assert.True(t, workflow.HasState(TaskStatusCancelled))
+	assert.False(t, workflow.HasState("archived"))
+
+	assert.NoError(t, workflow.CheckTransition(TaskStatusPending, TaskStatusInProgress, RoleUser))
+	assert.NoError(t, workflow.CheckTransition(TaskStatusCompleted, TaskStatusCompleted, RoleUser))
+	assert.NoError(t, workflow.CheckTransition("", TaskStatusCompleted, RoleUser))
+	assert.ErrorIs(t, workflow.CheckTransition(TaskStatusCancelled, TaskStatusPending, RoleUser), ErrPermissionDenied)
+	assert.NoError(t, workflow.CheckTransition(TaskStatusCancelled, TaskStatusPending, RoleAdmin))
+
+	err := workflow.CheckTransition(TaskStatusCancelled, TaskStatusInProgress, RoleAdmin)
+	var transitionErr *TransitionError
+	assert.ErrorAs(t, err, &transitionErr)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	assert.Equal(t, []string{TaskStatusPending}, transitionErr.Allowed)
+	assert.Equal(t, []string{}, workflow.NextStates(TaskStatusCancelled, RoleUser))
+}
+
+func TestWorkflow_Validate(t *testing.T) {
+	assert.Error(t, (&Workflow{}).Validate())
+	assert.Error(t, (&Workflow{States: []string{"open", "open"}}).Validate())
+	assert.Error(t, (&Workflow{States: []string{"open"}, Transitions: []Transition{{From: "open", To: "closed"}}}).Validate())
+	assert.Error(t, (&Workflow{States: []string{"open"}, Transitions: []Transition{{From: "open", To: "open"}}}).Validate())
+	assert.NoError(t, (&Workflow{States: []string{"open", "closed"}, Transitions: []Transition{{From: "open", To: "closed"}}}).Validate())
+}
+
+func TestDiffTasks(t *testing.T) {
+	dueDate := time.Date(2024, 7, 23, 12, 0, 0, 0, time.UTC)
+	before := &Task{ID: "task1", Title: "Old", Status: TaskStatusPending, DueDate: dueDate, AssigneeID: "user1"}
+	after := &Task{ID: "task1", Title: "New", Status: TaskStatusPending, DueDate: dueDate.Add(time.Hour)}
+
+	assert.Equal(t, []TaskChange{
+		{Field: "title", Before: "Old", After: "New"},
+		{Field: "due_date", Before: "2024-07-23T12:00:00Z", After: "2024-07-23T13:00:00Z"},
+		{Field: "assignee_id", Before: "user1"},
+	}, DiffTasks(before, after))
+	assert.Empty(t, DiffTasks(before, before))
+	assert.Equal(t, []TaskChange{{Field: "title", Before: "Old"}, {Field: "due_date", Before: "2024-07-23T12:00:00Z"}, {Field: "status", Before: TaskStatusPending}, {Field: "assignee_id", Before: "user1"}}, DiffTasks(before, nil))
+}
+
+func TestTaskPatch_Apply(t *testing.T) {
+	title, assignee := "New", ""
+	task := &Task{Title: "Old", Description: "Keep", AssigneeID: "user1", ProjectID: "proj1"}
+	patch := TaskPatch{Title: &title, AssigneeID: &assignee}
+
+	assert.False(t, patch.IsEmpty())
+	patch.Apply(task)
+	assert.Equal(t, &Task{Title: "New", Description: "Keep", ProjectID: "proj1"}, task)
+	assert.True(t, (&TaskPatch{}).IsEmpty())
+}
+
+func TestErrorCategories(t *testing.T) {
+	categories := map[error][]error{
+		ErrNotFound:   {ErrUserNotFound, ErrRoleNotFound, ErrOrganizationNotFound, ErrProjectNotFound, ErrTaskNotFound},
+		ErrConflict:   {ErrRoleExists, ErrLastAdmin, ErrLastOwner, ErrProjectArchived, ErrVersionConflict, &TransitionError{From: "a", To: "b"}},
+		ErrValidation: {ErrInvalidStatus, ErrInvalidTaskQuery, NewValidationError("title", ValidationRequired, "title is required")},
+		ErrForbidden:  {ErrPermissionDenied, ErrUserDeactivated, fmt.Errorf("%w: cannot grant", ErrPermissionDenied)},
+	}
+	for category, errs := range categories {
+		for _, err := range errs {
+			assert.ErrorIs(t, err, category, err.Error())
+		}
+	}
+	assert.NotErrorIs(t, ErrTaskNotFound, ErrConflict)
+	assert.Equal(t, "task not found", ErrTaskNotFound.Error())
+	assert.Equal(t, "title is required", NewValidationError("title", ValidationRequired, "title is required").Error())
+}
+
+func TestValidationErrors(t *testing.T) {
+	var errs ValidationErrors
+	assert.NoError(t, errs.Err())
+
+	errs.Add("title", ValidationRequired, "title is required")
+	errs.Add("due_date", ValidationRequired, "due date is required")
+	err := errs.Err()
+
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.EqualError(t, err, "title is required; due date is required")
+	var first *ValidationError
+	if assert.ErrorAs(t, err, &first) {
+		assert.Equal(t, "title", first.Field)
+		assert.Equal(t, ValidationRequired, first.Code)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	var domainErr *Error
+	if assert.ErrorAs(t, fmt.Errorf("%w: cannot grant", ErrPermissionDenied), &domainErr) {
+		assert.Equal(t, "permission_denied", domainErr.Code)
+	}
+	if assert.ErrorAs(t, &TransitionError{From: "a", To: "b"}, &domainErr) {
+		assert.Equal(t, "invalid_transition", domainErr.Code)
+	}
+}
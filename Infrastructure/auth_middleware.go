@@ -1,26 +1,44 @@
 package infrastructure
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"net/http"
 	"strings"
+	"task_manager/domain"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(jwtSecret []byte) gin.HandlerFunc {
+var (
+	errAuthorizationRequired      = domain.NewError(domain.ErrUnauthorized, "authorization_required", "Authorization header is required")
+	errInvalidAuthorizationHeader = domain.NewError(domain.ErrUnauthorized, "invalid_authorization_header", "Invalid authorization header")
+	errInvalidToken               = domain.NewError(domain.ErrUnauthorized, "invalid_token", "Invalid JWT")
+	errInvalidTokenClaims         = domain.NewError(domain.ErrUnauthorized, "invalid_token", "Invalid JWT claims")
+	errTokenRevoked               = domain.NewError(domain.ErrUnauthorized, "token_revoked", "Token has been revoked")
+)
+
+// AuthMiddleware validates the bearer JWT and stores the resulting
+// domain.Principal, including the permissions of its role, in the request
+// context. Tokens without a jti or whose jti has been revoked (for example
+// on logout) are rejected, and so are the tokens of users who have since
+// been deactivated, deleted or given another role, even if revoking their
+// tokens failed.
+func AuthMiddleware(jwtSecret []byte, tokenRepository domain.ITokenRepository, userRepository domain.IUserRepository, roleRepository domain.IRoleRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(401, gin.H{"error": "Authorization header is required"})
-			c.Abort()
+			WriteError(c, errAuthorizationRequired)
 			return
 		}
 
 		authParts := strings.Split(authHeader, " ")
 		if len(authParts) != 2 || strings.ToLower(authParts[0]) != "bearer" {
-			c.JSON(401, gin.H{"error": "Invalid authorization header"})
-			c.Abort()
+			WriteError(c, errInvalidAuthorizationHeader)
 			return
 		}
 
@@ -32,43 +50,139 @@ func AuthMiddleware(jwtSecret []byte) gin.HandlerFunc {
 		})
 
 		if err != nil || !token.Valid {
-			c.JSON(401, gin.H{"error": "Invalid JWT"})
-			c.Abort()
+			WriteError(c, errInvalidToken)
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.JSON(401, gin.H{"error": "Invalid JWT claims"})
-			c.Abort()
+			WriteError(c, errInvalidTokenClaims)
+			return
+		}
+
+		principal, err := principalFromClaims(claims)
+		if err != nil {
+			WriteError(c, errInvalidTokenClaims)
+			return
+		}
+		revoked, err := tokenRepository.IsAccessTokenRevoked(c.Request.Context(), principal.TokenID, principal.UserID, principal.IssuedAt)
+		if err != nil {
+			_ = c.Error(err)
+			WriteProblem(c, NewProblem(http.StatusInternalServerError, CodeInternalError, "Failed to verify token"))
+			return
+		}
+		if revoked {
+			WriteError(c, errTokenRevoked)
+			return
+		}
+		user, err := userRepository.GetUserByID(c.Request.Context(), principal.UserID)
+		if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+			_ = c.Error(err)
+			WriteProblem(c, NewProblem(http.StatusInternalServerError, CodeInternalError, "Failed to verify token"))
+			return
+		}
+		if err != nil || user.Deactivated || user.Role != principal.Role {
+			WriteError(c, errTokenRevoked)
 			return
 		}
-		c.Set("claims", claims)
+
+		permissions, err := rolePermissions(c.Request.Context(), roleRepository, principal.Role)
+		if err != nil {
+			_ = c.Error(err)
+			WriteProblem(c, NewProblem(http.StatusInternalServerError, CodeInternalError, "Failed to load role"))
+			return
+		}
+		principal.Permissions = permissions
+
+		c.Request = c.Request.WithContext(domain.WithPrincipal(c.Request.Context(), principal))
 		c.Next()
 	}
 }
 
-func AdminOnly() gin.HandlerFunc {
-    return func(c *gin.Context) {
-        claims, exists := c.Get("claims")
-        if !exists {
-            c.JSON(403, gin.H{"error": "Admin access required (no claims)"})
-            c.Abort()
-            return
-        }
-        jwtClaims, ok := claims.(jwt.MapClaims)
-        if !ok {
-            c.JSON(403, gin.H{"error": "Invalid claims type"})
-            c.Abort()
-            return
-        }
-		 role, ok := jwtClaims["role"].(string)
-        if !ok || role != "admin" {
-            c.JSON(403, gin.H{"error": "Admin access required"})
-            c.Abort()
-            return
-        }
-        c.Next()
-    }
+// principalFromClaims builds the principal for a validated access token.
+// Scopes come from the space-separated "scope" claim, as in OAuth 2.0.
+// Tokens without an organization are scoped to the default one.
+func principalFromClaims(claims jwt.MapClaims) (*domain.Principal, error) {
+	principal := &domain.Principal{}
+	principal.TokenID, _ = claims["jti"].(string)
+	principal.UserID, _ = claims["user_id"].(string)
+	if principal.TokenID == "" || principal.UserID == "" {
+		return nil, fmt.Errorf("access token must carry jti and user_id")
+	}
+	principal.Username, _ = claims["username"].(string)
+	principal.Email, _ = claims["email"].(string)
+	principal.Role, _ = claims["role"].(string)
+	principal.OrgID, _ = claims["org_id"].(string)
+	principal.OrgRole, _ = claims["org_role"].(string)
+	if principal.OrgID == "" {
+		principal.OrgID = domain.DefaultOrganizationID
+		principal.OrgRole = domain.OrgRoleMember
+	}
+	if scope, ok := claims["scope"].(string); ok {
+		principal.Scopes = strings.Fields(scope)
+	}
+	if iat, ok := claims["iat"].(float64); ok {
+		// iat carries milliseconds as a fraction, so a token is told apart
+		// from a revocation made later in the same second.
+		principal.IssuedAt = time.UnixMilli(int64(math.Round(iat * 1000)))
+	}
+	if exp, ok := claims["exp"].(float64); ok {
+		principal.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	return principal, nil
+}
+
+// rolePermissions returns the permissions granted by the built-in or custom
+// role called name. A role that no longer exists grants nothing.
+func rolePermissions(ctx context.Context, roleRepository domain.IRoleRepository, name string) ([]string, error) {
+	if role, ok := domain.BuiltInRole(name); ok {
+		return role.Permissions, nil
+	}
+	if name == "" {
+		return nil, nil
+	}
+	role, err := roleRepository.GetRole(ctx, name)
+	if errors.Is(err, domain.ErrRoleNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return role.Permissions, nil
+}
 
-}
\ No newline at end of file
+// RequirePermission lets through requests whose principal holds every one of
+// permissions. It must run after AuthMiddleware.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := domain.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			WriteError(c, domain.NewError(domain.ErrForbidden, "permission_denied", "Permission required (not authenticated)"))
+			return
+		}
+		for _, permission := range permissions {
+			if !principal.HasPermission(permission) {
+				WriteError(c, domain.NewError(domain.ErrForbidden, "permission_denied", "Permission required: "+permission))
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// AdminOnly lets through requests whose principal has the admin role. It must
+// run after AuthMiddleware.
+func AdminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := domain.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			WriteError(c, domain.NewError(domain.ErrForbidden, "permission_denied", "Admin access required (not authenticated)"))
+			return
+		}
+		if !principal.IsAdmin() {
+			WriteError(c, domain.NewError(domain.ErrForbidden, "permission_denied", "Admin access required"))
+			return
+		}
+		c.Next()
+	}
+}
@@ -1,6 +1,8 @@
 package infrastructure
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -10,14 +12,204 @@ import (
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
 
+// MockTokenRepository is a mock implementation of ITokenRepository
+type MockTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockTokenRepository) AddRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RefreshToken), args.Error(1)
+}
+
+func (m *MockTokenRepository) MarkRefreshTokenUsed(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenRepository) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	args := m.Called(ctx, tokenID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) RevokeUserAccessTokens(ctx context.Context, userID string, issuedBefore time.Time) error {
+	args := m.Called(ctx, userID, issuedBefore)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) IsAccessTokenRevoked(ctx context.Context, tokenID, userID string, issuedAt time.Time) (bool, error) {
+	args := m.Called(ctx, tokenID, userID, issuedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenRepository) PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockUserRepository is a mock implementation of IUserRepository
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) AddUser(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) AddFirstAdmin(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	args := m.Called(ctx, username)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) IsUsersCollectionEmpty(ctx context.Context) (bool, error) {
+	args := m.Called(ctx)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) GetUserByID(ctx context.Context, id string) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id string, hashedPassword string) error {
+	args := m.Called(ctx, id, hashedPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateProfile(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) PromoteUserToAdmin(ctx context.Context, identifier string) error {
+	args := m.Called(ctx, identifier)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ListUsers(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserPage), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateUserRole(ctx context.Context, id, role string) error {
+	args := m.Called(ctx, id, role)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetUserDeactivated(ctx context.Context, id string, deactivated bool) error {
+	args := m.Called(ctx, id, deactivated)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DeleteUser(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CountActiveAdmins(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) SetActiveOrganization(ctx context.Context, id, orgID string) error {
+	args := m.Called(ctx, id, orgID)
+	return args.Error(0)
+}
+
+// MockRoleRepository is a mock implementation of IRoleRepository
+type MockRoleRepository struct {
+	mock.Mock
+}
+
+func (m *MockRoleRepository) AddRole(ctx context.Context, role *domain.Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) GetRole(ctx context.Context, name string) (*domain.Role, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) UpdateRole(ctx context.Context, role *domain.Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) DeleteRole(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
 // AuthMiddlewareTestSuite is a test suite for authentication middleware
 type AuthMiddlewareTestSuite struct {
 	suite.Suite
 	jwtSecret []byte
 	router    *gin.Engine
+	tokenRepo *MockTokenRepository
+	userRepo  *MockUserRepository
+	roleRepo  *MockRoleRepository
 }
 
 // SetupSuite runs once before all tests in the suite
@@ -29,12 +221,26 @@ func (suite *AuthMiddlewareTestSuite) SetupSuite() {
 func (suite *AuthMiddlewareTestSuite) SetupTest() {
 	gin.SetMode(gin.TestMode)
 	suite.router = gin.New()
+	suite.tokenRepo = new(MockTokenRepository)
+	suite.tokenRepo.On("IsAccessTokenRevoked", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string"), mock.AnythingOfType("time.Time")).Return(false, nil).Maybe()
+	suite.userRepo = new(MockUserRepository)
+	// The users the tests issue tokens for, holding the roles the tokens name.
+	for id, role := range map[string]string{
+		"user123":  domain.RoleUser,
+		"admin123": domain.RoleAdmin,
+		"audit1":   "auditor",
+		"user789":  "retired",
+		"user790":  "flaky",
+	} {
+		suite.userRepo.On("GetUserByID", mock.Anything, id).Return(&domain.User{ID: id, Role: role}, nil).Maybe()
+	}
+	suite.roleRepo = new(MockRoleRepository)
 }
 
 // setupTestRouter creates a test router with auth middleware
 func (suite *AuthMiddlewareTestSuite) setupTestRouter() *gin.Engine {
 	router := gin.New()
-	router.Use(AuthMiddleware(suite.jwtSecret))
+	router.Use(AuthMiddleware(suite.jwtSecret, suite.tokenRepo, suite.userRepo, suite.roleRepo))
 	return router
 }
 
@@ -48,16 +254,23 @@ func (suite *AuthMiddlewareTestSuite) TestAuthMiddlewareSuite() {
 			Email:    "test@example.com",
 			Role:     "user",
 		}
-		
+
 		jwtService := NewJWTService(string(suite.jwtSecret))
-		token, err := jwtService.GenerateToken(user)
+		token, err := jwtService.GenerateToken(user, nil)
 		suite.NoError(err)
 
 		router := suite.setupTestRouter()
 		router.GET("/test", func(c *gin.Context) {
-			claims, exists := c.Get("claims")
+			principal, exists := domain.PrincipalFromContext(c.Request.Context())
 			suite.True(exists)
-			c.JSON(http.StatusOK, gin.H{"message": "success", "claims": claims})
+			suite.Equal("user123", principal.UserID)
+			suite.Equal("testuser", principal.Username)
+			suite.Equal("user", principal.Role)
+			suite.Equal(domain.DefaultOrganizationID, principal.OrgID)
+			suite.Equal(domain.OrgRoleMember, principal.OrgRole)
+			suite.NotEmpty(principal.TokenID)
+			suite.False(principal.ExpiresAt.IsZero())
+			c.JSON(http.StatusOK, gin.H{"message": "success"})
 		})
 
 		// Act
@@ -94,8 +307,8 @@ func (suite *AuthMiddlewareTestSuite) TestAuthMiddlewareSuite() {
 		})
 
 		testCases := []struct {
-			header       string
-			expectedMsg  string
+			header      string
+			expectedMsg string
 		}{
 			{"InvalidToken", "Invalid authorization header"},
 			{"Bearer", "Invalid authorization header"},
@@ -175,9 +388,9 @@ func (suite *AuthMiddlewareTestSuite) TestAuthMiddlewareSuite() {
 			Email:    "test@example.com",
 			Role:     "user",
 		}
-		
+
 		jwtService := NewJWTService("wrong_secret")
-		token, err := jwtService.GenerateToken(user)
+		token, err := jwtService.GenerateToken(user, nil)
 		suite.NoError(err)
 
 		router := suite.setupTestRouter()
@@ -197,6 +410,247 @@ func (suite *AuthMiddlewareTestSuite) TestAuthMiddlewareSuite() {
 	})
 }
 
+// TestTokenRevocationSuite tests that revoked or unidentifiable tokens are rejected
+func (suite *AuthMiddlewareTestSuite) TestTokenRevocationSuite() {
+	user := &domain.User{
+		ID:       "user123",
+		Username: "testuser",
+		Email:    "test@example.com",
+		Role:     "user",
+	}
+
+	suite.Run("RevokedToken", func() {
+		token, err := NewJWTService(string(suite.jwtSecret)).GenerateToken(user, nil)
+		suite.NoError(err)
+
+		tokenRepo := new(MockTokenRepository)
+		tokenRepo.On("IsAccessTokenRevoked", mock.Anything, mock.AnythingOfType("string"), "user123", mock.MatchedBy(func(issuedAt time.Time) bool {
+			return time.Since(issuedAt) < time.Minute
+		})).Return(true, nil)
+		router := gin.New()
+		router.Use(AuthMiddleware(suite.jwtSecret, tokenRepo, suite.userRepo, suite.roleRepo))
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+		// Act
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		suite.Equal(http.StatusUnauthorized, w.Code)
+		suite.Contains(w.Body.String(), "Token has been revoked")
+		tokenRepo.AssertExpectations(suite.T())
+	})
+
+	suite.Run("RevocationCheckFails", func() {
+		token, err := NewJWTService(string(suite.jwtSecret)).GenerateToken(user, nil)
+		suite.NoError(err)
+
+		tokenRepo := new(MockTokenRepository)
+		tokenRepo.On("IsAccessTokenRevoked", mock.Anything, mock.AnythingOfType("string"), "user123", mock.AnythingOfType("time.Time")).Return(false, errors.New("db down"))
+		router := gin.New()
+		router.Use(AuthMiddleware(suite.jwtSecret, tokenRepo, suite.userRepo, suite.roleRepo))
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+		// Act
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		suite.Equal(http.StatusInternalServerError, w.Code)
+		suite.Contains(w.Body.String(), "Failed to verify token")
+	})
+
+	suite.Run("MillisecondIssuedAt", func() {
+		claims := jwt.MapClaims{
+			"jti":     "token-with-ms-iat",
+			"user_id": "user123",
+			"role":    "user",
+			"iat":     1700000000.123,
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		}
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(suite.jwtSecret)
+		suite.NoError(err)
+
+		tokenRepo := new(MockTokenRepository)
+		tokenRepo.On("IsAccessTokenRevoked", mock.Anything, "token-with-ms-iat", "user123", time.UnixMilli(1700000000123)).Return(false, nil)
+		router := gin.New()
+		router.Use(AuthMiddleware(suite.jwtSecret, tokenRepo, suite.userRepo, suite.roleRepo))
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+		// Act
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		suite.Equal(http.StatusOK, w.Code)
+		tokenRepo.AssertExpectations(suite.T())
+	})
+
+	suite.Run("Scopes", func() {
+		claims := jwt.MapClaims{
+			"jti":     "token-with-scopes",
+			"user_id": "user123",
+			"role":    "user",
+			"scope":   "tasks:read  tasks:write",
+			"iat":     time.Now().Unix(),
+			"exp":     time.Now().Add(time.Hour).Unix(),
+		}
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(suite.jwtSecret)
+		suite.NoError(err)
+
+		router := suite.setupTestRouter()
+		router.GET("/test", func(c *gin.Context) {
+			principal, _ := domain.PrincipalFromContext(c.Request.Context())
+			suite.Equal([]string{"tasks:read", "tasks:write"}, principal.Scopes)
+			suite.True(principal.HasScope("tasks:write"))
+			suite.False(principal.HasScope("users:admin"))
+			c.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+		// Act
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		suite.Equal(http.StatusOK, w.Code)
+	})
+
+	suite.Run("MissingUserID", func() {
+		claims := jwt.MapClaims{
+			"jti":  "token-without-user",
+			"role": "admin",
+			"exp":  time.Now().Add(time.Hour).Unix(),
+		}
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(suite.jwtSecret)
+		suite.NoError(err)
+
+		router := suite.setupTestRouter()
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+		// Act
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		suite.Equal(http.StatusUnauthorized, w.Code)
+		suite.Contains(w.Body.String(), "Invalid JWT claims")
+	})
+
+	suite.Run("MissingTokenID", func() {
+		// Tokens issued before revocation support carry no jti
+		claims := jwt.MapClaims{
+			"user_id":  "user123",
+			"username": "testuser",
+			"role":     "user",
+			"exp":      time.Now().Add(time.Hour).Unix(),
+		}
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(suite.jwtSecret)
+		suite.NoError(err)
+
+		router := suite.setupTestRouter()
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+
+		// Act
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+tokenString)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		suite.Equal(http.StatusUnauthorized, w.Code)
+		suite.Contains(w.Body.String(), "Invalid JWT claims")
+	})
+}
+
+// TestStoredUserSuite tests that tokens are rejected once their user is
+// deactivated, deleted or given another role, whether or not the tokens were
+// revoked
+func (suite *AuthMiddlewareTestSuite) TestStoredUserSuite() {
+	token, err := NewJWTService(string(suite.jwtSecret)).GenerateToken(&domain.User{ID: "user456", Username: "testuser", Role: "admin"}, nil)
+	suite.Require().NoError(err)
+	serve := func(userRepo *MockUserRepository) *httptest.ResponseRecorder {
+		router := gin.New()
+		router.Use(AuthMiddleware(suite.jwtSecret, suite.tokenRepo, userRepo, suite.roleRepo))
+		router.GET("/test", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "success"})
+		})
+		req, _ := http.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	suite.Run("ActiveUser", func() {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetUserByID", mock.Anything, "user456").Return(&domain.User{ID: "user456", Role: "admin"}, nil)
+
+		w := serve(userRepo)
+
+		suite.Equal(http.StatusOK, w.Code)
+	})
+
+	suite.Run("DeactivatedUser", func() {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetUserByID", mock.Anything, "user456").Return(&domain.User{ID: "user456", Role: "admin", Deactivated: true}, nil)
+
+		w := serve(userRepo)
+
+		suite.Equal(http.StatusUnauthorized, w.Code)
+		suite.Contains(w.Body.String(), "Token has been revoked")
+	})
+
+	suite.Run("DeletedUser", func() {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetUserByID", mock.Anything, "user456").Return(nil, domain.ErrUserNotFound)
+
+		w := serve(userRepo)
+
+		suite.Equal(http.StatusUnauthorized, w.Code)
+		suite.Contains(w.Body.String(), "Token has been revoked")
+	})
+
+	suite.Run("RoleChanged", func() {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetUserByID", mock.Anything, "user456").Return(&domain.User{ID: "user456", Role: "user"}, nil)
+
+		w := serve(userRepo)
+
+		suite.Equal(http.StatusUnauthorized, w.Code)
+		suite.Contains(w.Body.String(), "Token has been revoked")
+	})
+
+	suite.Run("LookupFails", func() {
+		userRepo := new(MockUserRepository)
+		userRepo.On("GetUserByID", mock.Anything, "user456").Return(nil, errors.New("db down"))
+
+		w := serve(userRepo)
+
+		suite.Equal(http.StatusInternalServerError, w.Code)
+		suite.Contains(w.Body.String(), "Failed to verify token")
+	})
+}
+
 // TestAdminOnlySuite tests the AdminOnly middleware functionality
 func (suite *AuthMiddlewareTestSuite) TestAdminOnlySuite() {
 	suite.Run("ValidAdminUser", func() {
@@ -207,9 +661,9 @@ func (suite *AuthMiddlewareTestSuite) TestAdminOnlySuite() {
 			Email:    "admin@example.com",
 			Role:     "admin",
 		}
-		
+
 		jwtService := NewJWTService(string(suite.jwtSecret))
-		token, err := jwtService.GenerateToken(user)
+		token, err := jwtService.GenerateToken(user, nil)
 		suite.NoError(err)
 
 		router := suite.setupTestRouter()
@@ -236,9 +690,9 @@ func (suite *AuthMiddlewareTestSuite) TestAdminOnlySuite() {
 			Email:    "test@example.com",
 			Role:     "user",
 		}
-		
+
 		jwtService := NewJWTService(string(suite.jwtSecret))
-		token, err := jwtService.GenerateToken(user)
+		token, err := jwtService.GenerateToken(user, nil)
 		suite.NoError(err)
 
 		router := suite.setupTestRouter()
@@ -274,6 +728,83 @@ func (suite *AuthMiddlewareTestSuite) TestAdminOnlySuite() {
 	})
 }
 
+// TestRequirePermissionSuite tests the RequirePermission middleware
+func (suite *AuthMiddlewareTestSuite) TestRequirePermissionSuite() {
+	jwtService := NewJWTService(string(suite.jwtSecret))
+	tokenFor := func(id, role string) string {
+		token, err := jwtService.GenerateToken(&domain.User{ID: id, Username: id, Role: role}, nil)
+		suite.Require().NoError(err)
+		return token
+	}
+	serve := func(token string, permissions ...string) *httptest.ResponseRecorder {
+		router := suite.setupTestRouter()
+		router.GET("/protected", RequirePermission(permissions...), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "granted"})
+		})
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	suite.Run("BuiltInRoleGranted", func() {
+		w := serve(tokenFor("user123", domain.RoleUser), domain.PermissionTasksCreate, domain.PermissionTasksRead)
+
+		suite.Equal(http.StatusOK, w.Code)
+	})
+
+	suite.Run("BuiltInRoleDenied", func() {
+		w := serve(tokenFor("user123", domain.RoleUser), domain.PermissionUsersRead)
+
+		suite.Equal(http.StatusForbidden, w.Code)
+		suite.Contains(w.Body.String(), "Permission required: users:read")
+	})
+
+	suite.Run("AdminHasEveryPermission", func() {
+		w := serve(tokenFor("admin123", domain.RoleAdmin), domain.AllPermissions...)
+
+		suite.Equal(http.StatusOK, w.Code)
+	})
+
+	suite.Run("CustomRole", func() {
+		auditor := &domain.Role{Name: "auditor", Permissions: []string{domain.PermissionTasksRead, domain.PermissionTasksReadAll}}
+		suite.roleRepo.On("GetRole", mock.Anything, "auditor").Return(auditor, nil)
+
+		suite.Equal(http.StatusOK, serve(tokenFor("audit1", "auditor"), domain.PermissionTasksReadAll).Code)
+		suite.Equal(http.StatusForbidden, serve(tokenFor("audit1", "auditor"), domain.PermissionTasksDelete).Code)
+	})
+
+	suite.Run("DeletedRoleGrantsNothing", func() {
+		suite.roleRepo.On("GetRole", mock.Anything, "retired").Return(nil, domain.ErrRoleNotFound)
+
+		w := serve(tokenFor("user789", "retired"), domain.PermissionTasksRead)
+
+		suite.Equal(http.StatusForbidden, w.Code)
+	})
+
+	suite.Run("RoleLookupFails", func() {
+		suite.roleRepo.On("GetRole", mock.Anything, "flaky").Return(nil, errors.New("connection refused"))
+
+		w := serve(tokenFor("user790", "flaky"), domain.PermissionTasksRead)
+
+		suite.Equal(http.StatusInternalServerError, w.Code)
+		suite.Contains(w.Body.String(), "Failed to load role")
+	})
+
+	suite.Run("NotAuthenticated", func() {
+		router := gin.New()
+		router.GET("/protected", RequirePermission(domain.PermissionTasksRead), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"message": "granted"})
+		})
+		req, _ := http.NewRequest("GET", "/protected", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		suite.Equal(http.StatusForbidden, w.Code)
+	})
+}
+
 // TestAuthMiddlewareIntegrationSuite tests integration scenarios
 func (suite *AuthMiddlewareTestSuite) TestAuthMiddlewareIntegrationSuite() {
 	suite.Run("IntegrationScenarios", func() {
@@ -284,17 +815,17 @@ func (suite *AuthMiddlewareTestSuite) TestAuthMiddlewareIntegrationSuite() {
 			Email:    "admin@example.com",
 			Role:     "admin",
 		}
-		
+
 		regularUser := &domain.User{
 			ID:       "user123",
 			Username: "testuser",
 			Email:    "test@example.com",
 			Role:     "user",
 		}
-		
+
 		jwtService := NewJWTService(string(suite.jwtSecret))
-		adminToken, _ := jwtService.GenerateToken(adminUser)
-		userToken, _ := jwtService.GenerateToken(regularUser)
+		adminToken, _ := jwtService.GenerateToken(adminUser, nil)
+		userToken, _ := jwtService.GenerateToken(regularUser, nil)
 
 		router := suite.setupTestRouter()
 		router.GET("/public", func(c *gin.Context) {
@@ -339,4 +870,4 @@ func (suite *AuthMiddlewareTestSuite) TestAuthMiddlewareIntegrationSuite() {
 // TestAuthMiddlewareSuite runs the test suite
 func TestAuthMiddlewareSuite(t *testing.T) {
 	suite.Run(t, new(AuthMiddlewareTestSuite))
-} 
\ No newline at end of file
+}
@@ -0,0 +1,111 @@
+package infrastructure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// JSONPatchTestSuite is a test suite for the JSON Patch and JSON Merge Patch
+// implementations
+type JSONPatchTestSuite struct {
+	suite.Suite
+}
+
+// TestApplyMergePatchSuite tests ApplyMergePatch
+func (suite *JSONPatchTestSuite) TestApplyMergePatchSuite() {
+	suite.Run("SetAndRemoveMembers", func() {
+		doc := `{"title": "Old", "assignee_id": "user1", "tags": {"a": 1, "b": 2}}`
+
+		result, err := ApplyMergePatch([]byte(doc), []byte(`{"title": "New", "assignee_id": null, "tags": {"b": null, "c": 3}}`))
+
+		suite.NoError(err)
+		suite.JSONEq(`{"title": "New", "tags": {"a": 1, "c": 3}}`, string(result))
+	})
+
+	suite.Run("NonObjectPatchReplacesDocument", func() {
+		result, err := ApplyMergePatch([]byte(`{"a": 1}`), []byte(`["b"]`))
+
+		suite.NoError(err)
+		suite.JSONEq(`["b"]`, string(result))
+	})
+
+	suite.Run("Malformed", func() {
+		_, err := ApplyMergePatch([]byte(`{}`), []byte(`{"a":`))
+
+		suite.ErrorIs(err, ErrInvalidPatch)
+	})
+}
+
+// TestApplyJSONPatchSuite tests ApplyJSONPatch with the examples of RFC 6902
+// appendix A and a few error cases
+func (suite *JSONPatchTestSuite) TestApplyJSONPatchSuite() {
+	cases := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{"AddMember", `{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux"}]`, `{"foo": "bar", "baz": "qux"}`},
+		{"AddArrayElement", `{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo": ["bar", "qux", "baz"]}`},
+		{"AppendArrayElement", `{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": "qux"}]`, `{"foo": ["bar", "qux"]}`},
+		{"RemoveMember", `{"baz": "qux", "foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`, `{"foo": "bar"}`},
+		{"RemoveArrayElement", `{"foo": ["bar", "qux", "baz"]}`, `[{"op": "remove", "path": "/foo/1"}]`, `{"foo": ["bar", "baz"]}`},
+		{"Replace", `{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`, `{"baz": "boo", "foo": "bar"}`},
+		{"Move", `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`, `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`, `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`},
+		{"MoveArrayElement", `{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`, `{"foo": ["all", "cows", "eat", "grass"]}`},
+		{"Copy", `{"a": {"b": 1}}`, `[{"op": "copy", "from": "/a", "path": "/c"}]`, `{"a": {"b": 1}, "c": {"b": 1}}`},
+		{"Test", `{"baz": "qux", "foo": ["a", 2, "c"]}`, `[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2.0}]`, `{"baz": "qux", "foo": ["a", 2, "c"]}`},
+		{"EscapedPath", `{"a/b": 1, "m~n": 2}`, `[{"op": "replace", "path": "/a~1b", "value": 3}, {"op": "remove", "path": "/m~0n"}]`, `{"a/b": 3}`},
+	}
+	for _, tc := range cases {
+		suite.Run(tc.name, func() {
+			result, err := ApplyJSONPatch([]byte(tc.doc), []byte(tc.patch))
+
+			suite.NoError(err)
+			suite.JSONEq(tc.expected, string(result))
+		})
+	}
+
+	suite.Run("TestFails", func() {
+		_, err := ApplyJSONPatch([]byte(`{"baz": "qux"}`), []byte(`[{"op": "test", "path": "/baz", "value": "bar"}]`))
+
+		suite.ErrorIs(err, ErrPatchTestFailed)
+	})
+
+	suite.Run("RemoveMissingMember", func() {
+		_, err := ApplyJSONPatch([]byte(`{"foo": "bar"}`), []byte(`[{"op": "remove", "path": "/baz"}]`))
+
+		suite.ErrorIs(err, ErrInvalidPatch)
+	})
+
+	suite.Run("AddToMissingParent", func() {
+		_, err := ApplyJSONPatch([]byte(`{"foo": "bar"}`), []byte(`[{"op": "add", "path": "/baz/bat", "value": "qux"}]`))
+
+		suite.ErrorIs(err, ErrInvalidPatch)
+	})
+
+	suite.Run("UnknownOp", func() {
+		_, err := ApplyJSONPatch([]byte(`{}`), []byte(`[{"op": "merge", "path": "/a", "value": 1}]`))
+
+		suite.ErrorIs(err, ErrInvalidPatch)
+	})
+
+	suite.Run("NotAnArray", func() {
+		_, err := ApplyJSONPatch([]byte(`{}`), []byte(`{"op": "add", "path": "/a", "value": 1}`))
+
+		suite.ErrorIs(err, ErrInvalidPatch)
+	})
+
+	suite.Run("FailedOperationLeavesNoPartialResult", func() {
+		result, err := ApplyJSONPatch([]byte(`{"a": 1}`), []byte(`[{"op": "replace", "path": "/a", "value": 2}, {"op": "remove", "path": "/b"}]`))
+
+		suite.Error(err)
+		suite.Nil(result)
+	})
+}
+
+// TestJSONPatchSuite runs the test suite
+func TestJSONPatchSuite(t *testing.T) {
+	suite.Run(t, new(JSONPatchTestSuite))
+}
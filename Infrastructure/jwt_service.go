@@ -1,6 +1,8 @@
 package infrastructure
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"task_manager/domain"
 	"time"
@@ -8,6 +10,17 @@ import (
 	"github.com/dgrijalva/jwt-go"
 )
 
+// AccessTokenTTL is how long an access token stays valid. Access tokens are
+// kept short-lived; clients renew them with a refresh token.
+const AccessTokenTTL = 15 * time.Minute
+
+// EmailVerificationTTL is how long an emailed verification link stays valid.
+const EmailVerificationTTL = 24 * time.Hour
+
+// emailVerificationPurpose marks verification tokens. They carry no jti, so
+// AuthMiddleware never accepts them as access tokens either.
+const emailVerificationPurpose = "email_verification"
+
 type jwtService struct {
 	secretKey []byte
 }
@@ -16,19 +29,79 @@ func NewJWTService(secret string) domain.IJWTService {
 	return &jwtService{secretKey: []byte(secret)}
 }
 
-func (j *jwtService) GenerateToken(user *domain.User) (string, error) {
+func (j *jwtService) GenerateToken(user *domain.User, membership *domain.Membership) (string, error) {
 	if user == nil {
 		return "", fmt.Errorf("user cannot be nil")
 	}
+	if membership == nil {
+		membership = &domain.Membership{OrgID: domain.DefaultOrganizationID, UserID: user.ID, Role: domain.OrgRoleMember}
+	}
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
 	now := time.Now()
+	// iat is a fractional NumericDate, precise to the millisecond like the
+	// cutoffs of RevokeUserAccessTokens.
 	claims := jwt.MapClaims{
+		"jti":      tokenID,
 		"user_id":  user.ID,
 		"username": user.Username,
 		"email":    user.Email,
 		"role":     user.Role,
-		"iat":      now.Unix(),
-		"exp":      now.Add(time.Hour * 72).Unix(),
+		"org_id":   membership.OrgID,
+		"org_role": membership.Role,
+		"iat":      float64(now.UnixMilli()) / 1000,
+		"exp":      now.Add(AccessTokenTTL).Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(j.secretKey)
+}
+
+func (j *jwtService) GenerateEmailVerificationToken(user *domain.User) (string, error) {
+	if user == nil {
+		return "", fmt.Errorf("user cannot be nil")
+	}
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"purpose": emailVerificationPurpose,
+		"user_id": user.ID,
+		"email":   user.Email,
+		"iat":     now.Unix(),
+		"exp":     now.Add(EmailVerificationTTL).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(j.secretKey)
-}
\ No newline at end of file
+}
+
+func (j *jwtService) ParseEmailVerificationToken(tokenString string) (string, string, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return j.secretKey, nil
+	})
+	if err != nil || !token.Valid {
+		return "", "", fmt.Errorf("invalid or expired verification token")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != emailVerificationPurpose {
+		return "", "", fmt.Errorf("invalid or expired verification token")
+	}
+	userID, _ := claims["user_id"].(string)
+	email, _ := claims["email"].(string)
+	if userID == "" || email == "" {
+		return "", "", fmt.Errorf("invalid or expired verification token")
+	}
+	return userID, email, nil
+}
+
+// newTokenID returns a random identifier for the jti claim, which is what
+// revocation is keyed on.
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -1,11 +1,14 @@
 package infrastructure
 
 import (
+	"math"
 	"strings"
 	"testing"
+	"time"
 
 	"task_manager/domain"
 
+	"github.com/dgrijalva/jwt-go"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -40,7 +43,7 @@ func (suite *JWTServiceTestSuite) TestJWTServiceSuite() {
 		}
 
 		// Act
-		token, err := suite.jwtService.GenerateToken(user)
+		token, err := suite.jwtService.GenerateToken(user, nil)
 
 		// Assert
 		suite.NoError(err)
@@ -49,6 +52,75 @@ func (suite *JWTServiceTestSuite) TestJWTServiceSuite() {
 		suite.Equal(3, len(strings.Split(token, "."))) // JWT has 3 parts separated by dots
 	})
 
+	suite.Run("GenerateToken_ShortLivedWithTokenID", func() {
+		// Arrange
+		user := &domain.User{ID: "user123", Username: "testuser", Role: "user"}
+
+		// Act
+		token, err := suite.jwtService.GenerateToken(user, nil)
+		suite.NoError(err)
+		parsed, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) {
+			return suite.jwtService.secretKey, nil
+		})
+
+		// Assert
+		suite.NoError(err)
+		claims := parsed.Claims.(jwt.MapClaims)
+		suite.NotEmpty(claims["jti"])
+		suite.Equal("user123", claims["user_id"])
+		exp := time.Unix(int64(claims["exp"].(float64)), 0)
+		suite.WithinDuration(time.Now().Add(AccessTokenTTL), exp, 5*time.Second)
+
+		other, err := suite.jwtService.GenerateToken(user, nil)
+		suite.NoError(err)
+		suite.NotEqual(token, other) // every token gets its own jti
+	})
+
+	suite.Run("GenerateToken_MillisecondIssuedAt", func() {
+		// Arrange
+		user := &domain.User{ID: "user123", Username: "testuser", Role: "user"}
+		before := time.Now().Truncate(time.Millisecond)
+
+		// Act
+		token, err := suite.jwtService.GenerateToken(user, nil)
+		suite.NoError(err)
+		parsed, err := jwt.Parse(token, func(*jwt.Token) (interface{}, error) {
+			return suite.jwtService.secretKey, nil
+		})
+
+		// Assert
+		suite.NoError(err)
+		iat := parsed.Claims.(jwt.MapClaims)["iat"].(float64)
+		issuedAt := time.UnixMilli(int64(math.Round(iat * 1000)))
+		suite.False(issuedAt.Before(before), "iat %v is not truncated to the second", issuedAt)
+		suite.False(issuedAt.After(time.Now()))
+	})
+
+	suite.Run("GenerateToken_OrganizationClaims", func() {
+		// Arrange
+		user := &domain.User{ID: "user123", Username: "testuser", Role: "user"}
+		membership := &domain.Membership{OrgID: "org1", UserID: "user123", Role: domain.OrgRoleAdmin}
+
+		// Act
+		token, err := suite.jwtService.GenerateToken(user, membership)
+		suite.NoError(err)
+		defaultToken, err := suite.jwtService.GenerateToken(user, nil)
+		suite.NoError(err)
+
+		// Assert
+		claims := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) { return suite.jwtService.secretKey, nil })
+		suite.NoError(err)
+		suite.Equal("org1", claims["org_id"])
+		suite.Equal(domain.OrgRoleAdmin, claims["org_role"])
+
+		defaultClaims := jwt.MapClaims{}
+		_, err = jwt.ParseWithClaims(defaultToken, defaultClaims, func(*jwt.Token) (interface{}, error) { return suite.jwtService.secretKey, nil })
+		suite.NoError(err)
+		suite.Equal(domain.DefaultOrganizationID, defaultClaims["org_id"])
+		suite.Equal(domain.OrgRoleMember, defaultClaims["org_role"])
+	})
+
 	suite.Run("GenerateToken_EmptySecret", func() {
 		// Arrange
 		jwtService := NewJWTService("")
@@ -61,7 +133,7 @@ func (suite *JWTServiceTestSuite) TestJWTServiceSuite() {
 		}
 
 		// Act
-		token, err := jwtService.GenerateToken(user)
+		token, err := jwtService.GenerateToken(user, nil)
 
 		// Assert
 		suite.NoError(err)
@@ -70,7 +142,7 @@ func (suite *JWTServiceTestSuite) TestJWTServiceSuite() {
 
 	suite.Run("GenerateToken_NilUser", func() {
 		// Act
-		token, err := suite.jwtService.GenerateToken(nil)
+		token, err := suite.jwtService.GenerateToken(nil, nil)
 
 		// Assert
 		suite.Error(err)
@@ -89,7 +161,7 @@ func (suite *JWTServiceTestSuite) TestJWTServiceSuite() {
 		}
 
 		// Act
-		token, err := suite.jwtService.GenerateToken(user)
+		token, err := suite.jwtService.GenerateToken(user, nil)
 
 		// Assert
 		suite.NoError(err)
@@ -107,7 +179,7 @@ func (suite *JWTServiceTestSuite) TestJWTServiceSuite() {
 		}
 
 		// Act
-		token, err := suite.jwtService.GenerateToken(user)
+		token, err := suite.jwtService.GenerateToken(user, nil)
 
 		// Assert
 		suite.NoError(err)
@@ -129,8 +201,8 @@ func (suite *JWTServiceTestSuite) TestJWTServiceIntegrationSuite() {
 		}
 
 		// Act
-		token1, err1 := jwtService1.GenerateToken(user)
-		token2, err2 := jwtService2.GenerateToken(user)
+		token1, err1 := jwtService1.GenerateToken(user, nil)
+		token2, err2 := jwtService2.GenerateToken(user, nil)
 
 		// Assert
 		suite.NoError(err1)
@@ -165,7 +237,7 @@ func (suite *JWTServiceTestSuite) TestJWTServiceIntegrationSuite() {
 		for i, user := range users {
 			suite.Run("User_"+string(rune('1'+i)), func() {
 				// Act
-				token, err := suite.jwtService.GenerateToken(user)
+				token, err := suite.jwtService.GenerateToken(user, nil)
 
 				// Assert
 				suite.NoError(err)
@@ -177,7 +249,64 @@ func (suite *JWTServiceTestSuite) TestJWTServiceIntegrationSuite() {
 	})
 }
 
+// TestEmailVerificationTokenSuite tests signing and parsing verification tokens
+func (suite *JWTServiceTestSuite) TestEmailVerificationTokenSuite() {
+	user := &domain.User{ID: "user123", Username: "testuser", Email: "test@example.com", Role: "user"}
+
+	suite.Run("RoundTrip", func() {
+		token, err := suite.jwtService.GenerateEmailVerificationToken(user)
+		suite.NoError(err)
+
+		userID, email, err := suite.jwtService.ParseEmailVerificationToken(token)
+
+		suite.NoError(err)
+		suite.Equal("user123", userID)
+		suite.Equal("test@example.com", email)
+	})
+
+	suite.Run("RejectsAccessToken", func() {
+		token, err := suite.jwtService.GenerateToken(user, nil)
+		suite.NoError(err)
+
+		_, _, err = suite.jwtService.ParseEmailVerificationToken(token)
+
+		suite.Error(err)
+	})
+
+	suite.Run("RejectsOtherSecret", func() {
+		other := &jwtService{secretKey: []byte("other_secret")}
+		token, err := other.GenerateEmailVerificationToken(user)
+		suite.NoError(err)
+
+		_, _, err = suite.jwtService.ParseEmailVerificationToken(token)
+
+		suite.Error(err)
+	})
+
+	suite.Run("RejectsExpired", func() {
+		claims := jwt.MapClaims{
+			"purpose": emailVerificationPurpose,
+			"user_id": user.ID,
+			"email":   user.Email,
+			"exp":     time.Now().Add(-time.Minute).Unix(),
+		}
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(suite.jwtService.secretKey)
+		suite.NoError(err)
+
+		_, _, err = suite.jwtService.ParseEmailVerificationToken(token)
+
+		suite.Error(err)
+	})
+
+	suite.Run("NilUser", func() {
+		token, err := suite.jwtService.GenerateEmailVerificationToken(nil)
+
+		suite.Error(err)
+		suite.Empty(token)
+	})
+}
+
 // TestJWTServiceSuite runs the test suite
 func TestJWTServiceSuite(t *testing.T) {
 	suite.Run(t, new(JWTServiceTestSuite))
-} 
\ No newline at end of file
+}
@@ -0,0 +1,83 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"sync"
+	"task_manager/domain"
+	"time"
+)
+
+type smtpMailSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPMailSender sends mail through an SMTP server. PLAIN authentication
+// is used when a username is given; net/smtp only allows it over TLS or to
+// localhost.
+func NewSMTPMailSender(host string, port int, username, password, from string) domain.IMailSender {
+	sender := &smtpMailSender{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+	}
+	if username != "" {
+		sender.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return sender
+}
+
+func (s *smtpMailSender) Send(ctx context.Context, mail *domain.Mail) error {
+	if mail == nil {
+		return fmt.Errorf("mail cannot be nil")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{mail.To}, formatMail(s.from, mail))
+}
+
+type logMailSender struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from string
+}
+
+// NewLogMailSender writes every message to w instead of delivering it. It is
+// meant for local development and tests, where w is a log file or stdout.
+func NewLogMailSender(w io.Writer, from string) domain.IMailSender {
+	return &logMailSender{w: w, from: from}
+}
+
+func (s *logMailSender) Send(ctx context.Context, mail *domain.Mail) error {
+	if mail == nil {
+		return fmt.Errorf("mail cannot be nil")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintf(s.w, "%s\r\n\r\n", formatMail(s.from, mail))
+	return err
+}
+
+// headerSanitizer strips line breaks so header values cannot inject headers.
+var headerSanitizer = strings.NewReplacer("\r", "", "\n", "")
+
+// formatMail renders mail as an RFC 5322 message with CRLF line endings.
+func formatMail(from string, mail *domain.Mail) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", headerSanitizer.Replace(from))
+	fmt.Fprintf(&b, "To: %s\r\n", headerSanitizer.Replace(mail.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", headerSanitizer.Replace(mail.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(mail.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
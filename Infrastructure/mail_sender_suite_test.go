@@ -0,0 +1,142 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// MailSenderTestSuite is a test suite for the mail senders
+type MailSenderTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *MailSenderTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+}
+
+// fakeSMTPServer accepts a single SMTP session and returns the DATA payload
+// it received.
+func fakeSMTPServer(listener net.Listener) <-chan string {
+	received := make(chan string, 1)
+	go func() {
+		defer close(received)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		text := textproto.NewConn(conn)
+		text.PrintfLine("220 localhost ESMTP test")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+			case "EHLO", "HELO", "MAIL", "RCPT", "RSET", "NOOP":
+				text.PrintfLine("250 OK")
+			case "DATA":
+				text.PrintfLine("354 Go ahead")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				received <- string(data)
+				text.PrintfLine("250 Queued")
+			case "QUIT":
+				text.PrintfLine("221 Bye")
+				return
+			default:
+				text.PrintfLine("502 Not implemented")
+			}
+		}
+	}()
+	return received
+}
+
+// TestLogMailSenderSuite tests the log mail sender
+func (suite *MailSenderTestSuite) TestLogMailSenderSuite() {
+	suite.Run("WritesMessage", func() {
+		var buf bytes.Buffer
+		sender := NewLogMailSender(&buf, "no-reply@example.com")
+
+		err := sender.Send(suite.ctx, &domain.Mail{To: "user@example.com", Subject: "Hello", Body: "line one\nline two\n"})
+
+		suite.NoError(err)
+		out := buf.String()
+		suite.Contains(out, "From: no-reply@example.com\r\n")
+		suite.Contains(out, "To: user@example.com\r\n")
+		suite.Contains(out, "Subject: Hello\r\n")
+		suite.Contains(out, "\r\n\r\nline one\r\nline two\r\n")
+	})
+
+	suite.Run("StripsHeaderInjection", func() {
+		var buf bytes.Buffer
+		sender := NewLogMailSender(&buf, "no-reply@example.com")
+
+		err := sender.Send(suite.ctx, &domain.Mail{To: "user@example.com", Subject: "Hi\r\nBcc: evil@example.com", Body: "body"})
+
+		suite.NoError(err)
+		suite.Contains(buf.String(), "Subject: HiBcc: evil@example.com\r\n")
+		suite.NotContains(buf.String(), "\r\nBcc:")
+	})
+
+	suite.Run("NilMail", func() {
+		sender := NewLogMailSender(&bytes.Buffer{}, "no-reply@example.com")
+
+		err := sender.Send(suite.ctx, nil)
+
+		suite.Error(err)
+	})
+}
+
+// TestSMTPMailSenderSuite tests the SMTP mail sender against a local server
+func (suite *MailSenderTestSuite) TestSMTPMailSenderSuite() {
+	suite.Run("Success", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		suite.Require().NoError(err)
+		defer listener.Close()
+		received := fakeSMTPServer(listener)
+
+		host, portStr, _ := net.SplitHostPort(listener.Addr().String())
+		port, _ := strconv.Atoi(portStr)
+		sender := NewSMTPMailSender(host, port, "", "", "no-reply@example.com")
+
+		err = sender.Send(suite.ctx, &domain.Mail{To: "user@example.com", Subject: "Reset", Body: "click the link"})
+
+		suite.NoError(err)
+		data := <-received
+		headers, err := textproto.NewReader(bufio.NewReader(strings.NewReader(data))).ReadMIMEHeader()
+		suite.Require().NoError(err)
+		suite.Equal("user@example.com", headers.Get("To"))
+		suite.Equal("Reset", headers.Get("Subject"))
+		suite.Contains(data, "click the link")
+	})
+
+	suite.Run("CancelledContext", func() {
+		sender := NewSMTPMailSender("127.0.0.1", 1, "", "", "no-reply@example.com")
+		ctx, cancel := context.WithCancel(suite.ctx)
+		cancel()
+
+		err := sender.Send(ctx, &domain.Mail{To: "user@example.com", Subject: "Reset", Body: "body"})
+
+		suite.ErrorIs(err, context.Canceled)
+	})
+}
+
+// TestMailSenderSuite runs the test suite
+func TestMailSenderSuite(t *testing.T) {
+	suite.Run(t, new(MailSenderTestSuite))
+}
@@ -19,4 +19,4 @@ func (p *passwordService) HashPassword(password string) (string, error) {
 
 func (p *passwordService) CheckPasswordHash(password, hash string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
-}
\ No newline at end of file
+}
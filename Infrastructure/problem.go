@@ -0,0 +1,124 @@
+package infrastructure
+
+import (
+	"errors"
+	"net/http"
+	"task_manager/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the media type of error responses.
+const ProblemContentType = "application/problem+json"
+
+// Codes of problems that do not come from a domain.Error.
+const (
+	CodeValidationFailed = "validation_failed"
+	CodeInternalError    = "internal_error"
+)
+
+// Problem is an RFC 7807 problem details object. Type is always
+// "about:blank", so Title is the HTTP status text; Code is the stable,
+// machine-readable error code clients should branch on.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// Errors lists the invalid fields of a validation problem.
+	Errors []FieldProblem `json:"errors,omitempty"`
+	// AllowedTransitions is set on invalid_transition problems.
+	AllowedTransitions []string `json:"allowed_transitions,omitempty"`
+}
+
+// FieldProblem is one invalid input field. Code is one of the
+// domain.Validation* codes.
+type FieldProblem struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewProblem returns a problem with the given status, code and detail.
+func NewProblem(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// ProblemFromError describes err. The status follows the error's domain
+// category and the code comes from the domain.Error it wraps; errors
+// without a category are internal and their message is not disclosed.
+func ProblemFromError(err error) *Problem {
+	status, code := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		return NewProblem(status, code, "internal server error")
+	}
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		code = domainErr.Code
+	}
+	problem := NewProblem(status, code, err.Error())
+
+	var fieldErrs domain.ValidationErrors
+	var fieldErr *domain.ValidationError
+	switch {
+	case errors.As(err, &fieldErrs):
+		for _, e := range fieldErrs {
+			problem.Errors = append(problem.Errors, FieldProblem{Field: e.Field, Code: e.Code, Message: e.Message})
+		}
+	case errors.As(err, &fieldErr) && fieldErr.Field != "":
+		problem.Errors = []FieldProblem{{Field: fieldErr.Field, Code: fieldErr.Code, Message: fieldErr.Message}}
+	}
+	var transitionErr *domain.TransitionError
+	if errors.As(err, &transitionErr) {
+		problem.AllowedTransitions = transitionErr.Allowed
+	}
+	return problem
+}
+
+// errorStatus returns the status code for err's category and the code to
+// use when err carries none of its own.
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, domain.ErrVersionConflict):
+		return http.StatusPreconditionFailed, ""
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound, "not_found"
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict, "conflict"
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusBadRequest, CodeValidationFailed
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden, "forbidden"
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized, "unauthorized"
+	}
+	return http.StatusInternalServerError, CodeInternalError
+}
+
+// WriteProblem aborts the request with problem as an
+// application/problem+json response.
+func WriteProblem(c *gin.Context, problem *Problem) {
+	if problem.Instance == "" {
+		problem.Instance = c.Request.URL.Path
+	}
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// WriteError aborts the request with the problem describing err. Internal
+// errors are attached to the context so the logger still sees them.
+func WriteError(c *gin.Context, err error) {
+	problem := ProblemFromError(err)
+	if problem.Status == http.StatusInternalServerError {
+		_ = c.Error(err)
+	}
+	WriteProblem(c, problem)
+}
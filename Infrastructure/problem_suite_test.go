@@ -0,0 +1,151 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"task_manager/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/suite"
+)
+
+// ProblemTestSuite is a test suite for the problem details responses
+type ProblemTestSuite struct {
+	suite.Suite
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *ProblemTestSuite) SetupSuite() {
+	gin.SetMode(gin.TestMode)
+}
+
+// TestProblemFromErrorSuite tests ProblemFromError
+func (suite *ProblemTestSuite) TestProblemFromErrorSuite() {
+	suite.Run("DomainError", func() {
+		problem := ProblemFromError(domain.ErrTaskNotFound)
+
+		suite.Equal(http.StatusNotFound, problem.Status)
+		suite.Equal("Not Found", problem.Title)
+		suite.Equal("about:blank", problem.Type)
+		suite.Equal("task_not_found", problem.Code)
+		suite.Equal("task not found", problem.Detail)
+		suite.Empty(problem.Errors)
+	})
+
+	suite.Run("WrappedDomainError", func() {
+		problem := ProblemFromError(fmt.Errorf("%w: cannot grant %q", domain.ErrPermissionDenied, "users:manage"))
+
+		suite.Equal(http.StatusForbidden, problem.Status)
+		suite.Equal("permission_denied", problem.Code)
+		suite.Equal(`permission denied: cannot grant "users:manage"`, problem.Detail)
+	})
+
+	suite.Run("FieldError", func() {
+		problem := ProblemFromError(domain.NewValidationError("title", domain.ValidationRequired, "title is required"))
+
+		suite.Equal(http.StatusBadRequest, problem.Status)
+		suite.Equal(CodeValidationFailed, problem.Code)
+		suite.Equal([]FieldProblem{{Field: "title", Code: domain.ValidationRequired, Message: "title is required"}}, problem.Errors)
+	})
+
+	suite.Run("FieldErrors", func() {
+		var errs domain.ValidationErrors
+		errs.Add("title", domain.ValidationRequired, "title is required")
+		errs.Add("password", domain.ValidationTooShort, "password must be at least 6 characters long")
+
+		problem := ProblemFromError(errs.Err())
+
+		suite.Equal(http.StatusBadRequest, problem.Status)
+		suite.Equal(CodeValidationFailed, problem.Code)
+		suite.Require().Len(problem.Errors, 2)
+		suite.Equal("password", problem.Errors[1].Field)
+		suite.Equal(domain.ValidationTooShort, problem.Errors[1].Code)
+	})
+
+	suite.Run("ErrorWithoutField", func() {
+		problem := ProblemFromError(domain.NewValidationError("", domain.ValidationRequired, "task cannot be nil"))
+
+		suite.Equal(http.StatusBadRequest, problem.Status)
+		suite.Empty(problem.Errors)
+	})
+
+	suite.Run("VersionConflict", func() {
+		problem := ProblemFromError(domain.ErrVersionConflict)
+
+		suite.Equal(http.StatusPreconditionFailed, problem.Status)
+		suite.Equal("version_conflict", problem.Code)
+	})
+
+	suite.Run("InvalidTransition", func() {
+		problem := ProblemFromError(&domain.TransitionError{From: "done", To: "pending", Allowed: []string{"in_progress"}})
+
+		suite.Equal(http.StatusConflict, problem.Status)
+		suite.Equal("invalid_transition", problem.Code)
+		suite.Equal([]string{"in_progress"}, problem.AllowedTransitions)
+	})
+
+	suite.Run("InternalError", func() {
+		problem := ProblemFromError(errors.New("connection refused"))
+
+		suite.Equal(http.StatusInternalServerError, problem.Status)
+		suite.Equal(CodeInternalError, problem.Code)
+		suite.NotContains(problem.Detail, "connection refused")
+	})
+}
+
+// TestWriteErrorSuite tests WriteError
+func (suite *ProblemTestSuite) TestWriteErrorSuite() {
+	suite.Run("ProblemJSON", func() {
+		router := gin.New()
+		router.POST("/tasks", func(c *gin.Context) {
+			WriteError(c, domain.NewValidationError("title", domain.ValidationRequired, "title is required"))
+		})
+
+		req, _ := http.NewRequest(http.MethodPost, "/tasks", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		suite.Equal(http.StatusBadRequest, w.Code)
+		suite.Equal(ProblemContentType, w.Header().Get("Content-Type"))
+		suite.JSONEq(`{
+			"type": "about:blank",
+			"title": "Bad Request",
+			"status": 400,
+			"detail": "title is required",
+			"instance": "/tasks",
+			"code": "validation_failed",
+			"errors": [{"field": "title", "code": "required", "message": "title is required"}]
+		}`, w.Body.String())
+	})
+
+	suite.Run("InternalErrorIsLogged", func() {
+		var logged []string
+		router := gin.New()
+		router.GET("/tasks", func(c *gin.Context) {
+			c.Next()
+			logged = c.Errors.Errors()
+		}, func(c *gin.Context) {
+			WriteError(c, errors.New("connection refused"))
+		})
+
+		req, _ := http.NewRequest(http.MethodGet, "/tasks", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var problem Problem
+		suite.Require().NoError(json.Unmarshal(w.Body.Bytes(), &problem))
+		suite.Equal(http.StatusInternalServerError, problem.Status)
+		suite.Equal("internal server error", problem.Detail)
+		suite.Equal([]string{"connection refused"}, logged)
+	})
+}
+
+// TestProblemSuite runs the test suite
+func TestProblemSuite(t *testing.T) {
+	suite.Run(t, new(ProblemTestSuite))
+}
@@ -0,0 +1,57 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"task_manager/domain"
+)
+
+// workflowFile is the JSON layout of a workflow definition, e.g.
+//
+//	{
+//	  "states": ["todo", "doing", "done"],
+//	  "transitions": [
+//	    {"from": "todo", "to": "doing"},
+//	    {"from": "doing", "to": "done"},
+//	    {"from": "done", "to": "todo", "roles": ["admin"]}
+//	  ]
+//	}
+type workflowFile struct {
+	States      []string `json:"states"`
+	Transitions []struct {
+		From  string   `json:"from"`
+		To    string   `json:"to"`
+		Roles []string `json:"roles"`
+	} `json:"transitions"`
+}
+
+// LoadWorkflowFile reads a JSON workflow definition from path.
+func LoadWorkflowFile(path string) (*domain.Workflow, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseWorkflow(bytes.NewReader(data))
+}
+
+// ParseWorkflow decodes a JSON workflow definition and validates it. Unknown
+// fields are rejected so typos do not silently drop restrictions.
+func ParseWorkflow(r io.Reader) (*domain.Workflow, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var file workflowFile
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid workflow definition: %w", err)
+	}
+	workflow := &domain.Workflow{States: file.States}
+	for _, t := range file.Transitions {
+		workflow.Transitions = append(workflow.Transitions, domain.Transition{From: t.From, To: t.To, Roles: t.Roles})
+	}
+	if err := workflow.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid workflow definition: %w", err)
+	}
+	return workflow, nil
+}
@@ -0,0 +1,88 @@
+package infrastructure
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// WorkflowLoaderTestSuite is a test suite for the workflow loader
+type WorkflowLoaderTestSuite struct {
+	suite.Suite
+}
+
+// TestParseWorkflowSuite tests ParseWorkflow
+func (suite *WorkflowLoaderTestSuite) TestParseWorkflowSuite() {
+	suite.Run("Success", func() {
+		definition := `{
+			"states": ["todo", "doing", "done"],
+			"transitions": [
+				{"from": "todo", "to": "doing"},
+				{"from": "doing", "to": "done"},
+				{"from": "done", "to": "todo", "roles": ["admin"]}
+			]
+		}`
+
+		workflow, err := ParseWorkflow(strings.NewReader(definition))
+
+		suite.Require().NoError(err)
+		suite.Equal([]string{"todo", "doing", "done"}, workflow.States)
+		suite.Equal(domain.Transition{From: "done", To: "todo", Roles: []string{"admin"}}, workflow.Transitions[2])
+		suite.Equal([]string{"doing"}, workflow.NextStates("todo", domain.RoleUser))
+	})
+
+	suite.Run("UnknownState", func() {
+		definition := `{"states": ["todo", "done"], "transitions": [{"from": "todo", "to": "doing"}]}`
+
+		workflow, err := ParseWorkflow(strings.NewReader(definition))
+
+		suite.Error(err)
+		suite.Nil(workflow)
+	})
+
+	suite.Run("UnknownField", func() {
+		definition := `{"states": ["todo", "done"], "transitions": [{"from": "todo", "to": "done", "role": ["admin"]}]}`
+
+		workflow, err := ParseWorkflow(strings.NewReader(definition))
+
+		suite.Error(err)
+		suite.Nil(workflow)
+	})
+
+	suite.Run("NoStates", func() {
+		workflow, err := ParseWorkflow(strings.NewReader(`{}`))
+
+		suite.Error(err)
+		suite.Nil(workflow)
+	})
+}
+
+// TestLoadWorkflowFileSuite tests LoadWorkflowFile
+func (suite *WorkflowLoaderTestSuite) TestLoadWorkflowFileSuite() {
+	suite.Run("Success", func() {
+		path := filepath.Join(suite.T().TempDir(), "workflow.json")
+		suite.Require().NoError(os.WriteFile(path, []byte(`{"states": ["open", "closed"], "transitions": [{"from": "open", "to": "closed"}]}`), 0o600))
+
+		workflow, err := LoadWorkflowFile(path)
+
+		suite.Require().NoError(err)
+		suite.Equal([]string{"open", "closed"}, workflow.States)
+	})
+
+	suite.Run("MissingFile", func() {
+		workflow, err := LoadWorkflowFile(filepath.Join(suite.T().TempDir(), "missing.json"))
+
+		suite.Error(err)
+		suite.Nil(workflow)
+	})
+}
+
+// TestWorkflowLoaderSuite runs the test suite
+func TestWorkflowLoaderSuite(t *testing.T) {
+	suite.Run(t, new(WorkflowLoaderTestSuite))
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The in-memory repositories keep everything in process memory behind a
+// mutex and lose it on exit. They behave like the MongoDB ones, down to ID
+// format, ordering and time precision, and are meant for demos, local
+// development and end-to-end tests. Values are copied in and out, so callers
+// never share state with the store.
+
+// newObjectID returns a fresh ObjectID hex string, the ID format of the
+// MongoDB repositories, which the other stores share. IDs made by one
+// process sort in creation order.
+func newObjectID() string {
+	return primitive.NewObjectID().Hex()
+}
+
+// storedTime returns t as MongoDB stores it: in UTC, to the millisecond.
+func storedTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.Truncate(time.Millisecond).UTC()
+}
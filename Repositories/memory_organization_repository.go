@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"task_manager/domain"
+)
+
+type memoryOrganizationRepository struct {
+	mu            sync.RWMutex
+	organizations map[string]domain.Organization
+	// memberships is keyed by membershipID, which keeps them unique.
+	memberships map[string]domain.Membership
+}
+
+// NewMemoryOrganizationRepository returns an empty in-memory organization
+// repository.
+func NewMemoryOrganizationRepository() domain.IOrganizationRepository {
+	return &memoryOrganizationRepository{
+		organizations: make(map[string]domain.Organization),
+		memberships:   make(map[string]domain.Membership),
+	}
+}
+
+// AddOrganization stores a new organization under a freshly generated ID and
+// writes the assigned ID back onto org.
+func (r *memoryOrganizationRepository) AddOrganization(ctx context.Context, org *domain.Organization) error {
+	stored := *org
+	stored.ID = newObjectID()
+	stored.CreatedAt = storedTime(org.CreatedAt)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.organizations[stored.ID] = stored
+	org.ID = stored.ID
+	return nil
+}
+
+func (r *memoryOrganizationRepository) GetOrganization(ctx context.Context, id string) (*domain.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	org, ok := r.organizations[id]
+	if !ok {
+		return nil, domain.ErrOrganizationNotFound
+	}
+	return &org, nil
+}
+
+// ListOrganizationsForUser returns the user's organizations ordered by ID.
+func (r *memoryOrganizationRepository) ListOrganizationsForUser(ctx context.Context, userID string) ([]domain.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	orgs := make([]domain.Organization, 0)
+	for _, m := range r.memberships {
+		if org, ok := r.organizations[m.OrgID]; ok && m.UserID == userID {
+			orgs = append(orgs, org)
+		}
+	}
+	sort.Slice(orgs, func(i, j int) bool { return orgs[i].ID < orgs[j].ID })
+	return orgs, nil
+}
+
+func (r *memoryOrganizationRepository) AddMember(ctx context.Context, membership *domain.Membership) error {
+	id := membershipID(membership.OrgID, membership.UserID)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.memberships[id]; ok {
+		return domain.ErrAlreadyMember
+	}
+	stored := *membership
+	stored.CreatedAt = storedTime(membership.CreatedAt)
+	r.memberships[id] = stored
+	return nil
+}
+
+func (r *memoryOrganizationRepository) GetMembership(ctx context.Context, orgID, userID string) (*domain.Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	membership, ok := r.memberships[membershipID(orgID, userID)]
+	if !ok {
+		return nil, domain.ErrOrganizationNotFound
+	}
+	return &membership, nil
+}
+
+// ListMembers returns the members of an organization in the order they
+// joined.
+func (r *memoryOrganizationRepository) ListMembers(ctx context.Context, orgID string) ([]domain.Membership, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	members := make([]domain.Membership, 0)
+	for _, m := range r.memberships {
+		if m.OrgID == orgID {
+			members = append(members, m)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		a, b := &members[i], &members[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return membershipID(a.OrgID, a.UserID) < membershipID(b.OrgID, b.UserID)
+	})
+	return members, nil
+}
+
+func (r *memoryOrganizationRepository) UpdateMemberRole(ctx context.Context, orgID, userID, role string) error {
+	id := membershipID(orgID, userID)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	membership, ok := r.memberships[id]
+	if !ok {
+		return domain.ErrOrganizationNotFound
+	}
+	membership.Role = role
+	r.memberships[id] = membership
+	return nil
+}
+
+func (r *memoryOrganizationRepository) RemoveMember(ctx context.Context, orgID, userID string) error {
+	id := membershipID(orgID, userID)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.memberships[id]; !ok {
+		return domain.ErrOrganizationNotFound
+	}
+	delete(r.memberships, id)
+	return nil
+}
+
+func (r *memoryOrganizationRepository) RemoveUserMemberships(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, m := range r.memberships {
+		if m.UserID == userID {
+			delete(r.memberships, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryOrganizationRepository) CountMembersWithRole(ctx context.Context, orgID, role string) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var count int64
+	for _, m := range r.memberships {
+		if m.OrgID == orgID && m.Role == role {
+			count++
+		}
+	}
+	return count, nil
+}
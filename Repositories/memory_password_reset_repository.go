@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"task_manager/domain"
+	"time"
+)
+
+type memoryPasswordResetRepository struct {
+	mu     sync.RWMutex
+	resets map[string]domain.PasswordReset
+}
+
+// NewMemoryPasswordResetRepository returns an empty in-memory password reset
+// repository.
+func NewMemoryPasswordResetRepository() domain.IPasswordResetRepository {
+	return &memoryPasswordResetRepository{resets: make(map[string]domain.PasswordReset)}
+}
+
+func (r *memoryPasswordResetRepository) AddPasswordReset(ctx context.Context, reset *domain.PasswordReset) error {
+	stored := *reset
+	stored.ID = newObjectID()
+	stored.CreatedAt = storedTime(reset.CreatedAt)
+	stored.ExpiresAt = storedTime(reset.ExpiresAt)
+	stored.UsedAt = storedTime(reset.UsedAt)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resets[stored.ID] = stored
+	reset.ID = stored.ID
+	return nil
+}
+
+func (r *memoryPasswordResetRepository) GetPasswordResetByHash(ctx context.Context, hash string) (*domain.PasswordReset, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, reset := range r.resets {
+		if reset.TokenHash == hash {
+			return &reset, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *memoryPasswordResetRepository) MarkPasswordResetUsed(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	reset, ok := r.resets[id]
+	if !ok || !reset.UsedAt.IsZero() {
+		return false, nil
+	}
+	reset.UsedAt = storedTime(time.Now())
+	r.resets[id] = reset
+	return true, nil
+}
+
+func (r *memoryPasswordResetRepository) DeleteUserPasswordResets(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, reset := range r.resets {
+		if reset.UserID == userID {
+			delete(r.resets, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"task_manager/domain"
+)
+
+type memoryProjectRepository struct {
+	mu       sync.RWMutex
+	projects map[string]domain.Project
+	// members is keyed by membershipID, which keeps them unique.
+	members map[string]domain.ProjectMember
+}
+
+// NewMemoryProjectRepository returns an empty in-memory project repository.
+func NewMemoryProjectRepository() domain.IProjectRepository {
+	return &memoryProjectRepository{
+		projects: make(map[string]domain.Project),
+		members:  make(map[string]domain.ProjectMember),
+	}
+}
+
+// AddProject stores a new project under a freshly generated ID and writes
+// the assigned ID back onto project.
+func (r *memoryProjectRepository) AddProject(ctx context.Context, project *domain.Project) error {
+	stored := *project
+	stored.ID = newObjectID()
+	stored.CreatedAt = storedTime(project.CreatedAt)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.projects[stored.ID] = stored
+	project.ID = stored.ID
+	return nil
+}
+
+func (r *memoryProjectRepository) GetProject(ctx context.Context, orgID, id string) (*domain.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	project, ok := r.projects[id]
+	if !ok || project.OrgID != orgID {
+		return nil, domain.ErrProjectNotFound
+	}
+	return &project, nil
+}
+
+// ListProjects returns the projects matching query ordered by ID.
+func (r *memoryProjectRepository) ListProjects(ctx context.Context, query domain.ProjectQuery) ([]domain.Project, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	projects := make([]domain.Project, 0)
+	for _, project := range r.projects {
+		if project.OrgID != query.OrgID || (project.Archived && !query.IncludeArchived) {
+			continue
+		}
+		if query.MemberID != "" {
+			if _, ok := r.members[membershipID(project.ID, query.MemberID)]; !ok {
+				continue
+			}
+		}
+		projects = append(projects, project)
+	}
+	sort.Slice(projects, func(i, j int) bool { return projects[i].ID < projects[j].ID })
+	return projects, nil
+}
+
+func (r *memoryProjectRepository) UpdateProject(ctx context.Context, project *domain.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.projects[project.ID]
+	if !ok || stored.OrgID != project.OrgID {
+		return domain.ErrProjectNotFound
+	}
+	stored.Name = project.Name
+	stored.Description = project.Description
+	stored.Archived = project.Archived
+	r.projects[project.ID] = stored
+	return nil
+}
+
+func (r *memoryProjectRepository) DeleteProject(ctx context.Context, orgID, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	project, ok := r.projects[id]
+	if !ok || project.OrgID != orgID {
+		return domain.ErrProjectNotFound
+	}
+	delete(r.projects, id)
+	for key, m := range r.members {
+		if m.ProjectID == id {
+			delete(r.members, key)
+		}
+	}
+	return nil
+}
+
+func (r *memoryProjectRepository) AddProjectMember(ctx context.Context, member *domain.ProjectMember) error {
+	id := membershipID(member.ProjectID, member.UserID)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.members[id]; ok {
+		return domain.ErrAlreadyProjectMember
+	}
+	stored := *member
+	stored.CreatedAt = storedTime(member.CreatedAt)
+	r.members[id] = stored
+	return nil
+}
+
+func (r *memoryProjectRepository) GetProjectMember(ctx context.Context, projectID, userID string) (*domain.ProjectMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	member, ok := r.members[membershipID(projectID, userID)]
+	if !ok {
+		return nil, domain.ErrProjectNotFound
+	}
+	return &member, nil
+}
+
+// ListProjectMembers returns the members of a project in the order they
+// joined.
+func (r *memoryProjectRepository) ListProjectMembers(ctx context.Context, projectID string) ([]domain.ProjectMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	members := make([]domain.ProjectMember, 0)
+	for _, m := range r.members {
+		if m.ProjectID == projectID {
+			members = append(members, m)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		a, b := &members[i], &members[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return membershipID(a.ProjectID, a.UserID) < membershipID(b.ProjectID, b.UserID)
+	})
+	return members, nil
+}
+
+func (r *memoryProjectRepository) UpdateProjectMemberRole(ctx context.Context, projectID, userID, role string) error {
+	id := membershipID(projectID, userID)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	member, ok := r.members[id]
+	if !ok {
+		return domain.ErrProjectNotFound
+	}
+	member.Role = role
+	r.members[id] = member
+	return nil
+}
+
+func (r *memoryProjectRepository) RemoveProjectMember(ctx context.Context, projectID, userID string) error {
+	id := membershipID(projectID, userID)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.members[id]; !ok {
+		return domain.ErrProjectNotFound
+	}
+	delete(r.members, id)
+	return nil
+}
+
+func (r *memoryProjectRepository) RemoveUserProjectMemberships(ctx context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, m := range r.members {
+		if m.UserID == userID {
+			delete(r.members, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"task_manager/domain"
+)
+
+type memoryRoleRepository struct {
+	mu    sync.RWMutex
+	roles map[string]domain.Role
+}
+
+// NewMemoryRoleRepository returns an empty in-memory role repository.
+func NewMemoryRoleRepository() domain.IRoleRepository {
+	return &memoryRoleRepository{roles: make(map[string]domain.Role)}
+}
+
+// storedRole returns the parts of role the repository keeps.
+func storedRole(role *domain.Role) domain.Role {
+	return domain.Role{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: append([]string(nil), role.Permissions...),
+	}
+}
+
+func (r *memoryRoleRepository) AddRole(ctx context.Context, role *domain.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.roles[role.Name]; ok {
+		return domain.ErrRoleExists
+	}
+	r.roles[role.Name] = storedRole(role)
+	return nil
+}
+
+func (r *memoryRoleRepository) GetRole(ctx context.Context, name string) (*domain.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	role, ok := r.roles[name]
+	if !ok {
+		return nil, domain.ErrRoleNotFound
+	}
+	role = storedRole(&role)
+	return &role, nil
+}
+
+// ListRoles returns every custom role ordered by name.
+func (r *memoryRoleRepository) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	roles := make([]domain.Role, 0, len(r.roles))
+	for _, role := range r.roles {
+		roles = append(roles, storedRole(&role))
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+func (r *memoryRoleRepository) UpdateRole(ctx context.Context, role *domain.Role) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.roles[role.Name]; !ok {
+		return domain.ErrRoleNotFound
+	}
+	r.roles[role.Name] = storedRole(role)
+	return nil
+}
+
+func (r *memoryRoleRepository) DeleteRole(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.roles[name]; !ok {
+		return domain.ErrRoleNotFound
+	}
+	delete(r.roles, name)
+	return nil
+}
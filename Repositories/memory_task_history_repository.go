@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"task_manager/domain"
+)
+
+type memoryTaskHistoryRepository struct {
+	mu      sync.RWMutex
+	entries []domain.TaskHistoryEntry
+}
+
+// NewMemoryTaskHistoryRepository returns an empty in-memory task history
+// repository.
+func NewMemoryTaskHistoryRepository() domain.ITaskHistoryRepository {
+	return &memoryTaskHistoryRepository{}
+}
+
+// AddEntry stores a new history entry under a freshly generated ID and
+// writes the assigned ID back onto entry.
+func (r *memoryTaskHistoryRepository) AddEntry(ctx context.Context, entry *domain.TaskHistoryEntry) error {
+	stored := *entry
+	stored.ID = newObjectID()
+	stored.CreatedAt = storedTime(entry.CreatedAt)
+	stored.Changes = append([]domain.TaskChange(nil), entry.Changes...)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, stored)
+	entry.ID = stored.ID
+	return nil
+}
+
+func (r *memoryTaskHistoryRepository) ListEntries(ctx context.Context, orgID, taskID string) ([]domain.TaskHistoryEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entries := make([]domain.TaskHistoryEntry, 0)
+	for _, entry := range r.entries {
+		if entry.OrgID == orgID && entry.TaskID == taskID {
+			entry.Changes = append(make([]domain.TaskChange, 0, len(entry.Changes)), entry.Changes...)
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
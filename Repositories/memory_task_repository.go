@@ -0,0 +1,328 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"task_manager/domain"
+	"time"
+)
+
+type memoryTaskRepository struct {
+	mu    sync.RWMutex
+	tasks map[string]domain.Task
+}
+
+// NewMemoryTaskRepository returns an empty in-memory task repository.
+func NewMemoryTaskRepository() domain.ITaskRepository {
+	return &memoryTaskRepository{tasks: make(map[string]domain.Task)}
+}
+
+// storedTask returns task as the repository keeps it.
+func storedTask(task domain.Task) domain.Task {
+	task.DueDate = storedTime(task.DueDate)
+	task.DeletedAt = storedTime(task.DeletedAt)
+	if task.OrgID == "" {
+		task.OrgID = domain.DefaultOrganizationID
+	}
+	return task
+}
+
+// AddTask stores a new task under a freshly generated ID and writes the
+// assigned ID back onto the task.
+func (r *memoryTaskRepository) AddTask(ctx context.Context, task *domain.Task) error {
+	stored := storedTask(*task)
+	stored.ID = newObjectID()
+	stored.Version = 1
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tasks[stored.ID] = stored
+	task.ID = stored.ID
+	task.OrgID = stored.OrgID
+	task.Version = stored.Version
+	return nil
+}
+
+// GetAllTasks returns one page of tasks matching query. Pages are ordered by
+// the sort field with the ID as a tie-breaker, and NextCursor resumes after
+// the last task of the page.
+func (r *memoryTaskRepository) GetAllTasks(ctx context.Context, query domain.TaskQuery) (*domain.TaskPage, error) {
+	var cursor *taskCursor
+	if query.Cursor != "" {
+		var err error
+		if cursor, err = decodeTaskCursor(query); err != nil {
+			return nil, err
+		}
+	}
+
+	r.mu.RLock()
+	matching := make([]domain.Task, 0)
+	for _, task := range r.tasks {
+		if taskMatchesQuery(&task, query) {
+			matching = append(matching, task)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(matching, func(i, j int) bool {
+		c := compareTasks(&matching[i], &matching[j], query.SortBy)
+		if query.SortDesc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	page := &domain.TaskPage{Tasks: make([]domain.Task, 0), Total: int64(len(matching))}
+	for _, task := range matching {
+		if cursor != nil && !taskAfterCursor(&task, cursor, query) {
+			continue
+		}
+		if query.Limit > 0 && len(page.Tasks) == query.Limit {
+			page.NextCursor = encodeTaskCursor(&page.Tasks[len(page.Tasks)-1], query)
+			break
+		}
+		page.Tasks = append(page.Tasks, task)
+	}
+	return page, nil
+}
+
+// taskMatchesQuery applies the filters of query the way taskQueryFilter
+// does.
+func taskMatchesQuery(task *domain.Task, query domain.TaskQuery) bool {
+	if !inOrganization(task, query.OrgID) || query.Deleted == task.DeletedAt.IsZero() {
+		return false
+	}
+	if query.VisibleTo != "" && task.CreatedBy != query.VisibleTo && task.AssigneeID != query.VisibleTo &&
+		!(task.ProjectID != "" && containsID(query.VisibleProjectIDs, task.ProjectID)) {
+		return false
+	}
+	if query.ProjectID != "" {
+		if task.ProjectID != query.ProjectID {
+			return false
+		}
+	} else if containsID(query.ExcludeProjectIDs, task.ProjectID) {
+		return false
+	}
+	if query.Status != "" && task.Status != query.Status {
+		return false
+	}
+	if !query.DueAfter.IsZero() && task.DueDate.Before(storedTime(query.DueAfter)) {
+		return false
+	}
+	if !query.DueBefore.IsZero() && task.DueDate.After(storedTime(query.DueBefore)) {
+		return false
+	}
+	return strings.HasPrefix(task.Title, query.TitlePrefix)
+}
+
+// inOrganization reports whether task belongs to orgID, where an empty
+// orgID stands for the default organization.
+func inOrganization(task *domain.Task, orgID string) bool {
+	if orgID == "" {
+		orgID = domain.DefaultOrganizationID
+	}
+	return task.OrgID == orgID
+}
+
+func containsID(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// compareTasks orders a and b by the sort field, then by ID.
+func compareTasks(a, b *domain.Task, sortBy string) int {
+	var c int
+	switch sortBy {
+	case domain.TaskSortByTitle:
+		c = strings.Compare(a.Title, b.Title)
+	case domain.TaskSortByStatus:
+		c = strings.Compare(a.Status, b.Status)
+	case domain.TaskSortByDueDate:
+		c = a.DueDate.Compare(b.DueDate)
+	}
+	if c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+// taskAfterCursor reports whether task sorts strictly after cursor, like
+// taskCursorFilter.
+func taskAfterCursor(task *domain.Task, cursor *taskCursor, query domain.TaskQuery) bool {
+	last := domain.Task{ID: cursor.ID}
+	switch query.SortBy {
+	case domain.TaskSortByTitle:
+		last.Title = cursor.Value
+	case domain.TaskSortByStatus:
+		last.Status = cursor.Value
+	case domain.TaskSortByDueDate:
+		last.DueDate = cursor.dueDate()
+	}
+	c := compareTasks(task, &last, query.SortBy)
+	if query.SortDesc {
+		return c < 0
+	}
+	return c > 0
+}
+
+func (r *memoryTaskRepository) GetTaskByID(ctx context.Context, orgID, id string) (*domain.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	task, ok := r.liveTask(orgID, id)
+	if !ok {
+		return nil, domain.ErrTaskNotFound
+	}
+	return &task, nil
+}
+
+// liveTask returns the task id of orgID unless it is in the trash. The
+// caller must hold r.mu.
+func (r *memoryTaskRepository) liveTask(orgID, id string) (domain.Task, bool) {
+	task, ok := r.tasks[id]
+	if !ok || !inOrganization(&task, orgID) || !task.DeletedAt.IsZero() {
+		return domain.Task{}, false
+	}
+	return task, true
+}
+
+// versionedUpdate applies update to the live task id of orgID if it is still
+// at version, and increments the version.
+func (r *memoryTaskRepository) versionedUpdate(orgID, id string, version int64, update func(*domain.Task)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.liveTask(orgID, id)
+	if !ok {
+		return domain.ErrTaskNotFound
+	}
+	if task.Version != version {
+		return domain.ErrVersionConflict
+	}
+	update(&task)
+	task.Version = version + 1
+	r.tasks[id] = storedTask(task)
+	return nil
+}
+
+func (r *memoryTaskRepository) UpdateTask(ctx context.Context, task *domain.Task) error {
+	err := r.versionedUpdate(task.OrgID, task.ID, task.Version, func(stored *domain.Task) {
+		*stored = *task
+		stored.DeletedAt = time.Time{}
+		stored.DeletedBy = ""
+	})
+	if err != nil {
+		return err
+	}
+	task.Version++
+	return nil
+}
+
+func (r *memoryTaskRepository) PatchTask(ctx context.Context, orgID, id string, version int64, patch domain.TaskPatch) error {
+	return r.versionedUpdate(orgID, id, version, patch.Apply)
+}
+
+func (r *memoryTaskRepository) DeleteTask(ctx context.Context, task *domain.Task) error {
+	err := r.versionedUpdate(task.OrgID, task.ID, task.Version, func(stored *domain.Task) {
+		stored.DeletedAt = task.DeletedAt
+		stored.DeletedBy = task.DeletedBy
+	})
+	if err != nil {
+		return err
+	}
+	task.Version++
+	return nil
+}
+
+func (r *memoryTaskRepository) RestoreTask(ctx context.Context, orgID, id string) (*domain.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task, ok := r.tasks[id]
+	if !ok || !inOrganization(&task, orgID) || task.DeletedAt.IsZero() {
+		return nil, domain.ErrTaskNotFound
+	}
+	restored := task
+	restored.DeletedAt = time.Time{}
+	restored.DeletedBy = ""
+	restored.Version++
+	r.tasks[id] = restored
+	return &task, nil
+}
+
+func (r *memoryTaskRepository) PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var purged int64
+	for id, task := range r.tasks {
+		if !task.DeletedAt.IsZero() && task.DeletedAt.Before(deletedBefore) {
+			delete(r.tasks, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (r *memoryTaskRepository) ReassignTasks(ctx context.Context, fromUserID, toUserID string, orgIDs []string) error {
+	inOrg := make(map[string]bool)
+	for _, orgID := range orgIDs {
+		inOrg[taskOrgID(orgID)] = true
+	}
+	r.updateMany(func(task *domain.Task) bool {
+		if task.CreatedBy != fromUserID || !inOrg[task.OrgID] {
+			return false
+		}
+		task.CreatedBy = toUserID
+		return true
+	})
+	return nil
+}
+
+func (r *memoryTaskRepository) DeleteTasksByCreator(ctx context.Context, userID string, orgIDs []string, deletedBy string, deletedAt time.Time) ([]domain.Task, error) {
+	inOrg := make(map[string]bool)
+	for _, orgID := range orgIDs {
+		inOrg[taskOrgID(orgID)] = true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var deleted []domain.Task
+	for id, task := range r.tasks {
+		if task.CreatedBy != userID || !inOrg[task.OrgID] || !task.DeletedAt.IsZero() {
+			continue
+		}
+		task.DeletedAt = storedTime(deletedAt)
+		task.DeletedBy = deletedBy
+		task.Version++
+		r.tasks[id] = task
+		deleted = append(deleted, task)
+	}
+	sort.Slice(deleted, func(i, j int) bool { return deleted[i].ID < deleted[j].ID })
+	return deleted, nil
+}
+
+func (r *memoryTaskRepository) UnassignTasks(ctx context.Context, userID string) error {
+	r.updateMany(func(task *domain.Task) bool {
+		if task.AssigneeID != userID {
+			return false
+		}
+		task.AssigneeID = ""
+		return true
+	})
+	return nil
+}
+
+// updateMany applies update to every task, in the trash or not, and
+// increments the version of those it reports as changed.
+func (r *memoryTaskRepository) updateMany(update func(*domain.Task) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, task := range r.tasks {
+		if update(&task) {
+			task.Version++
+			r.tasks[id] = task
+		}
+	}
+}
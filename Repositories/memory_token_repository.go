@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"task_manager/domain"
+	"time"
+)
+
+type memoryTokenRepository struct {
+	mu            sync.RWMutex
+	refreshTokens map[string]domain.RefreshToken
+	// revokedTokens maps the jti of a revoked access token to its expiry.
+	revokedTokens map[string]time.Time
+	// revokedBefore maps a user ID to the cutoff set by
+	// RevokeUserAccessTokens.
+	revokedBefore map[string]time.Time
+}
+
+// NewMemoryTokenRepository returns an empty in-memory token repository.
+func NewMemoryTokenRepository() domain.ITokenRepository {
+	return &memoryTokenRepository{
+		refreshTokens: make(map[string]domain.RefreshToken),
+		revokedTokens: make(map[string]time.Time),
+		revokedBefore: make(map[string]time.Time),
+	}
+}
+
+func (r *memoryTokenRepository) AddRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
+	stored := *token
+	stored.ID = newObjectID()
+	stored.CreatedAt = storedTime(token.CreatedAt)
+	stored.ExpiresAt = storedTime(token.ExpiresAt)
+	stored.UsedAt = storedTime(token.UsedAt)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refreshTokens[stored.ID] = stored
+	token.ID = stored.ID
+	return nil
+}
+
+func (r *memoryTokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, token := range r.refreshTokens {
+		if token.TokenHash == hash {
+			return &token, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (r *memoryTokenRepository) MarkRefreshTokenUsed(ctx context.Context, id string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.refreshTokens[id]
+	if !ok || !token.UsedAt.IsZero() || token.Revoked {
+		return false, nil
+	}
+	token.UsedAt = storedTime(time.Now())
+	r.refreshTokens[id] = token
+	return true, nil
+}
+
+func (r *memoryTokenRepository) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	r.revokeRefreshTokens(func(token *domain.RefreshToken) bool { return token.FamilyID == familyID })
+	return nil
+}
+
+func (r *memoryTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	r.revokeRefreshTokens(func(token *domain.RefreshToken) bool { return token.UserID == userID })
+	return nil
+}
+
+// revokeRefreshTokens revokes every refresh token match accepts.
+func (r *memoryTokenRepository) revokeRefreshTokens(match func(*domain.RefreshToken) bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, token := range r.refreshTokens {
+		if match(&token) {
+			token.Revoked = true
+			r.refreshTokens[id] = token
+		}
+	}
+}
+
+func (r *memoryTokenRepository) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revokedTokens[tokenID] = storedTime(expiresAt)
+	return nil
+}
+
+func (r *memoryTokenRepository) RevokeUserAccessTokens(ctx context.Context, userID string, issuedBefore time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revokedBefore[userID] = storedTime(issuedBefore)
+	return nil
+}
+
+func (r *memoryTokenRepository) IsAccessTokenRevoked(ctx context.Context, tokenID, userID string, issuedAt time.Time) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if _, ok := r.revokedTokens[tokenID]; ok {
+		return true, nil
+	}
+	cutoff, ok := r.revokedBefore[userID]
+	return ok && cutoff.After(storedTime(issuedAt)), nil
+}
+
+func (r *memoryTokenRepository) PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var purged int64
+	for id, token := range r.refreshTokens {
+		if token.ExpiresAt.Before(now) {
+			delete(r.refreshTokens, id)
+			purged++
+		}
+	}
+	for id, expiresAt := range r.revokedTokens {
+		if expiresAt.Before(now) {
+			delete(r.revokedTokens, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
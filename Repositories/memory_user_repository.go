@@ -0,0 +1,282 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"task_manager/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[string]domain.User
+	// firstAdminID is the ID of the user added by AddFirstAdmin. It is
+	// stale once that user has been deleted.
+	firstAdminID string
+}
+
+// NewMemoryUserRepository returns an empty in-memory user repository.
+func NewMemoryUserRepository() domain.IUserRepository {
+	return &memoryUserRepository{users: make(map[string]domain.User)}
+}
+
+// AddUser stores a new user under a freshly generated ID and writes the
+// assigned ID back onto the user.
+func (r *memoryUserRepository) AddUser(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.add(user)
+}
+
+func (r *memoryUserRepository) AddFirstAdmin(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[r.firstAdminID]; ok {
+		return domain.ErrFirstAdminExists
+	}
+	if err := r.add(user); err != nil {
+		return err
+	}
+	r.firstAdminID = user.ID
+	return nil
+}
+
+// add stores user under a new ID unless its username or email is taken. The
+// caller must hold r.mu.
+func (r *memoryUserRepository) add(user *domain.User) error {
+	if err := r.ensureUnique("", user.Username, user.Email); err != nil {
+		return err
+	}
+	stored := *user
+	stored.ID = newObjectID()
+	r.users[stored.ID] = stored
+	user.ID = stored.ID
+	return nil
+}
+
+func (r *memoryUserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.findUser(func(u *domain.User) bool { return u.Email == email })
+}
+
+func (r *memoryUserRepository) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	return r.findUser(func(u *domain.User) bool { return u.Username == username })
+}
+
+func (r *memoryUserRepository) GetUserByID(ctx context.Context, id string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+// findUser returns the first user in ID order that match accepts, or
+// domain.ErrUserNotFound.
+func (r *memoryUserRepository) findUser(match func(*domain.User) bool) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.sortedUsers() {
+		if match(&user) {
+			return &user, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+// sortedUsers returns every user ordered by ID. The caller must hold r.mu.
+func (r *memoryUserRepository) sortedUsers() []domain.User {
+	users := make([]domain.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+// ensureUnique fails if another user than id already has username or
+// email. The caller must hold r.mu.
+func (r *memoryUserRepository) ensureUnique(id, username, email string) error {
+	for _, user := range r.users {
+		if user.ID == id {
+			continue
+		}
+		if user.Email == email {
+			return domain.ErrEmailTaken
+		}
+		if user.Username == username {
+			return domain.ErrUsernameTaken
+		}
+	}
+	return nil
+}
+
+func (r *memoryUserRepository) IsUsersCollectionEmpty(ctx context.Context) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.users) == 0, nil
+}
+
+func (r *memoryUserRepository) UpdatePassword(ctx context.Context, id, hashedPassword string) error {
+	return r.updateByID(id, func(u *domain.User) error {
+		u.Password = hashedPassword
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	return r.updateByID(id, func(u *domain.User) error {
+		u.EmailVerified = true
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) UpdateProfile(ctx context.Context, user *domain.User) error {
+	return r.updateByID(user.ID, func(u *domain.User) error {
+		if err := r.ensureUnique(u.ID, user.Username, user.Email); err != nil {
+			return err
+		}
+		u.Username = user.Username
+		u.Email = user.Email
+		u.EmailVerified = user.EmailVerified
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) PromoteUserToAdmin(ctx context.Context, identifier string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.sortedUsers() {
+		if user.Username == identifier || user.Email == identifier {
+			user.Role = domain.RoleAdmin
+			r.users[user.ID] = user
+			return nil
+		}
+	}
+	return domain.ErrUserNotFound
+}
+
+// ListUsers returns one page of users matching query, ordered by ID.
+// NextCursor is the ID of the last user on the page.
+func (r *memoryUserRepository) ListUsers(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error) {
+	if query.Cursor != "" {
+		if _, err := primitive.ObjectIDFromHex(query.Cursor); err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidUserQuery)
+		}
+	}
+	search := strings.ToLower(query.Search)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	page := &domain.UserPage{Users: make([]domain.User, 0)}
+	for _, user := range r.sortedUsers() {
+		if search != "" && !strings.Contains(strings.ToLower(user.Username), search) && !strings.Contains(strings.ToLower(user.Email), search) {
+			continue
+		}
+		if query.Role != "" && user.Role != query.Role {
+			continue
+		}
+		page.Total++
+		if query.Cursor != "" && user.ID <= query.Cursor {
+			continue
+		}
+		if query.Limit > 0 && len(page.Users) == query.Limit {
+			page.NextCursor = page.Users[len(page.Users)-1].ID
+			continue
+		}
+		page.Users = append(page.Users, user)
+	}
+	return page, nil
+}
+
+func (r *memoryUserRepository) UpdateUserRole(ctx context.Context, id, role string) error {
+	return r.updateByID(id, func(u *domain.User) error {
+		if role != domain.RoleAdmin {
+			if err := r.ensureOtherActiveAdmin(u); err != nil {
+				return err
+			}
+		}
+		u.Role = role
+		return nil
+	})
+}
+
+func (r *memoryUserRepository) SetUserDeactivated(ctx context.Context, id string, deactivated bool) error {
+	return r.updateByID(id, func(u *domain.User) error {
+		if deactivated {
+			if err := r.ensureOtherActiveAdmin(u); err != nil {
+				return err
+			}
+		}
+		u.Deactivated = deactivated
+		return nil
+	})
+}
+
+// ensureOtherActiveAdmin returns domain.ErrLastAdmin if u is the only active
+// admin. The caller holds the write lock.
+func (r *memoryUserRepository) ensureOtherActiveAdmin(u *domain.User) error {
+	if u.Role != domain.RoleAdmin || u.Deactivated {
+		return nil
+	}
+	for _, other := range r.users {
+		if other.ID != u.ID && other.Role == domain.RoleAdmin && !other.Deactivated {
+			return nil
+		}
+	}
+	return domain.ErrLastAdmin
+}
+
+func (r *memoryUserRepository) DeleteUser(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[id]; !ok {
+		return domain.ErrUserNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+func (r *memoryUserRepository) CountActiveAdmins(ctx context.Context) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var count int64
+	for _, user := range r.users {
+		if user.Role == domain.RoleAdmin && !user.Deactivated {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryUserRepository) SetActiveOrganization(ctx context.Context, id, orgID string) error {
+	if orgID == domain.DefaultOrganizationID {
+		orgID = ""
+	}
+	return r.updateByID(id, func(u *domain.User) error {
+		u.ActiveOrgID = orgID
+		return nil
+	})
+}
+
+// updateByID applies update to the user with the given ID and returns
+// domain.ErrUserNotFound if there is no such user. Nothing is stored if
+// update fails.
+func (r *memoryUserRepository) updateByID(id string, update func(*domain.User) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return domain.ErrUserNotFound
+	}
+	if err := update(&user); err != nil {
+		return err
+	}
+	r.users[id] = user
+	return nil
+}
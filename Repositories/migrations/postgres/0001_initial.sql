@@ -0,0 +1,120 @@
+-- Initial schema. IDs are ObjectID hex strings and times are milliseconds
+-- since the Unix epoch; NULL stands for an unset time. Text is compared
+-- byte by byte (COLLATE "C"), like MongoDB and SQLite do, so sorting and
+-- pagination do not depend on the database locale.
+
+CREATE TABLE users (
+	id TEXT COLLATE "C" PRIMARY KEY,
+	username TEXT COLLATE "C" NOT NULL UNIQUE,
+	email TEXT COLLATE "C" NOT NULL UNIQUE,
+	password TEXT COLLATE "C" NOT NULL,
+	role TEXT COLLATE "C" NOT NULL,
+	email_verified BOOLEAN NOT NULL,
+	deactivated BOOLEAN NOT NULL,
+	active_org_id TEXT COLLATE "C" NOT NULL
+);
+
+CREATE TABLE tasks (
+	id TEXT COLLATE "C" PRIMARY KEY,
+	org_id TEXT COLLATE "C" NOT NULL,
+	title TEXT COLLATE "C" NOT NULL,
+	description TEXT COLLATE "C" NOT NULL,
+	due_date BIGINT NOT NULL,
+	status TEXT COLLATE "C" NOT NULL,
+	created_by TEXT COLLATE "C" NOT NULL,
+	assignee_id TEXT COLLATE "C" NOT NULL,
+	project_id TEXT COLLATE "C" NOT NULL,
+	version BIGINT NOT NULL,
+	deleted_at BIGINT,
+	deleted_by TEXT COLLATE "C" NOT NULL
+);
+CREATE INDEX tasks_org_id_idx ON tasks (org_id, deleted_at);
+CREATE INDEX tasks_created_by_idx ON tasks (created_by);
+CREATE INDEX tasks_assignee_id_idx ON tasks (assignee_id);
+
+CREATE TABLE task_history (
+	id TEXT COLLATE "C" PRIMARY KEY,
+	task_id TEXT COLLATE "C" NOT NULL,
+	org_id TEXT COLLATE "C" NOT NULL,
+	action TEXT COLLATE "C" NOT NULL,
+	actor_id TEXT COLLATE "C" NOT NULL,
+	created_at BIGINT NOT NULL,
+	changes TEXT COLLATE "C" NOT NULL
+);
+CREATE INDEX task_history_task_id_idx ON task_history (org_id, task_id, created_at);
+
+CREATE TABLE refresh_tokens (
+	id TEXT COLLATE "C" PRIMARY KEY,
+	user_id TEXT COLLATE "C" NOT NULL,
+	family_id TEXT COLLATE "C" NOT NULL,
+	token_hash TEXT COLLATE "C" NOT NULL,
+	created_at BIGINT NOT NULL,
+	expires_at BIGINT NOT NULL,
+	used_at BIGINT,
+	revoked BOOLEAN NOT NULL
+);
+CREATE INDEX refresh_tokens_token_hash_idx ON refresh_tokens (token_hash);
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+CREATE TABLE revoked_access_tokens (
+	jti TEXT COLLATE "C" PRIMARY KEY,
+	expires_at BIGINT NOT NULL
+);
+
+CREATE TABLE access_token_cutoffs (
+	user_id TEXT COLLATE "C" PRIMARY KEY,
+	revoked_before BIGINT NOT NULL
+);
+
+CREATE TABLE password_resets (
+	id TEXT COLLATE "C" PRIMARY KEY,
+	user_id TEXT COLLATE "C" NOT NULL,
+	token_hash TEXT COLLATE "C" NOT NULL,
+	created_at BIGINT NOT NULL,
+	expires_at BIGINT NOT NULL,
+	used_at BIGINT
+);
+CREATE INDEX password_resets_token_hash_idx ON password_resets (token_hash);
+
+CREATE TABLE roles (
+	name TEXT COLLATE "C" PRIMARY KEY,
+	description TEXT COLLATE "C" NOT NULL,
+	permissions TEXT COLLATE "C" NOT NULL
+);
+
+CREATE TABLE organizations (
+	id TEXT COLLATE "C" PRIMARY KEY,
+	name TEXT COLLATE "C" NOT NULL,
+	created_by TEXT COLLATE "C" NOT NULL,
+	created_at BIGINT NOT NULL
+);
+
+CREATE TABLE memberships (
+	org_id TEXT COLLATE "C" NOT NULL,
+	user_id TEXT COLLATE "C" NOT NULL,
+	role TEXT COLLATE "C" NOT NULL,
+	created_at BIGINT NOT NULL,
+	PRIMARY KEY (org_id, user_id)
+);
+CREATE INDEX memberships_user_id_idx ON memberships (user_id);
+
+CREATE TABLE projects (
+	id TEXT COLLATE "C" PRIMARY KEY,
+	org_id TEXT COLLATE "C" NOT NULL,
+	name TEXT COLLATE "C" NOT NULL,
+	description TEXT COLLATE "C" NOT NULL,
+	created_by TEXT COLLATE "C" NOT NULL,
+	created_at BIGINT NOT NULL,
+	archived BOOLEAN NOT NULL
+);
+CREATE INDEX projects_org_id_idx ON projects (org_id);
+
+CREATE TABLE project_members (
+	project_id TEXT COLLATE "C" NOT NULL,
+	user_id TEXT COLLATE "C" NOT NULL,
+	role TEXT COLLATE "C" NOT NULL,
+	created_at BIGINT NOT NULL,
+	PRIMARY KEY (project_id, user_id)
+);
+CREATE INDEX project_members_user_id_idx ON project_members (user_id);
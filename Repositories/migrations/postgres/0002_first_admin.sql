@@ -0,0 +1,7 @@
+-- The account that bootstrapped the store has first_admin set to TRUE and
+-- every other one NULL, which a unique index does not compare, so only one
+-- first admin can exist at a time.
+
+ALTER TABLE users ADD COLUMN first_admin BOOLEAN;
+
+CREATE UNIQUE INDEX users_first_admin_key ON users (first_admin);
@@ -0,0 +1,5 @@
+-- Expired refresh tokens and revoked access tokens are swept periodically
+-- by PurgeExpiredTokens, which looks them up by expires_at.
+
+CREATE INDEX refresh_tokens_expires_at_idx ON refresh_tokens (expires_at);
+CREATE INDEX revoked_access_tokens_expires_at_idx ON revoked_access_tokens (expires_at);
@@ -0,0 +1,118 @@
+-- Initial schema. IDs are ObjectID hex strings and times are milliseconds
+-- since the Unix epoch; NULL stands for an unset time.
+
+CREATE TABLE users (
+	id TEXT PRIMARY KEY,
+	username TEXT NOT NULL UNIQUE,
+	email TEXT NOT NULL UNIQUE,
+	password TEXT NOT NULL,
+	role TEXT NOT NULL,
+	email_verified BOOLEAN NOT NULL,
+	deactivated BOOLEAN NOT NULL,
+	active_org_id TEXT NOT NULL
+);
+
+CREATE TABLE tasks (
+	id TEXT PRIMARY KEY,
+	org_id TEXT NOT NULL,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL,
+	due_date BIGINT NOT NULL,
+	status TEXT NOT NULL,
+	created_by TEXT NOT NULL,
+	assignee_id TEXT NOT NULL,
+	project_id TEXT NOT NULL,
+	version BIGINT NOT NULL,
+	deleted_at BIGINT,
+	deleted_by TEXT NOT NULL
+);
+CREATE INDEX tasks_org_id_idx ON tasks (org_id, deleted_at);
+CREATE INDEX tasks_created_by_idx ON tasks (created_by);
+CREATE INDEX tasks_assignee_id_idx ON tasks (assignee_id);
+
+CREATE TABLE task_history (
+	id TEXT PRIMARY KEY,
+	task_id TEXT NOT NULL,
+	org_id TEXT NOT NULL,
+	action TEXT NOT NULL,
+	actor_id TEXT NOT NULL,
+	created_at BIGINT NOT NULL,
+	changes TEXT NOT NULL
+);
+CREATE INDEX task_history_task_id_idx ON task_history (org_id, task_id, created_at);
+
+CREATE TABLE refresh_tokens (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	family_id TEXT NOT NULL,
+	token_hash TEXT NOT NULL,
+	created_at BIGINT NOT NULL,
+	expires_at BIGINT NOT NULL,
+	used_at BIGINT,
+	revoked BOOLEAN NOT NULL
+);
+CREATE INDEX refresh_tokens_token_hash_idx ON refresh_tokens (token_hash);
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+CREATE TABLE revoked_access_tokens (
+	jti TEXT PRIMARY KEY,
+	expires_at BIGINT NOT NULL
+);
+
+CREATE TABLE access_token_cutoffs (
+	user_id TEXT PRIMARY KEY,
+	revoked_before BIGINT NOT NULL
+);
+
+CREATE TABLE password_resets (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	token_hash TEXT NOT NULL,
+	created_at BIGINT NOT NULL,
+	expires_at BIGINT NOT NULL,
+	used_at BIGINT
+);
+CREATE INDEX password_resets_token_hash_idx ON password_resets (token_hash);
+
+CREATE TABLE roles (
+	name TEXT PRIMARY KEY,
+	description TEXT NOT NULL,
+	permissions TEXT NOT NULL
+);
+
+CREATE TABLE organizations (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	created_by TEXT NOT NULL,
+	created_at BIGINT NOT NULL
+);
+
+CREATE TABLE memberships (
+	org_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	role TEXT NOT NULL,
+	created_at BIGINT NOT NULL,
+	PRIMARY KEY (org_id, user_id)
+);
+CREATE INDEX memberships_user_id_idx ON memberships (user_id);
+
+CREATE TABLE projects (
+	id TEXT PRIMARY KEY,
+	org_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	created_by TEXT NOT NULL,
+	created_at BIGINT NOT NULL,
+	archived BOOLEAN NOT NULL
+);
+CREATE INDEX projects_org_id_idx ON projects (org_id);
+
+CREATE TABLE project_members (
+	project_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	role TEXT NOT NULL,
+	created_at BIGINT NOT NULL,
+	PRIMARY KEY (project_id, user_id)
+);
+CREATE INDEX project_members_user_id_idx ON project_members (user_id);
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrationTestSuite checks the SQL and MongoDB migration runners.
+type MigrationTestSuite struct {
+	suite.Suite
+	ctx context.Context
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *MigrationTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+}
+
+func (suite *MigrationTestSuite) TestSQLMigrateSuite() {
+	db, err := OpenSQLDatabase(suite.ctx, DialectSQLite, filepath.Join(suite.T().TempDir(), "test.db"))
+	suite.Require().NoError(err)
+	defer db.db.Close()
+
+	suite.Run("ListsPendingMigrations", func() {
+		pending, err := db.PendingMigrations(suite.ctx)
+		suite.NoError(err)
+		suite.Equal([]string{"0001_initial", "0002_first_admin", "0003_token_expiry"}, pending)
+	})
+
+	suite.Run("AppliesEveryMigrationOnce", func() {
+		applied, err := db.Migrate(suite.ctx)
+		suite.Require().NoError(err)
+		suite.Equal([]string{"0001_initial", "0002_first_admin", "0003_token_expiry"}, applied)
+
+		applied, err = db.Migrate(suite.ctx)
+		suite.NoError(err)
+		suite.Empty(applied)
+
+		pending, err := db.PendingMigrations(suite.ctx)
+		suite.NoError(err)
+		suite.Empty(pending)
+	})
+
+	suite.Run("ConcurrentInstances", func() {
+		path := filepath.Join(suite.T().TempDir(), "shared.db")
+		// Start from an empty schema_migrations, so every instance finds
+		// the migrations pending before any of them applies one.
+		setup, err := OpenSQLDatabase(suite.ctx, DialectSQLite, path)
+		suite.Require().NoError(err)
+		_, err = setup.db.Exec(`CREATE TABLE schema_migrations (version BIGINT PRIMARY KEY, applied_at BIGINT NOT NULL)`)
+		suite.Require().NoError(err)
+		setup.db.Close()
+
+		results := make([][]string, 8)
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := range results {
+			instance, err := OpenSQLDatabase(suite.ctx, DialectSQLite, path)
+			suite.Require().NoError(err)
+			defer instance.db.Close()
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				applied, err := instance.Migrate(suite.ctx)
+				suite.NoError(err)
+				results[i] = applied
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+
+		var applied []string
+		for _, names := range results {
+			applied = append(applied, names...)
+		}
+		sort.Strings(applied)
+		suite.Equal([]string{"0001_initial", "0002_first_admin", "0003_token_expiry"}, applied, "each migration is applied once")
+	})
+}
+
+func (suite *MigrationTestSuite) TestMongoMigrateSuite() {
+	db := testMongoDatabase(suite.T()).Client().Database("task_manager_migrations_test")
+	suite.Require().NoError(db.Drop(suite.ctx))
+	defer db.Drop(suite.ctx)
+
+	suite.Run("RefusesDuplicateUsers", func() {
+		users := db.Collection("users")
+		_, err := users.InsertMany(suite.ctx, []interface{}{
+			bson.M{"username": "alice", "email": "alice@example.com"},
+			bson.M{"username": "alice", "email": "alice2@example.com"},
+		})
+		suite.Require().NoError(err)
+
+		_, err = migrateMongoDatabase(suite.ctx, db)
+		suite.ErrorContains(err, "0001_users_unique_email_username")
+
+		count, err := db.Collection("schema_migrations").CountDocuments(suite.ctx, bson.M{})
+		suite.NoError(err)
+		suite.Zero(count, "a failed migration is not recorded")
+
+		_, err = users.DeleteMany(suite.ctx, bson.M{})
+		suite.Require().NoError(err)
+	})
+
+	suite.Run("AppliesEveryMigrationOnce", func() {
+		pending, err := pendingMongoMigrations(suite.ctx, db)
+		suite.Require().NoError(err)
+		suite.Len(pending, len(mongoMigrations))
+
+		applied, err := migrateMongoDatabase(suite.ctx, db)
+		suite.Require().NoError(err)
+		suite.Equal([]string{"0001_users_unique_email_username", "0002_tasks_status_due_date", "0003_users_unique_first_admin", "0004_tokens_lookup_and_expiry"}, applied)
+
+		applied, err = migrateMongoDatabase(suite.ctx, db)
+		suite.NoError(err)
+		suite.Empty(applied)
+
+		pending, err = pendingMongoMigrations(suite.ctx, db)
+		suite.NoError(err)
+		suite.Empty(pending)
+	})
+
+	suite.Run("UsersAreUnique", func() {
+		users := db.Collection("users")
+		_, err := users.InsertOne(suite.ctx, bson.M{"username": "bob", "email": "bob@example.com"})
+		suite.Require().NoError(err)
+
+		_, err = users.InsertOne(suite.ctx, bson.M{"username": "bob2", "email": "bob@example.com"})
+		suite.True(mongo.IsDuplicateKeyError(err))
+		_, err = users.InsertOne(suite.ctx, bson.M{"username": "bob", "email": "bob2@example.com"})
+		suite.True(mongo.IsDuplicateKeyError(err))
+	})
+}
+
+func TestMigrationSuite(t *testing.T) {
+	suite.Run(t, new(MigrationTestSuite))
+}
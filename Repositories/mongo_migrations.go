@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// mongoMigration is one versioned change to the MongoDB schema, such as
+// creating indexes. up must be safe to run again: a migration that fails
+// halfway, or runs on two instances at once, is simply retried.
+type mongoMigration struct {
+	version int64
+	name    string
+	up      func(ctx context.Context, db *mongo.Database) error
+}
+
+// mongoMigrations are applied in order. Once released, a migration must not
+// change; add a new one instead.
+var mongoMigrations = []mongoMigration{
+	{
+		version: 1,
+		name:    "users_unique_email_username",
+		up: func(ctx context.Context, db *mongo.Database) error {
+			err := createIndexes(ctx, db.Collection("users"),
+				mongo.IndexModel{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetName(usersEmailIndex).SetUnique(true)},
+				mongo.IndexModel{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetName(usersUsernameIndex).SetUnique(true)},
+			)
+			if mongo.IsDuplicateKeyError(err) {
+				return fmt.Errorf("some users share an email or username; rename them and migrate again: %w", err)
+			}
+			return err
+		},
+	},
+	{
+		version: 2,
+		name:    "tasks_status_due_date",
+		// Every task listing filters on org_id and sorts with _id as the
+		// tie-breaker, so both are part of the keys.
+		up: func(ctx context.Context, db *mongo.Database) error {
+			return createIndexes(ctx, db.Collection("tasks"),
+				mongo.IndexModel{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "status", Value: 1}, {Key: "_id", Value: 1}}, Options: options.Index().SetName("org_status")},
+				mongo.IndexModel{Keys: bson.D{{Key: "org_id", Value: 1}, {Key: "due_date", Value: 1}, {Key: "_id", Value: 1}}, Options: options.Index().SetName("org_due_date")},
+			)
+		},
+	},
+	{
+		version: 3,
+		name:    "users_unique_first_admin",
+		// Only the account added by AddFirstAdmin has first_admin set.
+		up: func(ctx context.Context, db *mongo.Database) error {
+			index := options.Index().SetName(usersFirstAdminIndex).SetUnique(true).
+				SetPartialFilterExpression(bson.M{"first_admin": true})
+			return createIndexes(ctx, db.Collection("users"),
+				mongo.IndexModel{Keys: bson.D{{Key: "first_admin", Value: 1}}, Options: index},
+			)
+		},
+	},
+	{
+		version: 4,
+		name:    "tokens_lookup_and_expiry",
+		// The TTL indexes let MongoDB delete tokens once they expire. User
+		// cutoffs in revoked_tokens have no expires_at and are kept.
+		up: func(ctx context.Context, db *mongo.Database) error {
+			expiry := mongo.IndexModel{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetName("expires_at_ttl").SetExpireAfterSeconds(0)}
+			err := createIndexes(ctx, db.Collection("refresh_tokens"),
+				mongo.IndexModel{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetName("token_hash")},
+				mongo.IndexModel{Keys: bson.D{{Key: "family_id", Value: 1}}, Options: options.Index().SetName("family_id")},
+				mongo.IndexModel{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetName("user_id")},
+				expiry,
+			)
+			if err != nil {
+				return err
+			}
+			return createIndexes(ctx, db.Collection("revoked_tokens"), expiry)
+		},
+	},
+}
+
+func createIndexes(ctx context.Context, collection *mongo.Collection, models ...mongo.IndexModel) error {
+	_, err := collection.Indexes().CreateMany(ctx, models)
+	return err
+}
+
+// mongoMigrationDAO records an applied migration in the schema_migrations
+// collection.
+type mongoMigrationDAO struct {
+	Version   int64     `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// MigrateMongo applies the MongoDB migrations that have not run yet on the
+// task_manager database and returns the names of those it applied.
+func MigrateMongo(ctx context.Context, client *mongo.Client) ([]string, error) {
+	return migrateMongoDatabase(ctx, client.Database("task_manager"))
+}
+
+// PendingMongoMigrations returns the names of the migrations MigrateMongo
+// would apply, in order.
+func PendingMongoMigrations(ctx context.Context, client *mongo.Client) ([]string, error) {
+	pending, err := pendingMongoMigrations(ctx, client.Database("task_manager"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(pending))
+	for _, migration := range pending {
+		names = append(names, migration.fullName())
+	}
+	return names, nil
+}
+
+// fullName is the name of the migration prefixed with its version, such as
+// 0001_users_unique_email_username.
+func (m *mongoMigration) fullName() string {
+	return fmt.Sprintf("%04d_%s", m.version, m.name)
+}
+
+// pendingMongoMigrations returns the migrations that schema_migrations
+// does not record yet, in order.
+func pendingMongoMigrations(ctx context.Context, db *mongo.Database) ([]mongoMigration, error) {
+	cursor, err := db.Collection("schema_migrations").Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var done []mongoMigrationDAO
+	if err := cursor.All(ctx, &done); err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]bool)
+	for _, record := range done {
+		applied[record.Version] = true
+	}
+	pending := make([]mongoMigration, 0)
+	for _, migration := range mongoMigrations {
+		if !applied[migration.version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+func migrateMongoDatabase(ctx context.Context, db *mongo.Database) ([]string, error) {
+	pending, err := pendingMongoMigrations(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	records := db.Collection("schema_migrations")
+	names := make([]string, 0)
+	for _, migration := range pending {
+		name := migration.fullName()
+		if err := migration.up(ctx, db); err != nil {
+			return names, fmt.Errorf("migration %s: %w", name, err)
+		}
+		record := mongoMigrationDAO{Version: migration.version, Name: migration.name, AppliedAt: time.Now()}
+		// A duplicate means another instance applied it at the same time.
+		if _, err := records.InsertOne(ctx, record); err != nil && !mongo.IsDuplicateKeyError(err) {
+			return names, fmt.Errorf("migration %s: %w", name, err)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
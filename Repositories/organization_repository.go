@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+	"task_manager/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OrganizationDAO is the MongoDB representation of an organization
+type OrganizationDAO struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Name      string             `bson:"name"`
+	CreatedBy string             `bson:"created_by"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// MembershipDAO is the MongoDB representation of a membership. The ID is
+// derived from the organization and user IDs, which keeps memberships
+// unique.
+type MembershipDAO struct {
+	ID        string    `bson:"_id"`
+	OrgID     string    `bson:"org_id"`
+	UserID    string    `bson:"user_id"`
+	Role      string    `bson:"role"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func membershipID(orgID, userID string) string {
+	return orgID + ":" + userID
+}
+
+func daoToOrganization(dao *OrganizationDAO) *domain.Organization {
+	return &domain.Organization{
+		ID:        dao.ID.Hex(),
+		Name:      dao.Name,
+		CreatedBy: dao.CreatedBy,
+		CreatedAt: dao.CreatedAt,
+	}
+}
+
+func membershipToDAO(membership *domain.Membership) *MembershipDAO {
+	return &MembershipDAO{
+		ID:        membershipID(membership.OrgID, membership.UserID),
+		OrgID:     membership.OrgID,
+		UserID:    membership.UserID,
+		Role:      membership.Role,
+		CreatedAt: membership.CreatedAt,
+	}
+}
+
+func daoToMembership(dao *MembershipDAO) *domain.Membership {
+	return &domain.Membership{
+		OrgID:     dao.OrgID,
+		UserID:    dao.UserID,
+		Role:      dao.Role,
+		CreatedAt: dao.CreatedAt,
+	}
+}
+
+type mongoOrganizationRepository struct {
+	organizations *mongo.Collection
+	memberships   *mongo.Collection
+}
+
+func NewOrganizationRepository(client *mongo.Client) domain.IOrganizationRepository {
+	return newMongoOrganizationRepository(client.Database("task_manager"))
+}
+
+// newMongoOrganizationRepository returns an organization repository on the
+// organizations and memberships collections of db.
+func newMongoOrganizationRepository(db *mongo.Database) domain.IOrganizationRepository {
+	return &mongoOrganizationRepository{
+		organizations: db.Collection("organizations"),
+		memberships:   db.Collection("memberships"),
+	}
+}
+
+// AddOrganization stores a new organization under a freshly generated
+// ObjectID and writes the assigned ID back onto org.
+func (r *mongoOrganizationRepository) AddOrganization(ctx context.Context, org *domain.Organization) error {
+	dao := &OrganizationDAO{
+		ID:        primitive.NewObjectID(),
+		Name:      org.Name,
+		CreatedBy: org.CreatedBy,
+		CreatedAt: org.CreatedAt,
+	}
+	if _, err := r.organizations.InsertOne(ctx, dao); err != nil {
+		return err
+	}
+	org.ID = dao.ID.Hex()
+	return nil
+}
+
+func (r *mongoOrganizationRepository) GetOrganization(ctx context.Context, id string) (*domain.Organization, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrOrganizationNotFound
+	}
+	var dao OrganizationDAO
+	err = r.organizations.FindOne(ctx, bson.M{"_id": objectID}).Decode(&dao)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return daoToOrganization(&dao), nil
+}
+
+// ListOrganizationsForUser returns the user's organizations ordered by ID.
+func (r *mongoOrganizationRepository) ListOrganizationsForUser(ctx context.Context, userID string) ([]domain.Organization, error) {
+	memberships, err := r.findMemberships(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	ids := make(bson.A, 0, len(memberships))
+	for _, m := range memberships {
+		if objectID, err := primitive.ObjectIDFromHex(m.OrgID); err == nil {
+			ids = append(ids, objectID)
+		}
+	}
+	orgs := make([]domain.Organization, 0, len(ids))
+	if len(ids) == 0 {
+		return orgs, nil
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cur, err := r.organizations.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var daos []OrganizationDAO
+	if err := cur.All(ctx, &daos); err != nil {
+		return nil, err
+	}
+	for i := range daos {
+		orgs = append(orgs, *daoToOrganization(&daos[i]))
+	}
+	return orgs, nil
+}
+
+func (r *mongoOrganizationRepository) AddMember(ctx context.Context, membership *domain.Membership) error {
+	_, err := r.memberships.InsertOne(ctx, membershipToDAO(membership))
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrAlreadyMember
+	}
+	return err
+}
+
+func (r *mongoOrganizationRepository) GetMembership(ctx context.Context, orgID, userID string) (*domain.Membership, error) {
+	var dao MembershipDAO
+	err := r.memberships.FindOne(ctx, bson.M{"_id": membershipID(orgID, userID)}).Decode(&dao)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrOrganizationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return daoToMembership(&dao), nil
+}
+
+// ListMembers returns the members of an organization in the order they
+// joined.
+func (r *mongoOrganizationRepository) ListMembers(ctx context.Context, orgID string) ([]domain.Membership, error) {
+	return r.findMemberships(ctx, bson.M{"org_id": orgID})
+}
+
+func (r *mongoOrganizationRepository) UpdateMemberRole(ctx context.Context, orgID, userID, role string) error {
+	result, err := r.memberships.UpdateOne(ctx, bson.M{"_id": membershipID(orgID, userID)}, bson.M{"$set": bson.M{"role": role}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrOrganizationNotFound
+	}
+	return nil
+}
+
+func (r *mongoOrganizationRepository) RemoveMember(ctx context.Context, orgID, userID string) error {
+	result, err := r.memberships.DeleteOne(ctx, bson.M{"_id": membershipID(orgID, userID)})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrOrganizationNotFound
+	}
+	return nil
+}
+
+func (r *mongoOrganizationRepository) RemoveUserMemberships(ctx context.Context, userID string) error {
+	_, err := r.memberships.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+func (r *mongoOrganizationRepository) CountMembersWithRole(ctx context.Context, orgID, role string) (int64, error) {
+	return r.memberships.CountDocuments(ctx, bson.M{"org_id": orgID, "role": role})
+}
+
+func (r *mongoOrganizationRepository) findMemberships(ctx context.Context, filter bson.M) ([]domain.Membership, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := r.memberships.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var daos []MembershipDAO
+	if err := cur.All(ctx, &daos); err != nil {
+		return nil, err
+	}
+	memberships := make([]domain.Membership, 0, len(daos))
+	for i := range daos {
+		memberships = append(memberships, *daoToMembership(&daos[i]))
+	}
+	return memberships, nil
+}
@@ -0,0 +1,198 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// OrganizationRepositoryContractSuite checks that an
+// IOrganizationRepository implementation behaves the way the usecases
+// expect. Run it against a backend with suite.Run, giving it a
+// newRepository that returns an empty repository.
+type OrganizationRepositoryContractSuite struct {
+	suite.Suite
+	// newRepository returns an empty repository; it is called before
+	// every test.
+	newRepository func() domain.IOrganizationRepository
+	repo          domain.IOrganizationRepository
+	ctx           context.Context
+	// now is a time the backends store without losing precision.
+	now time.Time
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *OrganizationRepositoryContractSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	suite.now = time.Date(2030, time.March, 1, 12, 0, 0, 0, time.UTC)
+}
+
+// SetupTest runs before each test
+func (suite *OrganizationRepositoryContractSuite) SetupTest() {
+	suite.repo = suite.newRepository()
+}
+
+// addOrganization stores an organization called name created by user1.
+func (suite *OrganizationRepositoryContractSuite) addOrganization(name string) *domain.Organization {
+	org := &domain.Organization{Name: name, CreatedBy: "user1", CreatedAt: suite.now}
+	suite.Require().NoError(suite.repo.AddOrganization(suite.ctx, org))
+	return org
+}
+
+// addMember adds userID to the organization with role, joining at
+// createdAt.
+func (suite *OrganizationRepositoryContractSuite) addMember(orgID, userID, role string, createdAt time.Time) *domain.Membership {
+	membership := &domain.Membership{OrgID: orgID, UserID: userID, Role: role, CreatedAt: createdAt}
+	suite.Require().NoError(suite.repo.AddMember(suite.ctx, membership))
+	return membership
+}
+
+func (suite *OrganizationRepositoryContractSuite) getMembership(orgID, userID string) (*domain.Membership, error) {
+	return suite.repo.GetMembership(suite.ctx, orgID, userID)
+}
+
+func (suite *OrganizationRepositoryContractSuite) TestOrganizationSuite() {
+	suite.Run("AssignsID", func() {
+		org := suite.addOrganization("Acme")
+
+		suite.True(primitive.IsValidObjectID(org.ID), "IDs are ObjectID hex strings")
+	})
+
+	suite.Run("StoresEveryField", func() {
+		org := suite.addOrganization("Globex")
+
+		stored, err := suite.repo.GetOrganization(suite.ctx, org.ID)
+
+		suite.Require().NoError(err)
+		suite.Equal(org, stored)
+	})
+
+	suite.Run("Missing", func() {
+		_, err := suite.repo.GetOrganization(suite.ctx, newObjectID())
+		suite.ErrorIs(err, domain.ErrOrganizationNotFound)
+	})
+
+	suite.Run("MalformedID", func() {
+		_, err := suite.repo.GetOrganization(suite.ctx, "not-an-id")
+		suite.ErrorIs(err, domain.ErrOrganizationNotFound)
+	})
+}
+
+func (suite *OrganizationRepositoryContractSuite) TestListOrganizationsForUserSuite() {
+	acme := suite.addOrganization("Acme")
+	globex := suite.addOrganization("Globex")
+	initech := suite.addOrganization("Initech")
+	suite.addMember(initech.ID, "user1", domain.OrgRoleMember, suite.now)
+	suite.addMember(acme.ID, "user1", domain.OrgRoleOwner, suite.now)
+	suite.addMember(globex.ID, "user2", domain.OrgRoleOwner, suite.now)
+
+	suite.Run("OrderedByID", func() {
+		orgs, err := suite.repo.ListOrganizationsForUser(suite.ctx, "user1")
+
+		suite.Require().NoError(err)
+		suite.Equal([]domain.Organization{*acme, *initech}, orgs)
+	})
+
+	suite.Run("NoMemberships", func() {
+		orgs, err := suite.repo.ListOrganizationsForUser(suite.ctx, "user3")
+
+		suite.NoError(err)
+		suite.Empty(orgs)
+	})
+}
+
+func (suite *OrganizationRepositoryContractSuite) TestMembershipSuite() {
+	org := suite.addOrganization("Acme")
+
+	suite.Run("StoresEveryField", func() {
+		membership := suite.addMember(org.ID, "user1", domain.OrgRoleOwner, suite.now)
+
+		stored, err := suite.getMembership(org.ID, "user1")
+
+		suite.Require().NoError(err)
+		suite.Equal(membership, stored)
+	})
+
+	suite.Run("AlreadyMember", func() {
+		err := suite.repo.AddMember(suite.ctx, &domain.Membership{OrgID: org.ID, UserID: "user1", Role: domain.OrgRoleMember, CreatedAt: suite.now})
+
+		suite.ErrorIs(err, domain.ErrAlreadyMember)
+		stored, err := suite.getMembership(org.ID, "user1")
+		suite.Require().NoError(err)
+		suite.Equal(domain.OrgRoleOwner, stored.Role, "the existing membership is kept")
+	})
+
+	suite.Run("Missing", func() {
+		_, err := suite.getMembership(org.ID, "user2")
+		suite.ErrorIs(err, domain.ErrOrganizationNotFound)
+		_, err = suite.getMembership(newObjectID(), "user1")
+		suite.ErrorIs(err, domain.ErrOrganizationNotFound)
+	})
+
+	suite.Run("ListMembersInJoinOrder", func() {
+		suite.addMember(org.ID, "user3", domain.OrgRoleMember, suite.now.Add(time.Minute))
+		suite.addMember(org.ID, "user2", domain.OrgRoleMember, suite.now.Add(time.Minute))
+		suite.addMember(newObjectID(), "user4", domain.OrgRoleOwner, suite.now)
+
+		members, err := suite.repo.ListMembers(suite.ctx, org.ID)
+
+		suite.Require().NoError(err)
+		suite.Equal([]domain.Membership{
+			{OrgID: org.ID, UserID: "user1", Role: domain.OrgRoleOwner, CreatedAt: suite.now},
+			{OrgID: org.ID, UserID: "user2", Role: domain.OrgRoleMember, CreatedAt: suite.now.Add(time.Minute)},
+			{OrgID: org.ID, UserID: "user3", Role: domain.OrgRoleMember, CreatedAt: suite.now.Add(time.Minute)},
+		}, members)
+	})
+
+	suite.Run("CountMembersWithRole", func() {
+		count, err := suite.repo.CountMembersWithRole(suite.ctx, org.ID, domain.OrgRoleMember)
+		suite.NoError(err)
+		suite.Equal(int64(2), count)
+
+		count, err = suite.repo.CountMembersWithRole(suite.ctx, org.ID, domain.OrgRoleAdmin)
+		suite.NoError(err)
+		suite.Zero(count)
+	})
+
+	suite.Run("UpdateMemberRole", func() {
+		suite.Require().NoError(suite.repo.UpdateMemberRole(suite.ctx, org.ID, "user2", domain.OrgRoleAdmin))
+
+		stored, err := suite.getMembership(org.ID, "user2")
+		suite.Require().NoError(err)
+		suite.Equal(domain.OrgRoleAdmin, stored.Role)
+	})
+
+	suite.Run("UpdateMissingMember", func() {
+		err := suite.repo.UpdateMemberRole(suite.ctx, org.ID, "user5", domain.OrgRoleAdmin)
+		suite.ErrorIs(err, domain.ErrOrganizationNotFound)
+	})
+
+	suite.Run("RemoveMember", func() {
+		suite.Require().NoError(suite.repo.RemoveMember(suite.ctx, org.ID, "user3"))
+
+		_, err := suite.getMembership(org.ID, "user3")
+		suite.ErrorIs(err, domain.ErrOrganizationNotFound)
+	})
+
+	suite.Run("RemoveMissingMember", func() {
+		err := suite.repo.RemoveMember(suite.ctx, org.ID, "user3")
+		suite.ErrorIs(err, domain.ErrOrganizationNotFound)
+	})
+
+	suite.Run("RemoveUserMemberships", func() {
+		other := suite.addOrganization("Globex")
+		suite.addMember(other.ID, "user2", domain.OrgRoleOwner, suite.now)
+
+		suite.Require().NoError(suite.repo.RemoveUserMemberships(suite.ctx, "user2"))
+
+		orgs, err := suite.repo.ListOrganizationsForUser(suite.ctx, "user2")
+		suite.NoError(err)
+		suite.Empty(orgs)
+		_, err = suite.getMembership(org.ID, "user1")
+		suite.NoError(err, "other users' memberships are kept")
+	})
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"task_manager/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// PasswordResetDAO is the MongoDB representation of a password reset request
+type PasswordResetDAO struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    string             `bson:"user_id"`
+	TokenHash string             `bson:"token_hash"`
+	CreatedAt time.Time          `bson:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty"`
+}
+
+func passwordResetToDAO(reset *domain.PasswordReset) *PasswordResetDAO {
+	dao := &PasswordResetDAO{
+		UserID:    reset.UserID,
+		TokenHash: reset.TokenHash,
+		CreatedAt: reset.CreatedAt,
+		ExpiresAt: reset.ExpiresAt,
+	}
+	if !reset.UsedAt.IsZero() {
+		usedAt := reset.UsedAt
+		dao.UsedAt = &usedAt
+	}
+	return dao
+}
+
+func daoToPasswordReset(dao *PasswordResetDAO) *domain.PasswordReset {
+	reset := &domain.PasswordReset{
+		ID:        dao.ID.Hex(),
+		UserID:    dao.UserID,
+		TokenHash: dao.TokenHash,
+		CreatedAt: dao.CreatedAt,
+		ExpiresAt: dao.ExpiresAt,
+	}
+	if dao.UsedAt != nil {
+		reset.UsedAt = *dao.UsedAt
+	}
+	return reset
+}
+
+type mongoPasswordResetRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPasswordResetRepository(client *mongo.Client) domain.IPasswordResetRepository {
+	return newMongoPasswordResetRepository(client.Database("task_manager"))
+}
+
+// newMongoPasswordResetRepository returns a password reset repository on the
+// password_resets collection of db.
+func newMongoPasswordResetRepository(db *mongo.Database) domain.IPasswordResetRepository {
+	return &mongoPasswordResetRepository{
+		collection: db.Collection("password_resets"),
+	}
+}
+
+func (r *mongoPasswordResetRepository) AddPasswordReset(ctx context.Context, reset *domain.PasswordReset) error {
+	dao := passwordResetToDAO(reset)
+	dao.ID = primitive.NewObjectID()
+	if _, err := r.collection.InsertOne(ctx, dao); err != nil {
+		return err
+	}
+	reset.ID = dao.ID.Hex()
+	return nil
+}
+
+func (r *mongoPasswordResetRepository) GetPasswordResetByHash(ctx context.Context, hash string) (*domain.PasswordReset, error) {
+	var dao PasswordResetDAO
+	err := r.collection.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&dao)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return daoToPasswordReset(&dao), nil
+}
+
+func (r *mongoPasswordResetRepository) MarkPasswordResetUsed(ctx context.Context, id string) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, nil
+	}
+	filter := bson.M{"_id": objectID, "used_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"used_at": time.Now()}}
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *mongoPasswordResetRepository) DeleteUserPasswordResets(ctx context.Context, userID string) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
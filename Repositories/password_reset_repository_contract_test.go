@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// PasswordResetRepositoryContractSuite checks that an
+// IPasswordResetRepository implementation behaves the way the usecases
+// expect. Run it against a backend with suite.Run, giving it a
+// newRepository that returns an empty repository.
+type PasswordResetRepositoryContractSuite struct {
+	suite.Suite
+	// newRepository returns an empty repository; it is called before
+	// every test.
+	newRepository func() domain.IPasswordResetRepository
+	repo          domain.IPasswordResetRepository
+	ctx           context.Context
+	// now is a time the backends store without losing precision.
+	now time.Time
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *PasswordResetRepositoryContractSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	suite.now = time.Date(2030, time.March, 1, 12, 0, 0, 0, time.UTC)
+}
+
+// SetupTest runs before each test
+func (suite *PasswordResetRepositoryContractSuite) SetupTest() {
+	suite.repo = suite.newRepository()
+}
+
+// addReset stores an unused reset of the user whose hash is hash.
+func (suite *PasswordResetRepositoryContractSuite) addReset(userID, hash string) *domain.PasswordReset {
+	reset := &domain.PasswordReset{
+		UserID:    userID,
+		TokenHash: hash,
+		CreatedAt: suite.now,
+		ExpiresAt: suite.now.Add(time.Hour),
+	}
+	suite.Require().NoError(suite.repo.AddPasswordReset(suite.ctx, reset))
+	return reset
+}
+
+func (suite *PasswordResetRepositoryContractSuite) TestPasswordResetSuite() {
+	suite.Run("StoresEveryField", func() {
+		reset := suite.addReset("user1", "hash1")
+
+		stored, err := suite.repo.GetPasswordResetByHash(suite.ctx, "hash1")
+
+		suite.Require().NoError(err)
+		suite.NotEmpty(reset.ID)
+		suite.Equal(reset, stored)
+	})
+
+	suite.Run("UnknownHash", func() {
+		_, err := suite.repo.GetPasswordResetByHash(suite.ctx, "unknown")
+		suite.ErrorIs(err, domain.ErrNotFound)
+	})
+
+	suite.Run("MarkUsedOnlyOnce", func() {
+		reset := suite.addReset("user1", "hash2")
+
+		marked, err := suite.repo.MarkPasswordResetUsed(suite.ctx, reset.ID)
+		suite.Require().NoError(err)
+		suite.True(marked)
+
+		stored, err := suite.repo.GetPasswordResetByHash(suite.ctx, "hash2")
+		suite.Require().NoError(err)
+		suite.False(stored.UsedAt.IsZero())
+
+		marked, err = suite.repo.MarkPasswordResetUsed(suite.ctx, reset.ID)
+		suite.NoError(err)
+		suite.False(marked, "a completed reset cannot be used again")
+	})
+
+	suite.Run("MarkUsedMissing", func() {
+		marked, err := suite.repo.MarkPasswordResetUsed(suite.ctx, newObjectID())
+		suite.NoError(err)
+		suite.False(marked)
+	})
+
+	suite.Run("DeleteUserPasswordResets", func() {
+		suite.addReset("user2", "hash3")
+		suite.addReset("user3", "hash4")
+
+		suite.Require().NoError(suite.repo.DeleteUserPasswordResets(suite.ctx, "user1"))
+		suite.Require().NoError(suite.repo.DeleteUserPasswordResets(suite.ctx, "user2"))
+
+		for _, hash := range []string{"hash1", "hash2", "hash3"} {
+			_, err := suite.repo.GetPasswordResetByHash(suite.ctx, hash)
+			suite.ErrorIs(err, domain.ErrNotFound, hash)
+		}
+		_, err := suite.repo.GetPasswordResetByHash(suite.ctx, "hash4")
+		suite.NoError(err, "other users' resets are kept")
+	})
+}
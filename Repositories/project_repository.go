@@ -0,0 +1,262 @@
+package repositories
+
+import (
+	"context"
+	"task_manager/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ProjectDAO is the MongoDB representation of a project
+type ProjectDAO struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	OrgID       string             `bson:"org_id"`
+	Name        string             `bson:"name"`
+	Description string             `bson:"description"`
+	CreatedBy   string             `bson:"created_by"`
+	CreatedAt   time.Time          `bson:"created_at"`
+	Archived    bool               `bson:"archived"`
+}
+
+// ProjectMemberDAO is the MongoDB representation of a project membership.
+// The ID is derived from the project and user IDs, which keeps memberships
+// unique.
+type ProjectMemberDAO struct {
+	ID        string    `bson:"_id"`
+	ProjectID string    `bson:"project_id"`
+	UserID    string    `bson:"user_id"`
+	Role      string    `bson:"role"`
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+func daoToProject(dao *ProjectDAO) *domain.Project {
+	return &domain.Project{
+		ID:          dao.ID.Hex(),
+		OrgID:       dao.OrgID,
+		Name:        dao.Name,
+		Description: dao.Description,
+		CreatedBy:   dao.CreatedBy,
+		CreatedAt:   dao.CreatedAt,
+		Archived:    dao.Archived,
+	}
+}
+
+func projectMemberToDAO(member *domain.ProjectMember) *ProjectMemberDAO {
+	return &ProjectMemberDAO{
+		ID:        membershipID(member.ProjectID, member.UserID),
+		ProjectID: member.ProjectID,
+		UserID:    member.UserID,
+		Role:      member.Role,
+		CreatedAt: member.CreatedAt,
+	}
+}
+
+func daoToProjectMember(dao *ProjectMemberDAO) *domain.ProjectMember {
+	return &domain.ProjectMember{
+		ProjectID: dao.ProjectID,
+		UserID:    dao.UserID,
+		Role:      dao.Role,
+		CreatedAt: dao.CreatedAt,
+	}
+}
+
+type mongoProjectRepository struct {
+	projects *mongo.Collection
+	members  *mongo.Collection
+}
+
+func NewProjectRepository(client *mongo.Client) domain.IProjectRepository {
+	return newMongoProjectRepository(client.Database("task_manager"))
+}
+
+// newMongoProjectRepository returns a project repository on the projects and
+// project_members collections of db.
+func newMongoProjectRepository(db *mongo.Database) domain.IProjectRepository {
+	return &mongoProjectRepository{
+		projects: db.Collection("projects"),
+		members:  db.Collection("project_members"),
+	}
+}
+
+// AddProject stores a new project under a freshly generated ObjectID and
+// writes the assigned ID back onto project.
+func (r *mongoProjectRepository) AddProject(ctx context.Context, project *domain.Project) error {
+	dao := &ProjectDAO{
+		ID:          primitive.NewObjectID(),
+		OrgID:       project.OrgID,
+		Name:        project.Name,
+		Description: project.Description,
+		CreatedBy:   project.CreatedBy,
+		CreatedAt:   project.CreatedAt,
+		Archived:    project.Archived,
+	}
+	if _, err := r.projects.InsertOne(ctx, dao); err != nil {
+		return err
+	}
+	project.ID = dao.ID.Hex()
+	return nil
+}
+
+func (r *mongoProjectRepository) GetProject(ctx context.Context, orgID, id string) (*domain.Project, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, domain.ErrProjectNotFound
+	}
+	var dao ProjectDAO
+	err = r.projects.FindOne(ctx, bson.M{"_id": objectID, "org_id": orgID}).Decode(&dao)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrProjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return daoToProject(&dao), nil
+}
+
+// ListProjects returns the projects matching query ordered by ID.
+func (r *mongoProjectRepository) ListProjects(ctx context.Context, query domain.ProjectQuery) ([]domain.Project, error) {
+	filter := bson.M{"org_id": query.OrgID}
+	if !query.IncludeArchived {
+		filter["archived"] = bson.M{"$ne": true}
+	}
+	projects := make([]domain.Project, 0)
+	if query.MemberID != "" {
+		members, err := r.findMembers(ctx, bson.M{"user_id": query.MemberID})
+		if err != nil {
+			return nil, err
+		}
+		ids := make(bson.A, 0, len(members))
+		for _, m := range members {
+			if objectID, err := primitive.ObjectIDFromHex(m.ProjectID); err == nil {
+				ids = append(ids, objectID)
+			}
+		}
+		if len(ids) == 0 {
+			return projects, nil
+		}
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cur, err := r.projects.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var daos []ProjectDAO
+	if err := cur.All(ctx, &daos); err != nil {
+		return nil, err
+	}
+	for i := range daos {
+		projects = append(projects, *daoToProject(&daos[i]))
+	}
+	return projects, nil
+}
+
+func (r *mongoProjectRepository) UpdateProject(ctx context.Context, project *domain.Project) error {
+	objectID, err := primitive.ObjectIDFromHex(project.ID)
+	if err != nil {
+		return domain.ErrProjectNotFound
+	}
+	update := bson.M{"$set": bson.M{
+		"name":        project.Name,
+		"description": project.Description,
+		"archived":    project.Archived,
+	}}
+	result, err := r.projects.UpdateOne(ctx, bson.M{"_id": objectID, "org_id": project.OrgID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrProjectNotFound
+	}
+	return nil
+}
+
+func (r *mongoProjectRepository) DeleteProject(ctx context.Context, orgID, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrProjectNotFound
+	}
+	result, err := r.projects.DeleteOne(ctx, bson.M{"_id": objectID, "org_id": orgID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrProjectNotFound
+	}
+	_, err = r.members.DeleteMany(ctx, bson.M{"project_id": id})
+	return err
+}
+
+func (r *mongoProjectRepository) AddProjectMember(ctx context.Context, member *domain.ProjectMember) error {
+	_, err := r.members.InsertOne(ctx, projectMemberToDAO(member))
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrAlreadyProjectMember
+	}
+	return err
+}
+
+func (r *mongoProjectRepository) GetProjectMember(ctx context.Context, projectID, userID string) (*domain.ProjectMember, error) {
+	var dao ProjectMemberDAO
+	err := r.members.FindOne(ctx, bson.M{"_id": membershipID(projectID, userID)}).Decode(&dao)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrProjectNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return daoToProjectMember(&dao), nil
+}
+
+// ListProjectMembers returns the members of a project in the order they
+// joined.
+func (r *mongoProjectRepository) ListProjectMembers(ctx context.Context, projectID string) ([]domain.ProjectMember, error) {
+	return r.findMembers(ctx, bson.M{"project_id": projectID})
+}
+
+func (r *mongoProjectRepository) UpdateProjectMemberRole(ctx context.Context, projectID, userID, role string) error {
+	result, err := r.members.UpdateOne(ctx, bson.M{"_id": membershipID(projectID, userID)}, bson.M{"$set": bson.M{"role": role}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrProjectNotFound
+	}
+	return nil
+}
+
+func (r *mongoProjectRepository) RemoveProjectMember(ctx context.Context, projectID, userID string) error {
+	result, err := r.members.DeleteOne(ctx, bson.M{"_id": membershipID(projectID, userID)})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrProjectNotFound
+	}
+	return nil
+}
+
+func (r *mongoProjectRepository) RemoveUserProjectMemberships(ctx context.Context, userID string) error {
+	_, err := r.members.DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
+
+func (r *mongoProjectRepository) findMembers(ctx context.Context, filter bson.M) ([]domain.ProjectMember, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := r.members.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var daos []ProjectMemberDAO
+	if err := cur.All(ctx, &daos); err != nil {
+		return nil, err
+	}
+	members := make([]domain.ProjectMember, 0, len(daos))
+	for i := range daos {
+		members = append(members, *daoToProjectMember(&daos[i]))
+	}
+	return members, nil
+}
@@ -0,0 +1,293 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProjectRepositoryContractSuite checks that an IProjectRepository
+// implementation behaves the way the usecases expect. Run it against a
+// backend with suite.Run, giving it a newRepository that returns an empty
+// repository.
+type ProjectRepositoryContractSuite struct {
+	suite.Suite
+	// newRepository returns an empty repository; it is called before
+	// every test.
+	newRepository func() domain.IProjectRepository
+	repo          domain.IProjectRepository
+	ctx           context.Context
+	// now is a time the backends store without losing precision.
+	now time.Time
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *ProjectRepositoryContractSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	suite.now = time.Date(2030, time.March, 1, 12, 0, 0, 0, time.UTC)
+}
+
+// SetupTest runs before each test
+func (suite *ProjectRepositoryContractSuite) SetupTest() {
+	suite.repo = suite.newRepository()
+}
+
+// addProject stores a project called name in the organization, after
+// applying the given changes to it.
+func (suite *ProjectRepositoryContractSuite) addProject(orgID, name string, changes ...func(*domain.Project)) *domain.Project {
+	project := &domain.Project{
+		OrgID:       orgID,
+		Name:        name,
+		Description: "About " + name,
+		CreatedBy:   "user1",
+		CreatedAt:   suite.now,
+	}
+	for _, change := range changes {
+		change(project)
+	}
+	suite.Require().NoError(suite.repo.AddProject(suite.ctx, project))
+	return project
+}
+
+func archived(project *domain.Project) {
+	project.Archived = true
+}
+
+// addProjectMember adds userID to the project with role, joining at
+// createdAt.
+func (suite *ProjectRepositoryContractSuite) addProjectMember(projectID, userID, role string, createdAt time.Time) *domain.ProjectMember {
+	member := &domain.ProjectMember{ProjectID: projectID, UserID: userID, Role: role, CreatedAt: createdAt}
+	suite.Require().NoError(suite.repo.AddProjectMember(suite.ctx, member))
+	return member
+}
+
+func (suite *ProjectRepositoryContractSuite) getProjectMember(projectID, userID string) (*domain.ProjectMember, error) {
+	return suite.repo.GetProjectMember(suite.ctx, projectID, userID)
+}
+
+// listIDs returns the IDs of the projects matching query.
+func (suite *ProjectRepositoryContractSuite) listIDs(query domain.ProjectQuery) []string {
+	projects, err := suite.repo.ListProjects(suite.ctx, query)
+	suite.Require().NoError(err)
+	ids := make([]string, 0, len(projects))
+	for _, project := range projects {
+		ids = append(ids, project.ID)
+	}
+	return ids
+}
+
+func (suite *ProjectRepositoryContractSuite) TestProjectSuite() {
+	suite.Run("AssignsID", func() {
+		project := suite.addProject("org1", "Website")
+
+		suite.True(primitive.IsValidObjectID(project.ID), "IDs are ObjectID hex strings")
+	})
+
+	suite.Run("StoresEveryField", func() {
+		project := suite.addProject("org1", "Launch", archived)
+
+		stored, err := suite.repo.GetProject(suite.ctx, "org1", project.ID)
+
+		suite.Require().NoError(err)
+		suite.Equal(project, stored)
+	})
+
+	suite.Run("Missing", func() {
+		_, err := suite.repo.GetProject(suite.ctx, "org1", newObjectID())
+		suite.ErrorIs(err, domain.ErrProjectNotFound)
+	})
+
+	suite.Run("MalformedID", func() {
+		_, err := suite.repo.GetProject(suite.ctx, "org1", "not-an-id")
+		suite.ErrorIs(err, domain.ErrProjectNotFound)
+	})
+
+	suite.Run("OtherOrganization", func() {
+		project := suite.addProject("org1", "Internal")
+
+		_, err := suite.repo.GetProject(suite.ctx, "org2", project.ID)
+
+		suite.ErrorIs(err, domain.ErrProjectNotFound)
+	})
+}
+
+func (suite *ProjectRepositoryContractSuite) TestListProjectsSuite() {
+	website := suite.addProject("org1", "Website")
+	launch := suite.addProject("org1", "Launch", archived)
+	mobile := suite.addProject("org1", "Mobile")
+	other := suite.addProject("org2", "Other")
+	suite.addProjectMember(website.ID, "user1", domain.ProjectRoleManager, suite.now)
+	suite.addProjectMember(launch.ID, "user1", domain.ProjectRoleViewer, suite.now)
+	suite.addProjectMember(mobile.ID, "user2", domain.ProjectRoleEditor, suite.now)
+	suite.addProjectMember(other.ID, "user1", domain.ProjectRoleManager, suite.now)
+
+	suite.Run("SkipsArchived", func() {
+		suite.Equal([]string{website.ID, mobile.ID}, suite.listIDs(domain.ProjectQuery{OrgID: "org1"}))
+	})
+
+	suite.Run("IncludeArchived", func() {
+		ids := suite.listIDs(domain.ProjectQuery{OrgID: "org1", IncludeArchived: true})
+		suite.Equal([]string{website.ID, launch.ID, mobile.ID}, ids)
+	})
+
+	suite.Run("MemberID", func() {
+		suite.Equal([]string{website.ID}, suite.listIDs(domain.ProjectQuery{OrgID: "org1", MemberID: "user1"}))
+		ids := suite.listIDs(domain.ProjectQuery{OrgID: "org1", MemberID: "user1", IncludeArchived: true})
+		suite.Equal([]string{website.ID, launch.ID}, ids)
+		suite.Empty(suite.listIDs(domain.ProjectQuery{OrgID: "org1", MemberID: "user3"}))
+	})
+
+	suite.Run("OtherOrganization", func() {
+		suite.Equal([]string{other.ID}, suite.listIDs(domain.ProjectQuery{OrgID: "org2"}))
+		suite.Empty(suite.listIDs(domain.ProjectQuery{OrgID: "org3"}))
+	})
+}
+
+func (suite *ProjectRepositoryContractSuite) TestUpdateProjectSuite() {
+	suite.Run("SavesNameDescriptionAndArchived", func() {
+		project := suite.addProject("org1", "Website")
+		update := *project
+		update.Name = "Site"
+		update.Description = "The new site"
+		update.Archived = true
+		update.CreatedBy = "user2"
+
+		suite.Require().NoError(suite.repo.UpdateProject(suite.ctx, &update))
+
+		stored, err := suite.repo.GetProject(suite.ctx, "org1", project.ID)
+		suite.Require().NoError(err)
+		update.CreatedBy = project.CreatedBy
+		suite.Equal(&update, stored)
+	})
+
+	suite.Run("OtherOrganization", func() {
+		project := suite.addProject("org1", "Launch")
+		update := *project
+		update.OrgID = "org2"
+		update.Name = "Hijacked"
+
+		suite.ErrorIs(suite.repo.UpdateProject(suite.ctx, &update), domain.ErrProjectNotFound)
+
+		stored, err := suite.repo.GetProject(suite.ctx, "org1", project.ID)
+		suite.Require().NoError(err)
+		suite.Equal("Launch", stored.Name)
+	})
+
+	suite.Run("Missing", func() {
+		project := &domain.Project{ID: newObjectID(), OrgID: "org1", Name: "Ghost"}
+		suite.ErrorIs(suite.repo.UpdateProject(suite.ctx, project), domain.ErrProjectNotFound)
+	})
+}
+
+func (suite *ProjectRepositoryContractSuite) TestDeleteProjectSuite() {
+	website := suite.addProject("org1", "Website")
+	mobile := suite.addProject("org1", "Mobile")
+	suite.addProjectMember(website.ID, "user1", domain.ProjectRoleManager, suite.now)
+	suite.addProjectMember(website.ID, "user2", domain.ProjectRoleEditor, suite.now)
+	suite.addProjectMember(mobile.ID, "user1", domain.ProjectRoleManager, suite.now)
+
+	suite.Run("OtherOrganization", func() {
+		suite.ErrorIs(suite.repo.DeleteProject(suite.ctx, "org2", website.ID), domain.ErrProjectNotFound)
+
+		_, err := suite.getProjectMember(website.ID, "user1")
+		suite.NoError(err, "a failed delete keeps the members")
+	})
+
+	suite.Run("RemovesProjectAndMembers", func() {
+		suite.Require().NoError(suite.repo.DeleteProject(suite.ctx, "org1", website.ID))
+
+		_, err := suite.repo.GetProject(suite.ctx, "org1", website.ID)
+		suite.ErrorIs(err, domain.ErrProjectNotFound)
+		members, err := suite.repo.ListProjectMembers(suite.ctx, website.ID)
+		suite.NoError(err)
+		suite.Empty(members)
+		_, err = suite.getProjectMember(mobile.ID, "user1")
+		suite.NoError(err, "other projects keep their members")
+	})
+
+	suite.Run("Missing", func() {
+		suite.ErrorIs(suite.repo.DeleteProject(suite.ctx, "org1", website.ID), domain.ErrProjectNotFound)
+	})
+}
+
+func (suite *ProjectRepositoryContractSuite) TestProjectMemberSuite() {
+	project := suite.addProject("org1", "Website")
+
+	suite.Run("StoresEveryField", func() {
+		member := suite.addProjectMember(project.ID, "user1", domain.ProjectRoleManager, suite.now)
+
+		stored, err := suite.getProjectMember(project.ID, "user1")
+
+		suite.Require().NoError(err)
+		suite.Equal(member, stored)
+	})
+
+	suite.Run("AlreadyMember", func() {
+		err := suite.repo.AddProjectMember(suite.ctx, &domain.ProjectMember{ProjectID: project.ID, UserID: "user1", Role: domain.ProjectRoleViewer, CreatedAt: suite.now})
+
+		suite.ErrorIs(err, domain.ErrAlreadyProjectMember)
+		stored, err := suite.getProjectMember(project.ID, "user1")
+		suite.Require().NoError(err)
+		suite.Equal(domain.ProjectRoleManager, stored.Role, "the existing membership is kept")
+	})
+
+	suite.Run("Missing", func() {
+		_, err := suite.getProjectMember(project.ID, "user2")
+		suite.ErrorIs(err, domain.ErrProjectNotFound)
+	})
+
+	suite.Run("ListProjectMembersInJoinOrder", func() {
+		suite.addProjectMember(project.ID, "user3", domain.ProjectRoleViewer, suite.now.Add(time.Minute))
+		suite.addProjectMember(project.ID, "user2", domain.ProjectRoleEditor, suite.now.Add(time.Minute))
+		suite.addProjectMember(newObjectID(), "user4", domain.ProjectRoleManager, suite.now)
+
+		members, err := suite.repo.ListProjectMembers(suite.ctx, project.ID)
+
+		suite.Require().NoError(err)
+		suite.Equal([]domain.ProjectMember{
+			{ProjectID: project.ID, UserID: "user1", Role: domain.ProjectRoleManager, CreatedAt: suite.now},
+			{ProjectID: project.ID, UserID: "user2", Role: domain.ProjectRoleEditor, CreatedAt: suite.now.Add(time.Minute)},
+			{ProjectID: project.ID, UserID: "user3", Role: domain.ProjectRoleViewer, CreatedAt: suite.now.Add(time.Minute)},
+		}, members)
+	})
+
+	suite.Run("UpdateProjectMemberRole", func() {
+		suite.Require().NoError(suite.repo.UpdateProjectMemberRole(suite.ctx, project.ID, "user3", domain.ProjectRoleEditor))
+
+		stored, err := suite.getProjectMember(project.ID, "user3")
+		suite.Require().NoError(err)
+		suite.Equal(domain.ProjectRoleEditor, stored.Role)
+	})
+
+	suite.Run("UpdateMissingMember", func() {
+		err := suite.repo.UpdateProjectMemberRole(suite.ctx, project.ID, "user5", domain.ProjectRoleEditor)
+		suite.ErrorIs(err, domain.ErrProjectNotFound)
+	})
+
+	suite.Run("RemoveProjectMember", func() {
+		suite.Require().NoError(suite.repo.RemoveProjectMember(suite.ctx, project.ID, "user3"))
+
+		_, err := suite.getProjectMember(project.ID, "user3")
+		suite.ErrorIs(err, domain.ErrProjectNotFound)
+	})
+
+	suite.Run("RemoveMissingMember", func() {
+		err := suite.repo.RemoveProjectMember(suite.ctx, project.ID, "user3")
+		suite.ErrorIs(err, domain.ErrProjectNotFound)
+	})
+
+	suite.Run("RemoveUserProjectMemberships", func() {
+		other := suite.addProject("org1", "Mobile")
+		suite.addProjectMember(other.ID, "user2", domain.ProjectRoleViewer, suite.now)
+
+		suite.Require().NoError(suite.repo.RemoveUserProjectMemberships(suite.ctx, "user2"))
+
+		suite.Empty(suite.listIDs(domain.ProjectQuery{OrgID: "org1", MemberID: "user2"}))
+		_, err := suite.getProjectMember(project.ID, "user1")
+		suite.NoError(err, "other users' memberships are kept")
+	})
+}
@@ -0,0 +1,259 @@
+package repositories
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// The contract suites run against every backend. The in-memory and SQLite
+// ones always run; MongoDB runs when a server answers at MONGODB_TEST_URI
+// (default mongodb://localhost:27017) and PostgreSQL when POSTGRES_TEST_URL
+// is set. Both use their database exclusively and clear it between tests.
+// A server named by one of those variables must be reachable: the tests
+// fail rather than skip, so CI cannot silently leave a backend out.
+
+// testMongoDatabase connects to the MongoDB test server and migrates its
+// test database. Without MONGODB_TEST_URI it skips the test when there is
+// no server at the default address.
+func testMongoDatabase(t *testing.T) *mongo.Database {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	unavailable, timeout := t.Fatalf, 5*time.Second
+	if uri == "" {
+		uri = "mongodb://localhost:27017"
+		unavailable, timeout = t.Skipf, time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*timeout)
+	defer cancel()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri).SetServerSelectionTimeout(timeout))
+	if err != nil {
+		unavailable("MongoDB not available: %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect(context.Background()) })
+	if err := client.Ping(ctx, nil); err != nil {
+		unavailable("MongoDB not available at %s: %v", uri, err)
+	}
+	db := client.Database("task_manager_test")
+	if _, err := migrateMongoDatabase(context.Background(), db); err != nil {
+		t.Fatalf("migrate MongoDB: %v", err)
+	}
+	return db
+}
+
+// emptyMongoCollection returns a function that deletes every document of
+// the named collection, keeping its indexes, and hands back a repository on
+// db.
+func emptyMongoCollection[R any](t *testing.T, db *mongo.Database, collection string, newRepository func(*mongo.Database) R) func() R {
+	return emptyMongoCollections(t, db, []string{collection}, newRepository)
+}
+
+// emptyMongoCollections is emptyMongoCollection for repositories that use
+// several collections.
+func emptyMongoCollections[R any](t *testing.T, db *mongo.Database, collections []string, newRepository func(*mongo.Database) R) func() R {
+	return func() R {
+		for _, collection := range collections {
+			if _, err := db.Collection(collection).DeleteMany(context.Background(), bson.M{}); err != nil {
+				t.Fatalf("clear %s: %v", collection, err)
+			}
+		}
+		return newRepository(db)
+	}
+}
+
+// testSQLDatabase opens and migrates a database of the dialect: a fresh
+// SQLite file, or the PostgreSQL database at POSTGRES_TEST_URL, skipping the
+// test when that is not set.
+func testSQLDatabase(t *testing.T, dialect string) *SQLDatabase {
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	if dialect == DialectPostgres {
+		if dsn = os.Getenv("POSTGRES_TEST_URL"); dsn == "" {
+			t.Skip("POSTGRES_TEST_URL is not set")
+		}
+	}
+	db, err := OpenSQLDatabase(context.Background(), dialect, dsn)
+	if err != nil {
+		t.Fatalf("open %s database: %v", dialect, err)
+	}
+	t.Cleanup(func() { db.db.Close() })
+	if _, err := db.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate %s database: %v", dialect, err)
+	}
+	return db
+}
+
+// emptySQLTable returns a function that deletes every row of the table and
+// hands back a repository on db.
+func emptySQLTable[R any](t *testing.T, db *SQLDatabase, table string, newRepository func(*SQLDatabase) R) func() R {
+	return emptySQLTables(t, db, []string{table}, newRepository)
+}
+
+// emptySQLTables is emptySQLTable for repositories that use several tables.
+func emptySQLTables[R any](t *testing.T, db *SQLDatabase, tables []string, newRepository func(*SQLDatabase) R) func() R {
+	return func() R {
+		for _, table := range tables {
+			if _, err := db.db.Exec(`DELETE FROM ` + table); err != nil {
+				t.Fatalf("clear %s: %v", table, err)
+			}
+		}
+		return newRepository(db)
+	}
+}
+
+func TestMemoryUserRepositoryContract(t *testing.T) {
+	suite.Run(t, &UserRepositoryContractSuite{newRepository: NewMemoryUserRepository})
+}
+
+func TestMemoryTaskRepositoryContract(t *testing.T) {
+	suite.Run(t, &TaskRepositoryContractSuite{newRepository: NewMemoryTaskRepository})
+}
+
+func TestMemoryTaskHistoryRepositoryContract(t *testing.T) {
+	suite.Run(t, &TaskHistoryRepositoryContractSuite{newRepository: NewMemoryTaskHistoryRepository})
+}
+
+func TestMemoryTokenRepositoryContract(t *testing.T) {
+	suite.Run(t, &TokenRepositoryContractSuite{newRepository: NewMemoryTokenRepository})
+}
+
+func TestMemoryPasswordResetRepositoryContract(t *testing.T) {
+	suite.Run(t, &PasswordResetRepositoryContractSuite{newRepository: NewMemoryPasswordResetRepository})
+}
+
+func TestMemoryRoleRepositoryContract(t *testing.T) {
+	suite.Run(t, &RoleRepositoryContractSuite{newRepository: NewMemoryRoleRepository})
+}
+
+func TestMemoryOrganizationRepositoryContract(t *testing.T) {
+	suite.Run(t, &OrganizationRepositoryContractSuite{newRepository: NewMemoryOrganizationRepository})
+}
+
+func TestMemoryProjectRepositoryContract(t *testing.T) {
+	suite.Run(t, &ProjectRepositoryContractSuite{newRepository: NewMemoryProjectRepository})
+}
+
+func TestSQLiteUserRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectSQLite)
+	suite.Run(t, &UserRepositoryContractSuite{newRepository: emptySQLTable(t, db, "users", NewSQLUserRepository)})
+}
+
+func TestSQLiteTaskRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectSQLite)
+	suite.Run(t, &TaskRepositoryContractSuite{newRepository: emptySQLTable(t, db, "tasks", NewSQLTaskRepository)})
+}
+
+func TestSQLiteTaskHistoryRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectSQLite)
+	suite.Run(t, &TaskHistoryRepositoryContractSuite{newRepository: emptySQLTable(t, db, "task_history", NewSQLTaskHistoryRepository)})
+}
+
+func TestSQLiteTokenRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectSQLite)
+	suite.Run(t, &TokenRepositoryContractSuite{newRepository: emptySQLTables(t, db, []string{"refresh_tokens", "revoked_access_tokens", "access_token_cutoffs"}, NewSQLTokenRepository)})
+}
+
+func TestSQLitePasswordResetRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectSQLite)
+	suite.Run(t, &PasswordResetRepositoryContractSuite{newRepository: emptySQLTable(t, db, "password_resets", NewSQLPasswordResetRepository)})
+}
+
+func TestSQLiteRoleRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectSQLite)
+	suite.Run(t, &RoleRepositoryContractSuite{newRepository: emptySQLTable(t, db, "roles", NewSQLRoleRepository)})
+}
+
+func TestSQLiteOrganizationRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectSQLite)
+	suite.Run(t, &OrganizationRepositoryContractSuite{newRepository: emptySQLTables(t, db, []string{"organizations", "memberships"}, NewSQLOrganizationRepository)})
+}
+
+func TestSQLiteProjectRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectSQLite)
+	suite.Run(t, &ProjectRepositoryContractSuite{newRepository: emptySQLTables(t, db, []string{"projects", "project_members"}, NewSQLProjectRepository)})
+}
+
+func TestPostgresUserRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectPostgres)
+	suite.Run(t, &UserRepositoryContractSuite{newRepository: emptySQLTable(t, db, "users", NewSQLUserRepository)})
+}
+
+func TestPostgresTaskRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectPostgres)
+	suite.Run(t, &TaskRepositoryContractSuite{newRepository: emptySQLTable(t, db, "tasks", NewSQLTaskRepository)})
+}
+
+func TestPostgresTaskHistoryRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectPostgres)
+	suite.Run(t, &TaskHistoryRepositoryContractSuite{newRepository: emptySQLTable(t, db, "task_history", NewSQLTaskHistoryRepository)})
+}
+
+func TestPostgresTokenRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectPostgres)
+	suite.Run(t, &TokenRepositoryContractSuite{newRepository: emptySQLTables(t, db, []string{"refresh_tokens", "revoked_access_tokens", "access_token_cutoffs"}, NewSQLTokenRepository)})
+}
+
+func TestPostgresPasswordResetRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectPostgres)
+	suite.Run(t, &PasswordResetRepositoryContractSuite{newRepository: emptySQLTable(t, db, "password_resets", NewSQLPasswordResetRepository)})
+}
+
+func TestPostgresRoleRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectPostgres)
+	suite.Run(t, &RoleRepositoryContractSuite{newRepository: emptySQLTable(t, db, "roles", NewSQLRoleRepository)})
+}
+
+func TestPostgresOrganizationRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectPostgres)
+	suite.Run(t, &OrganizationRepositoryContractSuite{newRepository: emptySQLTables(t, db, []string{"organizations", "memberships"}, NewSQLOrganizationRepository)})
+}
+
+func TestPostgresProjectRepositoryContract(t *testing.T) {
+	db := testSQLDatabase(t, DialectPostgres)
+	suite.Run(t, &ProjectRepositoryContractSuite{newRepository: emptySQLTables(t, db, []string{"projects", "project_members"}, NewSQLProjectRepository)})
+}
+
+func TestMongoUserRepositoryContract(t *testing.T) {
+	db := testMongoDatabase(t)
+	suite.Run(t, &UserRepositoryContractSuite{newRepository: emptyMongoCollection(t, db, "users", newMongoUserRepository)})
+}
+
+func TestMongoTaskRepositoryContract(t *testing.T) {
+	db := testMongoDatabase(t)
+	suite.Run(t, &TaskRepositoryContractSuite{newRepository: emptyMongoCollection(t, db, "tasks", newMongoTaskRepository)})
+}
+
+func TestMongoTaskHistoryRepositoryContract(t *testing.T) {
+	db := testMongoDatabase(t)
+	suite.Run(t, &TaskHistoryRepositoryContractSuite{newRepository: emptyMongoCollection(t, db, "task_history", newMongoTaskHistoryRepository)})
+}
+
+func TestMongoTokenRepositoryContract(t *testing.T) {
+	db := testMongoDatabase(t)
+	suite.Run(t, &TokenRepositoryContractSuite{newRepository: emptyMongoCollections(t, db, []string{"refresh_tokens", "revoked_tokens"}, newMongoTokenRepository)})
+}
+
+func TestMongoPasswordResetRepositoryContract(t *testing.T) {
+	db := testMongoDatabase(t)
+	suite.Run(t, &PasswordResetRepositoryContractSuite{newRepository: emptyMongoCollection(t, db, "password_resets", newMongoPasswordResetRepository)})
+}
+
+func TestMongoRoleRepositoryContract(t *testing.T) {
+	db := testMongoDatabase(t)
+	suite.Run(t, &RoleRepositoryContractSuite{newRepository: emptyMongoCollection(t, db, "roles", newMongoRoleRepository)})
+}
+
+func TestMongoOrganizationRepositoryContract(t *testing.T) {
+	db := testMongoDatabase(t)
+	suite.Run(t, &OrganizationRepositoryContractSuite{newRepository: emptyMongoCollections(t, db, []string{"organizations", "memberships"}, newMongoOrganizationRepository)})
+}
+
+func TestMongoProjectRepositoryContract(t *testing.T) {
+	db := testMongoDatabase(t)
+	suite.Run(t, &ProjectRepositoryContractSuite{newRepository: emptyMongoCollections(t, db, []string{"projects", "project_members"}, newMongoProjectRepository)})
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"task_manager/domain"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RoleDAO is the MongoDB representation of a custom role. The role name is
+// the document ID, which keeps names unique.
+type RoleDAO struct {
+	Name        string   `bson:"_id"`
+	Description string   `bson:"description"`
+	Permissions []string `bson:"permissions"`
+}
+
+func roleToDAO(role *domain.Role) *RoleDAO {
+	return &RoleDAO{
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: role.Permissions,
+	}
+}
+
+func daoToRole(dao *RoleDAO) *domain.Role {
+	return &domain.Role{
+		Name:        dao.Name,
+		Description: dao.Description,
+		Permissions: dao.Permissions,
+	}
+}
+
+type mongoRoleRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRoleRepository(client *mongo.Client) domain.IRoleRepository {
+	return newMongoRoleRepository(client.Database("task_manager"))
+}
+
+// newMongoRoleRepository returns a role repository on the roles collection of
+// db.
+func newMongoRoleRepository(db *mongo.Database) domain.IRoleRepository {
+	return &mongoRoleRepository{
+		collection: db.Collection("roles"),
+	}
+}
+
+func (r *mongoRoleRepository) AddRole(ctx context.Context, role *domain.Role) error {
+	_, err := r.collection.InsertOne(ctx, roleToDAO(role))
+	if mongo.IsDuplicateKeyError(err) {
+		return domain.ErrRoleExists
+	}
+	return err
+}
+
+func (r *mongoRoleRepository) GetRole(ctx context.Context, name string) (*domain.Role, error) {
+	var dao RoleDAO
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&dao)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return daoToRole(&dao), nil
+}
+
+// ListRoles returns every custom role ordered by name.
+func (r *mongoRoleRepository) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	cur, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	var daos []RoleDAO
+	if err := cur.All(ctx, &daos); err != nil {
+		return nil, err
+	}
+	roles := make([]domain.Role, 0, len(daos))
+	for i := range daos {
+		roles = append(roles, *daoToRole(&daos[i]))
+	}
+	return roles, nil
+}
+
+func (r *mongoRoleRepository) UpdateRole(ctx context.Context, role *domain.Role) error {
+	update := bson.M{"$set": bson.M{
+		"description": role.Description,
+		"permissions": role.Permissions,
+	}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": role.Name}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrRoleNotFound
+	}
+	return nil
+}
+
+func (r *mongoRoleRepository) DeleteRole(ctx context.Context, name string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": name})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrRoleNotFound
+	}
+	return nil
+}
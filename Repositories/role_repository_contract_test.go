@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// RoleRepositoryContractSuite checks that an IRoleRepository implementation
+// behaves the way the usecases expect. Run it against a backend with
+// suite.Run, giving it a newRepository that returns an empty repository.
+type RoleRepositoryContractSuite struct {
+	suite.Suite
+	// newRepository returns an empty repository; it is called before
+	// every test.
+	newRepository func() domain.IRoleRepository
+	repo          domain.IRoleRepository
+	ctx           context.Context
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *RoleRepositoryContractSuite) SetupSuite() {
+	suite.ctx = context.Background()
+}
+
+// SetupTest runs before each test
+func (suite *RoleRepositoryContractSuite) SetupTest() {
+	suite.repo = suite.newRepository()
+}
+
+// addRole stores a custom role called name with the given permissions.
+func (suite *RoleRepositoryContractSuite) addRole(name string, permissions ...string) *domain.Role {
+	role := &domain.Role{Name: name, Description: "The " + name + " role", Permissions: permissions}
+	suite.Require().NoError(suite.repo.AddRole(suite.ctx, role))
+	return role
+}
+
+func (suite *RoleRepositoryContractSuite) getRole(name string) *domain.Role {
+	stored, err := suite.repo.GetRole(suite.ctx, name)
+	suite.Require().NoError(err)
+	return stored
+}
+
+func (suite *RoleRepositoryContractSuite) TestRoleSuite() {
+	suite.Run("StoresEveryField", func() {
+		role := suite.addRole("auditor", domain.PermissionTasksRead, domain.PermissionTasksReadAll)
+
+		suite.Equal(role, suite.getRole("auditor"))
+	})
+
+	suite.Run("NoPermissions", func() {
+		suite.addRole("guest")
+
+		suite.Empty(suite.getRole("guest").Permissions)
+	})
+
+	suite.Run("NameTaken", func() {
+		err := suite.repo.AddRole(suite.ctx, &domain.Role{Name: "auditor", Description: "Another auditor"})
+
+		suite.ErrorIs(err, domain.ErrRoleExists)
+		suite.Equal("The auditor role", suite.getRole("auditor").Description, "the existing role is kept")
+	})
+
+	suite.Run("Missing", func() {
+		_, err := suite.repo.GetRole(suite.ctx, "unknown")
+		suite.ErrorIs(err, domain.ErrRoleNotFound)
+	})
+
+	suite.Run("ListRolesOrderedByName", func() {
+		suite.addRole("editor", domain.PermissionTasksUpdate)
+
+		roles, err := suite.repo.ListRoles(suite.ctx)
+
+		suite.Require().NoError(err)
+		names := make([]string, 0, len(roles))
+		for _, role := range roles {
+			names = append(names, role.Name)
+		}
+		suite.Equal([]string{"auditor", "editor", "guest"}, names)
+	})
+}
+
+func (suite *RoleRepositoryContractSuite) TestUpdateRoleSuite() {
+	suite.addRole("auditor", domain.PermissionTasksRead)
+	suite.addRole("editor", domain.PermissionTasksUpdate)
+
+	suite.Run("SavesDescriptionAndPermissions", func() {
+		role := &domain.Role{Name: "auditor", Description: "Reads everything", Permissions: []string{domain.PermissionTasksReadAll, domain.PermissionUsersRead}}
+
+		suite.Require().NoError(suite.repo.UpdateRole(suite.ctx, role))
+
+		suite.Equal(role, suite.getRole("auditor"))
+		suite.Equal([]string{domain.PermissionTasksUpdate}, suite.getRole("editor").Permissions, "other roles are kept")
+	})
+
+	suite.Run("UpdateMissing", func() {
+		err := suite.repo.UpdateRole(suite.ctx, &domain.Role{Name: "unknown"})
+		suite.ErrorIs(err, domain.ErrRoleNotFound)
+	})
+
+	suite.Run("DeleteRole", func() {
+		suite.Require().NoError(suite.repo.DeleteRole(suite.ctx, "auditor"))
+
+		_, err := suite.repo.GetRole(suite.ctx, "auditor")
+		suite.ErrorIs(err, domain.ErrRoleNotFound)
+		suite.getRole("editor")
+	})
+
+	suite.Run("DeleteMissing", func() {
+		suite.ErrorIs(suite.repo.DeleteRole(suite.ctx, "auditor"), domain.ErrRoleNotFound)
+	})
+}
@@ -0,0 +1,333 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" driver
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQL dialects accepted by OpenSQLDatabase.
+const (
+	DialectSQLite   = "sqlite"
+	DialectPostgres = "postgres"
+)
+
+// The SQL repositories store the same data as the MongoDB ones in one table
+// per collection. IDs are ObjectID hex strings and times are milliseconds
+// since the Unix epoch, so ordering, pagination and time precision match
+// MongoDB. Queries use $1-style placeholders, which both drivers accept.
+
+//go:embed migrations
+var migrations embed.FS
+
+// SQLDatabase is a database/sql connection pool together with the dialect
+// spoken over it. The SQL repositories share one.
+type SQLDatabase struct {
+	db      *sql.DB
+	dialect string
+}
+
+// OpenSQLDatabase connects to dsn, a file path for SQLite or a connection
+// URL for PostgreSQL. Call Migrate before using a new database.
+func OpenSQLDatabase(ctx context.Context, dialect, dsn string) (*SQLDatabase, error) {
+	var driver string
+	switch dialect {
+	case DialectSQLite:
+		driver = "sqlite"
+		sep := "?"
+		if strings.Contains(dsn, "?") {
+			sep = "&"
+		}
+		// Transactions take the write lock up front, so two processes
+		// sharing the file wait for each other instead of failing when
+		// both try to upgrade a read lock.
+		dsn += sep + "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	case DialectPostgres:
+		driver = "pgx"
+	default:
+		return nil, fmt.Errorf("unsupported SQL dialect %q", dialect)
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if dialect == DialectSQLite {
+		// SQLite allows a single writer; sharing one connection avoids
+		// "database is locked" errors under concurrent requests.
+		db.SetMaxOpenConns(1)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &SQLDatabase{db: db, dialect: dialect}, nil
+}
+
+// migrationLockID is the key of the PostgreSQL advisory lock held while
+// migrating.
+const migrationLockID int64 = 0x7461736b6d6967 // "taskmig"
+
+// sqlMigration is one of the embedded SQL migrations of a dialect.
+type sqlMigration struct {
+	version int64
+	name    string // file name without the .sql extension
+	path    string
+}
+
+// Migrate applies the embedded migrations of the dialect that have not run
+// yet, in version order, and returns the names of those it applied. Each
+// one runs in its own transaction and is recorded in schema_migrations, so
+// later runs skip it. Instances migrating at the same time take turns, and
+// a migration another instance applied in the meantime counts as applied.
+func (d *SQLDatabase) Migrate(ctx context.Context) ([]string, error) {
+	unlock, err := d.lockMigrations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("lock migrations: %w", err)
+	}
+	defer unlock()
+	pending, err := d.pendingMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0)
+	for _, migration := range pending {
+		script, err := migrations.ReadFile(migration.path)
+		if err != nil {
+			return names, err
+		}
+		ran := false
+		err = d.inTx(ctx, func(tx *sql.Tx) error {
+			var done bool
+			err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, migration.version).Scan(&done)
+			if err != nil || done {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+				return err
+			}
+			_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES ($1, $2)`, migration.version, sqlTime(time.Now()))
+			ran = err == nil
+			return err
+		})
+		if err != nil {
+			return names, fmt.Errorf("migration %s: %w", migration.name, err)
+		}
+		if ran {
+			names = append(names, migration.name)
+		}
+	}
+	return names, nil
+}
+
+// PendingMigrations returns the names of the embedded migrations that
+// Migrate would apply, in version order.
+func (d *SQLDatabase) PendingMigrations(ctx context.Context) ([]string, error) {
+	pending, err := d.pendingMigrations(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(pending))
+	for _, migration := range pending {
+		names = append(names, migration.name)
+	}
+	return names, nil
+}
+
+// pendingMigrations creates schema_migrations if needed and returns the
+// embedded migrations of the dialect it does not record, in version order.
+func (d *SQLDatabase) pendingMigrations(ctx context.Context) ([]sqlMigration, error) {
+	_, err := d.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		applied_at BIGINT NOT NULL
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]bool)
+	rows, err := d.db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var version int64
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	dir := "migrations/" + d.dialect
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	pending := make([]sqlMigration, 0)
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: name must start with a version number", name)
+		}
+		if !applied[version] {
+			pending = append(pending, sqlMigration{version: version, name: name, path: dir + "/" + entry.Name()})
+		}
+	}
+	return pending, nil
+}
+
+// lockMigrations keeps other instances from migrating until the returned
+// function is called. PostgreSQL holds an advisory lock on a connection of
+// its own. SQLite needs nothing more: its transactions begin IMMEDIATE, so
+// each migration holds the write lock from its first statement.
+func (d *SQLDatabase) lockMigrations(ctx context.Context) (func(), error) {
+	if d.dialect != DialectPostgres {
+		return func() {}, nil
+	}
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return func() {
+		conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+		conn.Close()
+	}, nil
+}
+
+// inTx runs fn in a transaction that is committed if fn succeeds and rolled
+// back otherwise.
+func (d *SQLDatabase) inTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// isUniqueViolation reports whether err was caused by a unique constraint or
+// primary key on table. A non-empty column narrows the check to the unique
+// constraint on that column.
+func isUniqueViolation(err error, table, column string) bool {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		code := sqliteErr.Code()
+		if code != sqlite3.SQLITE_CONSTRAINT_UNIQUE && code != sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+			return false
+		}
+		// SQLite names the columns: "UNIQUE constraint failed: users.email".
+		if column == "" {
+			return strings.Contains(sqliteErr.Error(), " "+table+".")
+		}
+		return strings.Contains(sqliteErr.Error(), " "+table+"."+column)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		if pgErr.Code != "23505" || pgErr.TableName != table {
+			return false
+		}
+		// PostgreSQL names single-column unique constraints <table>_<column>_key.
+		return column == "" || pgErr.ConstraintName == table+"_"+column+"_key"
+	}
+	return false
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// sqlTime returns the column value of t: milliseconds since the Unix epoch.
+func sqlTime(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+// sqlNullTime is sqlTime for nullable columns, which hold NULL for the zero
+// time.
+func sqlNullTime(t time.Time) sql.NullInt64 {
+	if t.IsZero() {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: sqlTime(t), Valid: true}
+}
+
+// timeFromSQL is the inverse of sqlTime.
+func timeFromSQL(ms int64) time.Time {
+	return time.UnixMilli(ms).UTC()
+}
+
+// timeFromSQLNull is the inverse of sqlNullTime.
+func timeFromSQLNull(ms sql.NullInt64) time.Time {
+	if !ms.Valid {
+		return time.Time{}
+	}
+	return timeFromSQL(ms.Int64)
+}
+
+// containsPattern returns a LIKE pattern, for use with ESCAPE '\', that
+// matches strings containing s.
+func containsPattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+// sqlArgs collects the arguments of a query built piece by piece and hands
+// out their placeholders.
+type sqlArgs []any
+
+// add appends v and returns its placeholder.
+func (a *sqlArgs) add(v any) string {
+	*a = append(*a, v)
+	return "$" + strconv.Itoa(len(*a))
+}
+
+// addList appends every value and returns their placeholders separated by
+// commas, for use in an IN list.
+func (a *sqlArgs) addList(values []string) string {
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		placeholders[i] = a.add(v)
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+// affectedOrNotFound returns err if the statement behind result failed, and
+// notFound if it changed no row.
+func affectedOrNotFound(result sql.Result, err error, notFound error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
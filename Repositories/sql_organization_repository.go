@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"task_manager/domain"
+)
+
+const membershipColumns = `org_id, user_id, role, created_at`
+
+func scanMembership(row rowScanner) (*domain.Membership, error) {
+	var membership domain.Membership
+	var createdAt int64
+	if err := row.Scan(&membership.OrgID, &membership.UserID, &membership.Role, &createdAt); err != nil {
+		return nil, err
+	}
+	membership.CreatedAt = timeFromSQL(createdAt)
+	return &membership, nil
+}
+
+type sqlOrganizationRepository struct {
+	db *SQLDatabase
+}
+
+// NewSQLOrganizationRepository returns an organization repository backed by
+// db.
+func NewSQLOrganizationRepository(db *SQLDatabase) domain.IOrganizationRepository {
+	return &sqlOrganizationRepository{db: db}
+}
+
+// AddOrganization stores a new organization under a freshly generated ID and
+// writes the assigned ID back onto org.
+func (r *sqlOrganizationRepository) AddOrganization(ctx context.Context, org *domain.Organization) error {
+	id := newObjectID()
+	_, err := r.db.db.ExecContext(ctx, `INSERT INTO organizations (id, name, created_by, created_at) VALUES ($1, $2, $3, $4)`,
+		id, org.Name, org.CreatedBy, sqlTime(org.CreatedAt))
+	if err != nil {
+		return err
+	}
+	org.ID = id
+	return nil
+}
+
+func scanOrganization(row rowScanner) (*domain.Organization, error) {
+	var org domain.Organization
+	var createdAt int64
+	if err := row.Scan(&org.ID, &org.Name, &org.CreatedBy, &createdAt); err != nil {
+		return nil, err
+	}
+	org.CreatedAt = timeFromSQL(createdAt)
+	return &org, nil
+}
+
+func (r *sqlOrganizationRepository) GetOrganization(ctx context.Context, id string) (*domain.Organization, error) {
+	row := r.db.db.QueryRowContext(ctx, `SELECT id, name, created_by, created_at FROM organizations WHERE id = $1`, id)
+	org, err := scanOrganization(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrOrganizationNotFound
+	}
+	return org, err
+}
+
+// ListOrganizationsForUser returns the user's organizations ordered by ID.
+func (r *sqlOrganizationRepository) ListOrganizationsForUser(ctx context.Context, userID string) ([]domain.Organization, error) {
+	rows, err := r.db.db.QueryContext(ctx, `SELECT o.id, o.name, o.created_by, o.created_at FROM organizations o
+		JOIN memberships m ON m.org_id = o.id WHERE m.user_id = $1 ORDER BY o.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	orgs := make([]domain.Organization, 0)
+	for rows.Next() {
+		org, err := scanOrganization(rows)
+		if err != nil {
+			return nil, err
+		}
+		orgs = append(orgs, *org)
+	}
+	return orgs, rows.Err()
+}
+
+func (r *sqlOrganizationRepository) AddMember(ctx context.Context, membership *domain.Membership) error {
+	_, err := r.db.db.ExecContext(ctx, `INSERT INTO memberships (`+membershipColumns+`) VALUES ($1, $2, $3, $4)`,
+		membership.OrgID, membership.UserID, membership.Role, sqlTime(membership.CreatedAt))
+	if isUniqueViolation(err, "memberships", "") {
+		return domain.ErrAlreadyMember
+	}
+	return err
+}
+
+func (r *sqlOrganizationRepository) GetMembership(ctx context.Context, orgID, userID string) (*domain.Membership, error) {
+	row := r.db.db.QueryRowContext(ctx, `SELECT `+membershipColumns+` FROM memberships WHERE org_id = $1 AND user_id = $2`, orgID, userID)
+	membership, err := scanMembership(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrOrganizationNotFound
+	}
+	return membership, err
+}
+
+// ListMembers returns the members of an organization in the order they
+// joined.
+func (r *sqlOrganizationRepository) ListMembers(ctx context.Context, orgID string) ([]domain.Membership, error) {
+	rows, err := r.db.db.QueryContext(ctx, `SELECT `+membershipColumns+` FROM memberships WHERE org_id = $1 ORDER BY created_at, user_id`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	members := make([]domain.Membership, 0)
+	for rows.Next() {
+		membership, err := scanMembership(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, *membership)
+	}
+	return members, rows.Err()
+}
+
+func (r *sqlOrganizationRepository) UpdateMemberRole(ctx context.Context, orgID, userID, role string) error {
+	result, err := r.db.db.ExecContext(ctx, `UPDATE memberships SET role = $3 WHERE org_id = $1 AND user_id = $2`, orgID, userID, role)
+	return affectedOrNotFound(result, err, domain.ErrOrganizationNotFound)
+}
+
+func (r *sqlOrganizationRepository) RemoveMember(ctx context.Context, orgID, userID string) error {
+	result, err := r.db.db.ExecContext(ctx, `DELETE FROM memberships WHERE org_id = $1 AND user_id = $2`, orgID, userID)
+	return affectedOrNotFound(result, err, domain.ErrOrganizationNotFound)
+}
+
+func (r *sqlOrganizationRepository) RemoveUserMemberships(ctx context.Context, userID string) error {
+	_, err := r.db.db.ExecContext(ctx, `DELETE FROM memberships WHERE user_id = $1`, userID)
+	return err
+}
+
+func (r *sqlOrganizationRepository) CountMembersWithRole(ctx context.Context, orgID, role string) (int64, error) {
+	var count int64
+	err := r.db.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM memberships WHERE org_id = $1 AND role = $2`, orgID, role).Scan(&count)
+	return count, err
+}
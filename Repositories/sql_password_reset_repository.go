@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"task_manager/domain"
+	"time"
+)
+
+type sqlPasswordResetRepository struct {
+	db *SQLDatabase
+}
+
+// NewSQLPasswordResetRepository returns a password reset repository backed
+// by db.
+func NewSQLPasswordResetRepository(db *SQLDatabase) domain.IPasswordResetRepository {
+	return &sqlPasswordResetRepository{db: db}
+}
+
+// AddPasswordReset stores a new reset under a freshly generated ID and
+// writes the assigned ID back onto reset.
+func (r *sqlPasswordResetRepository) AddPasswordReset(ctx context.Context, reset *domain.PasswordReset) error {
+	id := newObjectID()
+	_, err := r.db.db.ExecContext(ctx, `INSERT INTO password_resets (id, user_id, token_hash, created_at, expires_at, used_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		id, reset.UserID, reset.TokenHash, sqlTime(reset.CreatedAt), sqlTime(reset.ExpiresAt), sqlNullTime(reset.UsedAt))
+	if err != nil {
+		return err
+	}
+	reset.ID = id
+	return nil
+}
+
+func (r *sqlPasswordResetRepository) GetPasswordResetByHash(ctx context.Context, hash string) (*domain.PasswordReset, error) {
+	var reset domain.PasswordReset
+	var createdAt, expiresAt int64
+	var usedAt sql.NullInt64
+	err := r.db.db.QueryRowContext(ctx, `SELECT id, user_id, token_hash, created_at, expires_at, used_at
+		FROM password_resets WHERE token_hash = $1 ORDER BY id LIMIT 1`, hash).
+		Scan(&reset.ID, &reset.UserID, &reset.TokenHash, &createdAt, &expiresAt, &usedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	reset.CreatedAt = timeFromSQL(createdAt)
+	reset.ExpiresAt = timeFromSQL(expiresAt)
+	reset.UsedAt = timeFromSQLNull(usedAt)
+	return &reset, nil
+}
+
+func (r *sqlPasswordResetRepository) MarkPasswordResetUsed(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.db.ExecContext(ctx, `UPDATE password_resets SET used_at = $2 WHERE id = $1 AND used_at IS NULL`, id, sqlTime(time.Now()))
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *sqlPasswordResetRepository) DeleteUserPasswordResets(ctx context.Context, userID string) error {
+	_, err := r.db.db.ExecContext(ctx, `DELETE FROM password_resets WHERE user_id = $1`, userID)
+	return err
+}
@@ -0,0 +1,160 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"task_manager/domain"
+)
+
+const (
+	projectColumns       = `id, org_id, name, description, created_by, created_at, archived`
+	projectMemberColumns = `project_id, user_id, role, created_at`
+)
+
+func scanProject(row rowScanner) (*domain.Project, error) {
+	var project domain.Project
+	var createdAt int64
+	err := row.Scan(&project.ID, &project.OrgID, &project.Name, &project.Description, &project.CreatedBy, &createdAt, &project.Archived)
+	if err != nil {
+		return nil, err
+	}
+	project.CreatedAt = timeFromSQL(createdAt)
+	return &project, nil
+}
+
+func scanProjectMember(row rowScanner) (*domain.ProjectMember, error) {
+	var member domain.ProjectMember
+	var createdAt int64
+	if err := row.Scan(&member.ProjectID, &member.UserID, &member.Role, &createdAt); err != nil {
+		return nil, err
+	}
+	member.CreatedAt = timeFromSQL(createdAt)
+	return &member, nil
+}
+
+type sqlProjectRepository struct {
+	db *SQLDatabase
+}
+
+// NewSQLProjectRepository returns a project repository backed by db.
+func NewSQLProjectRepository(db *SQLDatabase) domain.IProjectRepository {
+	return &sqlProjectRepository{db: db}
+}
+
+// AddProject stores a new project under a freshly generated ID and writes
+// the assigned ID back onto project.
+func (r *sqlProjectRepository) AddProject(ctx context.Context, project *domain.Project) error {
+	id := newObjectID()
+	_, err := r.db.db.ExecContext(ctx, `INSERT INTO projects (`+projectColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		id, project.OrgID, project.Name, project.Description, project.CreatedBy, sqlTime(project.CreatedAt), project.Archived)
+	if err != nil {
+		return err
+	}
+	project.ID = id
+	return nil
+}
+
+func (r *sqlProjectRepository) GetProject(ctx context.Context, orgID, id string) (*domain.Project, error) {
+	row := r.db.db.QueryRowContext(ctx, `SELECT `+projectColumns+` FROM projects WHERE id = $1 AND org_id = $2`, id, orgID)
+	project, err := scanProject(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrProjectNotFound
+	}
+	return project, err
+}
+
+// ListProjects returns the projects matching query ordered by ID.
+func (r *sqlProjectRepository) ListProjects(ctx context.Context, query domain.ProjectQuery) ([]domain.Project, error) {
+	var args sqlArgs
+	where := `org_id = ` + args.add(query.OrgID)
+	if !query.IncludeArchived {
+		where += ` AND archived = ` + args.add(false)
+	}
+	if query.MemberID != "" {
+		where += ` AND id IN (SELECT project_id FROM project_members WHERE user_id = ` + args.add(query.MemberID) + `)`
+	}
+	rows, err := r.db.db.QueryContext(ctx, `SELECT `+projectColumns+` FROM projects WHERE `+where+` ORDER BY id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	projects := make([]domain.Project, 0)
+	for rows.Next() {
+		project, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *project)
+	}
+	return projects, rows.Err()
+}
+
+func (r *sqlProjectRepository) UpdateProject(ctx context.Context, project *domain.Project) error {
+	result, err := r.db.db.ExecContext(ctx, `UPDATE projects SET name = $3, description = $4, archived = $5 WHERE id = $1 AND org_id = $2`,
+		project.ID, project.OrgID, project.Name, project.Description, project.Archived)
+	return affectedOrNotFound(result, err, domain.ErrProjectNotFound)
+}
+
+// DeleteProject removes the project and its members in one transaction.
+func (r *sqlProjectRepository) DeleteProject(ctx context.Context, orgID, id string) error {
+	return r.db.inTx(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `DELETE FROM projects WHERE id = $1 AND org_id = $2`, id, orgID)
+		if err := affectedOrNotFound(result, err, domain.ErrProjectNotFound); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `DELETE FROM project_members WHERE project_id = $1`, id)
+		return err
+	})
+}
+
+func (r *sqlProjectRepository) AddProjectMember(ctx context.Context, member *domain.ProjectMember) error {
+	_, err := r.db.db.ExecContext(ctx, `INSERT INTO project_members (`+projectMemberColumns+`) VALUES ($1, $2, $3, $4)`,
+		member.ProjectID, member.UserID, member.Role, sqlTime(member.CreatedAt))
+	if isUniqueViolation(err, "project_members", "") {
+		return domain.ErrAlreadyProjectMember
+	}
+	return err
+}
+
+func (r *sqlProjectRepository) GetProjectMember(ctx context.Context, projectID, userID string) (*domain.ProjectMember, error) {
+	row := r.db.db.QueryRowContext(ctx, `SELECT `+projectMemberColumns+` FROM project_members WHERE project_id = $1 AND user_id = $2`, projectID, userID)
+	member, err := scanProjectMember(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrProjectNotFound
+	}
+	return member, err
+}
+
+// ListProjectMembers returns the members of a project in the order they
+// joined.
+func (r *sqlProjectRepository) ListProjectMembers(ctx context.Context, projectID string) ([]domain.ProjectMember, error) {
+	rows, err := r.db.db.QueryContext(ctx, `SELECT `+projectMemberColumns+` FROM project_members WHERE project_id = $1 ORDER BY created_at, user_id`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	members := make([]domain.ProjectMember, 0)
+	for rows.Next() {
+		member, err := scanProjectMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, *member)
+	}
+	return members, rows.Err()
+}
+
+func (r *sqlProjectRepository) UpdateProjectMemberRole(ctx context.Context, projectID, userID, role string) error {
+	result, err := r.db.db.ExecContext(ctx, `UPDATE project_members SET role = $3 WHERE project_id = $1 AND user_id = $2`, projectID, userID, role)
+	return affectedOrNotFound(result, err, domain.ErrProjectNotFound)
+}
+
+func (r *sqlProjectRepository) RemoveProjectMember(ctx context.Context, projectID, userID string) error {
+	result, err := r.db.db.ExecContext(ctx, `DELETE FROM project_members WHERE project_id = $1 AND user_id = $2`, projectID, userID)
+	return affectedOrNotFound(result, err, domain.ErrProjectNotFound)
+}
+
+func (r *sqlProjectRepository) RemoveUserProjectMemberships(ctx context.Context, userID string) error {
+	_, err := r.db.db.ExecContext(ctx, `DELETE FROM project_members WHERE user_id = $1`, userID)
+	return err
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"task_manager/domain"
+)
+
+type sqlRoleRepository struct {
+	db *SQLDatabase
+}
+
+// NewSQLRoleRepository returns a role repository backed by db. Permissions
+// are stored as a JSON array.
+func NewSQLRoleRepository(db *SQLDatabase) domain.IRoleRepository {
+	return &sqlRoleRepository{db: db}
+}
+
+func scanRole(row rowScanner) (*domain.Role, error) {
+	var role domain.Role
+	var permissions string
+	if err := row.Scan(&role.Name, &role.Description, &permissions); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(permissions), &role.Permissions); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// permissionsJSON returns the stored form of a role's permissions.
+func permissionsJSON(role *domain.Role) (string, error) {
+	permissions := role.Permissions
+	if permissions == nil {
+		permissions = []string{}
+	}
+	raw, err := json.Marshal(permissions)
+	return string(raw), err
+}
+
+func (r *sqlRoleRepository) AddRole(ctx context.Context, role *domain.Role) error {
+	permissions, err := permissionsJSON(role)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.db.ExecContext(ctx, `INSERT INTO roles (name, description, permissions) VALUES ($1, $2, $3)`,
+		role.Name, role.Description, permissions)
+	if isUniqueViolation(err, "roles", "") {
+		return domain.ErrRoleExists
+	}
+	return err
+}
+
+func (r *sqlRoleRepository) GetRole(ctx context.Context, name string) (*domain.Role, error) {
+	row := r.db.db.QueryRowContext(ctx, `SELECT name, description, permissions FROM roles WHERE name = $1`, name)
+	role, err := scanRole(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrRoleNotFound
+	}
+	return role, err
+}
+
+// ListRoles returns every custom role ordered by name.
+func (r *sqlRoleRepository) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	rows, err := r.db.db.QueryContext(ctx, `SELECT name, description, permissions FROM roles ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	roles := make([]domain.Role, 0)
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *sqlRoleRepository) UpdateRole(ctx context.Context, role *domain.Role) error {
+	permissions, err := permissionsJSON(role)
+	if err != nil {
+		return err
+	}
+	result, err := r.db.db.ExecContext(ctx, `UPDATE roles SET description = $2, permissions = $3 WHERE name = $1`,
+		role.Name, role.Description, permissions)
+	return affectedOrNotFound(result, err, domain.ErrRoleNotFound)
+}
+
+func (r *sqlRoleRepository) DeleteRole(ctx context.Context, name string) error {
+	result, err := r.db.db.ExecContext(ctx, `DELETE FROM roles WHERE name = $1`, name)
+	return affectedOrNotFound(result, err, domain.ErrRoleNotFound)
+}
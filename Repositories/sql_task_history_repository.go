@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"task_manager/domain"
+)
+
+type sqlTaskHistoryRepository struct {
+	db *SQLDatabase
+}
+
+// NewSQLTaskHistoryRepository returns a task history repository backed by
+// db. The changes of an entry are stored as a JSON array.
+func NewSQLTaskHistoryRepository(db *SQLDatabase) domain.ITaskHistoryRepository {
+	return &sqlTaskHistoryRepository{db: db}
+}
+
+// taskChangeJSON is the stored form of a domain.TaskChange.
+type taskChangeJSON struct {
+	Field  string `json:"field"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// AddEntry stores a new history entry under a freshly generated ID and
+// writes the assigned ID back onto entry.
+func (r *sqlTaskHistoryRepository) AddEntry(ctx context.Context, entry *domain.TaskHistoryEntry) error {
+	changes := make([]taskChangeJSON, 0, len(entry.Changes))
+	for _, c := range entry.Changes {
+		changes = append(changes, taskChangeJSON(c))
+	}
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	id := newObjectID()
+	_, err = r.db.db.ExecContext(ctx, `INSERT INTO task_history (id, task_id, org_id, action, actor_id, created_at, changes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		id, entry.TaskID, entry.OrgID, entry.Action, entry.ActorID, sqlTime(entry.CreatedAt), string(raw))
+	if err != nil {
+		return err
+	}
+	entry.ID = id
+	return nil
+}
+
+func (r *sqlTaskHistoryRepository) ListEntries(ctx context.Context, orgID, taskID string) ([]domain.TaskHistoryEntry, error) {
+	rows, err := r.db.db.QueryContext(ctx, `SELECT id, task_id, org_id, action, actor_id, created_at, changes FROM task_history
+		WHERE org_id = $1 AND task_id = $2 ORDER BY created_at, id`, orgID, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := make([]domain.TaskHistoryEntry, 0)
+	for rows.Next() {
+		var entry domain.TaskHistoryEntry
+		var createdAt int64
+		var raw string
+		if err := rows.Scan(&entry.ID, &entry.TaskID, &entry.OrgID, &entry.Action, &entry.ActorID, &createdAt, &raw); err != nil {
+			return nil, err
+		}
+		entry.CreatedAt = timeFromSQL(createdAt)
+		var changes []taskChangeJSON
+		if err := json.Unmarshal([]byte(raw), &changes); err != nil {
+			return nil, err
+		}
+		for _, c := range changes {
+			entry.Changes = append(entry.Changes, domain.TaskChange(c))
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
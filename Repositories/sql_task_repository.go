@@ -0,0 +1,382 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"task_manager/domain"
+	"time"
+	"unicode/utf8"
+)
+
+const taskColumns = `id, org_id, title, description, due_date, status, created_by, assignee_id, project_id, version, deleted_at, deleted_by`
+
+func scanTask(row rowScanner) (*domain.Task, error) {
+	var task domain.Task
+	var dueDate int64
+	var deletedAt sql.NullInt64
+	err := row.Scan(&task.ID, &task.OrgID, &task.Title, &task.Description, &dueDate, &task.Status,
+		&task.CreatedBy, &task.AssigneeID, &task.ProjectID, &task.Version, &deletedAt, &task.DeletedBy)
+	if err != nil {
+		return nil, err
+	}
+	task.DueDate = timeFromSQL(dueDate)
+	task.DeletedAt = timeFromSQLNull(deletedAt)
+	return &task, nil
+}
+
+// taskOrgID returns orgID, or DefaultOrganizationID if it is empty.
+func taskOrgID(orgID string) string {
+	if orgID == "" {
+		return domain.DefaultOrganizationID
+	}
+	return orgID
+}
+
+type sqlTaskRepository struct {
+	db *SQLDatabase
+}
+
+// NewSQLTaskRepository returns a task repository backed by db.
+func NewSQLTaskRepository(db *SQLDatabase) domain.ITaskRepository {
+	return &sqlTaskRepository{db: db}
+}
+
+// AddTask stores a new task under a freshly generated ID and writes the
+// assigned ID back onto the task.
+func (r *sqlTaskRepository) AddTask(ctx context.Context, task *domain.Task) error {
+	id := newObjectID()
+	orgID := taskOrgID(task.OrgID)
+	_, err := r.db.db.ExecContext(ctx, `INSERT INTO tasks (`+taskColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+		id, orgID, task.Title, task.Description, sqlTime(task.DueDate), task.Status,
+		task.CreatedBy, task.AssigneeID, task.ProjectID, int64(1), sqlNullTime(task.DeletedAt), task.DeletedBy)
+	if err != nil {
+		return err
+	}
+	task.ID = id
+	task.OrgID = orgID
+	task.Version = 1
+	return nil
+}
+
+// GetAllTasks returns one page of tasks matching query. Pages are ordered by
+// the sort field with the ID as a tie-breaker, and NextCursor resumes after
+// the last task of the page.
+func (r *sqlTaskRepository) GetAllTasks(ctx context.Context, query domain.TaskQuery) (*domain.TaskPage, error) {
+	var cursor *taskCursor
+	if query.Cursor != "" {
+		var err error
+		if cursor, err = decodeTaskCursor(query); err != nil {
+			return nil, err
+		}
+	}
+
+	var args sqlArgs
+	conditions := taskQueryConditions(query, &args)
+	page := &domain.TaskPage{Tasks: make([]domain.Task, 0)}
+	err := r.db.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks WHERE `+strings.Join(conditions, ` AND `), args...).Scan(&page.Total)
+	if err != nil {
+		return nil, err
+	}
+
+	column := taskSortColumn(query.SortBy)
+	if cursor != nil {
+		conditions = append(conditions, taskCursorCondition(cursor, column, query.SortDesc, &args))
+	}
+	direction := ` ASC`
+	if query.SortDesc {
+		direction = ` DESC`
+	}
+	order := column + direction
+	if column != "id" {
+		order += `, id` + direction
+	}
+	limit := ""
+	if query.Limit > 0 {
+		// Fetch one extra task to learn whether there is a next page.
+		limit = ` LIMIT ` + args.add(query.Limit+1)
+	}
+	rows, err := r.db.db.QueryContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE `+strings.Join(conditions, ` AND `)+` ORDER BY `+order+limit, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Tasks = append(page.Tasks, *task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if query.Limit > 0 && len(page.Tasks) > query.Limit {
+		page.Tasks = page.Tasks[:query.Limit]
+		page.NextCursor = encodeTaskCursor(&page.Tasks[query.Limit-1], query)
+	}
+	return page, nil
+}
+
+// taskQueryConditions translates the filters of query into WHERE
+// conditions, the way taskQueryFilter does for MongoDB.
+func taskQueryConditions(query domain.TaskQuery, args *sqlArgs) []string {
+	conditions := []string{`org_id = ` + args.add(taskOrgID(query.OrgID))}
+	if query.Deleted {
+		conditions = append(conditions, `deleted_at IS NOT NULL`)
+	} else {
+		conditions = append(conditions, `deleted_at IS NULL`)
+	}
+	if query.VisibleTo != "" {
+		user := args.add(query.VisibleTo)
+		visible := `created_by = ` + user + ` OR assignee_id = ` + user
+		if len(query.VisibleProjectIDs) > 0 {
+			visible += ` OR project_id IN (` + args.addList(query.VisibleProjectIDs) + `)`
+		}
+		conditions = append(conditions, `(`+visible+`)`)
+	}
+	if query.ProjectID != "" {
+		conditions = append(conditions, `project_id = `+args.add(query.ProjectID))
+	} else if len(query.ExcludeProjectIDs) > 0 {
+		conditions = append(conditions, `project_id NOT IN (`+args.addList(query.ExcludeProjectIDs)+`)`)
+	}
+	if query.Status != "" {
+		conditions = append(conditions, `status = `+args.add(query.Status))
+	}
+	if !query.DueAfter.IsZero() {
+		conditions = append(conditions, `due_date >= `+args.add(sqlTime(query.DueAfter)))
+	}
+	if !query.DueBefore.IsZero() {
+		conditions = append(conditions, `due_date <= `+args.add(sqlTime(query.DueBefore)))
+	}
+	if query.TitlePrefix != "" {
+		// substr rather than LIKE, which ignores case in SQLite.
+		n := args.add(utf8.RuneCountInString(query.TitlePrefix))
+		conditions = append(conditions, `substr(title, 1, `+n+`) = `+args.add(query.TitlePrefix))
+	}
+	return conditions
+}
+
+// taskSortColumn returns the column tasks are sorted by for sortBy.
+func taskSortColumn(sortBy string) string {
+	switch sortBy {
+	case domain.TaskSortByTitle, domain.TaskSortByStatus, domain.TaskSortByDueDate:
+		return sortBy
+	}
+	return "id"
+}
+
+// taskCursorCondition selects the tasks that sort strictly after cursor,
+// like taskCursorFilter.
+func taskCursorCondition(cursor *taskCursor, column string, desc bool, args *sqlArgs) string {
+	op := ` > `
+	if desc {
+		op = ` < `
+	}
+	id := args.add(cursor.ID)
+	if column == "id" {
+		return `id` + op + id
+	}
+	var value any = cursor.Value
+	if column == domain.TaskSortByDueDate {
+		value = sqlTime(cursor.dueDate())
+	}
+	v := args.add(value)
+	return `(` + column + op + v + ` OR (` + column + ` = ` + v + ` AND id` + op + id + `))`
+}
+
+func (r *sqlTaskRepository) GetTaskByID(ctx context.Context, orgID, id string) (*domain.Task, error) {
+	row := r.db.db.QueryRowContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = $1 AND org_id = $2 AND deleted_at IS NULL`, id, taskOrgID(orgID))
+	task, err := scanTask(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrTaskNotFound
+	}
+	return task, err
+}
+
+// versionedUpdate applies the assignments to the live task id of orgID if it
+// is still at version, and increments the version. args holds the arguments
+// of the assignments.
+func (r *sqlTaskRepository) versionedUpdate(ctx context.Context, orgID, id string, version int64, assignments []string, args sqlArgs) error {
+	assignments = append(assignments, `version = version + 1`)
+	query := `UPDATE tasks SET ` + strings.Join(assignments, `, `) +
+		` WHERE id = ` + args.add(id) + ` AND org_id = ` + args.add(taskOrgID(orgID)) +
+		` AND deleted_at IS NULL AND version = ` + args.add(version)
+	result, err := r.db.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	if _, err := r.GetTaskByID(ctx, orgID, id); err != nil {
+		return err
+	}
+	return domain.ErrVersionConflict
+}
+
+func (r *sqlTaskRepository) UpdateTask(ctx context.Context, task *domain.Task) error {
+	var args sqlArgs
+	assignments := []string{
+		`title = ` + args.add(task.Title),
+		`description = ` + args.add(task.Description),
+		`due_date = ` + args.add(sqlTime(task.DueDate)),
+		`status = ` + args.add(task.Status),
+		`created_by = ` + args.add(task.CreatedBy),
+		`assignee_id = ` + args.add(task.AssigneeID),
+		`project_id = ` + args.add(task.ProjectID),
+	}
+	if err := r.versionedUpdate(ctx, task.OrgID, task.ID, task.Version, assignments, args); err != nil {
+		return err
+	}
+	task.Version++
+	return nil
+}
+
+func (r *sqlTaskRepository) PatchTask(ctx context.Context, orgID, id string, version int64, patch domain.TaskPatch) error {
+	var args sqlArgs
+	var assignments []string
+	if patch.Title != nil {
+		assignments = append(assignments, `title = `+args.add(*patch.Title))
+	}
+	if patch.Description != nil {
+		assignments = append(assignments, `description = `+args.add(*patch.Description))
+	}
+	if patch.DueDate != nil {
+		assignments = append(assignments, `due_date = `+args.add(sqlTime(*patch.DueDate)))
+	}
+	if patch.Status != nil {
+		assignments = append(assignments, `status = `+args.add(*patch.Status))
+	}
+	if patch.AssigneeID != nil {
+		assignments = append(assignments, `assignee_id = `+args.add(*patch.AssigneeID))
+	}
+	if patch.ProjectID != nil {
+		assignments = append(assignments, `project_id = `+args.add(*patch.ProjectID))
+	}
+	return r.versionedUpdate(ctx, orgID, id, version, assignments, args)
+}
+
+func (r *sqlTaskRepository) DeleteTask(ctx context.Context, task *domain.Task) error {
+	var args sqlArgs
+	assignments := []string{
+		`deleted_at = ` + args.add(sqlNullTime(task.DeletedAt)),
+		`deleted_by = ` + args.add(task.DeletedBy),
+	}
+	if err := r.versionedUpdate(ctx, task.OrgID, task.ID, task.Version, assignments, args); err != nil {
+		return err
+	}
+	task.Version++
+	return nil
+}
+
+func (r *sqlTaskRepository) RestoreTask(ctx context.Context, orgID, id string) (*domain.Task, error) {
+	var task *domain.Task
+	err := r.db.inTx(ctx, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = $1 AND org_id = $2 AND deleted_at IS NOT NULL`, id, taskOrgID(orgID))
+		var err error
+		if task, err = scanTask(row); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, `UPDATE tasks SET deleted_at = NULL, deleted_by = '', version = version + 1
+			WHERE id = $1 AND deleted_at IS NOT NULL`, id)
+		if err != nil {
+			return err
+		}
+		// Someone else restored or purged the task in the meantime.
+		if n, err := result.RowsAffected(); err != nil || n == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (r *sqlTaskRepository) PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := r.db.db.ExecContext(ctx, `DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < $1`, sqlTime(deletedBefore))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *sqlTaskRepository) ReassignTasks(ctx context.Context, fromUserID, toUserID string, orgIDs []string) error {
+	if len(orgIDs) == 0 {
+		return nil
+	}
+	args := sqlArgs{fromUserID, toUserID}
+	orgs := make([]string, len(orgIDs))
+	for i, orgID := range orgIDs {
+		orgs[i] = taskOrgID(orgID)
+	}
+	_, err := r.db.db.ExecContext(ctx, `UPDATE tasks SET created_by = $2, version = version + 1
+		WHERE created_by = $1 AND org_id IN (`+args.addList(orgs)+`)`, args...)
+	return err
+}
+
+func (r *sqlTaskRepository) DeleteTasksByCreator(ctx context.Context, userID string, orgIDs []string, deletedBy string, deletedAt time.Time) ([]domain.Task, error) {
+	if len(orgIDs) == 0 {
+		return nil, nil
+	}
+	args := sqlArgs{userID}
+	orgs := make([]string, len(orgIDs))
+	for i, orgID := range orgIDs {
+		orgs[i] = taskOrgID(orgID)
+	}
+	var deleted []domain.Task
+	err := r.db.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `SELECT `+taskColumns+` FROM tasks
+			WHERE created_by = $1 AND org_id IN (`+args.addList(orgs)+`) AND deleted_at IS NULL ORDER BY id`, args...)
+		if err != nil {
+			return err
+		}
+		var tasks []domain.Task
+		for rows.Next() {
+			task, err := scanTask(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			tasks = append(tasks, *task)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			result, err := tx.ExecContext(ctx, `UPDATE tasks SET deleted_at = $1, deleted_by = $2, version = version + 1
+				WHERE id = $3 AND deleted_at IS NULL`, sqlTime(deletedAt), deletedBy, task.ID)
+			if err != nil {
+				return err
+			}
+			// Someone else moved the task to the trash in the meantime.
+			if n, err := result.RowsAffected(); err != nil || n == 0 {
+				continue
+			}
+			task.DeletedAt = timeFromSQL(sqlTime(deletedAt))
+			task.DeletedBy = deletedBy
+			task.Version++
+			deleted = append(deleted, task)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+func (r *sqlTaskRepository) UnassignTasks(ctx context.Context, userID string) error {
+	_, err := r.db.db.ExecContext(ctx, `UPDATE tasks SET assignee_id = '', version = version + 1 WHERE assignee_id = $1`, userID)
+	return err
+}
@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"task_manager/domain"
+	"time"
+)
+
+type sqlTokenRepository struct {
+	db *SQLDatabase
+}
+
+// NewSQLTokenRepository returns a token repository backed by db.
+func NewSQLTokenRepository(db *SQLDatabase) domain.ITokenRepository {
+	return &sqlTokenRepository{db: db}
+}
+
+// AddRefreshToken stores a new refresh token under a freshly generated ID
+// and writes the assigned ID back onto token.
+func (r *sqlTokenRepository) AddRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
+	id := newObjectID()
+	_, err := r.db.db.ExecContext(ctx, `INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, created_at, expires_at, used_at, revoked)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		id, token.UserID, token.FamilyID, token.TokenHash, sqlTime(token.CreatedAt), sqlTime(token.ExpiresAt), sqlNullTime(token.UsedAt), token.Revoked)
+	if err != nil {
+		return err
+	}
+	token.ID = id
+	return nil
+}
+
+func (r *sqlTokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	var createdAt, expiresAt int64
+	var usedAt sql.NullInt64
+	err := r.db.db.QueryRowContext(ctx, `SELECT id, user_id, family_id, token_hash, created_at, expires_at, used_at, revoked
+		FROM refresh_tokens WHERE token_hash = $1 ORDER BY id LIMIT 1`, hash).
+		Scan(&token.ID, &token.UserID, &token.FamilyID, &token.TokenHash, &createdAt, &expiresAt, &usedAt, &token.Revoked)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	token.CreatedAt = timeFromSQL(createdAt)
+	token.ExpiresAt = timeFromSQL(expiresAt)
+	token.UsedAt = timeFromSQLNull(usedAt)
+	return &token, nil
+}
+
+func (r *sqlTokenRepository) MarkRefreshTokenUsed(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.db.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = $2 WHERE id = $1 AND used_at IS NULL AND revoked = $3`,
+		id, sqlTime(time.Now()), false)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *sqlTokenRepository) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	_, err := r.db.db.ExecContext(ctx, `UPDATE refresh_tokens SET revoked = $2 WHERE family_id = $1`, familyID, true)
+	return err
+}
+
+func (r *sqlTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	_, err := r.db.db.ExecContext(ctx, `UPDATE refresh_tokens SET revoked = $2 WHERE user_id = $1`, userID, true)
+	return err
+}
+
+func (r *sqlTokenRepository) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	_, err := r.db.db.ExecContext(ctx, `INSERT INTO revoked_access_tokens (jti, expires_at) VALUES ($1, $2)
+		ON CONFLICT (jti) DO UPDATE SET expires_at = excluded.expires_at`, tokenID, sqlTime(expiresAt))
+	return err
+}
+
+func (r *sqlTokenRepository) RevokeUserAccessTokens(ctx context.Context, userID string, issuedBefore time.Time) error {
+	_, err := r.db.db.ExecContext(ctx, `INSERT INTO access_token_cutoffs (user_id, revoked_before) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET revoked_before = excluded.revoked_before`, userID, sqlTime(issuedBefore))
+	return err
+}
+
+func (r *sqlTokenRepository) IsAccessTokenRevoked(ctx context.Context, tokenID, userID string, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	err := r.db.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM revoked_access_tokens WHERE jti = $1)
+		OR EXISTS (SELECT 1 FROM access_token_cutoffs WHERE user_id = $2 AND revoked_before > $3)`,
+		tokenID, userID, sqlTime(issuedAt)).Scan(&revoked)
+	return revoked, err
+}
+
+func (r *sqlTokenRepository) PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	var purged int64
+	err := r.db.inTx(ctx, func(tx *sql.Tx) error {
+		for _, table := range []string{"refresh_tokens", "revoked_access_tokens"} {
+			result, err := tx.ExecContext(ctx, `DELETE FROM `+table+` WHERE expires_at < $1`, sqlTime(now))
+			if err != nil {
+				return err
+			}
+			n, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			purged += n
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
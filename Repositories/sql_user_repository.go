@@ -0,0 +1,258 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"task_manager/domain"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const userColumns = `id, username, email, password, role, email_verified, deactivated, active_org_id`
+
+func scanUser(row rowScanner) (*domain.User, error) {
+	var user domain.User
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role, &user.EmailVerified, &user.Deactivated, &user.ActiveOrgID)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+type sqlUserRepository struct {
+	db *SQLDatabase
+}
+
+// NewSQLUserRepository returns a user repository backed by db. Usernames and
+// emails are unique columns, so a taken one is refused with
+// domain.ErrUsernameTaken or domain.ErrEmailTaken, and so is the first_admin
+// flag of AddFirstAdmin.
+func NewSQLUserRepository(db *SQLDatabase) domain.IUserRepository {
+	return &sqlUserRepository{db: db}
+}
+
+// uniqueUserError translates a violation of a unique constraint on users
+// into the matching domain error.
+func uniqueUserError(err error) error {
+	switch {
+	case isUniqueViolation(err, "users", "email"):
+		return domain.ErrEmailTaken
+	case isUniqueViolation(err, "users", "username"):
+		return domain.ErrUsernameTaken
+	case isUniqueViolation(err, "users", "first_admin"):
+		return domain.ErrFirstAdminExists
+	}
+	return err
+}
+
+// AddUser stores a new user under a freshly generated ID and writes the
+// assigned ID back onto the user.
+func (r *sqlUserRepository) AddUser(ctx context.Context, user *domain.User) error {
+	return r.add(ctx, user, sql.NullBool{})
+}
+
+// AddFirstAdmin is AddUser with the first_admin column set.
+func (r *sqlUserRepository) AddFirstAdmin(ctx context.Context, user *domain.User) error {
+	return r.add(ctx, user, sql.NullBool{Bool: true, Valid: true})
+}
+
+func (r *sqlUserRepository) add(ctx context.Context, user *domain.User, firstAdmin sql.NullBool) error {
+	id := newObjectID()
+	_, err := r.db.db.ExecContext(ctx, `INSERT INTO users (`+userColumns+`, first_admin) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		id, user.Username, user.Email, user.Password, user.Role, user.EmailVerified, user.Deactivated, user.ActiveOrgID, firstAdmin)
+	if err != nil {
+		return uniqueUserError(err)
+	}
+	user.ID = id
+	return nil
+}
+
+func (r *sqlUserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.findUser(ctx, `email = $1`, email)
+}
+
+func (r *sqlUserRepository) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	return r.findUser(ctx, `username = $1`, username)
+}
+
+func (r *sqlUserRepository) GetUserByID(ctx context.Context, id string) (*domain.User, error) {
+	return r.findUser(ctx, `id = $1`, id)
+}
+
+// findUser returns the user matching the where clause, or
+// domain.ErrUserNotFound.
+func (r *sqlUserRepository) findUser(ctx context.Context, where string, args ...any) (*domain.User, error) {
+	row := r.db.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE `+where, args...)
+	user, err := scanUser(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrUserNotFound
+	}
+	return user, err
+}
+
+func (r *sqlUserRepository) IsUsersCollectionEmpty(ctx context.Context) (bool, error) {
+	var exists bool
+	err := r.db.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM users)`).Scan(&exists)
+	return !exists, err
+}
+
+func (r *sqlUserRepository) UpdatePassword(ctx context.Context, id, hashedPassword string) error {
+	return r.update(ctx, `UPDATE users SET password = $2 WHERE id = $1`, id, hashedPassword)
+}
+
+func (r *sqlUserRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	return r.update(ctx, `UPDATE users SET email_verified = $2 WHERE id = $1`, id, true)
+}
+
+func (r *sqlUserRepository) UpdateProfile(ctx context.Context, user *domain.User) error {
+	err := r.update(ctx, `UPDATE users SET username = $2, email = $3, email_verified = $4 WHERE id = $1`,
+		user.ID, user.Username, user.Email, user.EmailVerified)
+	return uniqueUserError(err)
+}
+
+// PromoteUserToAdmin makes the user whose username or email is identifier
+// an admin.
+func (r *sqlUserRepository) PromoteUserToAdmin(ctx context.Context, identifier string) error {
+	return r.update(ctx, `UPDATE users SET role = $2 WHERE id = (
+		SELECT id FROM users WHERE username = $1 OR email = $1 ORDER BY id LIMIT 1
+	)`, identifier, domain.RoleAdmin)
+}
+
+// ListUsers returns one page of users matching query, ordered by ID.
+// NextCursor is the ID of the last user on the page.
+func (r *sqlUserRepository) ListUsers(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error) {
+	if query.Cursor != "" {
+		if _, err := primitive.ObjectIDFromHex(query.Cursor); err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidUserQuery)
+		}
+	}
+	var args sqlArgs
+	var conditions []string
+	if query.Search != "" {
+		pattern := args.add(containsPattern(strings.ToLower(query.Search)))
+		conditions = append(conditions, `(LOWER(username) LIKE `+pattern+` ESCAPE '\' OR LOWER(email) LIKE `+pattern+` ESCAPE '\')`)
+	}
+	if query.Role != "" {
+		conditions = append(conditions, `role = `+args.add(query.Role))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+
+	page := &domain.UserPage{Users: make([]domain.User, 0)}
+	if err := r.db.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`+where, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	if query.Cursor != "" {
+		conditions = append(conditions, `id > `+args.add(query.Cursor))
+		where = ` WHERE ` + strings.Join(conditions, ` AND `)
+	}
+	limit := ""
+	if query.Limit > 0 {
+		// Fetch one extra user to learn whether there is a next page.
+		limit = ` LIMIT ` + args.add(query.Limit+1)
+	}
+	rows, err := r.db.db.QueryContext(ctx, `SELECT `+userColumns+` FROM users`+where+` ORDER BY id`+limit, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		page.Users = append(page.Users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if query.Limit > 0 && len(page.Users) > query.Limit {
+		page.Users = page.Users[:query.Limit]
+		page.NextCursor = page.Users[query.Limit-1].ID
+	}
+	return page, nil
+}
+
+func (r *sqlUserRepository) UpdateUserRole(ctx context.Context, id, role string) error {
+	return r.updateKeepingAnAdmin(ctx, `UPDATE users SET role = $2 WHERE id = $1`, id, role)
+}
+
+func (r *sqlUserRepository) SetUserDeactivated(ctx context.Context, id string, deactivated bool) error {
+	return r.updateKeepingAnAdmin(ctx, `UPDATE users SET deactivated = $2 WHERE id = $1`, id, deactivated)
+}
+
+func (r *sqlUserRepository) DeleteUser(ctx context.Context, id string) error {
+	return r.update(ctx, `DELETE FROM users WHERE id = $1`, id)
+}
+
+func (r *sqlUserRepository) CountActiveAdmins(ctx context.Context) (int64, error) {
+	return countActiveAdmins(ctx, r.db.db)
+}
+
+// sqlQueryer is implemented by both *sql.DB and *sql.Tx.
+type sqlQueryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func countActiveAdmins(ctx context.Context, q sqlQueryer) (int64, error) {
+	var count int64
+	err := q.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role = $1 AND deactivated = $2`, domain.RoleAdmin, false).Scan(&count)
+	return count, err
+}
+
+func (r *sqlUserRepository) SetActiveOrganization(ctx context.Context, id, orgID string) error {
+	if orgID == domain.DefaultOrganizationID {
+		orgID = ""
+	}
+	return r.update(ctx, `UPDATE users SET active_org_id = $2 WHERE id = $1`, id, orgID)
+}
+
+// update runs a statement that changes a single user and returns
+// domain.ErrUserNotFound if it matched none.
+func (r *sqlUserRepository) update(ctx context.Context, query string, args ...any) error {
+	result, err := r.db.db.ExecContext(ctx, query, args...)
+	return affectedOrNotFound(result, err, domain.ErrUserNotFound)
+}
+
+// updateKeepingAnAdmin runs a statement that changes a single user, like
+// update, and rolls it back with domain.ErrLastAdmin if it took away the
+// last active admin. PostgreSQL locks the active admins first, so concurrent
+// calls wait for each other and count what the others committed; SQLite
+// runs one transaction at a time anyway.
+func (r *sqlUserRepository) updateKeepingAnAdmin(ctx context.Context, query string, args ...any) error {
+	return r.db.inTx(ctx, func(tx *sql.Tx) error {
+		if r.db.dialect == DialectPostgres {
+			rows, err := tx.QueryContext(ctx, `SELECT id FROM users WHERE role = $1 AND deactivated = $2 FOR UPDATE`, domain.RoleAdmin, false)
+			if err != nil {
+				return err
+			}
+			for rows.Next() {
+				// Only the row locks are wanted.
+			}
+			if err := rows.Close(); err != nil {
+				return err
+			}
+		}
+		before, err := countActiveAdmins(ctx, tx)
+		if err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err := affectedOrNotFound(result, err, domain.ErrUserNotFound); err != nil {
+			return err
+		}
+		after, err := countActiveAdmins(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if before > 0 && after == 0 {
+			return domain.ErrLastAdmin
+		}
+		return nil
+	})
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"task_manager/domain"
+	"time"
+)
+
+// taskCursor is the decoded form of TaskPage.NextCursor. It records the sort
+// key and ID of the last task on a page so the next page can resume right
+// after it (keyset pagination), which stays fast no matter how deep the
+// client pages.
+type taskCursor struct {
+	SortBy string `json:"s"`
+	Desc   bool   `json:"d,omitempty"`
+	Value  string `json:"v,omitempty"`
+	ID     string `json:"id"`
+}
+
+// taskSortValue returns the value of the sort field for task, as stored in a
+// cursor.
+func taskSortValue(task *domain.Task, sortBy string) string {
+	switch sortBy {
+	case domain.TaskSortByTitle:
+		return task.Title
+	case domain.TaskSortByStatus:
+		return task.Status
+	case domain.TaskSortByDueDate:
+		return task.DueDate.UTC().Format(time.RFC3339Nano)
+	default:
+		return task.ID
+	}
+}
+
+func encodeTaskCursor(task *domain.Task, query domain.TaskQuery) string {
+	cursor := taskCursor{
+		SortBy: query.SortBy,
+		Desc:   query.SortDesc,
+		ID:     task.ID,
+	}
+	if query.SortBy != domain.TaskSortByID {
+		cursor.Value = taskSortValue(task, query.SortBy)
+	}
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// decodeTaskCursor parses an opaque cursor and checks that it was issued for
+// the same sort order as query.
+func decodeTaskCursor(query domain.TaskQuery) (*taskCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(query.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidTaskQuery)
+	}
+	var cursor taskCursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || cursor.ID == "" {
+		return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidTaskQuery)
+	}
+	if cursor.SortBy != query.SortBy || cursor.Desc != query.SortDesc {
+		return nil, fmt.Errorf("%w: cursor does not match the requested sort order", domain.ErrInvalidTaskQuery)
+	}
+	if cursor.SortBy == domain.TaskSortByDueDate {
+		if _, err := time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidTaskQuery)
+		}
+	}
+	return &cursor, nil
+}
+
+// dueDate returns the cursor value of a due_date cursor as a time.
+func (c *taskCursor) dueDate() time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, c.Value)
+	return t
+}
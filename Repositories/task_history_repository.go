@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"task_manager/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TaskHistoryDAO is the MongoDB representation of a task history entry
+type TaskHistoryDAO struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	TaskID    string             `bson:"task_id"`
+	OrgID     string             `bson:"org_id"`
+	Action    string             `bson:"action"`
+	ActorID   string             `bson:"actor_id"`
+	CreatedAt time.Time          `bson:"created_at"`
+	Changes   []TaskChangeDAO    `bson:"changes"`
+}
+
+// TaskChangeDAO is the MongoDB representation of one changed task field
+type TaskChangeDAO struct {
+	Field  string `bson:"field"`
+	Before string `bson:"before,omitempty"`
+	After  string `bson:"after,omitempty"`
+}
+
+func daoToTaskHistoryEntry(dao *TaskHistoryDAO) *domain.TaskHistoryEntry {
+	entry := &domain.TaskHistoryEntry{
+		ID:        dao.ID.Hex(),
+		TaskID:    dao.TaskID,
+		OrgID:     dao.OrgID,
+		Action:    dao.Action,
+		ActorID:   dao.ActorID,
+		CreatedAt: dao.CreatedAt,
+		Changes:   make([]domain.TaskChange, 0, len(dao.Changes)),
+	}
+	for _, c := range dao.Changes {
+		entry.Changes = append(entry.Changes, domain.TaskChange{Field: c.Field, Before: c.Before, After: c.After})
+	}
+	return entry
+}
+
+type mongoTaskHistoryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewTaskHistoryRepository(client *mongo.Client) domain.ITaskHistoryRepository {
+	return newMongoTaskHistoryRepository(client.Database("task_manager"))
+}
+
+// newMongoTaskHistoryRepository returns a task history repository on the
+// task_history collection of db.
+func newMongoTaskHistoryRepository(db *mongo.Database) domain.ITaskHistoryRepository {
+	return &mongoTaskHistoryRepository{
+		collection: db.Collection("task_history"),
+	}
+}
+
+// AddEntry stores a new history entry under a freshly generated ObjectID
+// and writes the assigned ID back onto entry.
+func (r *mongoTaskHistoryRepository) AddEntry(ctx context.Context, entry *domain.TaskHistoryEntry) error {
+	dao := &TaskHistoryDAO{
+		ID:        primitive.NewObjectID(),
+		TaskID:    entry.TaskID,
+		OrgID:     entry.OrgID,
+		Action:    entry.Action,
+		ActorID:   entry.ActorID,
+		CreatedAt: entry.CreatedAt,
+		Changes:   make([]TaskChangeDAO, 0, len(entry.Changes)),
+	}
+	for _, c := range entry.Changes {
+		dao.Changes = append(dao.Changes, TaskChangeDAO{Field: c.Field, Before: c.Before, After: c.After})
+	}
+	if _, err := r.collection.InsertOne(ctx, dao); err != nil {
+		return err
+	}
+	entry.ID = dao.ID.Hex()
+	return nil
+}
+
+func (r *mongoTaskHistoryRepository) ListEntries(ctx context.Context, orgID, taskID string) ([]domain.TaskHistoryEntry, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := r.collection.Find(ctx, bson.M{"org_id": orgID, "task_id": taskID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var daos []TaskHistoryDAO
+	if err := cur.All(ctx, &daos); err != nil {
+		return nil, err
+	}
+	entries := make([]domain.TaskHistoryEntry, 0, len(daos))
+	for i := range daos {
+		entries = append(entries, *daoToTaskHistoryEntry(&daos[i]))
+	}
+	return entries, nil
+}
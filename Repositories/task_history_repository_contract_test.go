@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// TaskHistoryRepositoryContractSuite checks that an ITaskHistoryRepository
+// implementation behaves the way the usecases expect. Run it against a
+// backend with suite.Run, giving it a newRepository that returns an empty
+// repository.
+type TaskHistoryRepositoryContractSuite struct {
+	suite.Suite
+	// newRepository returns an empty repository; it is called before
+	// every test.
+	newRepository func() domain.ITaskHistoryRepository
+	repo          domain.ITaskHistoryRepository
+	ctx           context.Context
+	// now is a time the backends store without losing precision.
+	now time.Time
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *TaskHistoryRepositoryContractSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	suite.now = time.Date(2030, time.March, 1, 12, 0, 0, 0, time.UTC)
+}
+
+// SetupTest runs before each test
+func (suite *TaskHistoryRepositoryContractSuite) SetupTest() {
+	suite.repo = suite.newRepository()
+}
+
+// addEntry stores an update of the task made at createdAt.
+func (suite *TaskHistoryRepositoryContractSuite) addEntry(orgID, taskID string, createdAt time.Time) *domain.TaskHistoryEntry {
+	entry := &domain.TaskHistoryEntry{
+		TaskID:    taskID,
+		OrgID:     orgID,
+		Action:    domain.TaskActionUpdated,
+		ActorID:   "user1",
+		CreatedAt: createdAt,
+		Changes:   []domain.TaskChange{{Field: "status", Before: domain.TaskStatusPending, After: domain.TaskStatusCompleted}},
+	}
+	suite.Require().NoError(suite.repo.AddEntry(suite.ctx, entry))
+	return entry
+}
+
+func (suite *TaskHistoryRepositoryContractSuite) listEntries(orgID, taskID string) []domain.TaskHistoryEntry {
+	entries, err := suite.repo.ListEntries(suite.ctx, orgID, taskID)
+	suite.Require().NoError(err)
+	return entries
+}
+
+func (suite *TaskHistoryRepositoryContractSuite) TestTaskHistorySuite() {
+	suite.Run("StoresEveryField", func() {
+		entry := &domain.TaskHistoryEntry{
+			TaskID:    "task1",
+			OrgID:     "org1",
+			Action:    domain.TaskActionCreated,
+			ActorID:   "user1",
+			CreatedAt: suite.now,
+			Changes: []domain.TaskChange{
+				{Field: "title", After: "Write docs"},
+				{Field: "status", After: domain.TaskStatusPending},
+			},
+		}
+		suite.Require().NoError(suite.repo.AddEntry(suite.ctx, entry))
+
+		suite.NotEmpty(entry.ID)
+		suite.Equal([]domain.TaskHistoryEntry{*entry}, suite.listEntries("org1", "task1"))
+	})
+
+	suite.Run("OldestFirst", func() {
+		third := suite.addEntry("org1", "task2", suite.now.Add(2*time.Minute))
+		first := suite.addEntry("org1", "task2", suite.now)
+		second := suite.addEntry("org1", "task2", suite.now.Add(time.Minute))
+
+		entries := suite.listEntries("org1", "task2")
+
+		suite.Require().Len(entries, 3)
+		suite.Equal([]string{first.ID, second.ID, third.ID}, []string{entries[0].ID, entries[1].ID, entries[2].ID})
+	})
+
+	suite.Run("OnlyThatTask", func() {
+		suite.addEntry("org2", "task1", suite.now)
+		suite.addEntry("org1", "task3", suite.now)
+
+		suite.Len(suite.listEntries("org1", "task1"), 1)
+		suite.Len(suite.listEntries("org2", "task1"), 1)
+		suite.Empty(suite.listEntries("org2", "task2"), "tasks are scoped to their organization")
+	})
+
+	suite.Run("UnknownTask", func() {
+		suite.Empty(suite.listEntries("org1", "unknown"))
+	})
+}
@@ -2,11 +2,14 @@ package repositories
 
 import (
 	"context"
+	"regexp"
 	"task_manager/domain"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // TaskDAO (Data Access Object) is the MongoDB representation of a task
@@ -17,26 +20,62 @@ type TaskDAO struct {
 	Description string    `bson:"description"`
 	DueDate     time.Time `bson:"due_date"`
 	Status      string    `bson:"status"`
+	CreatedBy   string    `bson:"created_by"`
+	AssigneeID  string    `bson:"assignee_id,omitempty"`
+	// OrgID is missing on tasks created before organizations existed; they
+	// belong to the default organization.
+	OrgID     string `bson:"org_id,omitempty"`
+	ProjectID string `bson:"project_id,omitempty"`
+	// Version is missing on tasks written before versioning; they count as
+	// version 0.
+	Version int64 `bson:"version"`
+	// DeletedAt is only present on tasks in the trash.
+	DeletedAt *time.Time `bson:"deleted_at,omitempty"`
+	DeletedBy string     `bson:"deleted_by,omitempty"`
 }
 
 func taskToDAO(task *domain.Task) *TaskDAO {
-	return &TaskDAO{
+	dao := &TaskDAO{
 		ID:          task.ID,
 		Title:       task.Title,
 		Description: task.Description,
 		DueDate:     task.DueDate,
 		Status:      task.Status,
+		CreatedBy:   task.CreatedBy,
+		AssigneeID:  task.AssigneeID,
+		OrgID:       task.OrgID,
+		ProjectID:   task.ProjectID,
+		Version:     task.Version,
+		DeletedBy:   task.DeletedBy,
 	}
+	if !task.DeletedAt.IsZero() {
+		deletedAt := task.DeletedAt
+		dao.DeletedAt = &deletedAt
+	}
+	return dao
 }
 
 func daoToTask(dao *TaskDAO) *domain.Task {
-	return &domain.Task{
+	task := &domain.Task{
 		ID:          dao.ID,
 		Title:       dao.Title,
 		Description: dao.Description,
 		DueDate:     dao.DueDate,
 		Status:      dao.Status,
+		CreatedBy:   dao.CreatedBy,
+		AssigneeID:  dao.AssigneeID,
+		OrgID:       dao.OrgID,
+		ProjectID:   dao.ProjectID,
+		Version:     dao.Version,
+		DeletedBy:   dao.DeletedBy,
+	}
+	if dao.DeletedAt != nil {
+		task.DeletedAt = *dao.DeletedAt
+	}
+	if task.OrgID == "" {
+		task.OrgID = domain.DefaultOrganizationID
 	}
+	return task
 }
 
 type mongoTaskRepository struct {
@@ -44,51 +83,353 @@ type mongoTaskRepository struct {
 }
 
 func NewTaskRepository(client *mongo.Client) domain.ITaskRepository {
-	db := client.Database("task_manager")
+	return newMongoTaskRepository(client.Database("task_manager"))
+}
+
+// newMongoTaskRepository returns a task repository on the tasks collection
+// of db.
+func newMongoTaskRepository(db *mongo.Database) domain.ITaskRepository {
 	return &mongoTaskRepository{
 		collection: db.Collection("tasks"),
 	}
 }
 
+// AddTask stores a new task under a freshly generated ObjectID (as its hex
+// string) and writes the assigned ID back onto the task.
 func (r *mongoTaskRepository) AddTask(ctx context.Context, task *domain.Task) error {
 	dao := taskToDAO(task)
-	_, err := r.collection.InsertOne(ctx, dao)
-	return err
+	dao.ID = primitive.NewObjectID().Hex()
+	dao.Version = 1
+	if dao.OrgID == "" {
+		dao.OrgID = domain.DefaultOrganizationID
+	}
+	if _, err := r.collection.InsertOne(ctx, dao); err != nil {
+		return err
+	}
+	task.ID = dao.ID
+	task.OrgID = dao.OrgID
+	task.Version = dao.Version
+	return nil
 }
 
-func (r *mongoTaskRepository) GetAllTasks(ctx context.Context) ([]domain.Task, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{})
+// GetAllTasks returns one page of tasks matching query. Pages are ordered by
+// the sort field with _id as a tie-breaker, and NextCursor resumes after the
+// last task of the page.
+func (r *mongoTaskRepository) GetAllTasks(ctx context.Context, query domain.TaskQuery) (*domain.TaskPage, error) {
+	filter := taskQueryFilter(query)
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	field := taskSortField(query.SortBy)
+	direction := 1
+	if query.SortDesc {
+		direction = -1
+	}
+	sort := bson.D{{Key: field, Value: direction}}
+	if field != "_id" {
+		sort = append(sort, bson.E{Key: "_id", Value: direction})
+	}
+
+	if query.Cursor != "" {
+		cursor, err := decodeTaskCursor(query)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"$and": bson.A{filter, taskCursorFilter(cursor, field, query.SortDesc)}}
+	}
+
+	// Fetch one extra document to find out whether another page follows.
+	opts := options.Find().SetSort(sort).SetLimit(int64(query.Limit) + 1)
+	cur, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
 	var daos []TaskDAO
-	if err := cursor.All(ctx, &daos); err != nil {
+	if err := cur.All(ctx, &daos); err != nil {
 		return nil, err
 	}
-	tasks := make([]domain.Task, len(daos))
-	for i, dao := range daos {
-		tasks[i] = *daoToTask(&dao)
+
+	page := &domain.TaskPage{Tasks: make([]domain.Task, 0, len(daos)), Total: total}
+	for i := range daos {
+		if i == query.Limit {
+			page.NextCursor = encodeTaskCursor(&page.Tasks[i-1], query)
+			break
+		}
+		page.Tasks = append(page.Tasks, *daoToTask(&daos[i]))
+	}
+	return page, nil
+}
+
+// taskQueryFilter translates the filters of query into a MongoDB filter.
+func taskQueryFilter(query domain.TaskQuery) bson.M {
+	filter := bson.M{"org_id": orgFilter(query.OrgID), "deleted_at": bson.M{"$exists": query.Deleted}}
+	if query.VisibleTo != "" {
+		visible := bson.A{
+			bson.M{"created_by": query.VisibleTo},
+			bson.M{"assignee_id": query.VisibleTo},
+		}
+		if len(query.VisibleProjectIDs) > 0 {
+			visible = append(visible, bson.M{"project_id": bson.M{"$in": query.VisibleProjectIDs}})
+		}
+		filter["$or"] = visible
+	}
+	if query.ProjectID != "" {
+		filter["project_id"] = query.ProjectID
+	} else if len(query.ExcludeProjectIDs) > 0 {
+		filter["project_id"] = bson.M{"$nin": query.ExcludeProjectIDs}
+	}
+	if query.Status != "" {
+		filter["status"] = query.Status
+	}
+	due := bson.M{}
+	if !query.DueAfter.IsZero() {
+		due["$gte"] = query.DueAfter
 	}
-	return tasks, nil
+	if !query.DueBefore.IsZero() {
+		due["$lte"] = query.DueBefore
+	}
+	if len(due) > 0 {
+		filter["due_date"] = due
+	}
+	if query.TitlePrefix != "" {
+		filter["title"] = bson.M{"$regex": "^" + regexp.QuoteMeta(query.TitlePrefix)}
+	}
+	return filter
+}
+
+// orgFilter matches the tasks of orgID. Tasks without an org_id belong to
+// the default organization.
+func orgFilter(orgID string) interface{} {
+	if orgID == "" || orgID == domain.DefaultOrganizationID {
+		return bson.M{"$in": bson.A{domain.DefaultOrganizationID, nil}}
+	}
+	return orgID
+}
+
+func taskSortField(sortBy string) string {
+	if sortBy == domain.TaskSortByID {
+		return "_id"
+	}
+	return sortBy
+}
+
+// taskCursorFilter selects the tasks that sort strictly after the cursor.
+func taskCursorFilter(cursor *taskCursor, field string, desc bool) bson.M {
+	op := "$gt"
+	if desc {
+		op = "$lt"
+	}
+	if field == "_id" {
+		return bson.M{"_id": bson.M{op: cursor.ID}}
+	}
+	var value interface{} = cursor.Value
+	if field == domain.TaskSortByDueDate {
+		value = cursor.dueDate()
+	}
+	return bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: value}},
+		bson.M{field: value, "_id": bson.M{op: cursor.ID}},
+	}}
+}
+
+// liveTaskFilter matches the task id of orgID unless it is in the trash.
+func liveTaskFilter(orgID, id string) bson.M {
+	return bson.M{"_id": id, "org_id": orgFilter(orgID), "deleted_at": bson.M{"$exists": false}}
 }
 
-func (r *mongoTaskRepository) GetTaskByID(ctx context.Context, id string) (*domain.Task, error) {
+func (r *mongoTaskRepository) GetTaskByID(ctx context.Context, orgID, id string) (*domain.Task, error) {
 	var dao TaskDAO
-	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&dao)
+	err := r.collection.FindOne(ctx, liveTaskFilter(orgID, id)).Decode(&dao)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrTaskNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
 	return daoToTask(&dao), nil
 }
 
+// versionFilter matches version; tasks without a version field count as
+// version 0.
+func versionFilter(version int64) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
+
+// versionedUpdate applies update to the live task id of orgID if it is still
+// at version. When nothing matches it tells a changed version apart from a
+// missing task.
+func (r *mongoTaskRepository) versionedUpdate(ctx context.Context, orgID, id string, version int64, update bson.M) error {
+	filter := liveTaskFilter(orgID, id)
+	filter["version"] = versionFilter(version)
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+	count, err := r.collection.CountDocuments(ctx, liveTaskFilter(orgID, id))
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return domain.ErrVersionConflict
+	}
+	return domain.ErrTaskNotFound
+}
+
 func (r *mongoTaskRepository) UpdateTask(ctx context.Context, task *domain.Task) error {
-	filter := bson.M{"_id": task.ID}
-	update := bson.M{"$set": taskToDAO(task)}
-	_, err := r.collection.UpdateOne(ctx, filter, update)
+	dao := taskToDAO(task)
+	dao.Version = task.Version + 1
+	dao.DeletedAt = nil
+	dao.DeletedBy = ""
+	if dao.OrgID == "" {
+		dao.OrgID = domain.DefaultOrganizationID
+	}
+	update := bson.M{"$set": dao}
+	// Optional fields left empty are omitted from $set and have to be
+	// removed explicitly, e.g. when a task leaves its project.
+	unset := bson.M{}
+	if dao.AssigneeID == "" {
+		unset["assignee_id"] = ""
+	}
+	if dao.ProjectID == "" {
+		unset["project_id"] = ""
+	}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	if err := r.versionedUpdate(ctx, task.OrgID, task.ID, task.Version, update); err != nil {
+		return err
+	}
+	task.Version = dao.Version
+	return nil
+}
+
+func (r *mongoTaskRepository) PatchTask(ctx context.Context, orgID, id string, version int64, patch domain.TaskPatch) error {
+	set := bson.M{"version": version + 1}
+	unset := bson.M{}
+	if patch.Title != nil {
+		set["title"] = *patch.Title
+	}
+	if patch.Description != nil {
+		set["description"] = *patch.Description
+	}
+	if patch.DueDate != nil {
+		set["due_date"] = *patch.DueDate
+	}
+	if patch.Status != nil {
+		set["status"] = *patch.Status
+	}
+	// Like UpdateTask, optional fields are removed rather than stored empty.
+	for field, value := range map[string]*string{"assignee_id": patch.AssigneeID, "project_id": patch.ProjectID} {
+		switch {
+		case value == nil:
+		case *value == "":
+			unset[field] = ""
+		default:
+			set[field] = *value
+		}
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return r.versionedUpdate(ctx, orgID, id, version, update)
+}
+
+func (r *mongoTaskRepository) DeleteTask(ctx context.Context, task *domain.Task) error {
+	update := bson.M{"$set": bson.M{
+		"deleted_at": task.DeletedAt,
+		"deleted_by": task.DeletedBy,
+		"version":    task.Version + 1,
+	}}
+	if err := r.versionedUpdate(ctx, task.OrgID, task.ID, task.Version, update); err != nil {
+		return err
+	}
+	task.Version++
+	return nil
+}
+
+func (r *mongoTaskRepository) RestoreTask(ctx context.Context, orgID, id string) (*domain.Task, error) {
+	filter := bson.M{"_id": id, "org_id": orgFilter(orgID), "deleted_at": bson.M{"$exists": true}}
+	update := bson.M{"$unset": bson.M{"deleted_at": "", "deleted_by": ""}, "$inc": bson.M{"version": 1}}
+	var dao TaskDAO
+	err := r.collection.FindOneAndUpdate(ctx, filter, update).Decode(&dao)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return daoToTask(&dao), nil
+}
+
+func (r *mongoTaskRepository) PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"deleted_at": bson.M{"$lt": deletedBefore}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (r *mongoTaskRepository) ReassignTasks(ctx context.Context, fromUserID, toUserID string, orgIDs []string) error {
+	orgs := make([]bson.M, len(orgIDs))
+	for i, orgID := range orgIDs {
+		orgs[i] = bson.M{"org_id": orgFilter(orgID)}
+	}
+	if len(orgs) == 0 {
+		return nil
+	}
+	filter := bson.M{"created_by": fromUserID, "$or": orgs}
+	update := bson.M{"$set": bson.M{"created_by": toUserID}, "$inc": bson.M{"version": 1}}
+	_, err := r.collection.UpdateMany(ctx, filter, update)
 	return err
 }
 
-func (r *mongoTaskRepository) DeleteTask(ctx context.Context, id string) error {
-	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+func (r *mongoTaskRepository) DeleteTasksByCreator(ctx context.Context, userID string, orgIDs []string, deletedBy string, deletedAt time.Time) ([]domain.Task, error) {
+	orgs := make([]bson.M, len(orgIDs))
+	for i, orgID := range orgIDs {
+		orgs[i] = bson.M{"org_id": orgFilter(orgID)}
+	}
+	if len(orgs) == 0 {
+		return nil, nil
+	}
+	filter := bson.M{"created_by": userID, "$or": orgs, "deleted_at": bson.M{"$exists": false}}
+	cur, err := r.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var matching []TaskDAO
+	if err := cur.All(ctx, &matching); err != nil {
+		return nil, err
+	}
+	// Move the tasks one at a time, so each one returned is exactly a task
+	// this call moved, at the version it left behind.
+	update := bson.M{"$set": bson.M{"deleted_at": deletedAt, "deleted_by": deletedBy}, "$inc": bson.M{"version": 1}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var deleted []domain.Task
+	for _, match := range matching {
+		var dao TaskDAO
+		err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": match.ID, "deleted_at": bson.M{"$exists": false}}, update, opts).Decode(&dao)
+		if err == mongo.ErrNoDocuments {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		deleted = append(deleted, *daoToTask(&dao))
+	}
+	return deleted, nil
+}
+
+func (r *mongoTaskRepository) UnassignTasks(ctx context.Context, userID string) error {
+	update := bson.M{"$unset": bson.M{"assignee_id": ""}, "$inc": bson.M{"version": 1}}
+	_, err := r.collection.UpdateMany(ctx, bson.M{"assignee_id": userID}, update)
 	return err
-}
\ No newline at end of file
+}
@@ -0,0 +1,482 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TaskRepositoryContractSuite checks that an ITaskRepository implementation
+// behaves the way the usecases expect. Run it against a backend with
+// suite.Run, giving it a newRepository that returns an empty repository.
+type TaskRepositoryContractSuite struct {
+	suite.Suite
+	// newRepository returns an empty repository; it is called before
+	// every test.
+	newRepository func() domain.ITaskRepository
+	repo          domain.ITaskRepository
+	ctx           context.Context
+	// due is a due date the backends store without losing precision.
+	due time.Time
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *TaskRepositoryContractSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	suite.due = time.Date(2030, time.March, 1, 12, 0, 0, 0, time.UTC)
+}
+
+// SetupTest runs before each test
+func (suite *TaskRepositoryContractSuite) SetupTest() {
+	suite.repo = suite.newRepository()
+}
+
+// addTask stores a pending task created by user1 in the default
+// organization, after applying the given changes to it.
+func (suite *TaskRepositoryContractSuite) addTask(title string, changes ...func(*domain.Task)) *domain.Task {
+	task := &domain.Task{
+		Title:     title,
+		DueDate:   suite.due,
+		Status:    domain.TaskStatusPending,
+		CreatedBy: "user1",
+	}
+	for _, change := range changes {
+		change(task)
+	}
+	suite.Require().NoError(suite.repo.AddTask(suite.ctx, task))
+	return task
+}
+
+func (suite *TaskRepositoryContractSuite) getTask(task *domain.Task) *domain.Task {
+	stored, err := suite.repo.GetTaskByID(suite.ctx, task.OrgID, task.ID)
+	suite.Require().NoError(err)
+	return stored
+}
+
+// requireSameTask compares tasks field by field, comparing times with
+// time.Equal since backends may hand back a different location.
+func (suite *TaskRepositoryContractSuite) requireSameTask(expected, actual *domain.Task) {
+	suite.Require().True(expected.DueDate.Equal(actual.DueDate), "due date %v != %v", expected.DueDate, actual.DueDate)
+	suite.Require().True(expected.DeletedAt.Equal(actual.DeletedAt), "deleted at %v != %v", expected.DeletedAt, actual.DeletedAt)
+	e, a := *expected, *actual
+	e.DueDate, a.DueDate = time.Time{}, time.Time{}
+	e.DeletedAt, a.DeletedAt = time.Time{}, time.Time{}
+	suite.Require().Equal(e, a)
+}
+
+// listIDs returns the IDs of every task matching query, following
+// NextCursor across pages.
+func (suite *TaskRepositoryContractSuite) listIDs(query domain.TaskQuery) []string {
+	ids := make([]string, 0)
+	for pages := 0; pages < 20; pages++ {
+		page, err := suite.repo.GetAllTasks(suite.ctx, query)
+		suite.Require().NoError(err)
+		for _, task := range page.Tasks {
+			ids = append(ids, task.ID)
+		}
+		if page.NextCursor == "" {
+			return ids
+		}
+		query.Cursor = page.NextCursor
+	}
+	suite.FailNow("listing did not end after 20 pages")
+	return nil
+}
+
+func (suite *TaskRepositoryContractSuite) TestAddTaskSuite() {
+	suite.Run("AssignsIDVersionAndOrganization", func() {
+		task := suite.addTask("Write report")
+
+		suite.True(primitive.IsValidObjectID(task.ID), "IDs are ObjectID hex strings")
+		suite.Equal(int64(1), task.Version)
+		suite.Equal(domain.DefaultOrganizationID, task.OrgID)
+	})
+
+	suite.Run("StoresEveryField", func() {
+		task := suite.addTask("Review", func(t *domain.Task) {
+			t.Description = "Review the report"
+			t.Status = domain.TaskStatusInProgress
+			t.AssigneeID = "user2"
+			t.OrgID = "org1"
+			t.ProjectID = "project1"
+		})
+
+		suite.requireSameTask(task, suite.getTask(task))
+	})
+}
+
+func (suite *TaskRepositoryContractSuite) TestGetTaskByIDSuite() {
+	task := suite.addTask("Write report", func(t *domain.Task) { t.OrgID = "org1" })
+
+	suite.Run("Missing", func() {
+		_, err := suite.repo.GetTaskByID(suite.ctx, "org1", primitive.NewObjectID().Hex())
+		suite.ErrorIs(err, domain.ErrTaskNotFound)
+	})
+
+	suite.Run("OtherOrganization", func() {
+		_, err := suite.repo.GetTaskByID(suite.ctx, "org2", task.ID)
+		suite.ErrorIs(err, domain.ErrTaskNotFound)
+	})
+
+	suite.Run("Trashed", func() {
+		trashed := suite.addTask("Old", func(t *domain.Task) { t.OrgID = "org1" })
+		trashed.DeletedAt = suite.due
+		trashed.DeletedBy = "user1"
+		suite.Require().NoError(suite.repo.DeleteTask(suite.ctx, trashed))
+
+		_, err := suite.repo.GetTaskByID(suite.ctx, "org1", trashed.ID)
+		suite.ErrorIs(err, domain.ErrTaskNotFound)
+	})
+}
+
+func (suite *TaskRepositoryContractSuite) TestUpdateTaskSuite() {
+	task := suite.addTask("Write report")
+
+	suite.Run("BumpsVersion", func() {
+		update := *task
+		update.Title = "Write the report"
+		update.Status = domain.TaskStatusCompleted
+		update.DueDate = suite.due.Add(time.Hour)
+		suite.Require().NoError(suite.repo.UpdateTask(suite.ctx, &update))
+
+		suite.Equal(int64(2), update.Version)
+		suite.requireSameTask(&update, suite.getTask(task))
+	})
+
+	suite.Run("StaleVersion", func() {
+		stale := *task
+		stale.Title = "Lost update"
+		suite.ErrorIs(suite.repo.UpdateTask(suite.ctx, &stale), domain.ErrVersionConflict)
+		suite.Equal("Write the report", suite.getTask(task).Title)
+	})
+
+	suite.Run("Missing", func() {
+		missing := *task
+		missing.ID = primitive.NewObjectID().Hex()
+		suite.ErrorIs(suite.repo.UpdateTask(suite.ctx, &missing), domain.ErrTaskNotFound)
+	})
+
+	suite.Run("OtherOrganization", func() {
+		other := *suite.getTask(task)
+		other.OrgID = "org2"
+		suite.ErrorIs(suite.repo.UpdateTask(suite.ctx, &other), domain.ErrTaskNotFound)
+	})
+}
+
+func (suite *TaskRepositoryContractSuite) TestPatchTaskSuite() {
+	task := suite.addTask("Write report", func(t *domain.Task) {
+		t.Description = "Quarterly"
+		t.AssigneeID = "user2"
+	})
+
+	suite.Run("ChangesOnlyPatchedFields", func() {
+		title, assignee := "Write the report", ""
+		patch := domain.TaskPatch{Title: &title, AssigneeID: &assignee}
+		suite.Require().NoError(suite.repo.PatchTask(suite.ctx, task.OrgID, task.ID, 1, patch))
+
+		stored := suite.getTask(task)
+		suite.Equal("Write the report", stored.Title)
+		suite.Equal("Quarterly", stored.Description)
+		suite.Empty(stored.AssigneeID)
+		suite.Equal(int64(2), stored.Version)
+	})
+
+	suite.Run("StaleVersion", func() {
+		title := "Lost update"
+		err := suite.repo.PatchTask(suite.ctx, task.OrgID, task.ID, 1, domain.TaskPatch{Title: &title})
+		suite.ErrorIs(err, domain.ErrVersionConflict)
+	})
+
+	suite.Run("Missing", func() {
+		title := "Nothing"
+		err := suite.repo.PatchTask(suite.ctx, task.OrgID, primitive.NewObjectID().Hex(), 1, domain.TaskPatch{Title: &title})
+		suite.ErrorIs(err, domain.ErrTaskNotFound)
+	})
+}
+
+func (suite *TaskRepositoryContractSuite) TestTrashSuite() {
+	task := suite.addTask("Write report")
+	deletedAt := suite.due.Add(-time.Hour)
+
+	suite.Run("DeleteStaleVersion", func() {
+		stale := *task
+		stale.Version = 5
+		stale.DeletedAt = deletedAt
+		suite.ErrorIs(suite.repo.DeleteTask(suite.ctx, &stale), domain.ErrVersionConflict)
+	})
+
+	suite.Run("DeleteMissing", func() {
+		missing := *task
+		missing.ID = primitive.NewObjectID().Hex()
+		missing.DeletedAt = deletedAt
+		suite.ErrorIs(suite.repo.DeleteTask(suite.ctx, &missing), domain.ErrTaskNotFound)
+	})
+
+	suite.Run("DeleteMovesToTrash", func() {
+		task.DeletedAt = deletedAt
+		task.DeletedBy = "user2"
+		suite.Require().NoError(suite.repo.DeleteTask(suite.ctx, task))
+		suite.Equal(int64(2), task.Version)
+
+		suite.Empty(suite.listIDs(domain.TaskQuery{SortBy: domain.TaskSortByID}))
+		suite.Equal([]string{task.ID}, suite.listIDs(domain.TaskQuery{SortBy: domain.TaskSortByID, Deleted: true}))
+	})
+
+	suite.Run("DeleteTrashedTask", func() {
+		suite.ErrorIs(suite.repo.DeleteTask(suite.ctx, task), domain.ErrTaskNotFound)
+	})
+
+	suite.Run("RestoreReturnsTrashedTask", func() {
+		restored, err := suite.repo.RestoreTask(suite.ctx, task.OrgID, task.ID)
+		suite.Require().NoError(err)
+		suite.requireSameTask(task, restored)
+
+		stored := suite.getTask(task)
+		suite.True(stored.DeletedAt.IsZero())
+		suite.Empty(stored.DeletedBy)
+		suite.Equal(int64(3), stored.Version)
+	})
+
+	suite.Run("RestoreLiveTask", func() {
+		_, err := suite.repo.RestoreTask(suite.ctx, task.OrgID, task.ID)
+		suite.ErrorIs(err, domain.ErrTaskNotFound)
+	})
+
+	suite.Run("RestoreMissing", func() {
+		_, err := suite.repo.RestoreTask(suite.ctx, task.OrgID, primitive.NewObjectID().Hex())
+		suite.ErrorIs(err, domain.ErrTaskNotFound)
+	})
+}
+
+func (suite *TaskRepositoryContractSuite) TestPurgeDeletedTasksSuite() {
+	for i, deletedAt := range []time.Time{suite.due.Add(-48 * time.Hour), suite.due.Add(-24 * time.Hour), suite.due} {
+		task := suite.addTask("Trashed " + string(rune('A'+i)))
+		task.DeletedAt = deletedAt
+		suite.Require().NoError(suite.repo.DeleteTask(suite.ctx, task))
+	}
+	live := suite.addTask("Live")
+
+	purged, err := suite.repo.PurgeDeletedTasks(suite.ctx, suite.due.Add(-time.Hour))
+
+	suite.NoError(err)
+	suite.Equal(int64(2), purged)
+	suite.Len(suite.listIDs(domain.TaskQuery{SortBy: domain.TaskSortByID, Deleted: true}), 1)
+	suite.Equal([]string{live.ID}, suite.listIDs(domain.TaskQuery{SortBy: domain.TaskSortByID}))
+}
+
+func (suite *TaskRepositoryContractSuite) TestGetAllTasksOrderingSuite() {
+	// Titles and statuses repeat so that the ID tie-breaker matters.
+	specs := []struct {
+		title  string
+		status string
+		due    time.Duration
+	}{
+		{"b", domain.TaskStatusPending, 2 * time.Hour},
+		{"a", domain.TaskStatusCompleted, time.Hour},
+		{"b", domain.TaskStatusCompleted, 2 * time.Hour},
+		{"c", domain.TaskStatusPending, 0},
+		{"a", domain.TaskStatusInProgress, 3 * time.Hour},
+	}
+	tasks := make([]*domain.Task, len(specs))
+	for i, spec := range specs {
+		tasks[i] = suite.addTask(spec.title, func(t *domain.Task) {
+			t.Status = spec.status
+			t.DueDate = suite.due.Add(spec.due)
+		})
+	}
+	order := func(indexes ...int) []string {
+		ids := make([]string, len(indexes))
+		for i, index := range indexes {
+			ids[i] = tasks[index].ID
+		}
+		return ids
+	}
+
+	cases := []struct {
+		sortBy   string
+		desc     bool
+		expected []string
+	}{
+		{domain.TaskSortByID, false, order(0, 1, 2, 3, 4)},
+		{domain.TaskSortByID, true, order(4, 3, 2, 1, 0)},
+		{domain.TaskSortByTitle, false, order(1, 4, 0, 2, 3)},
+		{domain.TaskSortByTitle, true, order(3, 2, 0, 4, 1)},
+		{domain.TaskSortByDueDate, false, order(3, 1, 0, 2, 4)},
+		{domain.TaskSortByDueDate, true, order(4, 2, 0, 1, 3)},
+		{domain.TaskSortByStatus, false, order(1, 2, 4, 0, 3)},
+		{domain.TaskSortByStatus, true, order(3, 0, 4, 2, 1)},
+	}
+	for _, c := range cases {
+		name := c.sortBy
+		if c.desc {
+			name += " desc"
+		}
+		suite.Run(name, func() {
+			for _, limit := range []int{1, 2, 5} {
+				query := domain.TaskQuery{SortBy: c.sortBy, SortDesc: c.desc, Limit: limit}
+				suite.Equal(c.expected, suite.listIDs(query), "limit %d", limit)
+			}
+		})
+	}
+
+	suite.Run("PageHasTotalAndCursor", func() {
+		page, err := suite.repo.GetAllTasks(suite.ctx, domain.TaskQuery{SortBy: domain.TaskSortByID, Limit: 2})
+		suite.Require().NoError(err)
+		suite.Equal(int64(5), page.Total)
+		suite.Len(page.Tasks, 2)
+		suite.NotEmpty(page.NextCursor)
+	})
+
+	suite.Run("MalformedCursor", func() {
+		_, err := suite.repo.GetAllTasks(suite.ctx, domain.TaskQuery{SortBy: domain.TaskSortByID, Limit: 2, Cursor: "!bogus"})
+		suite.ErrorIs(err, domain.ErrInvalidTaskQuery)
+	})
+
+	suite.Run("CursorForOtherSortOrder", func() {
+		page, err := suite.repo.GetAllTasks(suite.ctx, domain.TaskQuery{SortBy: domain.TaskSortByTitle, Limit: 2})
+		suite.Require().NoError(err)
+
+		_, err = suite.repo.GetAllTasks(suite.ctx, domain.TaskQuery{SortBy: domain.TaskSortByID, Limit: 2, Cursor: page.NextCursor})
+		suite.ErrorIs(err, domain.ErrInvalidTaskQuery)
+	})
+}
+
+func (suite *TaskRepositoryContractSuite) TestGetAllTasksFiltersSuite() {
+	report := suite.addTask("Report", func(t *domain.Task) { t.AssigneeID = "user2" })
+	review := suite.addTask("review", func(t *domain.Task) {
+		t.Status = domain.TaskStatusCompleted
+		t.DueDate = suite.due.Add(24 * time.Hour)
+		t.CreatedBy = "user2"
+	})
+	planned := suite.addTask("Report plan", func(t *domain.Task) {
+		t.CreatedBy = "user3"
+		t.ProjectID = "project1"
+		t.DueDate = suite.due.Add(48 * time.Hour)
+	})
+	other := suite.addTask("Report", func(t *domain.Task) { t.OrgID = "org1" })
+	trashed := suite.addTask("Report")
+	trashed.DeletedAt = suite.due
+	suite.Require().NoError(suite.repo.DeleteTask(suite.ctx, trashed))
+
+	cases := []struct {
+		name     string
+		query    domain.TaskQuery
+		expected []string
+	}{
+		{"DefaultOrganization", domain.TaskQuery{}, []string{report.ID, review.ID, planned.ID}},
+		{"OtherOrganization", domain.TaskQuery{OrgID: "org1"}, []string{other.ID}},
+		{"Trash", domain.TaskQuery{Deleted: true}, []string{trashed.ID}},
+		{"Status", domain.TaskQuery{Status: domain.TaskStatusCompleted}, []string{review.ID}},
+		{"TitlePrefixIsCaseSensitive", domain.TaskQuery{TitlePrefix: "Rep"}, []string{report.ID, planned.ID}},
+		{"TitlePrefixIsLiteral", domain.TaskQuery{TitlePrefix: "Report."}, []string{}},
+		{"DueAfter", domain.TaskQuery{DueAfter: suite.due.Add(time.Hour)}, []string{review.ID, planned.ID}},
+		{"DueBefore", domain.TaskQuery{DueBefore: suite.due.Add(24 * time.Hour)}, []string{report.ID, review.ID}},
+		{"VisibleToCreatorOrAssignee", domain.TaskQuery{VisibleTo: "user2"}, []string{report.ID, review.ID}},
+		{"VisibleThroughProject", domain.TaskQuery{VisibleTo: "user4", VisibleProjectIDs: []string{"project1"}}, []string{planned.ID}},
+		{"Project", domain.TaskQuery{ProjectID: "project1"}, []string{planned.ID}},
+		{"ExcludeProjects", domain.TaskQuery{ExcludeProjectIDs: []string{"project1"}}, []string{report.ID, review.ID}},
+	}
+	for _, c := range cases {
+		suite.Run(c.name, func() {
+			query := c.query
+			query.SortBy = domain.TaskSortByID
+			query.Limit = 2
+			suite.Equal(c.expected, suite.listIDs(query))
+
+			page, err := suite.repo.GetAllTasks(suite.ctx, query)
+			suite.Require().NoError(err)
+			suite.Equal(int64(len(c.expected)), page.Total)
+		})
+	}
+}
+
+func (suite *TaskRepositoryContractSuite) TestUserCleanupSuite() {
+	created := suite.addTask("Created", func(t *domain.Task) { t.AssigneeID = "user2" })
+	assigned := suite.addTask("Assigned", func(t *domain.Task) {
+		t.CreatedBy = "user2"
+		t.AssigneeID = "user1"
+	})
+
+	suite.Run("ReassignTasks", func() {
+		elsewhere := suite.addTask("Elsewhere", func(t *domain.Task) { t.OrgID = "org2" })
+		suite.Require().NoError(suite.repo.ReassignTasks(suite.ctx, "user1", "user3", []string{domain.DefaultOrganizationID, "org1"}))
+
+		stored := suite.getTask(created)
+		suite.Equal("user3", stored.CreatedBy)
+		suite.Equal(int64(2), stored.Version)
+		suite.Equal("user2", suite.getTask(assigned).CreatedBy)
+		suite.Equal("user1", suite.getTask(elsewhere).CreatedBy, "tasks outside orgIDs stay")
+
+		suite.NoError(suite.repo.ReassignTasks(suite.ctx, "user1", "user3", nil))
+		suite.Equal("user1", suite.getTask(elsewhere).CreatedBy)
+	})
+
+	suite.Run("UnassignTasks", func() {
+		suite.Require().NoError(suite.repo.UnassignTasks(suite.ctx, "user1"))
+
+		stored := suite.getTask(assigned)
+		suite.Empty(stored.AssigneeID)
+		suite.Equal(int64(2), stored.Version)
+		suite.Equal("user2", suite.getTask(created).AssigneeID)
+	})
+
+	suite.Run("DeleteTasksByCreator", func() {
+		elsewhere := suite.addTask("Elsewhere", func(t *domain.Task) {
+			t.CreatedBy = "user2"
+			t.OrgID = "org2"
+		})
+		expected := *suite.getTask(assigned)
+		expected.DeletedAt = suite.due
+		expected.DeletedBy = "admin1"
+		expected.Version++
+
+		deleted, err := suite.repo.DeleteTasksByCreator(suite.ctx, "user2", []string{domain.DefaultOrganizationID, "org1"}, "admin1", suite.due)
+
+		suite.Require().NoError(err)
+		suite.Require().Len(deleted, 1)
+		suite.requireSameTask(&expected, &deleted[0])
+		_, err = suite.repo.GetTaskByID(suite.ctx, assigned.OrgID, assigned.ID)
+		suite.ErrorIs(err, domain.ErrTaskNotFound)
+		suite.Equal([]string{assigned.ID}, suite.listIDs(domain.TaskQuery{SortBy: domain.TaskSortByID, Deleted: true}), "tasks are moved to the trash")
+		suite.getTask(created)
+		suite.getTask(elsewhere)
+
+		deleted, err = suite.repo.DeleteTasksByCreator(suite.ctx, "user2", []string{domain.DefaultOrganizationID, "org1"}, "admin1", suite.due)
+		suite.NoError(err)
+		suite.Empty(deleted, "tasks already in the trash are left alone")
+	})
+}
+
+func (suite *TaskRepositoryContractSuite) TestConcurrencySuite() {
+	const workers = 8
+	task := suite.addTask("Contended")
+
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			update := *task
+			update.Title = "Update " + string(rune('A'+i))
+			errs[i] = suite.repo.UpdateTask(suite.ctx, &update)
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			suite.ErrorIs(err, domain.ErrVersionConflict)
+		}
+	}
+	suite.Equal(1, succeeded, "exactly one writer wins the version check")
+	suite.Equal(int64(2), suite.getTask(task).Version)
+}
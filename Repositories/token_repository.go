@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"context"
+	"task_manager/domain"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RefreshTokenDAO is the MongoDB representation of a refresh token
+type RefreshTokenDAO struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    string             `bson:"user_id"`
+	FamilyID  string             `bson:"family_id"`
+	TokenHash string             `bson:"token_hash"`
+	CreatedAt time.Time          `bson:"created_at"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+	UsedAt    *time.Time         `bson:"used_at,omitempty"`
+	Revoked   bool               `bson:"revoked"`
+}
+
+// RevokedTokenDAO records a revoked access token by its jti, or, for IDs
+// made by userRevocationID, every access token of a user issued before
+// RevokedBefore.
+type RevokedTokenDAO struct {
+	ID            string     `bson:"_id"`
+	ExpiresAt     time.Time  `bson:"expires_at,omitempty"`
+	RevokedBefore *time.Time `bson:"revoked_before,omitempty"`
+}
+
+// userRevocationID is the revoked_tokens key holding a user's cutoff. The
+// prefix cannot clash with a jti, which is plain hex.
+func userRevocationID(userID string) string {
+	return "user:" + userID
+}
+
+func refreshTokenToDAO(token *domain.RefreshToken) *RefreshTokenDAO {
+	dao := &RefreshTokenDAO{
+		UserID:    token.UserID,
+		FamilyID:  token.FamilyID,
+		TokenHash: token.TokenHash,
+		CreatedAt: token.CreatedAt,
+		ExpiresAt: token.ExpiresAt,
+		Revoked:   token.Revoked,
+	}
+	if !token.UsedAt.IsZero() {
+		usedAt := token.UsedAt
+		dao.UsedAt = &usedAt
+	}
+	return dao
+}
+
+func daoToRefreshToken(dao *RefreshTokenDAO) *domain.RefreshToken {
+	token := &domain.RefreshToken{
+		ID:        dao.ID.Hex(),
+		UserID:    dao.UserID,
+		FamilyID:  dao.FamilyID,
+		TokenHash: dao.TokenHash,
+		CreatedAt: dao.CreatedAt,
+		ExpiresAt: dao.ExpiresAt,
+		Revoked:   dao.Revoked,
+	}
+	if dao.UsedAt != nil {
+		token.UsedAt = *dao.UsedAt
+	}
+	return token
+}
+
+type mongoTokenRepository struct {
+	refreshTokens *mongo.Collection
+	revokedTokens *mongo.Collection
+}
+
+func NewTokenRepository(client *mongo.Client) domain.ITokenRepository {
+	return newMongoTokenRepository(client.Database("task_manager"))
+}
+
+// newMongoTokenRepository returns a token repository on the refresh_tokens
+// and revoked_tokens collections of db.
+func newMongoTokenRepository(db *mongo.Database) domain.ITokenRepository {
+	return &mongoTokenRepository{
+		refreshTokens: db.Collection("refresh_tokens"),
+		revokedTokens: db.Collection("revoked_tokens"),
+	}
+}
+
+func (r *mongoTokenRepository) AddRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
+	dao := refreshTokenToDAO(token)
+	dao.ID = primitive.NewObjectID()
+	if _, err := r.refreshTokens.InsertOne(ctx, dao); err != nil {
+		return err
+	}
+	token.ID = dao.ID.Hex()
+	return nil
+}
+
+func (r *mongoTokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	var dao RefreshTokenDAO
+	err := r.refreshTokens.FindOne(ctx, bson.M{"token_hash": hash}).Decode(&dao)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return daoToRefreshToken(&dao), nil
+}
+
+func (r *mongoTokenRepository) MarkRefreshTokenUsed(ctx context.Context, id string) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, nil
+	}
+	filter := bson.M{"_id": objectID, "used_at": bson.M{"$exists": false}, "revoked": false}
+	update := bson.M{"$set": bson.M{"used_at": time.Now()}}
+	result, err := r.refreshTokens.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *mongoTokenRepository) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	update := bson.M{"$set": bson.M{"revoked": true}}
+	_, err := r.refreshTokens.UpdateMany(ctx, bson.M{"family_id": familyID}, update)
+	return err
+}
+
+func (r *mongoTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	update := bson.M{"$set": bson.M{"revoked": true}}
+	_, err := r.refreshTokens.UpdateMany(ctx, bson.M{"user_id": userID}, update)
+	return err
+}
+
+func (r *mongoTokenRepository) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	filter := bson.M{"_id": tokenID}
+	update := bson.M{"$set": bson.M{"expires_at": expiresAt}}
+	_, err := r.revokedTokens.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *mongoTokenRepository) RevokeUserAccessTokens(ctx context.Context, userID string, issuedBefore time.Time) error {
+	filter := bson.M{"_id": userRevocationID(userID)}
+	update := bson.M{"$set": bson.M{"revoked_before": issuedBefore}}
+	_, err := r.revokedTokens.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
+}
+
+func (r *mongoTokenRepository) IsAccessTokenRevoked(ctx context.Context, tokenID, userID string, issuedAt time.Time) (bool, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"_id": tokenID},
+		{"_id": userRevocationID(userID), "revoked_before": bson.M{"$gt": issuedAt}},
+	}}
+	count, err := r.revokedTokens.CountDocuments(ctx, filter)
+	return count > 0, err
+}
+
+// PurgeExpiredTokens removes what the TTL indexes on expires_at have not
+// removed yet; MongoDB only runs them about once a minute.
+func (r *mongoTokenRepository) PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	filter := bson.M{"expires_at": bson.M{"$lt": now}}
+	refresh, err := r.refreshTokens.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	revoked, err := r.revokedTokens.DeleteMany(ctx, filter)
+	if err != nil {
+		return refresh.DeletedCount, err
+	}
+	return refresh.DeletedCount + revoked.DeletedCount, nil
+}
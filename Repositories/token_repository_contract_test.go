@@ -0,0 +1,203 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// TokenRepositoryContractSuite checks that an ITokenRepository
+// implementation behaves the way the usecases expect. Run it against a
+// backend with suite.Run, giving it a newRepository that returns an empty
+// repository.
+type TokenRepositoryContractSuite struct {
+	suite.Suite
+	// newRepository returns an empty repository; it is called before
+	// every test.
+	newRepository func() domain.ITokenRepository
+	repo          domain.ITokenRepository
+	ctx           context.Context
+	// now is a time the backends store without losing precision. Tokens
+	// expire after it, so MongoDB's TTL indexes leave them alone.
+	now time.Time
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *TokenRepositoryContractSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	suite.now = time.Date(2030, time.March, 1, 12, 0, 0, 0, time.UTC)
+}
+
+// SetupTest runs before each test
+func (suite *TokenRepositoryContractSuite) SetupTest() {
+	suite.repo = suite.newRepository()
+}
+
+// addRefreshToken stores an unused refresh token of the user and family,
+// whose hash is hash, expiring an hour after suite.now.
+func (suite *TokenRepositoryContractSuite) addRefreshToken(userID, familyID, hash string) *domain.RefreshToken {
+	token := &domain.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hash,
+		CreatedAt: suite.now,
+		ExpiresAt: suite.now.Add(time.Hour),
+	}
+	suite.Require().NoError(suite.repo.AddRefreshToken(suite.ctx, token))
+	return token
+}
+
+func (suite *TokenRepositoryContractSuite) getRefreshToken(hash string) *domain.RefreshToken {
+	stored, err := suite.repo.GetRefreshTokenByHash(suite.ctx, hash)
+	suite.Require().NoError(err)
+	return stored
+}
+
+func (suite *TokenRepositoryContractSuite) TestRefreshTokenSuite() {
+	suite.Run("StoresEveryField", func() {
+		token := suite.addRefreshToken("user1", "family1", "hash1")
+
+		suite.NotEmpty(token.ID)
+		suite.Equal(token, suite.getRefreshToken("hash1"))
+	})
+
+	suite.Run("UnknownHash", func() {
+		_, err := suite.repo.GetRefreshTokenByHash(suite.ctx, "unknown")
+		suite.ErrorIs(err, domain.ErrNotFound)
+	})
+
+	suite.Run("MarkUsedOnlyOnce", func() {
+		token := suite.addRefreshToken("user1", "family2", "hash2")
+
+		marked, err := suite.repo.MarkRefreshTokenUsed(suite.ctx, token.ID)
+		suite.Require().NoError(err)
+		suite.True(marked)
+		suite.False(suite.getRefreshToken("hash2").UsedAt.IsZero())
+
+		marked, err = suite.repo.MarkRefreshTokenUsed(suite.ctx, token.ID)
+		suite.NoError(err)
+		suite.False(marked, "a used token cannot be exchanged again")
+	})
+
+	suite.Run("MarkUsedRevoked", func() {
+		token := suite.addRefreshToken("user1", "family3", "hash3")
+		suite.Require().NoError(suite.repo.RevokeTokenFamily(suite.ctx, "family3"))
+
+		marked, err := suite.repo.MarkRefreshTokenUsed(suite.ctx, token.ID)
+		suite.NoError(err)
+		suite.False(marked)
+		suite.True(suite.getRefreshToken("hash3").UsedAt.IsZero())
+	})
+
+	suite.Run("MarkUsedMissing", func() {
+		marked, err := suite.repo.MarkRefreshTokenUsed(suite.ctx, newObjectID())
+		suite.NoError(err)
+		suite.False(marked)
+	})
+}
+
+func (suite *TokenRepositoryContractSuite) TestRevokeRefreshTokensSuite() {
+	suite.addRefreshToken("user1", "family1", "hash1")
+	suite.addRefreshToken("user1", "family1", "hash2")
+	suite.addRefreshToken("user1", "family2", "hash3")
+	suite.addRefreshToken("user2", "family3", "hash4")
+
+	suite.Run("RevokeTokenFamily", func() {
+		suite.Require().NoError(suite.repo.RevokeTokenFamily(suite.ctx, "family1"))
+
+		suite.True(suite.getRefreshToken("hash1").Revoked)
+		suite.True(suite.getRefreshToken("hash2").Revoked)
+		suite.False(suite.getRefreshToken("hash3").Revoked, "other families are kept")
+	})
+
+	suite.Run("RevokeUserRefreshTokens", func() {
+		suite.Require().NoError(suite.repo.RevokeUserRefreshTokens(suite.ctx, "user1"))
+
+		suite.True(suite.getRefreshToken("hash3").Revoked)
+		suite.False(suite.getRefreshToken("hash4").Revoked, "other users are kept")
+	})
+
+	suite.Run("UnknownFamilyAndUser", func() {
+		suite.NoError(suite.repo.RevokeTokenFamily(suite.ctx, "unknown"))
+		suite.NoError(suite.repo.RevokeUserRefreshTokens(suite.ctx, "unknown"))
+	})
+}
+
+func (suite *TokenRepositoryContractSuite) TestAccessTokenSuite() {
+	isRevoked := func(tokenID, userID string, issuedAt time.Time) bool {
+		revoked, err := suite.repo.IsAccessTokenRevoked(suite.ctx, tokenID, userID, issuedAt)
+		suite.Require().NoError(err)
+		return revoked
+	}
+
+	suite.Run("RevokedByID", func() {
+		suite.Require().NoError(suite.repo.RevokeAccessToken(suite.ctx, "jti1", suite.now.Add(time.Hour)))
+
+		suite.True(isRevoked("jti1", "user1", suite.now))
+		suite.False(isRevoked("jti2", "user1", suite.now))
+	})
+
+	suite.Run("RevokeTwice", func() {
+		suite.NoError(suite.repo.RevokeAccessToken(suite.ctx, "jti1", suite.now.Add(2*time.Hour)))
+		suite.True(isRevoked("jti1", "user1", suite.now))
+	})
+
+	suite.Run("RevokedByCutoff", func() {
+		suite.Require().NoError(suite.repo.RevokeUserAccessTokens(suite.ctx, "user2", suite.now))
+
+		suite.True(isRevoked("jti3", "user2", suite.now.Add(-time.Second)))
+		suite.False(isRevoked("jti3", "user2", suite.now), "tokens issued at the cutoff stay valid")
+		suite.False(isRevoked("jti3", "user2", suite.now.Add(time.Second)))
+		suite.False(isRevoked("jti3", "user3", suite.now.Add(-time.Second)), "other users are kept")
+	})
+
+	suite.Run("LaterCutoffReplacesEarlier", func() {
+		suite.Require().NoError(suite.repo.RevokeUserAccessTokens(suite.ctx, "user2", suite.now.Add(time.Minute)))
+
+		suite.True(isRevoked("jti3", "user2", suite.now.Add(time.Second)))
+	})
+}
+
+func (suite *TokenRepositoryContractSuite) TestPurgeExpiredTokensSuite() {
+	suite.addRefreshToken("user1", "family1", "expired")
+	kept := &domain.RefreshToken{
+		UserID:    "user1",
+		FamilyID:  "family1",
+		TokenHash: "kept",
+		CreatedAt: suite.now,
+		ExpiresAt: suite.now.Add(3 * time.Hour),
+	}
+	suite.Require().NoError(suite.repo.AddRefreshToken(suite.ctx, kept))
+	suite.Require().NoError(suite.repo.RevokeAccessToken(suite.ctx, "jti1", suite.now.Add(time.Hour)))
+	suite.Require().NoError(suite.repo.RevokeAccessToken(suite.ctx, "jti2", suite.now.Add(3*time.Hour)))
+	suite.Require().NoError(suite.repo.RevokeUserAccessTokens(suite.ctx, "user1", suite.now))
+
+	suite.Run("RemovesExpiredTokens", func() {
+		purged, err := suite.repo.PurgeExpiredTokens(suite.ctx, suite.now.Add(2*time.Hour))
+		suite.Require().NoError(err)
+		suite.Equal(int64(2), purged)
+
+		_, err = suite.repo.GetRefreshTokenByHash(suite.ctx, "expired")
+		suite.ErrorIs(err, domain.ErrNotFound)
+		suite.Equal(kept, suite.getRefreshToken("kept"))
+
+		revoked, err := suite.repo.IsAccessTokenRevoked(suite.ctx, "jti2", "user2", suite.now)
+		suite.NoError(err)
+		suite.True(revoked)
+	})
+
+	suite.Run("KeepsUserCutoffs", func() {
+		revoked, err := suite.repo.IsAccessTokenRevoked(suite.ctx, "jti3", "user1", suite.now.Add(-time.Second))
+		suite.NoError(err)
+		suite.True(revoked)
+	})
+
+	suite.Run("NothingLeftToPurge", func() {
+		purged, err := suite.repo.PurgeExpiredTokens(suite.ctx, suite.now.Add(2*time.Hour))
+		suite.NoError(err)
+		suite.Zero(purged)
+	})
+}
@@ -2,38 +2,87 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"regexp"
+	"strings"
 	"task_manager/domain"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // UserDAO (Data Access Object) is the MongoDB representation of a user
 // Used for database serialization/deserialization with bson tags
 type UserDAO struct {
 	ID       primitive.ObjectID `bson:"_id,omitempty"`
-	Username string `bson:"username"`
-	Email    string `bson:"email"`
-	Password string `bson:"password"`
-	Role     string `bson:"role"`
+	Username string             `bson:"username"`
+	Email    string             `bson:"email"`
+	Password string             `bson:"password"`
+	Role     string             `bson:"role"`
+	// EmailVerified is nil for accounts created before email verification
+	// existed; those are treated as verified.
+	EmailVerified *bool  `bson:"email_verified,omitempty"`
+	Deactivated   bool   `bson:"deactivated,omitempty"`
+	ActiveOrgID   string `bson:"active_org_id,omitempty"`
+	// FirstAdmin marks the account added by AddFirstAdmin.
+	FirstAdmin bool `bson:"first_admin,omitempty"`
 }
 
+// Names of the unique indexes on the users collection, created by
+// mongoMigrations.
+const (
+	usersEmailIndex      = "email_unique"
+	usersUsernameIndex   = "username_unique"
+	usersFirstAdminIndex = "first_admin_unique"
+)
+
+// duplicateUserError translates a duplicate key error on one of the unique
+// indexes of the users collection into the matching domain error.
+func duplicateUserError(err error) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	// The server names the index: "E11000 duplicate key error collection:
+	// task_manager.users index: email_unique dup key: ...".
+	switch msg := err.Error(); {
+	case strings.Contains(msg, "index: "+usersEmailIndex+" "):
+		return domain.ErrEmailTaken
+	case strings.Contains(msg, "index: "+usersUsernameIndex+" "):
+		return domain.ErrUsernameTaken
+	case strings.Contains(msg, "index: "+usersFirstAdminIndex+" "):
+		return domain.ErrFirstAdminExists
+	}
+	return err
+}
+
+// userToDAO converts a user to its DAO. An ID that is not a valid ObjectID
+// hex string is left empty.
 func userToDAO(user *domain.User) *UserDAO {
+	objectID, _ := primitive.ObjectIDFromHex(user.ID)
 	return &UserDAO{
-		Username: user.Username,
-		Email:    user.Email,
-		Password: user.Password,
-		Role:     user.Role,
+		ID:            objectID,
+		Username:      user.Username,
+		Email:         user.Email,
+		Password:      user.Password,
+		Role:          user.Role,
+		EmailVerified: &user.EmailVerified,
+		Deactivated:   user.Deactivated,
+		ActiveOrgID:   user.ActiveOrgID,
 	}
 }
 
 func daoToUser(dao *UserDAO) *domain.User {
 	return &domain.User{
-		Username: dao.Username,
-		Email:    dao.Email,
-		Password: dao.Password,
-		Role:     dao.Role,
+		ID:            dao.ID.Hex(),
+		Username:      dao.Username,
+		Email:         dao.Email,
+		Password:      dao.Password,
+		Role:          dao.Role,
+		EmailVerified: dao.EmailVerified == nil || *dao.EmailVerified,
+		Deactivated:   dao.Deactivated,
+		ActiveOrgID:   dao.ActiveOrgID,
 	}
 }
 
@@ -41,31 +90,67 @@ type mongoUserRepository struct {
 	collection *mongo.Collection
 }
 
+// NewUserRepository returns a user repository on the task_manager database.
+// Usernames and emails are only refused as taken once mongoMigrations have
+// created the unique indexes.
 func NewUserRepository(client *mongo.Client) domain.IUserRepository {
-	db := client.Database("task_manager")
+	return newMongoUserRepository(client.Database("task_manager"))
+}
+
+// newMongoUserRepository returns a user repository on the users collection
+// of db.
+func newMongoUserRepository(db *mongo.Database) domain.IUserRepository {
 	return &mongoUserRepository{
 		collection: db.Collection("users"),
 	}
 }
 
+// AddUser stores a new user under a freshly generated ObjectID and writes the
+// assigned ID back onto the user.
 func (r *mongoUserRepository) AddUser(ctx context.Context, user *domain.User) error {
-    dao := userToDAO(user)
-    _, err := r.collection.InsertOne(ctx, dao)
-    return err
+	return r.add(ctx, userToDAO(user), user)
+}
+
+// AddFirstAdmin is AddUser with the first_admin flag set, which the unique
+// first_admin_unique index allows on one user only.
+func (r *mongoUserRepository) AddFirstAdmin(ctx context.Context, user *domain.User) error {
+	dao := userToDAO(user)
+	dao.FirstAdmin = true
+	return r.add(ctx, dao, user)
+}
+
+func (r *mongoUserRepository) add(ctx context.Context, dao *UserDAO, user *domain.User) error {
+	dao.ID = primitive.NewObjectID()
+	if _, err := r.collection.InsertOne(ctx, dao); err != nil {
+		return duplicateUserError(err)
+	}
+	user.ID = dao.ID.Hex()
+	return nil
 }
 
 func (r *mongoUserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
-	var dao UserDAO
-	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&dao)
+	return r.findUser(ctx, bson.M{"email": email})
+}
+
+func (r *mongoUserRepository) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+	return r.findUser(ctx, bson.M{"username": username})
+}
+
+func (r *mongoUserRepository) GetUserByID(ctx context.Context, id string) (*domain.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return nil, err
+		return nil, domain.ErrUserNotFound
 	}
-	return daoToUser(&dao), nil
+	return r.findUser(ctx, bson.M{"_id": objectID})
 }
 
-func (r *mongoUserRepository) GetUserByUsername(ctx context.Context, username string) (*domain.User, error) {
+// findUser returns the user matching filter, or domain.ErrUserNotFound.
+func (r *mongoUserRepository) findUser(ctx context.Context, filter bson.M) (*domain.User, error) {
 	var dao UserDAO
-	err := r.collection.FindOne(ctx, bson.M{"username": username}).Decode(&dao)
+	err := r.collection.FindOne(ctx, filter).Decode(&dao)
+	if err == mongo.ErrNoDocuments {
+		return nil, domain.ErrUserNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -77,19 +162,181 @@ func (r *mongoUserRepository) IsUsersCollectionEmpty(ctx context.Context) (bool,
 	return count == 0, err
 }
 
-func (r *mongoUserRepository) UserExistsByEmail(ctx context.Context, email string) (bool, error) {
-	count, err := r.collection.CountDocuments(ctx, bson.M{"email": email})
-	return count > 0, err
+func (r *mongoUserRepository) UpdatePassword(ctx context.Context, id, hashedPassword string) error {
+	return r.updateByID(ctx, id, bson.M{"$set": bson.M{"password": hashedPassword}})
 }
 
-func (r *mongoUserRepository) UserExistsByUsername(ctx context.Context, username string) (bool, error) {
-	count, err := r.collection.CountDocuments(ctx, bson.M{"username": username})
-	return count > 0, err
+func (r *mongoUserRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	return r.updateByID(ctx, id, bson.M{"$set": bson.M{"email_verified": true}})
+}
+
+func (r *mongoUserRepository) UpdateProfile(ctx context.Context, user *domain.User) error {
+	objectID, err := primitive.ObjectIDFromHex(user.ID)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+	update := bson.M{"$set": bson.M{
+		"username":       user.Username,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+	}}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	if err != nil {
+		return duplicateUserError(err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
 }
 
 func (r *mongoUserRepository) PromoteUserToAdmin(ctx context.Context, identifier string) error {
 	filter := bson.M{"$or": []bson.M{{"username": identifier}, {"email": identifier}}}
 	update := bson.M{"$set": bson.M{"role": "admin"}}
-	_, err := r.collection.UpdateOne(ctx, filter, update)
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// ListUsers returns one page of users matching query, ordered by ID.
+// NextCursor is the ID of the last user on the page.
+func (r *mongoUserRepository) ListUsers(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error) {
+	filter := bson.M{}
+	if query.Search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(query.Search), Options: "i"}
+		filter["$or"] = bson.A{
+			bson.M{"username": pattern},
+			bson.M{"email": pattern},
+		}
+	}
+	if query.Role != "" {
+		filter["role"] = query.Role
+	}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.Cursor != "" {
+		after, err := primitive.ObjectIDFromHex(query.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%w: malformed cursor", domain.ErrInvalidUserQuery)
+		}
+		filter["_id"] = bson.M{"$gt": after}
+	}
+
+	// Fetch one extra document to find out whether another page follows.
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(int64(query.Limit) + 1)
+	cur, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var daos []UserDAO
+	if err := cur.All(ctx, &daos); err != nil {
+		return nil, err
+	}
+
+	page := &domain.UserPage{Users: make([]domain.User, 0, len(daos)), Total: total}
+	for i := range daos {
+		if i == query.Limit {
+			page.NextCursor = page.Users[i-1].ID
+			break
+		}
+		page.Users = append(page.Users, *daoToUser(&daos[i]))
+	}
+	return page, nil
+}
+
+func (r *mongoUserRepository) UpdateUserRole(ctx context.Context, id, role string) error {
+	return r.setKeepingAnAdmin(ctx, id, "role", role)
+}
+
+func (r *mongoUserRepository) SetUserDeactivated(ctx context.Context, id string, deactivated bool) error {
+	return r.setKeepingAnAdmin(ctx, id, "deactivated", deactivated)
+}
+
+// setKeepingAnAdmin sets field to value on the user with the given hex ID.
+// Without a transaction the other admins cannot be locked, so when the user
+// was an active admin the change is made first and undone, failing with
+// domain.ErrLastAdmin, if no active admin is left. Of concurrent calls that
+// together remove every admin, the last to count therefore sees none and
+// undoes its change.
+func (r *mongoUserRepository) setKeepingAnAdmin(ctx context.Context, id, field string, value any) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+	var before UserDAO
+	err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": objectID}, bson.M{"$set": bson.M{field: value}}).Decode(&before)
+	if err == mongo.ErrNoDocuments {
+		return domain.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if before.Role != domain.RoleAdmin || before.Deactivated {
+		return nil
+	}
+	count, err := r.CountActiveAdmins(ctx)
+	if err == nil && count > 0 {
+		return nil
+	}
+	if err == nil {
+		err = domain.ErrLastAdmin
+	}
+	// Undo the change, unless the user has been changed again since.
+	filter := bson.M{"_id": objectID, field: value}
+	undo := bson.M{"$set": bson.M{"role": before.Role, "deactivated": before.Deactivated}}
+	if _, undoErr := r.collection.UpdateOne(ctx, filter, undo); undoErr != nil {
+		return undoErr
+	}
 	return err
-}
\ No newline at end of file
+}
+
+func (r *mongoUserRepository) DeleteUser(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepository) CountActiveAdmins(ctx context.Context) (int64, error) {
+	return r.collection.CountDocuments(ctx, bson.M{"role": domain.RoleAdmin, "deactivated": bson.M{"$ne": true}})
+}
+
+func (r *mongoUserRepository) SetActiveOrganization(ctx context.Context, id, orgID string) error {
+	if orgID == "" || orgID == domain.DefaultOrganizationID {
+		return r.updateByID(ctx, id, bson.M{"$unset": bson.M{"active_org_id": ""}})
+	}
+	return r.updateByID(ctx, id, bson.M{"$set": bson.M{"active_org_id": orgID}})
+}
+
+// updateByID applies update to the user with the given hex ID and returns
+// domain.ErrUserNotFound if there is no such user.
+func (r *mongoUserRepository) updateByID(ctx context.Context, id string, update bson.M) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return domain.ErrUserNotFound
+	}
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,500 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/suite"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserRepositoryContractSuite checks that an IUserRepository implementation
+// behaves the way the usecases expect. Run it against a backend with
+// suite.Run, giving it a newRepository that returns an empty repository.
+type UserRepositoryContractSuite struct {
+	suite.Suite
+	// newRepository returns an empty repository; it is called before
+	// every test.
+	newRepository func() domain.IUserRepository
+	repo          domain.IUserRepository
+	ctx           context.Context
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *UserRepositoryContractSuite) SetupSuite() {
+	suite.ctx = context.Background()
+}
+
+// SetupTest runs before each test
+func (suite *UserRepositoryContractSuite) SetupTest() {
+	suite.repo = suite.newRepository()
+}
+
+// addUser stores a regular user called name with email name@example.com.
+func (suite *UserRepositoryContractSuite) addUser(name string) *domain.User {
+	user := &domain.User{
+		Username: name,
+		Email:    name + "@example.com",
+		Password: "hashed-" + name,
+		Role:     domain.RoleUser,
+	}
+	suite.Require().NoError(suite.repo.AddUser(suite.ctx, user))
+	return user
+}
+
+func (suite *UserRepositoryContractSuite) addAdmin(name string) *domain.User {
+	user := suite.addUser(name)
+	suite.Require().NoError(suite.repo.UpdateUserRole(suite.ctx, user.ID, domain.RoleAdmin))
+	user.Role = domain.RoleAdmin
+	return user
+}
+
+func (suite *UserRepositoryContractSuite) TestAddUserSuite() {
+	suite.Run("AssignsID", func() {
+		user := suite.addUser("alice")
+
+		suite.NotEmpty(user.ID)
+		suite.True(primitive.IsValidObjectID(user.ID), "IDs are ObjectID hex strings")
+	})
+
+	suite.Run("StoresEveryField", func() {
+		user := &domain.User{
+			Username:      "bob",
+			Email:         "bob@example.com",
+			Password:      "hashed",
+			Role:          domain.RoleAdmin,
+			EmailVerified: true,
+			Deactivated:   true,
+			ActiveOrgID:   "org1",
+		}
+		suite.Require().NoError(suite.repo.AddUser(suite.ctx, user))
+
+		stored, err := suite.repo.GetUserByID(suite.ctx, user.ID)
+
+		suite.Require().NoError(err)
+		suite.Equal(user, stored)
+	})
+
+	suite.Run("FindsByEmailAndUsername", func() {
+		user := suite.addUser("carol")
+
+		byEmail, err := suite.repo.GetUserByEmail(suite.ctx, "carol@example.com")
+		suite.Require().NoError(err)
+		suite.Equal(user.ID, byEmail.ID)
+
+		byUsername, err := suite.repo.GetUserByUsername(suite.ctx, "carol")
+		suite.Require().NoError(err)
+		suite.Equal(user.ID, byUsername.ID)
+	})
+}
+
+func (suite *UserRepositoryContractSuite) TestAddFirstAdminSuite() {
+	newAdmin := func(name string) *domain.User {
+		return &domain.User{Username: name, Email: name + "@example.com", Role: domain.RoleAdmin, EmailVerified: true}
+	}
+	first := newAdmin("root")
+
+	suite.Run("StoresUser", func() {
+		suite.Require().NoError(suite.repo.AddFirstAdmin(suite.ctx, first))
+
+		stored, err := suite.repo.GetUserByID(suite.ctx, first.ID)
+		suite.Require().NoError(err)
+		suite.Equal(first, stored)
+	})
+
+	suite.Run("OnlyOnce", func() {
+		err := suite.repo.AddFirstAdmin(suite.ctx, newAdmin("root2"))
+		suite.ErrorIs(err, domain.ErrFirstAdminExists)
+
+		_, err = suite.repo.GetUserByUsername(suite.ctx, "root2")
+		suite.ErrorIs(err, domain.ErrUserNotFound)
+	})
+
+	suite.Run("RegularUsersDoNotCount", func() {
+		suite.addUser("alice")
+		suite.ErrorIs(suite.repo.AddFirstAdmin(suite.ctx, newAdmin("root3")), domain.ErrFirstAdminExists)
+	})
+
+	suite.Run("AgainAfterDeletion", func() {
+		suite.Require().NoError(suite.repo.DeleteUser(suite.ctx, first.ID))
+		suite.NoError(suite.repo.AddFirstAdmin(suite.ctx, newAdmin("root4")))
+	})
+}
+
+func (suite *UserRepositoryContractSuite) TestIsUsersCollectionEmptySuite() {
+	empty, err := suite.repo.IsUsersCollectionEmpty(suite.ctx)
+	suite.NoError(err)
+	suite.True(empty)
+
+	suite.addUser("alice")
+
+	empty, err = suite.repo.IsUsersCollectionEmpty(suite.ctx)
+	suite.NoError(err)
+	suite.False(empty)
+}
+
+func (suite *UserRepositoryContractSuite) TestUniquenessSuite() {
+	alice := suite.addUser("alice")
+	bob := suite.addUser("bob")
+
+	suite.Run("EmailTaken", func() {
+		err := suite.repo.AddUser(suite.ctx, &domain.User{Username: "alice2", Email: "alice@example.com", Role: domain.RoleUser})
+		suite.ErrorIs(err, domain.ErrEmailTaken)
+	})
+
+	suite.Run("UsernameTaken", func() {
+		err := suite.repo.AddUser(suite.ctx, &domain.User{Username: "alice", Email: "alice2@example.com", Role: domain.RoleUser})
+		suite.ErrorIs(err, domain.ErrUsernameTaken)
+	})
+
+	suite.Run("UpdateProfileToTakenEmail", func() {
+		err := suite.repo.UpdateProfile(suite.ctx, &domain.User{ID: bob.ID, Username: "bob", Email: alice.Email})
+		suite.ErrorIs(err, domain.ErrEmailTaken)
+
+		stored, err := suite.repo.GetUserByID(suite.ctx, bob.ID)
+		suite.Require().NoError(err)
+		suite.Equal("bob@example.com", stored.Email)
+	})
+
+	suite.Run("UpdateProfileKeepsOwnValues", func() {
+		err := suite.repo.UpdateProfile(suite.ctx, &domain.User{ID: alice.ID, Username: "alice", Email: alice.Email, EmailVerified: true})
+		suite.NoError(err)
+	})
+}
+
+func (suite *UserRepositoryContractSuite) TestNotFoundSuite() {
+	suite.addUser("alice")
+	missingID := primitive.NewObjectID().Hex()
+
+	for _, id := range []string{missingID, "not-an-object-id"} {
+		suite.Run("ID "+id, func() {
+			_, err := suite.repo.GetUserByID(suite.ctx, id)
+			suite.ErrorIs(err, domain.ErrUserNotFound)
+
+			suite.ErrorIs(suite.repo.UpdatePassword(suite.ctx, id, "hashed"), domain.ErrUserNotFound)
+			suite.ErrorIs(suite.repo.MarkEmailVerified(suite.ctx, id), domain.ErrUserNotFound)
+			suite.ErrorIs(suite.repo.UpdateProfile(suite.ctx, &domain.User{ID: id, Username: "x", Email: "x@example.com"}), domain.ErrUserNotFound)
+			suite.ErrorIs(suite.repo.UpdateUserRole(suite.ctx, id, domain.RoleAdmin), domain.ErrUserNotFound)
+			suite.ErrorIs(suite.repo.SetUserDeactivated(suite.ctx, id, true), domain.ErrUserNotFound)
+			suite.ErrorIs(suite.repo.SetActiveOrganization(suite.ctx, id, "org1"), domain.ErrUserNotFound)
+			suite.ErrorIs(suite.repo.DeleteUser(suite.ctx, id), domain.ErrUserNotFound)
+		})
+	}
+
+	suite.Run("ByEmailAndUsername", func() {
+		_, err := suite.repo.GetUserByEmail(suite.ctx, "nobody@example.com")
+		suite.ErrorIs(err, domain.ErrUserNotFound)
+
+		_, err = suite.repo.GetUserByUsername(suite.ctx, "nobody")
+		suite.ErrorIs(err, domain.ErrUserNotFound)
+	})
+
+	suite.Run("PromoteUnknownUser", func() {
+		suite.ErrorIs(suite.repo.PromoteUserToAdmin(suite.ctx, "nobody"), domain.ErrUserNotFound)
+	})
+}
+
+func (suite *UserRepositoryContractSuite) TestUpdatesSuite() {
+	user := suite.addUser("alice")
+	// Another admin, so that alice can be demoted and deactivated.
+	suite.addAdmin("root")
+
+	get := func() *domain.User {
+		stored, err := suite.repo.GetUserByID(suite.ctx, user.ID)
+		suite.Require().NoError(err)
+		return stored
+	}
+
+	suite.Run("UpdatePassword", func() {
+		suite.Require().NoError(suite.repo.UpdatePassword(suite.ctx, user.ID, "new-hash"))
+		suite.Equal("new-hash", get().Password)
+	})
+
+	suite.Run("MarkEmailVerified", func() {
+		suite.Require().NoError(suite.repo.MarkEmailVerified(suite.ctx, user.ID))
+		suite.True(get().EmailVerified)
+	})
+
+	suite.Run("UpdateProfileLeavesPasswordAndRole", func() {
+		err := suite.repo.UpdateProfile(suite.ctx, &domain.User{ID: user.ID, Username: "alicia", Email: "alicia@example.com", Password: "ignored", Role: domain.RoleAdmin})
+		suite.Require().NoError(err)
+
+		stored := get()
+		suite.Equal("alicia", stored.Username)
+		suite.Equal("alicia@example.com", stored.Email)
+		suite.False(stored.EmailVerified)
+		suite.Equal("new-hash", stored.Password)
+		suite.Equal(domain.RoleUser, stored.Role)
+	})
+
+	suite.Run("PromoteByUsernameOrEmail", func() {
+		suite.Require().NoError(suite.repo.PromoteUserToAdmin(suite.ctx, "alicia@example.com"))
+		suite.Equal(domain.RoleAdmin, get().Role)
+
+		suite.Require().NoError(suite.repo.UpdateUserRole(suite.ctx, user.ID, domain.RoleUser))
+		suite.Require().NoError(suite.repo.PromoteUserToAdmin(suite.ctx, "alicia"))
+		suite.Equal(domain.RoleAdmin, get().Role)
+	})
+
+	suite.Run("SetUserDeactivated", func() {
+		suite.Require().NoError(suite.repo.SetUserDeactivated(suite.ctx, user.ID, true))
+		suite.True(get().Deactivated)
+		suite.Require().NoError(suite.repo.SetUserDeactivated(suite.ctx, user.ID, false))
+		suite.False(get().Deactivated)
+	})
+
+	suite.Run("SetActiveOrganization", func() {
+		suite.Require().NoError(suite.repo.SetActiveOrganization(suite.ctx, user.ID, "org1"))
+		suite.Equal("org1", get().ActiveOrgID)
+
+		// The default organization is stored as no organization.
+		suite.Require().NoError(suite.repo.SetActiveOrganization(suite.ctx, user.ID, domain.DefaultOrganizationID))
+		suite.Empty(get().ActiveOrgID)
+	})
+
+	suite.Run("DeleteUser", func() {
+		suite.Require().NoError(suite.repo.DeleteUser(suite.ctx, user.ID))
+		_, err := suite.repo.GetUserByID(suite.ctx, user.ID)
+		suite.ErrorIs(err, domain.ErrUserNotFound)
+	})
+}
+
+func (suite *UserRepositoryContractSuite) TestCountActiveAdminsSuite() {
+	for _, name := range []string{"admin1", "admin2", "admin3"} {
+		user := suite.addUser(name)
+		suite.Require().NoError(suite.repo.UpdateUserRole(suite.ctx, user.ID, domain.RoleAdmin))
+		if name == "admin3" {
+			suite.Require().NoError(suite.repo.SetUserDeactivated(suite.ctx, user.ID, true))
+		}
+	}
+	suite.addUser("user1")
+
+	count, err := suite.repo.CountActiveAdmins(suite.ctx)
+
+	suite.NoError(err)
+	suite.Equal(int64(2), count)
+}
+
+func (suite *UserRepositoryContractSuite) TestLastAdminSuite() {
+	admin := suite.addAdmin("admin1")
+	user := suite.addUser("user1")
+
+	suite.Run("CannotDemoteLastAdmin", func() {
+		err := suite.repo.UpdateUserRole(suite.ctx, admin.ID, domain.RoleUser)
+
+		suite.ErrorIs(err, domain.ErrLastAdmin)
+		stored, err := suite.repo.GetUserByID(suite.ctx, admin.ID)
+		suite.Require().NoError(err)
+		suite.Equal(domain.RoleAdmin, stored.Role)
+	})
+
+	suite.Run("CannotDeactivateLastAdmin", func() {
+		err := suite.repo.SetUserDeactivated(suite.ctx, admin.ID, true)
+
+		suite.ErrorIs(err, domain.ErrLastAdmin)
+		stored, err := suite.repo.GetUserByID(suite.ctx, admin.ID)
+		suite.Require().NoError(err)
+		suite.False(stored.Deactivated)
+	})
+
+	suite.Run("DeactivatedAdminsDoNotCount", func() {
+		other := suite.addAdmin("admin2")
+		suite.Require().NoError(suite.repo.SetUserDeactivated(suite.ctx, other.ID, true))
+
+		err := suite.repo.UpdateUserRole(suite.ctx, admin.ID, domain.RoleUser)
+
+		suite.ErrorIs(err, domain.ErrLastAdmin)
+	})
+
+	suite.Run("OtherChangesAreAllowed", func() {
+		suite.NoError(suite.repo.UpdateUserRole(suite.ctx, admin.ID, domain.RoleAdmin))
+		suite.NoError(suite.repo.SetUserDeactivated(suite.ctx, admin.ID, false))
+		suite.NoError(suite.repo.SetUserDeactivated(suite.ctx, user.ID, true))
+		suite.NoError(suite.repo.UpdateUserRole(suite.ctx, user.ID, "auditor"))
+	})
+
+	var others []*domain.User
+	suite.Run("AdminCanGoWhileAnotherIsLeft", func() {
+		others = append(others, suite.addAdmin("admin3"))
+
+		suite.NoError(suite.repo.UpdateUserRole(suite.ctx, admin.ID, domain.RoleUser))
+		suite.ErrorIs(suite.repo.SetUserDeactivated(suite.ctx, others[0].ID, true), domain.ErrLastAdmin)
+	})
+
+	suite.Run("ConcurrentRemovalsKeepAnAdmin", func() {
+		const workers = 8
+		for i := len(others); i < workers; i++ {
+			others = append(others, suite.addAdmin(fmt.Sprintf("admin%d", 10+i)))
+		}
+
+		// Every active admin is demoted or deactivated at once.
+		errs := make([]error, workers)
+		var wg sync.WaitGroup
+		for i, other := range others {
+			wg.Add(1)
+			go func(i int, id string) {
+				defer wg.Done()
+				if i%2 == 0 {
+					errs[i] = suite.repo.UpdateUserRole(suite.ctx, id, domain.RoleUser)
+				} else {
+					errs[i] = suite.repo.SetUserDeactivated(suite.ctx, id, true)
+				}
+			}(i, other.ID)
+		}
+		wg.Wait()
+
+		var failed int64
+		for _, err := range errs {
+			if err != nil {
+				suite.ErrorIs(err, domain.ErrLastAdmin)
+				failed++
+			}
+		}
+		count, err := suite.repo.CountActiveAdmins(suite.ctx)
+		suite.NoError(err)
+		suite.GreaterOrEqual(count, int64(1))
+		suite.Equal(failed, count, "exactly the admins whose removal failed are left")
+	})
+}
+
+func (suite *UserRepositoryContractSuite) TestListUsersSuite() {
+	var ids []string
+	for _, name := range []string{"Erin", "dave", "carol", "bob", "alice"} {
+		ids = append(ids, suite.addUser(name).ID)
+	}
+	suite.Require().NoError(suite.repo.UpdateUserRole(suite.ctx, ids[1], domain.RoleAdmin))
+
+	suite.Run("PagesInIDOrder", func() {
+		var listed []string
+		query := domain.UserQuery{Limit: 2}
+		for pages := 0; pages < 5; pages++ {
+			page, err := suite.repo.ListUsers(suite.ctx, query)
+			suite.Require().NoError(err)
+			suite.Equal(int64(5), page.Total)
+			for _, user := range page.Users {
+				listed = append(listed, user.ID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			suite.Len(page.Users, 2)
+			query.Cursor = page.NextCursor
+		}
+		suite.Equal(ids, listed)
+	})
+
+	suite.Run("LastPageHasNoCursor", func() {
+		page, err := suite.repo.ListUsers(suite.ctx, domain.UserQuery{Limit: 5})
+		suite.Require().NoError(err)
+		suite.Len(page.Users, 5)
+		suite.Empty(page.NextCursor)
+	})
+
+	suite.Run("SearchIgnoresCase", func() {
+		page, err := suite.repo.ListUsers(suite.ctx, domain.UserQuery{Search: "ERI", Limit: 10})
+		suite.Require().NoError(err)
+		suite.Equal(int64(1), page.Total)
+		suite.Require().Len(page.Users, 1)
+		suite.Equal("Erin", page.Users[0].Username)
+	})
+
+	suite.Run("SearchMatchesEmailLiterally", func() {
+		page, err := suite.repo.ListUsers(suite.ctx, domain.UserQuery{Search: "@example.", Limit: 10})
+		suite.Require().NoError(err)
+		suite.Equal(int64(5), page.Total)
+
+		page, err = suite.repo.ListUsers(suite.ctx, domain.UserQuery{Search: "a_i", Limit: 10})
+		suite.Require().NoError(err)
+		suite.Zero(page.Total)
+	})
+
+	suite.Run("FiltersByRole", func() {
+		page, err := suite.repo.ListUsers(suite.ctx, domain.UserQuery{Role: domain.RoleAdmin, Limit: 10})
+		suite.Require().NoError(err)
+		suite.Equal(int64(1), page.Total)
+		suite.Require().Len(page.Users, 1)
+		suite.Equal(ids[1], page.Users[0].ID)
+	})
+
+	suite.Run("MalformedCursor", func() {
+		_, err := suite.repo.ListUsers(suite.ctx, domain.UserQuery{Limit: 2, Cursor: "bogus"})
+		suite.ErrorIs(err, domain.ErrInvalidUserQuery)
+	})
+}
+
+func (suite *UserRepositoryContractSuite) TestConcurrencySuite() {
+	const workers = 8
+
+	suite.Run("ConcurrentAddsGetDistinctIDs", func() {
+		ids := make([]string, workers)
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				user := &domain.User{Username: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@example.com", i), Role: domain.RoleUser}
+				suite.NoError(suite.repo.AddUser(suite.ctx, user))
+				ids[i] = user.ID
+			}(i)
+		}
+		wg.Wait()
+
+		seen := make(map[string]bool)
+		for _, id := range ids {
+			suite.NotEmpty(id)
+			seen[id] = true
+		}
+		suite.Len(seen, workers)
+	})
+
+	suite.Run("OnlyOneRegistrationPerEmail", func() {
+		errs := make([]error, workers)
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				user := &domain.User{Username: fmt.Sprintf("racer%d", i), Email: "racer@example.com", Role: domain.RoleUser}
+				errs[i] = suite.repo.AddUser(suite.ctx, user)
+			}(i)
+		}
+		wg.Wait()
+
+		succeeded := 0
+		for _, err := range errs {
+			if err == nil {
+				succeeded++
+			} else {
+				suite.ErrorIs(err, domain.ErrEmailTaken)
+			}
+		}
+		suite.Equal(1, succeeded)
+	})
+
+	suite.Run("OnlyOneFirstAdmin", func() {
+		errs := make([]error, workers)
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				user := &domain.User{Username: fmt.Sprintf("admin%d", i), Email: fmt.Sprintf("admin%d@example.com", i), Role: domain.RoleAdmin}
+				errs[i] = suite.repo.AddFirstAdmin(suite.ctx, user)
+			}(i)
+		}
+		wg.Wait()
+
+		succeeded := 0
+		for _, err := range errs {
+			if err == nil {
+				succeeded++
+			} else {
+				suite.ErrorIs(err, domain.ErrFirstAdminExists)
+			}
+		}
+		suite.Equal(1, succeeded)
+	})
+}
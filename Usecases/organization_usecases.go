@@ -0,0 +1,315 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"task_manager/domain"
+	"time"
+)
+
+// MaxOrganizationNameLength is the longest organization name accepted.
+const MaxOrganizationNameLength = 100
+
+// defaultOrganizationName is shown for domain.DefaultOrganizationID.
+const defaultOrganizationName = "Default"
+
+var errDefaultOrganizationMembers = domain.NewError(domain.ErrConflict, "default_organization_members", "every user is a member of the default organization; its members cannot be changed")
+
+// OrganizationUsecase manages organizations and their members.
+type OrganizationUsecase struct {
+	orgRepository   domain.IOrganizationRepository
+	userRepository  domain.IUserRepository
+	tokenRepository domain.ITokenRepository
+	contextTimeout  time.Duration
+}
+
+func NewOrganizationUsecase(orgRepository domain.IOrganizationRepository, userRepository domain.IUserRepository, tokenRepository domain.ITokenRepository, timeout time.Duration) *OrganizationUsecase {
+	return &OrganizationUsecase{
+		orgRepository:   orgRepository,
+		userRepository:  userRepository,
+		tokenRepository: tokenRepository,
+		contextTimeout:  timeout,
+	}
+}
+
+// CreateOrganization creates an organization owned by the caller.
+func (ou *OrganizationUsecase) CreateOrganization(ctx context.Context, name string) (*domain.Organization, error) {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, domain.NewValidationError("name", domain.ValidationRequired, "organization name is required")
+	}
+	if len(name) > MaxOrganizationNameLength {
+		return nil, domain.NewValidationError("name", domain.ValidationTooLong, "organization name must be at most 100 characters long")
+	}
+
+	c, cancel := context.WithTimeout(ctx, ou.contextTimeout)
+	defer cancel()
+	now := time.Now()
+	org := &domain.Organization{Name: name, CreatedBy: principal.UserID, CreatedAt: now}
+	if err := ou.orgRepository.AddOrganization(c, org); err != nil {
+		return nil, err
+	}
+	owner := &domain.Membership{OrgID: org.ID, UserID: principal.UserID, Role: domain.OrgRoleOwner, CreatedAt: now}
+	if err := ou.orgRepository.AddMember(c, owner); err != nil {
+		return nil, err
+	}
+	return org, nil
+}
+
+// ListOrganizations returns the default organization followed by the other
+// organizations the caller belongs to, each with the caller's role.
+func (ou *OrganizationUsecase) ListOrganizations(ctx context.Context) ([]domain.OrganizationMembership, error) {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, cancel := context.WithTimeout(ctx, ou.contextTimeout)
+	defer cancel()
+	orgs, err := ou.orgRepository.ListOrganizationsForUser(c, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]domain.OrganizationMembership, 0, len(orgs)+1)
+	result = append(result, domain.OrganizationMembership{Organization: defaultOrganization(), Role: domain.OrgRoleMember})
+	for _, org := range orgs {
+		membership, err := ou.orgRepository.GetMembership(c, org.ID, principal.UserID)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, domain.OrganizationMembership{Organization: org, Role: membership.Role})
+	}
+	return result, nil
+}
+
+// GetOrganization returns an organization the caller belongs to, with the
+// caller's role in it.
+func (ou *OrganizationUsecase) GetOrganization(ctx context.Context, id string) (*domain.OrganizationMembership, error) {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, cancel := context.WithTimeout(ctx, ou.contextTimeout)
+	defer cancel()
+	membership, err := getMembership(c, ou.orgRepository, id, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if membership.OrgID == domain.DefaultOrganizationID {
+		return &domain.OrganizationMembership{Organization: defaultOrganization(), Role: membership.Role}, nil
+	}
+	org, err := ou.orgRepository.GetOrganization(c, id)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.OrganizationMembership{Organization: *org, Role: membership.Role}, nil
+}
+
+// ListMembers returns the members of an organization the caller belongs to.
+func (ou *OrganizationUsecase) ListMembers(ctx context.Context, orgID string) ([]domain.Membership, error) {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if isDefaultOrganization(orgID) {
+		return nil, errDefaultOrganizationMembers
+	}
+
+	c, cancel := context.WithTimeout(ctx, ou.contextTimeout)
+	defer cancel()
+	if _, err := getMembership(c, ou.orgRepository, orgID, principal.UserID); err != nil {
+		return nil, err
+	}
+	return ou.orgRepository.ListMembers(c, orgID)
+}
+
+// AddMember adds a user to an organization. Owners and admins can add
+// members; only owners can add other owners.
+func (ou *OrganizationUsecase) AddMember(ctx context.Context, orgID, userID, role string) (*domain.Membership, error) {
+	if err := validateOrgRole(role); err != nil {
+		return nil, err
+	}
+	if isDefaultOrganization(orgID) {
+		return nil, errDefaultOrganizationMembers
+	}
+
+	c, cancel := context.WithTimeout(ctx, ou.contextTimeout)
+	defer cancel()
+	caller, err := ou.callerMembership(c, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureCanAssignOrgRole(caller, role); err != nil {
+		return nil, err
+	}
+	user, err := ou.userRepository.GetUserByID(c, userID)
+	if err != nil {
+		return nil, err
+	}
+	membership := &domain.Membership{OrgID: orgID, UserID: user.ID, Role: role, CreatedAt: time.Now()}
+	if err := ou.orgRepository.AddMember(c, membership); err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// UpdateMemberRole changes a member's role. Only owners can make someone an
+// owner or change an owner's role, and the last owner cannot step down.
+func (ou *OrganizationUsecase) UpdateMemberRole(ctx context.Context, orgID, userID, role string) error {
+	if err := validateOrgRole(role); err != nil {
+		return err
+	}
+	if isDefaultOrganization(orgID) {
+		return errDefaultOrganizationMembers
+	}
+
+	c, cancel := context.WithTimeout(ctx, ou.contextTimeout)
+	defer cancel()
+	caller, err := ou.callerMembership(c, orgID)
+	if err != nil {
+		return err
+	}
+	target, err := ou.orgRepository.GetMembership(c, orgID, userID)
+	if errors.Is(err, domain.ErrOrganizationNotFound) {
+		return domain.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if target.Role == role {
+		return nil
+	}
+	if err := ensureCanAssignOrgRole(caller, role); err != nil {
+		return err
+	}
+	if err := ensureCanAssignOrgRole(caller, target.Role); err != nil {
+		return err
+	}
+	if err := ou.ensureNotLastOwner(c, target); err != nil {
+		return err
+	}
+	if err := ou.orgRepository.UpdateMemberRole(c, orgID, userID, role); err != nil {
+		return err
+	}
+	// Access tokens carry the org role; make the member pick up the new one.
+	return ou.tokenRepository.RevokeUserAccessTokens(c, userID, time.Now())
+}
+
+// RemoveMember removes a user from an organization. Members can always
+// leave; removing someone else requires being an owner or admin, and only
+// owners can remove owners. The last owner cannot leave.
+func (ou *OrganizationUsecase) RemoveMember(ctx context.Context, orgID, userID string) error {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	if isDefaultOrganization(orgID) {
+		return errDefaultOrganizationMembers
+	}
+
+	c, cancel := context.WithTimeout(ctx, ou.contextTimeout)
+	defer cancel()
+	var caller *domain.Membership
+	if userID != principal.UserID {
+		if caller, err = ou.callerMembership(c, orgID); err != nil {
+			return err
+		}
+	}
+	target, err := ou.orgRepository.GetMembership(c, orgID, userID)
+	if errors.Is(err, domain.ErrOrganizationNotFound) && caller != nil {
+		return domain.ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if caller != nil {
+		if err := ensureCanAssignOrgRole(caller, target.Role); err != nil {
+			return err
+		}
+	}
+	if err := ou.ensureNotLastOwner(c, target); err != nil {
+		return err
+	}
+	if err := ou.orgRepository.RemoveMember(c, orgID, userID); err != nil {
+		return err
+	}
+	if user, err := ou.userRepository.GetUserByID(c, userID); err == nil && user != nil && user.ActiveOrgID == orgID {
+		if err := ou.userRepository.SetActiveOrganization(c, userID, domain.DefaultOrganizationID); err != nil {
+			return err
+		}
+	}
+	// Tokens scoped to the organization must stop working right away.
+	return ou.tokenRepository.RevokeUserAccessTokens(c, userID, time.Now())
+}
+
+// callerMembership returns the caller's membership of orgID and fails unless
+// the caller may manage it.
+func (ou *OrganizationUsecase) callerMembership(ctx context.Context, orgID string) (*domain.Membership, error) {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	membership, err := getMembership(ctx, ou.orgRepository, orgID, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !membership.CanManage() {
+		return nil, fmt.Errorf("%w: only organization owners and admins can manage members", domain.ErrPermissionDenied)
+	}
+	return membership, nil
+}
+
+// ensureNotLastOwner fails if membership is the organization's only owner.
+func (ou *OrganizationUsecase) ensureNotLastOwner(ctx context.Context, membership *domain.Membership) error {
+	if membership.Role != domain.OrgRoleOwner {
+		return nil
+	}
+	count, err := ou.orgRepository.CountMembersWithRole(ctx, membership.OrgID, domain.OrgRoleOwner)
+	if err != nil {
+		return err
+	}
+	if count <= 1 {
+		return domain.ErrLastOwner
+	}
+	return nil
+}
+
+// getMembership returns userID's membership of orgID. Every user is a member
+// of the default organization.
+func getMembership(ctx context.Context, orgRepository domain.IOrganizationRepository, orgID, userID string) (*domain.Membership, error) {
+	if isDefaultOrganization(orgID) {
+		return &domain.Membership{OrgID: domain.DefaultOrganizationID, UserID: userID, Role: domain.OrgRoleMember}, nil
+	}
+	return orgRepository.GetMembership(ctx, orgID, userID)
+}
+
+func isDefaultOrganization(orgID string) bool {
+	return orgID == "" || orgID == domain.DefaultOrganizationID
+}
+
+func defaultOrganization() domain.Organization {
+	return domain.Organization{ID: domain.DefaultOrganizationID, Name: defaultOrganizationName}
+}
+
+func validateOrgRole(role string) error {
+	switch role {
+	case domain.OrgRoleOwner, domain.OrgRoleAdmin, domain.OrgRoleMember:
+		return nil
+	}
+	return domain.NewValidationError("role", domain.ValidationInvalid, "invalid organization role: must be owner, admin or member")
+}
+
+// ensureCanAssignOrgRole stops admins from creating or touching owners.
+func ensureCanAssignOrgRole(caller *domain.Membership, role string) error {
+	if role == domain.OrgRoleOwner && caller.Role != domain.OrgRoleOwner {
+		return fmt.Errorf("%w: only organization owners can manage owners", domain.ErrPermissionDenied)
+	}
+	return nil
+}
@@ -0,0 +1,332 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// MockOrganizationRepository is a mock implementation of IOrganizationRepository
+type MockOrganizationRepository struct {
+	mock.Mock
+}
+
+func (m *MockOrganizationRepository) AddOrganization(ctx context.Context, org *domain.Organization) error {
+	args := m.Called(ctx, org)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) GetOrganization(ctx context.Context, id string) (*domain.Organization, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) ListOrganizationsForUser(ctx context.Context, userID string) ([]domain.Organization, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Organization), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) AddMember(ctx context.Context, membership *domain.Membership) error {
+	args := m.Called(ctx, membership)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) GetMembership(ctx context.Context, orgID, userID string) (*domain.Membership, error) {
+	args := m.Called(ctx, orgID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Membership), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) ListMembers(ctx context.Context, orgID string) ([]domain.Membership, error) {
+	args := m.Called(ctx, orgID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Membership), args.Error(1)
+}
+
+func (m *MockOrganizationRepository) UpdateMemberRole(ctx context.Context, orgID, userID, role string) error {
+	args := m.Called(ctx, orgID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) RemoveMember(ctx context.Context, orgID, userID string) error {
+	args := m.Called(ctx, orgID, userID)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) RemoveUserMemberships(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockOrganizationRepository) CountMembersWithRole(ctx context.Context, orgID, role string) (int64, error) {
+	args := m.Called(ctx, orgID, role)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// OrganizationUsecaseTestSuite is a test suite for OrganizationUsecase
+type OrganizationUsecaseTestSuite struct {
+	suite.Suite
+	mockOrgRepo   *MockOrganizationRepository
+	mockUserRepo  *MockUserRepository
+	mockTokenRepo *MockTokenRepository
+	usecase       *OrganizationUsecase
+	ctx           context.Context
+	asOwner       context.Context
+	asAdmin       context.Context
+	asMember      context.Context
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *OrganizationUsecaseTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	suite.asOwner = withRole(suite.ctx, "owner1", domain.RoleUser)
+	suite.asAdmin = withRole(suite.ctx, "orgadmin1", domain.RoleUser)
+	suite.asMember = withRole(suite.ctx, "member1", domain.RoleUser)
+}
+
+// SetupTest runs before each test
+func (suite *OrganizationUsecaseTestSuite) SetupTest() {
+	suite.mockOrgRepo = new(MockOrganizationRepository)
+	suite.mockUserRepo = new(MockUserRepository)
+	suite.mockTokenRepo = new(MockTokenRepository)
+	suite.usecase = NewOrganizationUsecase(suite.mockOrgRepo, suite.mockUserRepo, suite.mockTokenRepo, 5*time.Second)
+}
+
+// TearDownTest runs after each test
+func (suite *OrganizationUsecaseTestSuite) TearDownTest() {
+	suite.mockOrgRepo.AssertExpectations(suite.T())
+	suite.mockUserRepo.AssertExpectations(suite.T())
+	suite.mockTokenRepo.AssertExpectations(suite.T())
+}
+
+// expectMembership stubs the membership lookup for userID in org1.
+func (suite *OrganizationUsecaseTestSuite) expectMembership(userID, role string) {
+	membership := &domain.Membership{OrgID: "org1", UserID: userID, Role: role}
+	suite.mockOrgRepo.On("GetMembership", mock.AnythingOfType("*context.timerCtx"), "org1", userID).Return(membership, nil).Once()
+}
+
+// TestCreateOrganizationSuite tests the CreateOrganization method
+func (suite *OrganizationUsecaseTestSuite) TestCreateOrganizationSuite() {
+	suite.Run("Success", func() {
+		suite.mockOrgRepo.On("AddOrganization", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("*domain.Organization")).
+			Run(func(args mock.Arguments) {
+				args.Get(1).(*domain.Organization).ID = "org1"
+			}).
+			Return(nil)
+		suite.mockOrgRepo.On("AddMember", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(m *domain.Membership) bool {
+			return m.OrgID == "org1" && m.UserID == "owner1" && m.Role == domain.OrgRoleOwner
+		})).Return(nil)
+
+		org, err := suite.usecase.CreateOrganization(suite.asOwner, "  Engineering ")
+
+		suite.NoError(err)
+		suite.Equal("org1", org.ID)
+		suite.Equal("Engineering", org.Name)
+		suite.Equal("owner1", org.CreatedBy)
+	})
+
+	suite.Run("MissingName", func() {
+		org, err := suite.usecase.CreateOrganization(suite.asOwner, " ")
+
+		suite.Error(err)
+		suite.Nil(org)
+		suite.Equal("organization name is required", err.Error())
+	})
+}
+
+// TestListOrganizationsSuite tests the ListOrganizations method
+func (suite *OrganizationUsecaseTestSuite) TestListOrganizationsSuite() {
+	suite.Run("DefaultFirst", func() {
+		orgs := []domain.Organization{{ID: "org1", Name: "Engineering"}}
+		suite.mockOrgRepo.On("ListOrganizationsForUser", mock.AnythingOfType("*context.timerCtx"), "owner1").Return(orgs, nil)
+		suite.expectMembership("owner1", domain.OrgRoleOwner)
+
+		result, err := suite.usecase.ListOrganizations(suite.asOwner)
+
+		suite.NoError(err)
+		suite.Require().Len(result, 2)
+		suite.Equal(domain.DefaultOrganizationID, result[0].ID)
+		suite.Equal(domain.OrgRoleMember, result[0].Role)
+		suite.Equal("org1", result[1].ID)
+		suite.Equal(domain.OrgRoleOwner, result[1].Role)
+	})
+}
+
+// TestGetOrganizationSuite tests the GetOrganization method
+func (suite *OrganizationUsecaseTestSuite) TestGetOrganizationSuite() {
+	suite.Run("Member", func() {
+		suite.expectMembership("member1", domain.OrgRoleMember)
+		suite.mockOrgRepo.On("GetOrganization", mock.AnythingOfType("*context.timerCtx"), "org1").Return(&domain.Organization{ID: "org1", Name: "Engineering"}, nil)
+
+		org, err := suite.usecase.GetOrganization(suite.asMember, "org1")
+
+		suite.NoError(err)
+		suite.Equal("Engineering", org.Name)
+		suite.Equal(domain.OrgRoleMember, org.Role)
+	})
+
+	suite.Run("NotMember", func() {
+		suite.mockOrgRepo.On("GetMembership", mock.AnythingOfType("*context.timerCtx"), "org2", "member1").Return(nil, domain.ErrOrganizationNotFound)
+
+		org, err := suite.usecase.GetOrganization(suite.asMember, "org2")
+
+		suite.ErrorIs(err, domain.ErrOrganizationNotFound)
+		suite.Nil(org)
+	})
+
+	suite.Run("Default", func() {
+		org, err := suite.usecase.GetOrganization(suite.asMember, domain.DefaultOrganizationID)
+
+		suite.NoError(err)
+		suite.Equal(domain.DefaultOrganizationID, org.ID)
+	})
+}
+
+// TestAddMemberSuite tests the AddMember method
+func (suite *OrganizationUsecaseTestSuite) TestAddMemberSuite() {
+	suite.Run("Success", func() {
+		suite.expectMembership("orgadmin1", domain.OrgRoleAdmin)
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user2").Return(&domain.User{ID: "user2"}, nil)
+		suite.mockOrgRepo.On("AddMember", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("*domain.Membership")).Return(nil)
+
+		membership, err := suite.usecase.AddMember(suite.asAdmin, "org1", "user2", domain.OrgRoleMember)
+
+		suite.NoError(err)
+		suite.Equal("user2", membership.UserID)
+		suite.Equal(domain.OrgRoleMember, membership.Role)
+	})
+
+	suite.Run("MemberCannotAdd", func() {
+		suite.expectMembership("member1", domain.OrgRoleMember)
+
+		_, err := suite.usecase.AddMember(suite.asMember, "org1", "user3", domain.OrgRoleMember)
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+	})
+
+	suite.Run("AdminCannotAddOwner", func() {
+		suite.expectMembership("orgadmin1", domain.OrgRoleAdmin)
+
+		_, err := suite.usecase.AddMember(suite.asAdmin, "org1", "user4", domain.OrgRoleOwner)
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+	})
+
+	suite.Run("InvalidRole", func() {
+		_, err := suite.usecase.AddMember(suite.asOwner, "org1", "user5", "superuser")
+
+		suite.Error(err)
+	})
+
+	suite.Run("DefaultOrganization", func() {
+		_, err := suite.usecase.AddMember(suite.asOwner, domain.DefaultOrganizationID, "user6", domain.OrgRoleMember)
+
+		suite.Error(err)
+	})
+}
+
+// TestUpdateMemberRoleSuite tests the UpdateMemberRole method
+func (suite *OrganizationUsecaseTestSuite) TestUpdateMemberRoleSuite() {
+	suite.Run("Success", func() {
+		suite.expectMembership("owner1", domain.OrgRoleOwner)
+		suite.expectMembership("user2", domain.OrgRoleMember)
+		suite.mockOrgRepo.On("UpdateMemberRole", mock.AnythingOfType("*context.timerCtx"), "org1", "user2", domain.OrgRoleAdmin).Return(nil)
+		suite.mockTokenRepo.On("RevokeUserAccessTokens", mock.AnythingOfType("*context.timerCtx"), "user2", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := suite.usecase.UpdateMemberRole(suite.asOwner, "org1", "user2", domain.OrgRoleAdmin)
+
+		suite.NoError(err)
+	})
+
+	suite.Run("LastOwner", func() {
+		suite.expectMembership("owner1", domain.OrgRoleOwner)
+		suite.expectMembership("owner1", domain.OrgRoleOwner)
+		suite.mockOrgRepo.On("CountMembersWithRole", mock.AnythingOfType("*context.timerCtx"), "org1", domain.OrgRoleOwner).Return(int64(1), nil).Once()
+
+		err := suite.usecase.UpdateMemberRole(suite.asOwner, "org1", "owner1", domain.OrgRoleMember)
+
+		suite.ErrorIs(err, domain.ErrLastOwner)
+	})
+
+	suite.Run("AdminCannotDemoteOwner", func() {
+		suite.expectMembership("orgadmin1", domain.OrgRoleAdmin)
+		suite.expectMembership("owner1", domain.OrgRoleOwner)
+
+		err := suite.usecase.UpdateMemberRole(suite.asAdmin, "org1", "owner1", domain.OrgRoleMember)
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+	})
+
+	suite.Run("NotMember", func() {
+		suite.expectMembership("owner1", domain.OrgRoleOwner)
+		suite.mockOrgRepo.On("GetMembership", mock.AnythingOfType("*context.timerCtx"), "org1", "ghost").Return(nil, domain.ErrOrganizationNotFound)
+
+		err := suite.usecase.UpdateMemberRole(suite.asOwner, "org1", "ghost", domain.OrgRoleAdmin)
+
+		suite.ErrorIs(err, domain.ErrUserNotFound)
+	})
+}
+
+// TestRemoveMemberSuite tests the RemoveMember method
+func (suite *OrganizationUsecaseTestSuite) TestRemoveMemberSuite() {
+	suite.Run("AdminRemovesMember", func() {
+		suite.expectMembership("orgadmin1", domain.OrgRoleAdmin)
+		suite.expectMembership("user2", domain.OrgRoleMember)
+		suite.mockOrgRepo.On("RemoveMember", mock.AnythingOfType("*context.timerCtx"), "org1", "user2").Return(nil)
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user2").Return(&domain.User{ID: "user2", ActiveOrgID: "org1"}, nil)
+		suite.mockUserRepo.On("SetActiveOrganization", mock.AnythingOfType("*context.timerCtx"), "user2", domain.DefaultOrganizationID).Return(nil)
+		suite.mockTokenRepo.On("RevokeUserAccessTokens", mock.AnythingOfType("*context.timerCtx"), "user2", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := suite.usecase.RemoveMember(suite.asAdmin, "org1", "user2")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("MemberLeaves", func() {
+		suite.expectMembership("member1", domain.OrgRoleMember)
+		suite.mockOrgRepo.On("RemoveMember", mock.AnythingOfType("*context.timerCtx"), "org1", "member1").Return(nil)
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "member1").Return(&domain.User{ID: "member1"}, nil)
+		suite.mockTokenRepo.On("RevokeUserAccessTokens", mock.AnythingOfType("*context.timerCtx"), "member1", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := suite.usecase.RemoveMember(suite.asMember, "org1", "member1")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("MemberCannotRemoveOthers", func() {
+		suite.expectMembership("member1", domain.OrgRoleMember)
+
+		err := suite.usecase.RemoveMember(suite.asMember, "org1", "user3")
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+	})
+
+	suite.Run("LastOwnerCannotLeave", func() {
+		suite.expectMembership("owner1", domain.OrgRoleOwner)
+		suite.mockOrgRepo.On("CountMembersWithRole", mock.AnythingOfType("*context.timerCtx"), "org1", domain.OrgRoleOwner).Return(int64(1), nil).Once()
+
+		err := suite.usecase.RemoveMember(suite.asOwner, "org1", "owner1")
+
+		suite.ErrorIs(err, domain.ErrLastOwner)
+	})
+}
+
+// TestOrganizationUsecaseSuite runs the test suite
+func TestOrganizationUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(OrganizationUsecaseTestSuite))
+}
@@ -0,0 +1,134 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log"
+	"task_manager/domain"
+	"time"
+)
+
+// PasswordResetTTL is how long an emailed password reset link stays valid.
+const PasswordResetTTL = time.Hour
+
+var errInvalidResetToken = domain.NewValidationError("token", domain.ValidationInvalid, "invalid or expired reset token")
+
+type PasswordResetUsecase struct {
+	userRepository  domain.IUserRepository
+	resetRepository domain.IPasswordResetRepository
+	tokenRepository domain.ITokenRepository
+	passwordService domain.IPasswordService
+	mailSender      domain.IMailSender
+	resetURL        string
+	contextTimeout  time.Duration
+}
+
+// NewPasswordResetUsecase creates a PasswordResetUsecase. resetURL is the page
+// users land on from the email; the reset token is appended to it as the
+// "token" query parameter.
+func NewPasswordResetUsecase(userRepository domain.IUserRepository, resetRepository domain.IPasswordResetRepository, tokenRepository domain.ITokenRepository, passwordService domain.IPasswordService, mailSender domain.IMailSender, resetURL string, timeout time.Duration) *PasswordResetUsecase {
+	return &PasswordResetUsecase{
+		userRepository:  userRepository,
+		resetRepository: resetRepository,
+		tokenRepository: tokenRepository,
+		passwordService: passwordService,
+		mailSender:      mailSender,
+		resetURL:        resetURL,
+		contextTimeout:  timeout,
+	}
+}
+
+// RequestPasswordReset emails a reset link to the account registered with
+// email. Unknown addresses are silently ignored, and failures to send the
+// email are only logged, so the endpoint cannot be used to discover which
+// emails have accounts.
+func (pu *PasswordResetUsecase) RequestPasswordReset(ctx context.Context, email string) error {
+	if email == "" {
+		return domain.NewValidationError("email", domain.ValidationRequired, "email is required")
+	}
+
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+	user, err := pu.userRepository.GetUserByEmail(c, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	token, err := newOpaqueToken()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	reset := &domain.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(PasswordResetTTL),
+	}
+	if err := pu.resetRepository.AddPasswordReset(c, reset); err != nil {
+		return err
+	}
+	err = pu.mailSender.Send(c, &domain.Mail{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Someone asked to reset the password for your account. If it was you, open the link below within the next hour:\n\n" +
+			tokenLink(pu.resetURL, token) + "\n\n" +
+			"If you did not ask for this, you can ignore this email.\n",
+	})
+	if err != nil {
+		log.Printf("Failed to send password reset email to user %s: %v", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password using an emailed reset token. The token
+// works once, and afterwards every other pending reset and every access and
+// refresh token of the account is revoked.
+func (pu *PasswordResetUsecase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	var errs domain.ValidationErrors
+	if token == "" {
+		errs.Add("token", domain.ValidationRequired, "reset token is required")
+	}
+	if newPassword == "" {
+		errs.Add("password", domain.ValidationRequired, "password is required")
+	} else if len(newPassword) < 6 {
+		errs.Add("password", domain.ValidationTooShort, "password must be at least 6 characters long")
+	}
+	if err := errs.Err(); err != nil {
+		return err
+	}
+
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+	reset, err := pu.resetRepository.GetPasswordResetByHash(c, hashToken(token))
+	if errors.Is(err, domain.ErrNotFound) {
+		return errInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+	if !reset.UsedAt.IsZero() || time.Now().After(reset.ExpiresAt) {
+		return errInvalidResetToken
+	}
+	consumed, err := pu.resetRepository.MarkPasswordResetUsed(c, reset.ID)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return errInvalidResetToken
+	}
+	hashed, err := pu.passwordService.HashPassword(newPassword)
+	if err != nil {
+		return err
+	}
+	if err := pu.userRepository.UpdatePassword(c, reset.UserID, hashed); err != nil {
+		return err
+	}
+	if err := pu.resetRepository.DeleteUserPasswordResets(c, reset.UserID); err != nil {
+		return err
+	}
+	return revokeAllUserTokens(c, pu.tokenRepository, reset.UserID)
+}
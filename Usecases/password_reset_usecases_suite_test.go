@@ -0,0 +1,228 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type MockPasswordResetRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetRepository) AddPasswordReset(ctx context.Context, reset *domain.PasswordReset) error {
+	args := m.Called(ctx, reset)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetRepository) GetPasswordResetByHash(ctx context.Context, hash string) (*domain.PasswordReset, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.PasswordReset), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) MarkPasswordResetUsed(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockPasswordResetRepository) DeleteUserPasswordResets(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+type MockMailSender struct {
+	mock.Mock
+}
+
+func (m *MockMailSender) Send(ctx context.Context, mail *domain.Mail) error {
+	args := m.Called(ctx, mail)
+	return args.Error(0)
+}
+
+// PasswordResetUsecaseTestSuite is a test suite for PasswordResetUsecase
+type PasswordResetUsecaseTestSuite struct {
+	suite.Suite
+	mockUserRepo        *MockUserRepository
+	mockResetRepo       *MockPasswordResetRepository
+	mockTokenRepo       *MockTokenRepository
+	mockPasswordService *MockPasswordService
+	mockMailSender      *MockMailSender
+	usecase             *PasswordResetUsecase
+	ctx                 context.Context
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *PasswordResetUsecaseTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+}
+
+// SetupTest runs before each test
+func (suite *PasswordResetUsecaseTestSuite) SetupTest() {
+	suite.mockUserRepo = new(MockUserRepository)
+	suite.mockResetRepo = new(MockPasswordResetRepository)
+	suite.mockTokenRepo = new(MockTokenRepository)
+	suite.mockPasswordService = new(MockPasswordService)
+	suite.mockMailSender = new(MockMailSender)
+	suite.usecase = NewPasswordResetUsecase(suite.mockUserRepo, suite.mockResetRepo, suite.mockTokenRepo, suite.mockPasswordService, suite.mockMailSender, "https://app.example.com/reset?lang=en", 5*time.Second)
+}
+
+// TearDownTest runs after each test
+func (suite *PasswordResetUsecaseTestSuite) TearDownTest() {
+	suite.mockUserRepo.AssertExpectations(suite.T())
+	suite.mockResetRepo.AssertExpectations(suite.T())
+	suite.mockTokenRepo.AssertExpectations(suite.T())
+	suite.mockPasswordService.AssertExpectations(suite.T())
+	suite.mockMailSender.AssertExpectations(suite.T())
+}
+
+// TestRequestPasswordResetSuite tests the RequestPasswordReset method
+func (suite *PasswordResetUsecaseTestSuite) TestRequestPasswordResetSuite() {
+	suite.Run("Success", func() {
+		user := &domain.User{ID: "user123", Username: "testuser", Email: "test@example.com"}
+		var stored *domain.PasswordReset
+		var sent *domain.Mail
+
+		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), "test@example.com").Return(user, nil)
+		suite.mockResetRepo.On("AddPasswordReset", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("*domain.PasswordReset")).
+			Run(func(args mock.Arguments) { stored = args.Get(1).(*domain.PasswordReset) }).Return(nil).Once()
+		suite.mockMailSender.On("Send", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("*domain.Mail")).
+			Run(func(args mock.Arguments) { sent = args.Get(1).(*domain.Mail) }).Return(nil).Once()
+
+		err := suite.usecase.RequestPasswordReset(suite.ctx, "test@example.com")
+
+		suite.NoError(err)
+		suite.Equal("user123", stored.UserID)
+		suite.WithinDuration(time.Now().Add(PasswordResetTTL), stored.ExpiresAt, time.Minute)
+		suite.Equal("test@example.com", sent.To)
+
+		// The emailed link carries the raw token; only its hash is stored.
+		var link string
+		for _, line := range strings.Split(sent.Body, "\n") {
+			if strings.HasPrefix(line, "https://") {
+				link = line
+			}
+		}
+		parsed, err := url.Parse(link)
+		suite.Require().NoError(err)
+		suite.Equal("en", parsed.Query().Get("lang"))
+		token := parsed.Query().Get("token")
+		suite.NotEmpty(token)
+		suite.Equal(hashToken(token), stored.TokenHash)
+	})
+
+	suite.Run("SendFails", func() {
+		user := &domain.User{ID: "user456", Username: "other", Email: "other@example.com"}
+		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), "other@example.com").Return(user, nil)
+		suite.mockResetRepo.On("AddPasswordReset", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("*domain.PasswordReset")).Return(nil).Once()
+		suite.mockMailSender.On("Send", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("*domain.Mail")).Return(errors.New("smtp unavailable")).Once()
+
+		err := suite.usecase.RequestPasswordReset(suite.ctx, "other@example.com")
+
+		// Answering like for an unknown address keeps accounts hidden.
+		suite.NoError(err)
+	})
+
+	suite.Run("UnknownEmail", func() {
+		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), "nobody@example.com").Return(nil, domain.ErrUserNotFound)
+
+		err := suite.usecase.RequestPasswordReset(suite.ctx, "nobody@example.com")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("EmptyEmail", func() {
+		err := suite.usecase.RequestPasswordReset(suite.ctx, "")
+
+		suite.Error(err)
+		suite.Equal("email is required", err.Error())
+	})
+}
+
+// TestResetPasswordSuite tests the ResetPassword method
+func (suite *PasswordResetUsecaseTestSuite) TestResetPasswordSuite() {
+	suite.Run("Success", func() {
+		reset := &domain.PasswordReset{ID: "reset1", UserID: "user123", TokenHash: hashToken("good-token"), ExpiresAt: time.Now().Add(time.Hour)}
+
+		suite.mockResetRepo.On("GetPasswordResetByHash", mock.AnythingOfType("*context.timerCtx"), hashToken("good-token")).Return(reset, nil)
+		suite.mockResetRepo.On("MarkPasswordResetUsed", mock.AnythingOfType("*context.timerCtx"), "reset1").Return(true, nil)
+		suite.mockPasswordService.On("HashPassword", "newpassword").Return("hashed_new", nil)
+		suite.mockUserRepo.On("UpdatePassword", mock.AnythingOfType("*context.timerCtx"), "user123", "hashed_new").Return(nil)
+		suite.mockResetRepo.On("DeleteUserPasswordResets", mock.AnythingOfType("*context.timerCtx"), "user123").Return(nil)
+		suite.mockTokenRepo.On("RevokeUserRefreshTokens", mock.AnythingOfType("*context.timerCtx"), "user123").Return(nil)
+		suite.mockTokenRepo.On("RevokeUserAccessTokens", mock.AnythingOfType("*context.timerCtx"), "user123", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := suite.usecase.ResetPassword(suite.ctx, "good-token", "newpassword")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("UnknownToken", func() {
+		suite.mockResetRepo.On("GetPasswordResetByHash", mock.AnythingOfType("*context.timerCtx"), hashToken("unknown")).Return(nil, domain.ErrNotFound)
+
+		err := suite.usecase.ResetPassword(suite.ctx, "unknown", "newpassword")
+
+		suite.Error(err)
+		suite.Equal("invalid or expired reset token", err.Error())
+	})
+
+	suite.Run("UsedToken", func() {
+		reset := &domain.PasswordReset{ID: "reset2", UserID: "user123", ExpiresAt: time.Now().Add(time.Hour), UsedAt: time.Now()}
+		suite.mockResetRepo.On("GetPasswordResetByHash", mock.AnythingOfType("*context.timerCtx"), hashToken("used")).Return(reset, nil)
+
+		err := suite.usecase.ResetPassword(suite.ctx, "used", "newpassword")
+
+		suite.Error(err)
+		suite.Equal("invalid or expired reset token", err.Error())
+	})
+
+	suite.Run("ExpiredToken", func() {
+		reset := &domain.PasswordReset{ID: "reset3", UserID: "user123", ExpiresAt: time.Now().Add(-time.Minute)}
+		suite.mockResetRepo.On("GetPasswordResetByHash", mock.AnythingOfType("*context.timerCtx"), hashToken("expired")).Return(reset, nil)
+
+		err := suite.usecase.ResetPassword(suite.ctx, "expired", "newpassword")
+
+		suite.Error(err)
+		suite.Equal("invalid or expired reset token", err.Error())
+	})
+
+	suite.Run("ConsumedConcurrently", func() {
+		reset := &domain.PasswordReset{ID: "reset4", UserID: "user123", ExpiresAt: time.Now().Add(time.Hour)}
+		suite.mockResetRepo.On("GetPasswordResetByHash", mock.AnythingOfType("*context.timerCtx"), hashToken("raced")).Return(reset, nil)
+		suite.mockResetRepo.On("MarkPasswordResetUsed", mock.AnythingOfType("*context.timerCtx"), "reset4").Return(false, nil)
+
+		err := suite.usecase.ResetPassword(suite.ctx, "raced", "newpassword")
+
+		suite.Error(err)
+		suite.Equal("invalid or expired reset token", err.Error())
+	})
+
+	suite.Run("EmptyToken", func() {
+		err := suite.usecase.ResetPassword(suite.ctx, "", "newpassword")
+
+		suite.Error(err)
+		suite.Equal("reset token is required", err.Error())
+	})
+
+	suite.Run("ShortPassword", func() {
+		err := suite.usecase.ResetPassword(suite.ctx, "good-token", "123")
+
+		suite.Error(err)
+		suite.Equal("password must be at least 6 characters long", err.Error())
+	})
+}
+
+// TestPasswordResetUsecaseSuite runs the test suite
+func TestPasswordResetUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(PasswordResetUsecaseTestSuite))
+}
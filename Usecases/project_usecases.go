@@ -0,0 +1,318 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"task_manager/domain"
+	"time"
+)
+
+// MaxProjectNameLength is the longest project name accepted.
+const MaxProjectNameLength = 100
+
+// ProjectUsecase manages the projects of the caller's active organization
+// and their members.
+type ProjectUsecase struct {
+	projectRepository domain.IProjectRepository
+	taskRepository    domain.ITaskRepository
+	orgRepository     domain.IOrganizationRepository
+	userRepository    domain.IUserRepository
+	contextTimeout    time.Duration
+}
+
+func NewProjectUsecase(projectRepository domain.IProjectRepository, taskRepository domain.ITaskRepository, orgRepository domain.IOrganizationRepository, userRepository domain.IUserRepository, timeout time.Duration) *ProjectUsecase {
+	return &ProjectUsecase{
+		projectRepository: projectRepository,
+		taskRepository:    taskRepository,
+		orgRepository:     orgRepository,
+		userRepository:    userRepository,
+		contextTimeout:    timeout,
+	}
+}
+
+// CreateProject creates a project in the caller's active organization and
+// makes the caller its manager.
+func (pu *ProjectUsecase) CreateProject(ctx context.Context, project *domain.Project) error {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	if project == nil {
+		return domain.NewValidationError("", domain.ValidationRequired, "project cannot be nil")
+	}
+	if project.ID != "" {
+		return domain.NewValidationError("id", domain.ValidationReadOnly, "project ID is assigned by the server and must not be provided")
+	}
+	if err := validateProjectName(project); err != nil {
+		return err
+	}
+
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+	now := time.Now()
+	project.OrgID = activeOrgID(principal)
+	project.CreatedBy = principal.UserID
+	project.CreatedAt = now
+	project.Archived = false
+	if err := pu.projectRepository.AddProject(c, project); err != nil {
+		return err
+	}
+	manager := &domain.ProjectMember{ProjectID: project.ID, UserID: principal.UserID, Role: domain.ProjectRoleManager, CreatedAt: now}
+	return pu.projectRepository.AddProjectMember(c, manager)
+}
+
+// ListProjects returns the projects of the active organization the caller
+// can see: all of them for organization owners and admins and holders of
+// tasks:read_all, otherwise the ones the caller is a member of.
+func (pu *ProjectUsecase) ListProjects(ctx context.Context, includeArchived bool) ([]domain.Project, error) {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+	query := domain.ProjectQuery{OrgID: activeOrgID(principal), IncludeArchived: includeArchived}
+	if !canSeeAllTasks(principal) {
+		query.MemberID = principal.UserID
+	}
+	return pu.projectRepository.ListProjects(c, query)
+}
+
+// GetProject returns a project the caller can see.
+func (pu *ProjectUsecase) GetProject(ctx context.Context, id string) (*domain.Project, error) {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+	project, _, err := getAccessibleProject(c, pu.projectRepository, principal, id)
+	return project, err
+}
+
+// UpdateProject changes the name and description of a project the caller
+// manages.
+func (pu *ProjectUsecase) UpdateProject(ctx context.Context, project *domain.Project) error {
+	if project == nil {
+		return domain.NewValidationError("", domain.ValidationRequired, "project cannot be nil")
+	}
+	if err := validateProjectName(project); err != nil {
+		return err
+	}
+
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+	existing, err := pu.manageableProject(c, project.ID)
+	if err != nil {
+		return err
+	}
+	existing.Name = project.Name
+	existing.Description = project.Description
+	if err := pu.projectRepository.UpdateProject(c, existing); err != nil {
+		return err
+	}
+	*project = *existing
+	return nil
+}
+
+// SetProjectArchived archives or restores a project the caller manages.
+// The tasks of archived projects are hidden from default task listings and
+// no tasks can be added to them.
+func (pu *ProjectUsecase) SetProjectArchived(ctx context.Context, id string, archived bool) (*domain.Project, error) {
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+	project, err := pu.manageableProject(c, id)
+	if err != nil {
+		return nil, err
+	}
+	if project.Archived == archived {
+		return project, nil
+	}
+	project.Archived = archived
+	if err := pu.projectRepository.UpdateProject(c, project); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// DeleteProject removes a project the caller manages. Projects that still
+// have tasks are refused with ErrProjectNotEmpty.
+func (pu *ProjectUsecase) DeleteProject(ctx context.Context, id string) error {
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+	project, err := pu.manageableProject(c, id)
+	if err != nil {
+		return err
+	}
+	tasks, err := pu.taskRepository.GetAllTasks(c, domain.TaskQuery{OrgID: project.OrgID, ProjectID: project.ID, SortBy: domain.TaskSortByID, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if tasks.Total > 0 {
+		return domain.ErrProjectNotEmpty
+	}
+	return pu.projectRepository.DeleteProject(c, project.OrgID, project.ID)
+}
+
+// ListMembers returns the members of a project the caller can see.
+func (pu *ProjectUsecase) ListMembers(ctx context.Context, projectID string) ([]domain.ProjectMember, error) {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+	if _, _, err := getAccessibleProject(c, pu.projectRepository, principal, projectID); err != nil {
+		return nil, err
+	}
+	return pu.projectRepository.ListProjectMembers(c, projectID)
+}
+
+// AddMember adds a member of the project's organization to a project the
+// caller manages.
+func (pu *ProjectUsecase) AddMember(ctx context.Context, projectID, userID, role string) (*domain.ProjectMember, error) {
+	if err := validateProjectRole(role); err != nil {
+		return nil, err
+	}
+
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+	project, err := pu.manageableProject(c, projectID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := pu.userRepository.GetUserByID(c, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := getMembership(c, pu.orgRepository, project.OrgID, user.ID); err != nil {
+		if errors.Is(err, domain.ErrOrganizationNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, err
+	}
+	member := &domain.ProjectMember{ProjectID: project.ID, UserID: user.ID, Role: role, CreatedAt: time.Now()}
+	if err := pu.projectRepository.AddProjectMember(c, member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// UpdateMemberRole changes a member's role in a project the caller manages.
+func (pu *ProjectUsecase) UpdateMemberRole(ctx context.Context, projectID, userID, role string) error {
+	if err := validateProjectRole(role); err != nil {
+		return err
+	}
+
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+	if _, err := pu.manageableProject(c, projectID); err != nil {
+		return err
+	}
+	err := pu.projectRepository.UpdateProjectMemberRole(c, projectID, userID, role)
+	if errors.Is(err, domain.ErrProjectNotFound) {
+		return domain.ErrUserNotFound
+	}
+	return err
+}
+
+// RemoveMember removes a user from a project. Members can always leave;
+// removing someone else requires managing the project.
+func (pu *ProjectUsecase) RemoveMember(ctx context.Context, projectID, userID string) error {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return err
+	}
+
+	c, cancel := context.WithTimeout(ctx, pu.contextTimeout)
+	defer cancel()
+	if userID == principal.UserID {
+		if _, _, err := getAccessibleProject(c, pu.projectRepository, principal, projectID); err != nil {
+			return err
+		}
+		return pu.projectRepository.RemoveProjectMember(c, projectID, userID)
+	}
+	if _, err := pu.manageableProject(c, projectID); err != nil {
+		return err
+	}
+	err = pu.projectRepository.RemoveProjectMember(c, projectID, userID)
+	if errors.Is(err, domain.ErrProjectNotFound) {
+		return domain.ErrUserNotFound
+	}
+	return err
+}
+
+// manageableProject returns a project of the caller's active organization
+// and fails unless the caller manages it, is an organization owner or admin,
+// or holds tasks:manage_all.
+func (pu *ProjectUsecase) manageableProject(ctx context.Context, id string) (*domain.Project, error) {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	project, member, err := getAccessibleProject(ctx, pu.projectRepository, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	if !principal.HasPermission(domain.PermissionTasksManageAll) && !principal.CanManageOrg() && !member.CanManage() {
+		return nil, fmt.Errorf("%w: only project managers can change the project", domain.ErrPermissionDenied)
+	}
+	return project, nil
+}
+
+// getAccessibleProject returns a project of the principal's active
+// organization together with the principal's membership, which is nil for
+// non-members. Projects are hidden from non-members who cannot see every
+// task of the organization.
+func getAccessibleProject(ctx context.Context, projectRepository domain.IProjectRepository, principal *domain.Principal, id string) (*domain.Project, *domain.ProjectMember, error) {
+	if id == "" {
+		return nil, nil, domain.NewValidationError("id", domain.ValidationRequired, "project ID is required")
+	}
+	project, err := projectRepository.GetProject(ctx, activeOrgID(principal), id)
+	if err != nil {
+		return nil, nil, err
+	}
+	member, err := projectRepository.GetProjectMember(ctx, project.ID, principal.UserID)
+	if err != nil && !errors.Is(err, domain.ErrProjectNotFound) {
+		return nil, nil, err
+	}
+	if member == nil && !canSeeAllTasks(principal) {
+		return nil, nil, domain.ErrProjectNotFound
+	}
+	return project, member, nil
+}
+
+// projectIDs returns the IDs of projects, or only of the archived ones.
+func projectIDs(projects []domain.Project, archivedOnly bool) []string {
+	var ids []string
+	for _, p := range projects {
+		if !archivedOnly || p.Archived {
+			ids = append(ids, p.ID)
+		}
+	}
+	return ids
+}
+
+func validateProjectName(project *domain.Project) error {
+	project.Name = strings.TrimSpace(project.Name)
+	if project.Name == "" {
+		return domain.NewValidationError("name", domain.ValidationRequired, "project name is required")
+	}
+	if len(project.Name) > MaxProjectNameLength {
+		return domain.NewValidationError("name", domain.ValidationTooLong, "project name must be at most 100 characters long")
+	}
+	return nil
+}
+
+func validateProjectRole(role string) error {
+	switch role {
+	case domain.ProjectRoleManager, domain.ProjectRoleEditor, domain.ProjectRoleViewer:
+		return nil
+	}
+	return domain.NewValidationError("role", domain.ValidationInvalid, "invalid project role: must be manager, editor or viewer")
+}
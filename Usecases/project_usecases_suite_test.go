@@ -0,0 +1,334 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// MockProjectRepository is a mock implementation of IProjectRepository
+type MockProjectRepository struct {
+	mock.Mock
+}
+
+func (m *MockProjectRepository) AddProject(ctx context.Context, project *domain.Project) error {
+	args := m.Called(ctx, project)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) GetProject(ctx context.Context, orgID, id string) (*domain.Project, error) {
+	args := m.Called(ctx, orgID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) ListProjects(ctx context.Context, query domain.ProjectQuery) ([]domain.Project, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Project), args.Error(1)
+}
+
+func (m *MockProjectRepository) UpdateProject(ctx context.Context, project *domain.Project) error {
+	args := m.Called(ctx, project)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) DeleteProject(ctx context.Context, orgID, id string) error {
+	args := m.Called(ctx, orgID, id)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) AddProjectMember(ctx context.Context, member *domain.ProjectMember) error {
+	args := m.Called(ctx, member)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) GetProjectMember(ctx context.Context, projectID, userID string) (*domain.ProjectMember, error) {
+	args := m.Called(ctx, projectID, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.ProjectMember), args.Error(1)
+}
+
+func (m *MockProjectRepository) ListProjectMembers(ctx context.Context, projectID string) ([]domain.ProjectMember, error) {
+	args := m.Called(ctx, projectID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.ProjectMember), args.Error(1)
+}
+
+func (m *MockProjectRepository) UpdateProjectMemberRole(ctx context.Context, projectID, userID, role string) error {
+	args := m.Called(ctx, projectID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) RemoveProjectMember(ctx context.Context, projectID, userID string) error {
+	args := m.Called(ctx, projectID, userID)
+	return args.Error(0)
+}
+
+func (m *MockProjectRepository) RemoveUserProjectMemberships(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// ProjectUsecaseTestSuite is a test suite for ProjectUsecase
+type ProjectUsecaseTestSuite struct {
+	suite.Suite
+	mockProjectRepo *MockProjectRepository
+	mockTaskRepo    *MockTaskRepository
+	mockOrgRepo     *MockOrganizationRepository
+	mockUserRepo    *MockUserRepository
+	usecase         *ProjectUsecase
+	ctx             context.Context
+	asManager       context.Context
+	asViewer        context.Context
+	asOutsider      context.Context
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *ProjectUsecaseTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	suite.asManager = withRole(suite.ctx, "manager1", domain.RoleUser)
+	suite.asViewer = withRole(suite.ctx, "viewer1", domain.RoleUser)
+	suite.asOutsider = withRole(suite.ctx, "outsider1", domain.RoleUser)
+}
+
+// SetupTest runs before each test
+func (suite *ProjectUsecaseTestSuite) SetupTest() {
+	suite.mockProjectRepo = new(MockProjectRepository)
+	suite.mockTaskRepo = new(MockTaskRepository)
+	suite.mockOrgRepo = new(MockOrganizationRepository)
+	suite.mockUserRepo = new(MockUserRepository)
+	suite.usecase = NewProjectUsecase(suite.mockProjectRepo, suite.mockTaskRepo, suite.mockOrgRepo, suite.mockUserRepo, 5*time.Second)
+}
+
+// TearDownTest runs after each test
+func (suite *ProjectUsecaseTestSuite) TearDownTest() {
+	suite.mockProjectRepo.AssertExpectations(suite.T())
+	suite.mockTaskRepo.AssertExpectations(suite.T())
+	suite.mockOrgRepo.AssertExpectations(suite.T())
+	suite.mockUserRepo.AssertExpectations(suite.T())
+}
+
+// expectProject stubs the lookup of proj1 and the membership of userID in
+// it; an empty role means userID is not a member.
+func (suite *ProjectUsecaseTestSuite) expectProject(userID, role string) *domain.Project {
+	project := &domain.Project{ID: "proj1", OrgID: domain.DefaultOrganizationID, Name: "Launch"}
+	suite.mockProjectRepo.On("GetProject", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "proj1").Return(project, nil).Once()
+	if role == "" {
+		suite.mockProjectRepo.On("GetProjectMember", mock.AnythingOfType("*context.timerCtx"), "proj1", userID).Return(nil, domain.ErrProjectNotFound).Once()
+	} else {
+		member := &domain.ProjectMember{ProjectID: "proj1", UserID: userID, Role: role}
+		suite.mockProjectRepo.On("GetProjectMember", mock.AnythingOfType("*context.timerCtx"), "proj1", userID).Return(member, nil).Once()
+	}
+	return project
+}
+
+// TestCreateProjectSuite tests the CreateProject method
+func (suite *ProjectUsecaseTestSuite) TestCreateProjectSuite() {
+	suite.Run("Success", func() {
+		project := &domain.Project{Name: " Launch ", Description: "Q3 release"}
+		suite.mockProjectRepo.On("AddProject", mock.AnythingOfType("*context.timerCtx"), project).
+			Run(func(args mock.Arguments) {
+				args.Get(1).(*domain.Project).ID = "proj1"
+			}).
+			Return(nil)
+		suite.mockProjectRepo.On("AddProjectMember", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(m *domain.ProjectMember) bool {
+			return m.ProjectID == "proj1" && m.UserID == "manager1" && m.Role == domain.ProjectRoleManager
+		})).Return(nil)
+
+		err := suite.usecase.CreateProject(suite.asManager, project)
+
+		suite.NoError(err)
+		suite.Equal("Launch", project.Name)
+		suite.Equal(domain.DefaultOrganizationID, project.OrgID)
+		suite.Equal("manager1", project.CreatedBy)
+	})
+
+	suite.Run("MissingName", func() {
+		err := suite.usecase.CreateProject(suite.asManager, &domain.Project{})
+
+		suite.Error(err)
+		suite.Equal("project name is required", err.Error())
+	})
+}
+
+// TestListProjectsSuite tests the ListProjects method
+func (suite *ProjectUsecaseTestSuite) TestListProjectsSuite() {
+	suite.Run("MemberSeesOwnProjects", func() {
+		query := domain.ProjectQuery{OrgID: domain.DefaultOrganizationID, MemberID: "viewer1"}
+		suite.mockProjectRepo.On("ListProjects", mock.AnythingOfType("*context.timerCtx"), query).Return([]domain.Project{{ID: "proj1"}}, nil)
+
+		projects, err := suite.usecase.ListProjects(suite.asViewer, false)
+
+		suite.NoError(err)
+		suite.Len(projects, 1)
+	})
+
+	suite.Run("OrganizationAdminSeesAll", func() {
+		orgAdmin := withOrganization(suite.asViewer, "org1", domain.OrgRoleAdmin)
+		query := domain.ProjectQuery{OrgID: "org1", IncludeArchived: true}
+		suite.mockProjectRepo.On("ListProjects", mock.AnythingOfType("*context.timerCtx"), query).Return([]domain.Project{}, nil)
+
+		_, err := suite.usecase.ListProjects(orgAdmin, true)
+
+		suite.NoError(err)
+	})
+}
+
+// TestGetProjectSuite tests the GetProject method
+func (suite *ProjectUsecaseTestSuite) TestGetProjectSuite() {
+	suite.Run("Member", func() {
+		expected := suite.expectProject("viewer1", domain.ProjectRoleViewer)
+
+		project, err := suite.usecase.GetProject(suite.asViewer, "proj1")
+
+		suite.NoError(err)
+		suite.Equal(expected, project)
+	})
+
+	suite.Run("NotMember", func() {
+		suite.expectProject("outsider1", "")
+
+		project, err := suite.usecase.GetProject(suite.asOutsider, "proj1")
+
+		suite.ErrorIs(err, domain.ErrProjectNotFound)
+		suite.Nil(project)
+	})
+}
+
+// TestUpdateProjectSuite tests the UpdateProject and SetProjectArchived methods
+func (suite *ProjectUsecaseTestSuite) TestUpdateProjectSuite() {
+	suite.Run("Manager", func() {
+		suite.expectProject("manager1", domain.ProjectRoleManager)
+		suite.mockProjectRepo.On("UpdateProject", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(p *domain.Project) bool {
+			return p.ID == "proj1" && p.Name == "Relaunch" && !p.Archived
+		})).Return(nil).Once()
+
+		project := &domain.Project{ID: "proj1", Name: "Relaunch"}
+		err := suite.usecase.UpdateProject(suite.asManager, project)
+
+		suite.NoError(err)
+		suite.Equal(domain.DefaultOrganizationID, project.OrgID)
+	})
+
+	suite.Run("ViewerCannotUpdate", func() {
+		suite.expectProject("viewer1", domain.ProjectRoleViewer)
+
+		err := suite.usecase.UpdateProject(suite.asViewer, &domain.Project{ID: "proj1", Name: "Relaunch"})
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+	})
+
+	suite.Run("Archive", func() {
+		suite.expectProject("manager1", domain.ProjectRoleManager)
+		suite.mockProjectRepo.On("UpdateProject", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(p *domain.Project) bool {
+			return p.ID == "proj1" && p.Archived
+		})).Return(nil).Once()
+
+		project, err := suite.usecase.SetProjectArchived(suite.asManager, "proj1", true)
+
+		suite.NoError(err)
+		suite.True(project.Archived)
+	})
+}
+
+// TestDeleteProjectSuite tests the DeleteProject method
+func (suite *ProjectUsecaseTestSuite) TestDeleteProjectSuite() {
+	taskQuery := domain.TaskQuery{OrgID: domain.DefaultOrganizationID, ProjectID: "proj1", SortBy: domain.TaskSortByID, Limit: 1}
+
+	suite.Run("Empty", func() {
+		suite.expectProject("manager1", domain.ProjectRoleManager)
+		suite.mockTaskRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx"), taskQuery).Return(&domain.TaskPage{}, nil).Once()
+		suite.mockProjectRepo.On("DeleteProject", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "proj1").Return(nil)
+
+		err := suite.usecase.DeleteProject(suite.asManager, "proj1")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("NotEmpty", func() {
+		suite.expectProject("manager1", domain.ProjectRoleManager)
+		suite.mockTaskRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx"), taskQuery).Return(&domain.TaskPage{Total: 2}, nil).Once()
+
+		err := suite.usecase.DeleteProject(suite.asManager, "proj1")
+
+		suite.ErrorIs(err, domain.ErrProjectNotEmpty)
+	})
+}
+
+// TestMembersSuite tests the project membership methods
+func (suite *ProjectUsecaseTestSuite) TestMembersSuite() {
+	suite.Run("AddMember", func() {
+		suite.expectProject("manager1", domain.ProjectRoleManager)
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user2").Return(&domain.User{ID: "user2"}, nil)
+		suite.mockProjectRepo.On("AddProjectMember", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("*domain.ProjectMember")).Return(nil)
+
+		member, err := suite.usecase.AddMember(suite.asManager, "proj1", "user2", domain.ProjectRoleViewer)
+
+		suite.NoError(err)
+		suite.Equal(domain.ProjectRoleViewer, member.Role)
+	})
+
+	suite.Run("AddMemberOutsideOrganization", func() {
+		project := &domain.Project{ID: "proj3", OrgID: "org1", Name: "Secret"}
+		manager := withOrganization(suite.asManager, "org1", domain.OrgRoleMember)
+		suite.mockProjectRepo.On("GetProject", mock.AnythingOfType("*context.timerCtx"), "org1", "proj3").Return(project, nil)
+		suite.mockProjectRepo.On("GetProjectMember", mock.AnythingOfType("*context.timerCtx"), "proj3", "manager1").Return(&domain.ProjectMember{Role: domain.ProjectRoleManager}, nil)
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user3").Return(&domain.User{ID: "user3"}, nil)
+		suite.mockOrgRepo.On("GetMembership", mock.AnythingOfType("*context.timerCtx"), "org1", "user3").Return(nil, domain.ErrOrganizationNotFound)
+
+		_, err := suite.usecase.AddMember(manager, "proj3", "user3", domain.ProjectRoleEditor)
+
+		suite.ErrorIs(err, domain.ErrUserNotFound)
+	})
+
+	suite.Run("InvalidRole", func() {
+		_, err := suite.usecase.AddMember(suite.asManager, "proj1", "user2", "owner")
+
+		suite.Error(err)
+	})
+
+	suite.Run("ViewerCannotAdd", func() {
+		suite.expectProject("viewer1", domain.ProjectRoleViewer)
+
+		_, err := suite.usecase.AddMember(suite.asViewer, "proj1", "user4", domain.ProjectRoleEditor)
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+	})
+
+	suite.Run("ViewerLeaves", func() {
+		suite.expectProject("viewer1", domain.ProjectRoleViewer)
+		suite.mockProjectRepo.On("RemoveProjectMember", mock.AnythingOfType("*context.timerCtx"), "proj1", "viewer1").Return(nil)
+
+		err := suite.usecase.RemoveMember(suite.asViewer, "proj1", "viewer1")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("UpdateUnknownMember", func() {
+		suite.expectProject("manager1", domain.ProjectRoleManager)
+		suite.mockProjectRepo.On("UpdateProjectMemberRole", mock.AnythingOfType("*context.timerCtx"), "proj1", "ghost", domain.ProjectRoleEditor).Return(domain.ErrProjectNotFound)
+
+		err := suite.usecase.UpdateMemberRole(suite.asManager, "proj1", "ghost", domain.ProjectRoleEditor)
+
+		suite.ErrorIs(err, domain.ErrUserNotFound)
+	})
+}
+
+// TestProjectUsecaseSuite runs the test suite
+func TestProjectUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(ProjectUsecaseTestSuite))
+}
@@ -0,0 +1,168 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"task_manager/domain"
+	"time"
+)
+
+// roleNamePattern keeps role names usable in URLs and JWT claims, e.g.
+// "project_manager" or "read-only-auditor".
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// RoleUsecase manages custom role definitions.
+type RoleUsecase struct {
+	roleRepository domain.IRoleRepository
+	userRepository domain.IUserRepository
+	contextTimeout time.Duration
+}
+
+func NewRoleUsecase(roleRepository domain.IRoleRepository, userRepository domain.IUserRepository, timeout time.Duration) *RoleUsecase {
+	return &RoleUsecase{
+		roleRepository: roleRepository,
+		userRepository: userRepository,
+		contextTimeout: timeout,
+	}
+}
+
+// ListRoles returns the built-in roles followed by the custom ones.
+func (ru *RoleUsecase) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	c, cancel := context.WithTimeout(ctx, ru.contextTimeout)
+	defer cancel()
+	custom, err := ru.roleRepository.ListRoles(c)
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]domain.Role, 0, len(custom)+2)
+	for _, name := range []string{domain.RoleUser, domain.RoleAdmin} {
+		role, _ := domain.BuiltInRole(name)
+		roles = append(roles, *role)
+	}
+	return append(roles, custom...), nil
+}
+
+// GetRole returns the built-in or custom role called name.
+func (ru *RoleUsecase) GetRole(ctx context.Context, name string) (*domain.Role, error) {
+	c, cancel := context.WithTimeout(ctx, ru.contextTimeout)
+	defer cancel()
+	return resolveRole(c, ru.roleRepository, name)
+}
+
+// CreateRole stores a new custom role. Callers can only grant permissions
+// they hold themselves.
+func (ru *RoleUsecase) CreateRole(ctx context.Context, role *domain.Role) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	if _, ok := domain.BuiltInRole(role.Name); ok {
+		return domain.ErrRoleExists
+	}
+	if err := ensureCanGrant(ctx, role); err != nil {
+		return err
+	}
+
+	c, cancel := context.WithTimeout(ctx, ru.contextTimeout)
+	defer cancel()
+	return ru.roleRepository.AddRole(c, role)
+}
+
+// UpdateRole replaces the description and permissions of a custom role.
+// Users holding the role are affected on their next request.
+func (ru *RoleUsecase) UpdateRole(ctx context.Context, role *domain.Role) error {
+	if err := validateRole(role); err != nil {
+		return err
+	}
+	if _, ok := domain.BuiltInRole(role.Name); ok {
+		return domain.ErrBuiltInRole
+	}
+	if err := ensureCanGrant(ctx, role); err != nil {
+		return err
+	}
+
+	c, cancel := context.WithTimeout(ctx, ru.contextTimeout)
+	defer cancel()
+	return ru.roleRepository.UpdateRole(c, role)
+}
+
+// DeleteRole removes a custom role nobody holds any more.
+func (ru *RoleUsecase) DeleteRole(ctx context.Context, name string) error {
+	if _, ok := domain.BuiltInRole(name); ok {
+		return domain.ErrBuiltInRole
+	}
+
+	c, cancel := context.WithTimeout(ctx, ru.contextTimeout)
+	defer cancel()
+	holders, err := ru.userRepository.ListUsers(c, domain.UserQuery{Role: name, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if holders.Total > 0 {
+		return domain.ErrRoleInUse
+	}
+	return ru.roleRepository.DeleteRole(c, name)
+}
+
+// validateRole checks the name and removes duplicate permissions.
+func validateRole(role *domain.Role) error {
+	if role == nil {
+		return domain.NewValidationError("", domain.ValidationRequired, "role cannot be nil")
+	}
+	if !roleNamePattern.MatchString(role.Name) {
+		return domain.NewValidationError("name", domain.ValidationInvalid, "role name must be 2-50 lowercase letters, digits, '_' or '-', starting with a letter")
+	}
+	if len(role.Permissions) == 0 {
+		return domain.NewValidationError("permissions", domain.ValidationRequired, "at least one permission is required")
+	}
+	seen := make(map[string]bool, len(role.Permissions))
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, p := range role.Permissions {
+		if !isKnownPermission(p) {
+			return domain.NewValidationError("permissions", domain.ValidationInvalid, fmt.Sprintf("unknown permission %q", p))
+		}
+		if !seen[p] {
+			seen[p] = true
+			permissions = append(permissions, p)
+		}
+	}
+	role.Permissions = permissions
+	role.BuiltIn = false
+	return nil
+}
+
+func isKnownPermission(permission string) bool {
+	for _, p := range domain.AllPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveRole looks name up among the built-in roles first, then the custom
+// ones.
+func resolveRole(ctx context.Context, roleRepository domain.IRoleRepository, name string) (*domain.Role, error) {
+	if role, ok := domain.BuiltInRole(name); ok {
+		return role, nil
+	}
+	if name == "" {
+		return nil, domain.ErrRoleNotFound
+	}
+	return roleRepository.GetRole(ctx, name)
+}
+
+// ensureCanGrant stops callers from handing out permissions they do not hold
+// themselves, e.g. a role with users:promote making someone an admin.
+func ensureCanGrant(ctx context.Context, role *domain.Role) error {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	for _, p := range role.Permissions {
+		if !principal.HasPermission(p) {
+			return fmt.Errorf("%w: cannot grant %q", domain.ErrPermissionDenied, p)
+		}
+	}
+	return nil
+}
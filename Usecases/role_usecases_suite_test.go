@@ -0,0 +1,224 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// MockRoleRepository is a mock implementation of IRoleRepository
+type MockRoleRepository struct {
+	mock.Mock
+}
+
+func (m *MockRoleRepository) AddRole(ctx context.Context, role *domain.Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) GetRole(ctx context.Context, name string) (*domain.Role, error) {
+	args := m.Called(ctx, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) ListRoles(ctx context.Context) ([]domain.Role, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) UpdateRole(ctx context.Context, role *domain.Role) error {
+	args := m.Called(ctx, role)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) DeleteRole(ctx context.Context, name string) error {
+	args := m.Called(ctx, name)
+	return args.Error(0)
+}
+
+// RoleUsecaseTestSuite is a test suite for RoleUsecase
+type RoleUsecaseTestSuite struct {
+	suite.Suite
+	mockRoleRepo *MockRoleRepository
+	mockUserRepo *MockUserRepository
+	usecase      *RoleUsecase
+	ctx          context.Context
+	asAdmin      context.Context
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *RoleUsecaseTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	suite.asAdmin = withRole(suite.ctx, "admin1", domain.RoleAdmin)
+}
+
+// SetupTest runs before each test
+func (suite *RoleUsecaseTestSuite) SetupTest() {
+	suite.mockRoleRepo = new(MockRoleRepository)
+	suite.mockUserRepo = new(MockUserRepository)
+	suite.usecase = NewRoleUsecase(suite.mockRoleRepo, suite.mockUserRepo, 5*time.Second)
+}
+
+// TearDownTest runs after each test
+func (suite *RoleUsecaseTestSuite) TearDownTest() {
+	suite.mockRoleRepo.AssertExpectations(suite.T())
+	suite.mockUserRepo.AssertExpectations(suite.T())
+}
+
+// TestListRolesSuite tests the ListRoles method
+func (suite *RoleUsecaseTestSuite) TestListRolesSuite() {
+	suite.Run("BuiltInRolesFirst", func() {
+		custom := []domain.Role{{Name: "auditor", Permissions: []string{domain.PermissionTasksRead}}}
+		suite.mockRoleRepo.On("ListRoles", mock.AnythingOfType("*context.timerCtx")).Return(custom, nil)
+
+		roles, err := suite.usecase.ListRoles(suite.ctx)
+
+		suite.NoError(err)
+		suite.Require().Len(roles, 3)
+		suite.Equal(domain.RoleUser, roles[0].Name)
+		suite.Equal(domain.RoleAdmin, roles[1].Name)
+		suite.True(roles[1].BuiltIn)
+		suite.Equal("auditor", roles[2].Name)
+	})
+}
+
+// TestGetRoleSuite tests the GetRole method
+func (suite *RoleUsecaseTestSuite) TestGetRoleSuite() {
+	suite.Run("BuiltIn", func() {
+		role, err := suite.usecase.GetRole(suite.ctx, domain.RoleAdmin)
+
+		suite.NoError(err)
+		suite.ElementsMatch(domain.AllPermissions, role.Permissions)
+	})
+
+	suite.Run("Custom", func() {
+		auditor := &domain.Role{Name: "auditor", Permissions: []string{domain.PermissionTasksRead}}
+		suite.mockRoleRepo.On("GetRole", mock.AnythingOfType("*context.timerCtx"), "auditor").Return(auditor, nil)
+
+		role, err := suite.usecase.GetRole(suite.ctx, "auditor")
+
+		suite.NoError(err)
+		suite.Equal(auditor, role)
+	})
+
+	suite.Run("NotFound", func() {
+		suite.mockRoleRepo.On("GetRole", mock.AnythingOfType("*context.timerCtx"), "ghost").Return(nil, domain.ErrRoleNotFound)
+
+		role, err := suite.usecase.GetRole(suite.ctx, "ghost")
+
+		suite.ErrorIs(err, domain.ErrRoleNotFound)
+		suite.Nil(role)
+	})
+}
+
+// TestCreateRoleSuite tests the CreateRole method
+func (suite *RoleUsecaseTestSuite) TestCreateRoleSuite() {
+	suite.Run("Success", func() {
+		role := &domain.Role{
+			Name:        "project_manager",
+			Description: "Manages every task",
+			Permissions: []string{domain.PermissionTasksRead, domain.PermissionTasksReadAll, domain.PermissionTasksManageAll, domain.PermissionTasksRead},
+		}
+		suite.mockRoleRepo.On("AddRole", mock.AnythingOfType("*context.timerCtx"), role).Return(nil)
+
+		err := suite.usecase.CreateRole(suite.asAdmin, role)
+
+		suite.NoError(err)
+		suite.Equal([]string{domain.PermissionTasksRead, domain.PermissionTasksReadAll, domain.PermissionTasksManageAll}, role.Permissions)
+	})
+
+	suite.Run("BuiltInName", func() {
+		err := suite.usecase.CreateRole(suite.asAdmin, &domain.Role{Name: domain.RoleAdmin, Permissions: []string{domain.PermissionTasksRead}})
+
+		suite.ErrorIs(err, domain.ErrRoleExists)
+	})
+
+	suite.Run("InvalidName", func() {
+		err := suite.usecase.CreateRole(suite.asAdmin, &domain.Role{Name: "Project Manager", Permissions: []string{domain.PermissionTasksRead}})
+
+		suite.Error(err)
+	})
+
+	suite.Run("UnknownPermission", func() {
+		err := suite.usecase.CreateRole(suite.asAdmin, &domain.Role{Name: "auditor", Permissions: []string{"tasks:everything"}})
+
+		suite.Error(err)
+		suite.Equal(`unknown permission "tasks:everything"`, err.Error())
+	})
+
+	suite.Run("NoPermissions", func() {
+		err := suite.usecase.CreateRole(suite.asAdmin, &domain.Role{Name: "auditor"})
+
+		suite.Error(err)
+		suite.Equal("at least one permission is required", err.Error())
+	})
+
+	suite.Run("CannotGrantMissingPermissions", func() {
+		manager := domain.WithPrincipal(suite.ctx, &domain.Principal{UserID: "rm1", Role: "role_manager", Permissions: []string{domain.PermissionRolesManage}})
+
+		err := suite.usecase.CreateRole(manager, &domain.Role{Name: "superuser", Permissions: []string{domain.PermissionUsersManage}})
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+	})
+}
+
+// TestUpdateRoleSuite tests the UpdateRole method
+func (suite *RoleUsecaseTestSuite) TestUpdateRoleSuite() {
+	suite.Run("Success", func() {
+		role := &domain.Role{Name: "auditor", Permissions: []string{domain.PermissionTasksRead, domain.PermissionTasksReadAll}}
+		suite.mockRoleRepo.On("UpdateRole", mock.AnythingOfType("*context.timerCtx"), role).Return(nil)
+
+		err := suite.usecase.UpdateRole(suite.asAdmin, role)
+
+		suite.NoError(err)
+	})
+
+	suite.Run("BuiltIn", func() {
+		err := suite.usecase.UpdateRole(suite.asAdmin, &domain.Role{Name: domain.RoleUser, Permissions: []string{domain.PermissionTasksRead}})
+
+		suite.ErrorIs(err, domain.ErrBuiltInRole)
+	})
+}
+
+// TestDeleteRoleSuite tests the DeleteRole method
+func (suite *RoleUsecaseTestSuite) TestDeleteRoleSuite() {
+	suite.Run("Success", func() {
+		suite.mockUserRepo.On("ListUsers", mock.AnythingOfType("*context.timerCtx"), domain.UserQuery{Role: "auditor", Limit: 1}).Return(&domain.UserPage{}, nil)
+		suite.mockRoleRepo.On("DeleteRole", mock.AnythingOfType("*context.timerCtx"), "auditor").Return(nil)
+
+		err := suite.usecase.DeleteRole(suite.ctx, "auditor")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("InUse", func() {
+		page := &domain.UserPage{Users: []domain.User{{ID: "user1", Role: "project_manager"}}, Total: 1}
+		suite.mockUserRepo.On("ListUsers", mock.AnythingOfType("*context.timerCtx"), domain.UserQuery{Role: "project_manager", Limit: 1}).Return(page, nil)
+
+		err := suite.usecase.DeleteRole(suite.ctx, "project_manager")
+
+		suite.ErrorIs(err, domain.ErrRoleInUse)
+	})
+
+	suite.Run("BuiltIn", func() {
+		err := suite.usecase.DeleteRole(suite.ctx, domain.RoleAdmin)
+
+		suite.ErrorIs(err, domain.ErrBuiltInRole)
+	})
+}
+
+// TestRoleUsecaseSuite runs the test suite
+func TestRoleUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(RoleUsecaseTestSuite))
+}
@@ -3,121 +3,590 @@ package usecases
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"task_manager/domain"
 	"time"
 )
 
 type TaskUsecase struct {
-	taskRepository domain.ITaskRepository
-	contextTimeout time.Duration
+	taskRepository    domain.ITaskRepository
+	projectRepository domain.IProjectRepository
+	historyRepository domain.ITaskHistoryRepository
+	workflow          *domain.Workflow
+	contextTimeout    time.Duration
 }
 
-func NewTaskUsecase(taskRepository domain.ITaskRepository, timeout time.Duration) *TaskUsecase {
+// NewTaskUsecase returns a TaskUsecase enforcing workflow, or
+// domain.DefaultWorkflow when workflow is nil.
+func NewTaskUsecase(taskRepository domain.ITaskRepository, projectRepository domain.IProjectRepository, historyRepository domain.ITaskHistoryRepository, workflow *domain.Workflow, timeout time.Duration) *TaskUsecase {
+	if workflow == nil {
+		workflow = domain.DefaultWorkflow()
+	}
 	return &TaskUsecase{
-		taskRepository: taskRepository,
-		contextTimeout: timeout,
+		taskRepository:    taskRepository,
+		projectRepository: projectRepository,
+		historyRepository: historyRepository,
+		workflow:          workflow,
+		contextTimeout:    timeout,
 	}
 }
 
+// Workflow returns the status workflow tasks follow.
+func (tu *TaskUsecase) Workflow() *domain.Workflow {
+	return tu.workflow
+}
+
+// Create stores a new task owned by the authenticated caller in their active
+// organization and records it in the task's history.
 func (tu *TaskUsecase) Create(c context.Context, task *domain.Task) error {
+	requester, err := currentPrincipal(c)
+	if err != nil {
+		return err
+	}
 	// Validate task data
 	if task == nil {
-		return errors.New("task cannot be nil")
+		return domain.NewValidationError("", domain.ValidationRequired, "task cannot be nil")
 	}
-	if task.Title == "" {
-		return errors.New("title is required")
+	if task.ID != "" {
+		return domain.NewValidationError("id", domain.ValidationReadOnly, "task ID is assigned by the server and must not be provided")
 	}
-	if task.Description == "" {
-		return errors.New("description is required")
+	if err := tu.validateTaskFields(task); err != nil {
+		return err
 	}
-	if task.Status == "" {
-		return errors.New("status is required")
+
+	task.CreatedBy = requester.UserID
+	task.OrgID = activeOrgID(requester)
+
+	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
+	defer cancel()
+	if task.ProjectID != "" {
+		if err := tu.ensureCanAddToProject(ctx, requester, task.ProjectID); err != nil {
+			return err
+		}
 	}
-	if task.DueDate.IsZero() {
-		return errors.New("due date is required")
-	}
-	
-	// Validate status values
-	validStatuses := []string{"pending", "in_progress", "completed", "cancelled"}
-	statusValid := false
-	for _, status := range validStatuses {
-		if task.Status == status {
-			statusValid = true
-			break
+	if err := tu.taskRepository.AddTask(ctx, task); err != nil {
+		return err
+	}
+	return tu.recordHistory(ctx, requester, domain.TaskActionCreated, nil, task)
+}
+
+const (
+	// DefaultTaskPageSize is the page size used when a listing sets no limit.
+	DefaultTaskPageSize = 20
+	// MaxTaskPageSize is the largest page a single listing may request.
+	MaxTaskPageSize = 100
+)
+
+// GetAllTasks returns one page of the tasks of the caller's active
+// organization that they can see and that match query. Tasks of archived
+// projects are left out unless query asks for them or for a single project.
+// An empty SortBy sorts by ID (creation order) and a zero Limit uses
+// DefaultTaskPageSize.
+func (tu *TaskUsecase) GetAllTasks(c context.Context, query domain.TaskQuery) (*domain.TaskPage, error) {
+	requester, err := currentPrincipal(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := tu.validateTaskQuery(&query); err != nil {
+		return nil, err
+	}
+	query.OrgID = activeOrgID(requester)
+	query.VisibleTo = ""
+	query.VisibleProjectIDs = nil
+	query.ExcludeProjectIDs = nil
+	query.Deleted = false
+
+	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
+	defer cancel()
+	if !canSeeAllTasks(requester) {
+		query.VisibleTo = requester.UserID
+		projects, err := tu.projectRepository.ListProjects(ctx, domain.ProjectQuery{OrgID: query.OrgID, MemberID: requester.UserID, IncludeArchived: true})
+		if err != nil {
+			return nil, err
+		}
+		query.VisibleProjectIDs = projectIDs(projects, false)
+	}
+	if query.ProjectID == "" && !query.IncludeArchived {
+		projects, err := tu.projectRepository.ListProjects(ctx, domain.ProjectQuery{OrgID: query.OrgID, IncludeArchived: true})
+		if err != nil {
+			return nil, err
 		}
+		query.ExcludeProjectIDs = projectIDs(projects, true)
 	}
-	if !statusValid {
-		return errors.New("invalid status: must be pending, in_progress, completed, or cancelled")
+	return tu.taskRepository.GetAllTasks(ctx, query)
+}
+
+// ListProjectTasks returns one page of the tasks of a project. Project
+// members see every task of the project, archived or not.
+func (tu *TaskUsecase) ListProjectTasks(c context.Context, projectID string, query domain.TaskQuery) (*domain.TaskPage, error) {
+	requester, err := currentPrincipal(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := tu.validateTaskQuery(&query); err != nil {
+		return nil, err
 	}
 
 	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
 	defer cancel()
-	return tu.taskRepository.AddTask(ctx, task)
+	if _, _, err := getAccessibleProject(ctx, tu.projectRepository, requester, projectID); err != nil {
+		return nil, err
+	}
+	query.OrgID = activeOrgID(requester)
+	query.ProjectID = projectID
+	query.VisibleTo = ""
+	query.VisibleProjectIDs = nil
+	query.ExcludeProjectIDs = nil
+	query.Deleted = false
+	return tu.taskRepository.GetAllTasks(ctx, query)
 }
 
-func (tu *TaskUsecase) GetAllTasks(c context.Context) ([]domain.Task, error) {
+// ListDeletedTasks returns one page of the tasks in the trash of the
+// caller's active organization. Only callers who see every task of the
+// organization can look into the trash.
+func (tu *TaskUsecase) ListDeletedTasks(c context.Context, query domain.TaskQuery) (*domain.TaskPage, error) {
+	requester, err := currentPrincipal(c)
+	if err != nil {
+		return nil, err
+	}
+	if !canSeeAllTasks(requester) {
+		return nil, fmt.Errorf("%w: only organization admins and holders of tasks:read_all can see the trash", domain.ErrPermissionDenied)
+	}
+	if err := tu.validateTaskQuery(&query); err != nil {
+		return nil, err
+	}
+	query.OrgID = activeOrgID(requester)
+	query.VisibleTo = ""
+	query.VisibleProjectIDs = nil
+	query.ExcludeProjectIDs = nil
+	query.Deleted = true
+
 	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
 	defer cancel()
-	return tu.taskRepository.GetAllTasks(ctx)
+	return tu.taskRepository.GetAllTasks(ctx, query)
+}
+
+// validateTaskQuery checks the filters, sorting and limit of a listing and
+// fills in their defaults.
+func (tu *TaskUsecase) validateTaskQuery(query *domain.TaskQuery) error {
+	if query.SortBy == "" {
+		query.SortBy = domain.TaskSortByID
+	}
+	switch query.SortBy {
+	case domain.TaskSortByID, domain.TaskSortByTitle, domain.TaskSortByDueDate, domain.TaskSortByStatus:
+	default:
+		return fmt.Errorf("%w: cannot sort by %q", domain.ErrInvalidTaskQuery, query.SortBy)
+	}
+	if query.Limit == 0 {
+		query.Limit = DefaultTaskPageSize
+	}
+	if query.Limit < 0 || query.Limit > MaxTaskPageSize {
+		return fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidTaskQuery, MaxTaskPageSize)
+	}
+	if query.Status != "" && !tu.workflow.HasState(query.Status) {
+		return fmt.Errorf("%w: invalid status filter %q", domain.ErrInvalidTaskQuery, query.Status)
+	}
+	if !query.DueAfter.IsZero() && !query.DueBefore.IsZero() && query.DueAfter.After(query.DueBefore) {
+		return fmt.Errorf("%w: due_after must not be later than due_before", domain.ErrInvalidTaskQuery)
+	}
+	return nil
 }
 
+// GetTaskByID returns a task the caller owns, is assigned to or can see
+// through its project, or any task with tasks:read_all. Tasks the caller
+// cannot access are reported as not found.
 func (tu *TaskUsecase) GetTaskByID(c context.Context, id string) (*domain.Task, error) {
+	requester, err := currentPrincipal(c)
+	if err != nil {
+		return nil, err
+	}
 	if id == "" {
-		return nil, errors.New("task ID is required")
+		return nil, domain.NewValidationError("id", domain.ValidationRequired, "task ID is required")
 	}
-	
+
 	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
 	defer cancel()
-	return tu.taskRepository.GetTaskByID(ctx, id)
+	return tu.getAccessibleTask(ctx, requester, id, false)
 }
 
+// UpdateTask replaces a task the caller owns, is assigned to or edits through
+// its project, or any task with tasks:manage_all. The creator of a task never
+// changes. Moving a task into a project requires editing rights there, and
+// status changes must follow the workflow. A non-zero task.Version is the
+// version the caller expects the task to be at; if it moved on the update
+// fails with domain.ErrVersionConflict. On success task.Version is the new
+// version.
 func (tu *TaskUsecase) UpdateTask(c context.Context, task *domain.Task) error {
+	requester, err := currentPrincipal(c)
+	if err != nil {
+		return err
+	}
 	// Validate task data
 	if task == nil {
-		return errors.New("task cannot be nil")
+		return domain.NewValidationError("", domain.ValidationRequired, "task cannot be nil")
 	}
 	if task.ID == "" {
-		return errors.New("task ID is required")
+		return domain.NewValidationError("id", domain.ValidationRequired, "task ID is required")
 	}
-	if task.Title == "" {
-		return errors.New("title is required")
+	if err := tu.validateTaskFields(task); err != nil {
+		return err
 	}
-	if task.Description == "" {
-		return errors.New("description is required")
+
+	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
+	defer cancel()
+	existing, err := tu.getAccessibleTask(ctx, requester, task.ID, true)
+	if err != nil {
+		return err
 	}
-	if task.Status == "" {
-		return errors.New("status is required")
+	if err := checkVersion(existing, task.Version); err != nil {
+		return err
 	}
-	if task.DueDate.IsZero() {
-		return errors.New("due date is required")
-	}
-	
-	// Validate status values
-	validStatuses := []string{"pending", "in_progress", "completed", "cancelled"}
-	statusValid := false
-	for _, status := range validStatuses {
-		if task.Status == status {
-			statusValid = true
-			break
+	if err := tu.workflow.CheckTransition(existing.Status, task.Status, requester.Role); err != nil {
+		return err
+	}
+	if task.ProjectID != "" && task.ProjectID != existing.ProjectID {
+		if err := tu.ensureCanAddToProject(ctx, requester, task.ProjectID); err != nil {
+			return err
+		}
+	}
+	task.CreatedBy = existing.CreatedBy
+	task.OrgID = existing.OrgID
+	task.Version = existing.Version
+	if err := tu.taskRepository.UpdateTask(ctx, task); err != nil {
+		return err
+	}
+	return tu.recordHistory(ctx, requester, domain.TaskActionUpdated, existing, task)
+}
+
+// TransitionTask moves a task the caller can update to status, following the
+// workflow. Invalid transitions fail with a *domain.TransitionError listing
+// the statuses the caller could move the task to instead.
+func (tu *TaskUsecase) TransitionTask(c context.Context, id, status string) (*domain.Task, error) {
+	requester, err := currentPrincipal(c)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, domain.NewValidationError("id", domain.ValidationRequired, "task ID is required")
+	}
+	if status == "" {
+		return nil, domain.NewValidationError("status", domain.ValidationRequired, "status is required")
+	}
+	if !tu.workflow.HasState(status) {
+		return nil, tu.invalidStatusError()
+	}
+
+	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
+	defer cancel()
+	task, err := tu.getAccessibleTask(ctx, requester, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := tu.workflow.CheckTransition(task.Status, status, requester.Role); err != nil {
+		return nil, err
+	}
+	if task.Status == status {
+		return task, nil
+	}
+	before := *task
+	task.Status = status
+	if err := tu.taskRepository.UpdateTask(ctx, task); err != nil {
+		return nil, err
+	}
+	if err := tu.recordHistory(ctx, requester, domain.TaskActionTransitioned, &before, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// PatchTask changes only the fields set in patch on a task the caller can
+// update. The patched task has to pass the same checks as in UpdateTask,
+// and version works the same way. It returns the patched task.
+func (tu *TaskUsecase) PatchTask(c context.Context, id string, version int64, patch domain.TaskPatch) (*domain.Task, error) {
+	requester, err := currentPrincipal(c)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, domain.NewValidationError("id", domain.ValidationRequired, "task ID is required")
+	}
+
+	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
+	defer cancel()
+	existing, err := tu.getAccessibleTask(ctx, requester, id, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(existing, version); err != nil {
+		return nil, err
+	}
+	if patch.IsEmpty() {
+		return existing, nil
+	}
+	task := *existing
+	patch.Apply(&task)
+	if err := tu.validateTaskFields(&task); err != nil {
+		return nil, err
+	}
+	if err := tu.workflow.CheckTransition(existing.Status, task.Status, requester.Role); err != nil {
+		return nil, err
+	}
+	if task.ProjectID != "" && task.ProjectID != existing.ProjectID {
+		if err := tu.ensureCanAddToProject(ctx, requester, task.ProjectID); err != nil {
+			return nil, err
 		}
 	}
-	if !statusValid {
-		return errors.New("invalid status: must be pending, in_progress, completed, or cancelled")
+	if err := tu.taskRepository.PatchTask(ctx, existing.OrgID, id, existing.Version, patch); err != nil {
+		return nil, err
+	}
+	task.Version = existing.Version + 1
+	if err := tu.recordHistory(ctx, requester, domain.TaskActionUpdated, existing, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// DeleteTask moves a task the caller owns, is assigned to or edits through
+// its project, or any task with tasks:manage_all, to the trash. A non-zero
+// version must match the task's current version, as in UpdateTask.
+func (tu *TaskUsecase) DeleteTask(c context.Context, id string, version int64) error {
+	requester, err := currentPrincipal(c)
+	if err != nil {
+		return err
+	}
+	if id == "" {
+		return domain.NewValidationError("id", domain.ValidationRequired, "task ID is required")
+	}
+
+	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
+	defer cancel()
+	task, err := tu.getAccessibleTask(ctx, requester, id, true)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(task, version); err != nil {
+		return err
+	}
+	deleted := *task
+	deleted.DeletedAt = time.Now()
+	deleted.DeletedBy = requester.UserID
+	if err := tu.taskRepository.DeleteTask(ctx, &deleted); err != nil {
+		return err
+	}
+	return tu.recordHistory(ctx, requester, domain.TaskActionDeleted, task, &deleted)
+}
+
+// RestoreTask takes a task of the caller's active organization out of the
+// trash. It requires tasks:manage_all or managing the organization.
+func (tu *TaskUsecase) RestoreTask(c context.Context, id string) (*domain.Task, error) {
+	requester, err := currentPrincipal(c)
+	if err != nil {
+		return nil, err
+	}
+	if !canManageAllTasks(requester) {
+		return nil, fmt.Errorf("%w: only organization admins and holders of tasks:manage_all can restore tasks", domain.ErrPermissionDenied)
+	}
+	if id == "" {
+		return nil, domain.NewValidationError("id", domain.ValidationRequired, "task ID is required")
+	}
+
+	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
+	defer cancel()
+	deleted, err := tu.taskRepository.RestoreTask(ctx, activeOrgID(requester), id)
+	if err != nil {
+		return nil, err
+	}
+	task := *deleted
+	task.DeletedAt = time.Time{}
+	task.DeletedBy = ""
+	task.Version++
+	if err := tu.recordHistory(ctx, requester, domain.TaskActionRestored, deleted, &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// PurgeDeletedTasks permanently removes the tasks that have been in the
+// trash for longer than retention, across every organization, and returns
+// how many were removed. It is run periodically in the background.
+func (tu *TaskUsecase) PurgeDeletedTasks(c context.Context, retention time.Duration) (int64, error) {
+	if retention < 0 {
+		return 0, domain.NewValidationError("retention", domain.ValidationInvalid, "retention must not be negative")
 	}
 
 	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
 	defer cancel()
-	return tu.taskRepository.UpdateTask(ctx, task)
+	return tu.taskRepository.PurgeDeletedTasks(ctx, time.Now().Add(-retention))
 }
 
-func (tu *TaskUsecase) DeleteTask(c context.Context, id string) error {
+// GetTaskHistory returns every recorded change of a task the caller can
+// see, oldest first. The history of deleted tasks stays readable for
+// callers who see every task of the organization.
+func (tu *TaskUsecase) GetTaskHistory(c context.Context, id string) ([]domain.TaskHistoryEntry, error) {
+	requester, err := currentPrincipal(c)
+	if err != nil {
+		return nil, err
+	}
 	if id == "" {
-		return errors.New("task ID is required")
+		return nil, domain.NewValidationError("id", domain.ValidationRequired, "task ID is required")
 	}
-	
+
 	ctx, cancel := context.WithTimeout(c, tu.contextTimeout)
 	defer cancel()
-	return tu.taskRepository.DeleteTask(ctx, id)
+	if _, err := tu.getAccessibleTask(ctx, requester, id, false); err != nil {
+		// The task may be gone from the trash; its history outlives it.
+		if !errors.Is(err, domain.ErrTaskNotFound) || !canSeeAllTasks(requester) {
+			return nil, err
+		}
+	}
+	return tu.historyRepository.ListEntries(ctx, activeOrgID(requester), id)
+}
+
+// recordHistory records a change requester made to a task.
+func (tu *TaskUsecase) recordHistory(ctx context.Context, requester *domain.Principal, action string, before, after *domain.Task) error {
+	return recordTaskHistory(ctx, tu.historyRepository, requester.UserID, action, before, after)
+}
+
+// recordTaskHistory stores the difference between two versions of a task
+// as a history entry made by actorID. Changes that leave every field as it
+// was are not recorded.
+func recordTaskHistory(ctx context.Context, historyRepository domain.ITaskHistoryRepository, actorID, action string, before, after *domain.Task) error {
+	changes := domain.DiffTasks(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	task := after
+	if task == nil {
+		task = before
+	}
+	entry := &domain.TaskHistoryEntry{
+		TaskID:    task.ID,
+		OrgID:     task.OrgID,
+		Action:    action,
+		ActorID:   actorID,
+		CreatedAt: time.Now(),
+		Changes:   changes,
+	}
+	return historyRepository.AddEntry(ctx, entry)
+}
+
+// invalidStatusError lists the workflow's states, e.g. "invalid status: must
+// be pending, in_progress, completed, or cancelled".
+func (tu *TaskUsecase) invalidStatusError() error {
+	states := tu.workflow.States
+	if len(states) == 1 {
+		return fmt.Errorf("%w: must be %s", domain.ErrInvalidStatus, states[0])
+	}
+	return fmt.Errorf("%w: must be %s, or %s", domain.ErrInvalidStatus, strings.Join(states[:len(states)-1], ", "), states[len(states)-1])
+}
+
+// validateTaskFields checks the fields every stored task needs, reporting
+// every missing one at once.
+func (tu *TaskUsecase) validateTaskFields(task *domain.Task) error {
+	var errs domain.ValidationErrors
+	if task.Title == "" {
+		errs.Add("title", domain.ValidationRequired, "title is required")
+	}
+	if task.Description == "" {
+		errs.Add("description", domain.ValidationRequired, "description is required")
+	}
+	if task.Status == "" {
+		errs.Add("status", domain.ValidationRequired, "status is required")
+	}
+	if task.DueDate.IsZero() {
+		errs.Add("due_date", domain.ValidationRequired, "due date is required")
+	}
+	if err := errs.Err(); err != nil {
+		return err
+	}
+	if !tu.workflow.HasState(task.Status) {
+		return tu.invalidStatusError()
+	}
+	return nil
+}
+
+// checkVersion fails with domain.ErrVersionConflict unless expected is zero
+// or the task's current version.
+func checkVersion(task *domain.Task, expected int64) error {
+	if expected != 0 && expected != task.Version {
+		return domain.ErrVersionConflict
+	}
+	return nil
+}
+
+// getAccessibleTask loads a task of the requester's active organization and
+// hides it from requesters who are neither its creator nor its assignee nor
+// a member of its project, unless they hold tasks:read_all (tasks:manage_all
+// for write) or manage the organization. Writing through a project requires
+// the editor or manager role there.
+func (tu *TaskUsecase) getAccessibleTask(ctx context.Context, requester *domain.Principal, id string, write bool) (*domain.Task, error) {
+	task, err := tu.taskRepository.GetTaskByID(ctx, activeOrgID(requester), id)
+	if err != nil {
+		return nil, err
+	}
+	allPermission := domain.PermissionTasksReadAll
+	if write {
+		allPermission = domain.PermissionTasksManageAll
+	}
+	if requester.HasPermission(allPermission) || requester.CanManageOrg() || task.CreatedBy == requester.UserID || task.AssigneeID == requester.UserID {
+		return task, nil
+	}
+	if task.ProjectID != "" {
+		member, err := tu.projectRepository.GetProjectMember(ctx, task.ProjectID, requester.UserID)
+		if err != nil && !errors.Is(err, domain.ErrProjectNotFound) {
+			return nil, err
+		}
+		if member != nil && (!write || member.CanEdit()) {
+			return task, nil
+		}
+	}
+	return nil, domain.ErrTaskNotFound
+}
+
+// ensureCanAddToProject fails unless the requester may put tasks into the
+// project: it must be active and the requester an editor or manager of it,
+// an organization owner or admin, or hold tasks:manage_all.
+func (tu *TaskUsecase) ensureCanAddToProject(ctx context.Context, requester *domain.Principal, projectID string) error {
+	project, member, err := getAccessibleProject(ctx, tu.projectRepository, requester, projectID)
+	if err != nil {
+		return err
+	}
+	if project.Archived {
+		return domain.ErrProjectArchived
+	}
+	if !canManageAllTasks(requester) && !member.CanEdit() {
+		return fmt.Errorf("%w: only project editors and managers can add tasks", domain.ErrPermissionDenied)
+	}
+	return nil
+}
+
+// canSeeAllTasks reports whether the principal sees every task of its
+// active organization.
+func canSeeAllTasks(principal *domain.Principal) bool {
+	return principal.HasPermission(domain.PermissionTasksReadAll) || principal.CanManageOrg()
+}
+
+// canManageAllTasks reports whether the principal may change every task of
+// its active organization.
+func canManageAllTasks(principal *domain.Principal) bool {
+	return principal.HasPermission(domain.PermissionTasksManageAll) || principal.CanManageOrg()
 }
 
+// activeOrgID returns the organization the principal's token is scoped to.
+func activeOrgID(principal *domain.Principal) string {
+	if principal.OrgID == "" {
+		return domain.DefaultOrganizationID
+	}
+	return principal.OrgID
+}
 
+// currentPrincipal returns the authenticated caller stored in ctx by the
+// delivery layer.
+func currentPrincipal(ctx context.Context) (*domain.Principal, error) {
+	principal, ok := domain.PrincipalFromContext(ctx)
+	if !ok || principal.UserID == "" {
+		return nil, domain.NewError(domain.ErrUnauthorized, "unauthenticated", "authenticated user is required")
+	}
+	return principal, nil
+}
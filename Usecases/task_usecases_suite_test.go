@@ -3,6 +3,7 @@ package usecases
 import (
 	"context"
 	"errors"
+	"reflect"
 	"testing"
 	"time"
 
@@ -22,13 +23,16 @@ func (m *MockTaskRepository) AddTask(ctx context.Context, task *domain.Task) err
 	return args.Error(0)
 }
 
-func (m *MockTaskRepository) GetAllTasks(ctx context.Context) ([]domain.Task, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]domain.Task), args.Error(1)
+func (m *MockTaskRepository) GetAllTasks(ctx context.Context, query domain.TaskQuery) (*domain.TaskPage, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.TaskPage), args.Error(1)
 }
 
-func (m *MockTaskRepository) GetTaskByID(ctx context.Context, id string) (*domain.Task, error) {
-	args := m.Called(ctx, id)
+func (m *MockTaskRepository) GetTaskByID(ctx context.Context, orgID, id string) (*domain.Task, error) {
+	args := m.Called(ctx, orgID, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -40,55 +44,175 @@ func (m *MockTaskRepository) UpdateTask(ctx context.Context, task *domain.Task)
 	return args.Error(0)
 }
 
-func (m *MockTaskRepository) DeleteTask(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+func (m *MockTaskRepository) PatchTask(ctx context.Context, orgID, id string, version int64, patch domain.TaskPatch) error {
+	args := m.Called(ctx, orgID, id, version, patch)
+	return args.Error(0)
+}
+
+func (m *MockTaskRepository) DeleteTask(ctx context.Context, task *domain.Task) error {
+	args := m.Called(ctx, task)
+	return args.Error(0)
+}
+
+func (m *MockTaskRepository) RestoreTask(ctx context.Context, orgID, id string) (*domain.Task, error) {
+	args := m.Called(ctx, orgID, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) PurgeDeletedTasks(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockTaskRepository) ReassignTasks(ctx context.Context, fromUserID, toUserID string, orgIDs []string) error {
+	args := m.Called(ctx, fromUserID, toUserID, orgIDs)
 	return args.Error(0)
 }
 
+func (m *MockTaskRepository) DeleteTasksByCreator(ctx context.Context, userID string, orgIDs []string, deletedBy string, deletedAt time.Time) ([]domain.Task, error) {
+	args := m.Called(ctx, userID, orgIDs, deletedBy, deletedAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) UnassignTasks(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// MockTaskHistoryRepository is a mock implementation of ITaskHistoryRepository
+type MockTaskHistoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockTaskHistoryRepository) AddEntry(ctx context.Context, entry *domain.TaskHistoryEntry) error {
+	args := m.Called(ctx, entry)
+	return args.Error(0)
+}
+
+func (m *MockTaskHistoryRepository) ListEntries(ctx context.Context, orgID, taskID string) ([]domain.TaskHistoryEntry, error) {
+	args := m.Called(ctx, orgID, taskID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]domain.TaskHistoryEntry), args.Error(1)
+}
+
+// withRole returns ctx carrying a principal with the permissions of the
+// built-in role.
+func withRole(ctx context.Context, userID, role string) context.Context {
+	builtIn, _ := domain.BuiltInRole(role)
+	return domain.WithPrincipal(ctx, &domain.Principal{UserID: userID, Role: role, Permissions: builtIn.Permissions})
+}
+
+// withOrganization returns a copy of ctx whose principal is active in orgID
+// with the given organization role.
+func withOrganization(ctx context.Context, orgID, orgRole string) context.Context {
+	p, _ := domain.PrincipalFromContext(ctx)
+	scoped := *p
+	scoped.OrgID = orgID
+	scoped.OrgRole = orgRole
+	return domain.WithPrincipal(ctx, &scoped)
+}
+
 // TaskUsecaseTestSuite is a test suite for TaskUsecase
 type TaskUsecaseTestSuite struct {
 	suite.Suite
-	mockRepo *MockTaskRepository
-	usecase  *TaskUsecase
-	ctx      context.Context
+	mockRepo        *MockTaskRepository
+	mockProjectRepo *MockProjectRepository
+	mockHistoryRepo *MockTaskHistoryRepository
+	usecase         *TaskUsecase
+	ctx             context.Context
+	asOwner         context.Context
+	asOther         context.Context
+	asAdmin         context.Context
 }
 
 // SetupSuite runs once before all tests in the suite
 func (suite *TaskUsecaseTestSuite) SetupSuite() {
 	suite.ctx = context.Background()
+	suite.asOwner = withRole(suite.ctx, "user123", domain.RoleUser)
+	suite.asOther = withRole(suite.ctx, "user456", domain.RoleUser)
+	suite.asAdmin = withRole(suite.ctx, "admin123", domain.RoleAdmin)
 }
 
 // SetupTest runs before each test
 func (suite *TaskUsecaseTestSuite) SetupTest() {
 	suite.mockRepo = new(MockTaskRepository)
-	suite.usecase = NewTaskUsecase(suite.mockRepo, 5*time.Second)
+	suite.mockProjectRepo = new(MockProjectRepository)
+	suite.mockHistoryRepo = new(MockTaskHistoryRepository)
+	// Most tests do not care about history; those that do assert the calls.
+	suite.mockHistoryRepo.On("AddEntry", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("*domain.TaskHistoryEntry")).Return(nil).Maybe()
+	suite.usecase = NewTaskUsecase(suite.mockRepo, suite.mockProjectRepo, suite.mockHistoryRepo, domain.DefaultWorkflow(), 5*time.Second)
 }
 
 // TearDownTest runs after each test
 func (suite *TaskUsecaseTestSuite) TearDownTest() {
 	suite.mockRepo.AssertExpectations(suite.T())
+	suite.mockProjectRepo.AssertExpectations(suite.T())
+	suite.mockHistoryRepo.AssertExpectations(suite.T())
 }
 
 // TestCreateTaskSuite tests the Create method
 func (suite *TaskUsecaseTestSuite) TestCreateTaskSuite() {
 	suite.Run("Success", func() {
 		task := &domain.Task{
-			ID:          "task123",
 			Title:       "Test Task",
 			Description: "Test Description",
 			DueDate:     time.Now().Add(24 * time.Hour),
 			Status:      "pending",
 		}
 
-		suite.mockRepo.On("AddTask", mock.AnythingOfType("*context.timerCtx"), task).Return(nil)
+		suite.mockRepo.On("AddTask", mock.AnythingOfType("*context.timerCtx"), task).
+			Run(func(args mock.Arguments) {
+				args.Get(1).(*domain.Task).ID = "generated123"
+			}).
+			Return(nil)
 
-		err := suite.usecase.Create(suite.ctx, task)
+		err := suite.usecase.Create(suite.asOwner, task)
 
 		suite.NoError(err)
+		suite.Equal("generated123", task.ID)
+		suite.Equal("user123", task.CreatedBy)
+		suite.Equal(domain.DefaultOrganizationID, task.OrgID)
+	})
+
+	suite.Run("MissingRequester", func() {
+		task := &domain.Task{
+			Title:       "Test Task",
+			Description: "Test Description",
+			DueDate:     time.Now().Add(24 * time.Hour),
+			Status:      "pending",
+		}
+
+		err := suite.usecase.Create(suite.ctx, task)
+
+		suite.Error(err)
+		suite.Equal("authenticated user is required", err.Error())
+	})
+
+	suite.Run("ClientSuppliedID", func() {
+		task := &domain.Task{
+			ID:          "task123",
+			Title:       "Test Task",
+			Description: "Test Description",
+			DueDate:     time.Now().Add(24 * time.Hour),
+			Status:      "pending",
+		}
+
+		err := suite.usecase.Create(suite.asOwner, task)
+
+		suite.Error(err)
+		suite.Equal("task ID is assigned by the server and must not be provided", err.Error())
 	})
 
 	suite.Run("NilTask", func() {
-		err := suite.usecase.Create(suite.ctx, nil)
+		err := suite.usecase.Create(suite.asOwner, nil)
 
 		suite.Error(err)
 		suite.Equal("task cannot be nil", err.Error())
@@ -96,29 +220,42 @@ func (suite *TaskUsecaseTestSuite) TestCreateTaskSuite() {
 
 	suite.Run("EmptyTitle", func() {
 		task := &domain.Task{
-			ID:          "task123",
 			Title:       "", // Empty title
 			Description: "Test Description",
 			DueDate:     time.Now().Add(24 * time.Hour),
 			Status:      "pending",
 		}
 
-		err := suite.usecase.Create(suite.ctx, task)
+		err := suite.usecase.Create(suite.asOwner, task)
 
 		suite.Error(err)
 		suite.Equal("title is required", err.Error())
+		var validationErr *domain.ValidationError
+		suite.Require().ErrorAs(err, &validationErr)
+		suite.Equal("title", validationErr.Field)
+		suite.ErrorIs(err, domain.ErrValidation)
+	})
+
+	suite.Run("MissingFields", func() {
+		err := suite.usecase.Create(suite.asOwner, &domain.Task{Status: "pending"})
+
+		suite.EqualError(err, "title is required; description is required; due date is required")
+		var errs domain.ValidationErrors
+		suite.Require().ErrorAs(err, &errs)
+		suite.Len(errs, 3)
+		suite.Equal("due_date", errs[2].Field)
+		suite.Equal(domain.ValidationRequired, errs[2].Code)
 	})
 
 	suite.Run("EmptyDescription", func() {
 		task := &domain.Task{
-			ID:          "task123",
 			Title:       "Test Task",
 			Description: "", // Empty description
 			DueDate:     time.Now().Add(24 * time.Hour),
 			Status:      "pending",
 		}
 
-		err := suite.usecase.Create(suite.ctx, task)
+		err := suite.usecase.Create(suite.asOwner, task)
 
 		suite.Error(err)
 		suite.Equal("description is required", err.Error())
@@ -126,14 +263,13 @@ func (suite *TaskUsecaseTestSuite) TestCreateTaskSuite() {
 
 	suite.Run("EmptyStatus", func() {
 		task := &domain.Task{
-			ID:          "task123",
 			Title:       "Test Task",
 			Description: "Test Description",
 			DueDate:     time.Now().Add(24 * time.Hour),
 			Status:      "", // Empty status
 		}
 
-		err := suite.usecase.Create(suite.ctx, task)
+		err := suite.usecase.Create(suite.asOwner, task)
 
 		suite.Error(err)
 		suite.Equal("status is required", err.Error())
@@ -141,14 +277,13 @@ func (suite *TaskUsecaseTestSuite) TestCreateTaskSuite() {
 
 	suite.Run("ZeroDueDate", func() {
 		task := &domain.Task{
-			ID:          "task123",
 			Title:       "Test Task",
 			Description: "Test Description",
 			DueDate:     time.Time{}, // Zero due date
 			Status:      "pending",
 		}
 
-		err := suite.usecase.Create(suite.ctx, task)
+		err := suite.usecase.Create(suite.asOwner, task)
 
 		suite.Error(err)
 		suite.Equal("due date is required", err.Error())
@@ -156,14 +291,13 @@ func (suite *TaskUsecaseTestSuite) TestCreateTaskSuite() {
 
 	suite.Run("InvalidStatus", func() {
 		task := &domain.Task{
-			ID:          "task123",
 			Title:       "Test Task",
 			Description: "Test Description",
 			DueDate:     time.Now().Add(24 * time.Hour),
 			Status:      "invalid_status", // Invalid status
 		}
 
-		err := suite.usecase.Create(suite.ctx, task)
+		err := suite.usecase.Create(suite.asOwner, task)
 
 		suite.Error(err)
 		suite.Equal("invalid status: must be pending, in_progress, completed, or cancelled", err.Error())
@@ -175,7 +309,6 @@ func (suite *TaskUsecaseTestSuite) TestCreateTaskSuite() {
 		for _, status := range validStatuses {
 			suite.Run("Status_"+status, func() {
 				task := &domain.Task{
-					ID:          "task123",
 					Title:       "Test Task",
 					Description: "Test Description",
 					DueDate:     time.Now().Add(24 * time.Hour),
@@ -184,7 +317,7 @@ func (suite *TaskUsecaseTestSuite) TestCreateTaskSuite() {
 
 				suite.mockRepo.On("AddTask", mock.AnythingOfType("*context.timerCtx"), task).Return(nil)
 
-				err := suite.usecase.Create(suite.ctx, task)
+				err := suite.usecase.Create(suite.asOwner, task)
 
 				suite.NoError(err)
 			})
@@ -194,6 +327,8 @@ func (suite *TaskUsecaseTestSuite) TestCreateTaskSuite() {
 
 // TestGetAllTasksSuite tests the GetAllTasks method
 func (suite *TaskUsecaseTestSuite) TestGetAllTasksSuite() {
+	suite.mockProjectRepo.On("ListProjects", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("domain.ProjectQuery")).Return([]domain.Project{}, nil)
+
 	suite.Run("Success", func() {
 		expectedTasks := []domain.Task{
 			{
@@ -212,27 +347,97 @@ func (suite *TaskUsecaseTestSuite) TestGetAllTasksSuite() {
 			},
 		}
 
-		suite.mockRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx")).Return(expectedTasks, nil)
+		expectedQuery := domain.TaskQuery{OrgID: domain.DefaultOrganizationID, VisibleTo: "user123", SortBy: domain.TaskSortByID, Limit: DefaultTaskPageSize}
+		expectedPage := &domain.TaskPage{Tasks: expectedTasks, NextCursor: "next", Total: 5}
+		suite.mockRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx"), expectedQuery).Return(expectedPage, nil)
+
+		page, err := suite.usecase.GetAllTasks(suite.asOwner, domain.TaskQuery{})
+
+		suite.NoError(err)
+		suite.Equal(expectedPage, page)
+		suite.Len(page.Tasks, 2)
+	})
+
+	suite.Run("AdminSeesAllTasks", func() {
+		expectedQuery := domain.TaskQuery{OrgID: domain.DefaultOrganizationID, SortBy: domain.TaskSortByID, Limit: DefaultTaskPageSize}
+		suite.mockRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx"), expectedQuery).Return(&domain.TaskPage{}, nil)
 
-		tasks, err := suite.usecase.GetAllTasks(suite.ctx)
+		_, err := suite.usecase.GetAllTasks(suite.asAdmin, domain.TaskQuery{VisibleTo: "someone"})
 
 		suite.NoError(err)
-		suite.Equal(expectedTasks, tasks)
-		suite.Len(tasks, 2)
+	})
+
+	suite.Run("ScopedToActiveOrganization", func() {
+		member := withOrganization(suite.asOther, "org1", domain.OrgRoleMember)
+		expectedQuery := domain.TaskQuery{OrgID: "org1", VisibleTo: "user456", SortBy: domain.TaskSortByID, Limit: DefaultTaskPageSize}
+		suite.mockRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx"), expectedQuery).Return(&domain.TaskPage{}, nil)
+
+		_, err := suite.usecase.GetAllTasks(member, domain.TaskQuery{OrgID: "org2"})
+
+		suite.NoError(err)
+	})
+
+	suite.Run("OrganizationAdminSeesOrganizationTasks", func() {
+		orgAdmin := withOrganization(suite.asOther, "org3", domain.OrgRoleAdmin)
+		expectedQuery := domain.TaskQuery{OrgID: "org3", SortBy: domain.TaskSortByID, Limit: DefaultTaskPageSize}
+		suite.mockRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx"), expectedQuery).Return(&domain.TaskPage{}, nil)
+
+		_, err := suite.usecase.GetAllTasks(orgAdmin, domain.TaskQuery{})
+
+		suite.NoError(err)
+	})
+
+	suite.Run("FiltersAndSorting", func() {
+		query := domain.TaskQuery{
+			OrgID:       domain.DefaultOrganizationID,
+			VisibleTo:   "user123",
+			Status:      "pending",
+			DueAfter:    time.Now(),
+			DueBefore:   time.Now().Add(24 * time.Hour),
+			TitlePrefix: "Task",
+			SortBy:      domain.TaskSortByDueDate,
+			SortDesc:    true,
+			Limit:       50,
+			Cursor:      "abc",
+		}
+		suite.mockRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx"), query).Return(&domain.TaskPage{}, nil)
+
+		_, err := suite.usecase.GetAllTasks(suite.asOwner, query)
+
+		suite.NoError(err)
+	})
+
+	suite.Run("InvalidQuery", func() {
+		queries := map[string]domain.TaskQuery{
+			"UnknownSortField": {SortBy: "description"},
+			"NegativeLimit":    {Limit: -1},
+			"LimitTooLarge":    {Limit: MaxTaskPageSize + 1},
+			"UnknownStatus":    {Status: "archived"},
+			"InvertedDueRange": {DueAfter: time.Now().Add(time.Hour), DueBefore: time.Now()},
+		}
+
+		for name, query := range queries {
+			suite.Run(name, func() {
+				page, err := suite.usecase.GetAllTasks(suite.asOwner, query)
+
+				suite.ErrorIs(err, domain.ErrInvalidTaskQuery)
+				suite.Nil(page)
+			})
+		}
 	})
 
 	suite.Run("Error", func() {
 		// Create a new mock for this specific test to avoid interference
 		mockRepo := new(MockTaskRepository)
-		usecase := NewTaskUsecase(mockRepo, 5*time.Second)
-		
+		usecase := NewTaskUsecase(mockRepo, suite.mockProjectRepo, suite.mockHistoryRepo, domain.DefaultWorkflow(), 5*time.Second)
+
 		expectedError := errors.New("database connection failed")
-		mockRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx")).Return([]domain.Task{}, expectedError)
+		mockRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("domain.TaskQuery")).Return(nil, expectedError)
 
-		tasks, err := usecase.GetAllTasks(suite.ctx)
+		page, err := usecase.GetAllTasks(suite.asOwner, domain.TaskQuery{})
 
 		suite.Error(err)
-		suite.Empty(tasks)
+		suite.Nil(page)
 		suite.Equal(expectedError, err)
 		mockRepo.AssertExpectations(suite.T())
 	})
@@ -247,18 +452,82 @@ func (suite *TaskUsecaseTestSuite) TestGetTaskByIDSuite() {
 			Description: "Test Description",
 			DueDate:     time.Now().Add(24 * time.Hour),
 			Status:      "pending",
+			CreatedBy:   "user123",
 		}
 
-		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), "task123").Return(expectedTask, nil)
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task123").Return(expectedTask, nil)
+
+		task, err := suite.usecase.GetTaskByID(suite.asOwner, "task123")
+
+		suite.NoError(err)
+		suite.Equal(expectedTask, task)
+	})
+
+	suite.Run("Assignee", func() {
+		expectedTask := &domain.Task{ID: "task789", CreatedBy: "user123", AssigneeID: "user456"}
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task789").Return(expectedTask, nil)
+
+		task, err := suite.usecase.GetTaskByID(suite.asOther, "task789")
+
+		suite.NoError(err)
+		suite.Equal(expectedTask, task)
+	})
+
+	suite.Run("NotVisibleToOtherUsers", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task456").
+			Return(&domain.Task{ID: "task456", CreatedBy: "user123"}, nil)
 
-		task, err := suite.usecase.GetTaskByID(suite.ctx, "task123")
+		task, err := suite.usecase.GetTaskByID(suite.asOther, "task456")
+
+		suite.Error(err)
+		suite.Nil(task)
+		suite.Equal("task not found", err.Error())
+	})
+
+	suite.Run("AdminSeesAnyTask", func() {
+		expectedTask := &domain.Task{ID: "task999", CreatedBy: "user123"}
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task999").Return(expectedTask, nil)
+
+		task, err := suite.usecase.GetTaskByID(suite.asAdmin, "task999")
+
+		suite.NoError(err)
+		suite.Equal(expectedTask, task)
+	})
+
+	suite.Run("ReadAllPermission", func() {
+		auditor := domain.WithPrincipal(suite.ctx, &domain.Principal{UserID: "auditor1", Role: "auditor", Permissions: []string{domain.PermissionTasksRead, domain.PermissionTasksReadAll}})
+		expectedTask := &domain.Task{ID: "task998", CreatedBy: "user123"}
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task998").Return(expectedTask, nil)
+
+		task, err := suite.usecase.GetTaskByID(auditor, "task998")
+
+		suite.NoError(err)
+		suite.Equal(expectedTask, task)
+	})
+
+	suite.Run("OtherOrganization", func() {
+		member := withOrganization(suite.asOwner, "org1", domain.OrgRoleMember)
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), "org1", "task997").Return(nil, domain.ErrTaskNotFound)
+
+		task, err := suite.usecase.GetTaskByID(member, "task997")
+
+		suite.EqualError(err, "task not found")
+		suite.Nil(task)
+	})
+
+	suite.Run("OrganizationOwner", func() {
+		owner := withOrganization(suite.asOther, "org1", domain.OrgRoleOwner)
+		expectedTask := &domain.Task{ID: "task996", OrgID: "org1", CreatedBy: "user123"}
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), "org1", "task996").Return(expectedTask, nil)
+
+		task, err := suite.usecase.GetTaskByID(owner, "task996")
 
 		suite.NoError(err)
 		suite.Equal(expectedTask, task)
 	})
 
 	suite.Run("EmptyID", func() {
-		task, err := suite.usecase.GetTaskByID(suite.ctx, "")
+		task, err := suite.usecase.GetTaskByID(suite.asOwner, "")
 
 		suite.Error(err)
 		suite.Nil(task)
@@ -267,9 +536,9 @@ func (suite *TaskUsecaseTestSuite) TestGetTaskByIDSuite() {
 
 	suite.Run("NotFound", func() {
 		expectedError := errors.New("task not found")
-		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), "nonexistent").Return(nil, expectedError)
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "nonexistent").Return(nil, expectedError)
 
-		task, err := suite.usecase.GetTaskByID(suite.ctx, "nonexistent")
+		task, err := suite.usecase.GetTaskByID(suite.asOwner, "nonexistent")
 
 		suite.Error(err)
 		suite.Nil(task)
@@ -286,17 +555,39 @@ func (suite *TaskUsecaseTestSuite) TestUpdateTaskSuite() {
 			Description: "Updated Description",
 			DueDate:     time.Now().Add(24 * time.Hour),
 			Status:      "in_progress",
+			CreatedBy:   "spoofed",
 		}
 
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task123").
+			Return(&domain.Task{ID: "task123", CreatedBy: "user123"}, nil)
 		suite.mockRepo.On("UpdateTask", mock.AnythingOfType("*context.timerCtx"), task).Return(nil)
 
-		err := suite.usecase.UpdateTask(suite.ctx, task)
+		err := suite.usecase.UpdateTask(suite.asOwner, task)
 
 		suite.NoError(err)
+		suite.Equal("user123", task.CreatedBy)
+	})
+
+	suite.Run("NotOwnerOrAssignee", func() {
+		task := &domain.Task{
+			ID:          "task456",
+			Title:       "Updated Task",
+			Description: "Updated Description",
+			DueDate:     time.Now().Add(24 * time.Hour),
+			Status:      "in_progress",
+		}
+
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task456").
+			Return(&domain.Task{ID: "task456", CreatedBy: "user123"}, nil)
+
+		err := suite.usecase.UpdateTask(suite.asOther, task)
+
+		suite.Error(err)
+		suite.Equal("task not found", err.Error())
 	})
 
 	suite.Run("NilTask", func() {
-		err := suite.usecase.UpdateTask(suite.ctx, nil)
+		err := suite.usecase.UpdateTask(suite.asOwner, nil)
 
 		suite.Error(err)
 		suite.Equal("task cannot be nil", err.Error())
@@ -311,7 +602,7 @@ func (suite *TaskUsecaseTestSuite) TestUpdateTaskSuite() {
 			Status:      "in_progress",
 		}
 
-		err := suite.usecase.UpdateTask(suite.ctx, task)
+		err := suite.usecase.UpdateTask(suite.asOwner, task)
 
 		suite.Error(err)
 		suite.Equal("task ID is required", err.Error())
@@ -326,7 +617,7 @@ func (suite *TaskUsecaseTestSuite) TestUpdateTaskSuite() {
 			Status:      "in_progress",
 		}
 
-		err := suite.usecase.UpdateTask(suite.ctx, task)
+		err := suite.usecase.UpdateTask(suite.asOwner, task)
 
 		suite.Error(err)
 		suite.Equal("title is required", err.Error())
@@ -335,8 +626,8 @@ func (suite *TaskUsecaseTestSuite) TestUpdateTaskSuite() {
 	suite.Run("Error", func() {
 		// Create a new mock for this specific test to avoid interference
 		mockRepo := new(MockTaskRepository)
-		usecase := NewTaskUsecase(mockRepo, 5*time.Second)
-		
+		usecase := NewTaskUsecase(mockRepo, suite.mockProjectRepo, suite.mockHistoryRepo, domain.DefaultWorkflow(), 5*time.Second)
+
 		task := &domain.Task{
 			ID:          "task123",
 			Title:       "Updated Task",
@@ -346,9 +637,11 @@ func (suite *TaskUsecaseTestSuite) TestUpdateTaskSuite() {
 		}
 
 		expectedError := errors.New("task not found")
+		mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task123").
+			Return(&domain.Task{ID: "task123", CreatedBy: "user123"}, nil)
 		mockRepo.On("UpdateTask", mock.AnythingOfType("*context.timerCtx"), task).Return(expectedError)
 
-		err := usecase.UpdateTask(suite.ctx, task)
+		err := usecase.UpdateTask(suite.asOwner, task)
 
 		suite.Error(err)
 		suite.Equal(expectedError, err)
@@ -359,15 +652,52 @@ func (suite *TaskUsecaseTestSuite) TestUpdateTaskSuite() {
 // TestDeleteTaskSuite tests the DeleteTask method
 func (suite *TaskUsecaseTestSuite) TestDeleteTaskSuite() {
 	suite.Run("Success", func() {
-		suite.mockRepo.On("DeleteTask", mock.AnythingOfType("*context.timerCtx"), "task123").Return(nil)
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task123").
+			Return(&domain.Task{ID: "task123", CreatedBy: "user123"}, nil)
+		suite.mockRepo.On("DeleteTask", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(t *domain.Task) bool {
+			return t.ID == "task123" && t.DeletedBy == "user123" && !t.DeletedAt.IsZero()
+		})).Return(nil)
+
+		err := suite.usecase.DeleteTask(suite.asOwner, "task123", 0)
+
+		suite.NoError(err)
+	})
+
+	suite.Run("NotOwnerOrAssignee", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task456").
+			Return(&domain.Task{ID: "task456", CreatedBy: "user123"}, nil)
+
+		err := suite.usecase.DeleteTask(suite.asOther, "task456", 0)
+
+		suite.Error(err)
+		suite.Equal("task not found", err.Error())
+	})
+
+	suite.Run("ReadAllDoesNotAllowDelete", func() {
+		auditor := domain.WithPrincipal(suite.ctx, &domain.Principal{UserID: "auditor1", Role: "auditor", Permissions: []string{domain.PermissionTasksRead, domain.PermissionTasksReadAll}})
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task457").
+			Return(&domain.Task{ID: "task457", CreatedBy: "user123"}, nil)
+
+		err := suite.usecase.DeleteTask(auditor, "task457", 0)
+
+		suite.Error(err)
+		suite.Equal("task not found", err.Error())
+	})
 
-		err := suite.usecase.DeleteTask(suite.ctx, "task123")
+	suite.Run("ManageAllPermission", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task458").
+			Return(&domain.Task{ID: "task458", CreatedBy: "user123"}, nil)
+		suite.mockRepo.On("DeleteTask", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(t *domain.Task) bool {
+			return t.ID == "task458" && t.DeletedBy == "admin123" && !t.DeletedAt.IsZero()
+		})).Return(nil)
+
+		err := suite.usecase.DeleteTask(suite.asAdmin, "task458", 0)
 
 		suite.NoError(err)
 	})
 
 	suite.Run("EmptyID", func() {
-		err := suite.usecase.DeleteTask(suite.ctx, "")
+		err := suite.usecase.DeleteTask(suite.asOwner, "", 0)
 
 		suite.Error(err)
 		suite.Equal("task ID is required", err.Error())
@@ -375,23 +705,565 @@ func (suite *TaskUsecaseTestSuite) TestDeleteTaskSuite() {
 
 	suite.Run("Error", func() {
 		expectedError := errors.New("task not found")
-		suite.mockRepo.On("DeleteTask", mock.AnythingOfType("*context.timerCtx"), "nonexistent").Return(expectedError)
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "nonexistent").Return(nil, expectedError)
 
-		err := suite.usecase.DeleteTask(suite.ctx, "nonexistent")
+		err := suite.usecase.DeleteTask(suite.asOwner, "nonexistent", 0)
 
 		suite.Error(err)
 		suite.Equal(expectedError, err)
 	})
 }
 
+// TestProjectTasksSuite tests how project membership and archiving affect
+// task access
+func (suite *TaskUsecaseTestSuite) TestProjectTasksSuite() {
+	project := &domain.Project{ID: "proj1", OrgID: domain.DefaultOrganizationID, Name: "Launch"}
+	archived := &domain.Project{ID: "proj2", OrgID: domain.DefaultOrganizationID, Name: "Old", Archived: true}
+	editor := &domain.ProjectMember{ProjectID: "proj1", UserID: "user456", Role: domain.ProjectRoleEditor}
+	viewer := &domain.ProjectMember{ProjectID: "proj1", UserID: "viewer1", Role: domain.ProjectRoleViewer}
+	asViewer := withRole(suite.ctx, "viewer1", domain.RoleUser)
+
+	suite.mockProjectRepo.On("GetProject", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "proj1").Return(project, nil)
+	suite.mockProjectRepo.On("GetProject", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "proj2").Return(archived, nil)
+	suite.mockProjectRepo.On("GetProjectMember", mock.AnythingOfType("*context.timerCtx"), "proj1", "user456").Return(editor, nil)
+	suite.mockProjectRepo.On("GetProjectMember", mock.AnythingOfType("*context.timerCtx"), "proj1", "viewer1").Return(viewer, nil)
+	suite.mockProjectRepo.On("GetProjectMember", mock.AnythingOfType("*context.timerCtx"), "proj2", "user456").Return(editor, nil)
+	suite.mockProjectRepo.On("GetProjectMember", mock.AnythingOfType("*context.timerCtx"), "proj1", "user123").Return(nil, domain.ErrProjectNotFound)
+
+	suite.Run("ListingIncludesProjectTasksAndHidesArchived", func() {
+		suite.mockProjectRepo.On("ListProjects", mock.AnythingOfType("*context.timerCtx"), domain.ProjectQuery{OrgID: domain.DefaultOrganizationID, MemberID: "user456", IncludeArchived: true}).
+			Return([]domain.Project{*project, *archived}, nil)
+		suite.mockProjectRepo.On("ListProjects", mock.AnythingOfType("*context.timerCtx"), domain.ProjectQuery{OrgID: domain.DefaultOrganizationID, IncludeArchived: true}).
+			Return([]domain.Project{*project, *archived}, nil)
+		expectedQuery := domain.TaskQuery{
+			OrgID:             domain.DefaultOrganizationID,
+			VisibleTo:         "user456",
+			VisibleProjectIDs: []string{"proj1", "proj2"},
+			ExcludeProjectIDs: []string{"proj2"},
+			SortBy:            domain.TaskSortByID,
+			Limit:             DefaultTaskPageSize,
+		}
+		suite.mockRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx"), expectedQuery).Return(&domain.TaskPage{}, nil)
+
+		_, err := suite.usecase.GetAllTasks(suite.asOther, domain.TaskQuery{})
+
+		suite.NoError(err)
+	})
+
+	suite.Run("ListProjectTasks", func() {
+		expectedQuery := domain.TaskQuery{OrgID: domain.DefaultOrganizationID, ProjectID: "proj1", SortBy: domain.TaskSortByID, Limit: DefaultTaskPageSize}
+		suite.mockRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx"), expectedQuery).Return(&domain.TaskPage{Total: 3}, nil)
+
+		page, err := suite.usecase.ListProjectTasks(asViewer, "proj1", domain.TaskQuery{VisibleTo: "someone"})
+
+		suite.NoError(err)
+		suite.Equal(int64(3), page.Total)
+	})
+
+	suite.Run("ListProjectTasksNotMember", func() {
+		page, err := suite.usecase.ListProjectTasks(suite.asOwner, "proj1", domain.TaskQuery{})
+
+		suite.ErrorIs(err, domain.ErrProjectNotFound)
+		suite.Nil(page)
+	})
+
+	suite.Run("ViewerReadsProjectTask", func() {
+		expectedTask := &domain.Task{ID: "task701", CreatedBy: "user123", ProjectID: "proj1"}
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task701").Return(expectedTask, nil)
+
+		task, err := suite.usecase.GetTaskByID(asViewer, "task701")
+
+		suite.NoError(err)
+		suite.Equal(expectedTask, task)
+	})
+
+	suite.Run("ViewerCannotDeleteProjectTask", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task702").
+			Return(&domain.Task{ID: "task702", CreatedBy: "user123", ProjectID: "proj1"}, nil)
+
+		err := suite.usecase.DeleteTask(asViewer, "task702", 0)
+
+		suite.EqualError(err, "task not found")
+	})
+
+	suite.Run("EditorCreatesProjectTask", func() {
+		task := &domain.Task{Title: "Ship it", Description: "Release", DueDate: time.Now().Add(time.Hour), Status: "pending", ProjectID: "proj1"}
+		suite.mockRepo.On("AddTask", mock.AnythingOfType("*context.timerCtx"), task).Return(nil)
+
+		err := suite.usecase.Create(suite.asOther, task)
+
+		suite.NoError(err)
+	})
+
+	suite.Run("ViewerCannotCreateProjectTask", func() {
+		task := &domain.Task{Title: "Ship it", Description: "Release", DueDate: time.Now().Add(time.Hour), Status: "pending", ProjectID: "proj1"}
+
+		err := suite.usecase.Create(asViewer, task)
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+	})
+
+	suite.Run("NonMemberCannotCreateProjectTask", func() {
+		task := &domain.Task{Title: "Ship it", Description: "Release", DueDate: time.Now().Add(time.Hour), Status: "pending", ProjectID: "proj1"}
+
+		err := suite.usecase.Create(suite.asOwner, task)
+
+		suite.ErrorIs(err, domain.ErrProjectNotFound)
+	})
+
+	suite.Run("ArchivedProjectRefusesTasks", func() {
+		task := &domain.Task{Title: "Ship it", Description: "Release", DueDate: time.Now().Add(time.Hour), Status: "pending", ProjectID: "proj2"}
+
+		err := suite.usecase.Create(suite.asOther, task)
+
+		suite.ErrorIs(err, domain.ErrProjectArchived)
+	})
+}
+
+// TestWorkflowSuite tests that status changes follow the workflow
+func (suite *TaskUsecaseTestSuite) TestWorkflowSuite() {
+	suite.Run("TransitionTask", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task801").
+			Return(&domain.Task{ID: "task801", CreatedBy: "user123", Status: domain.TaskStatusPending}, nil)
+		suite.mockRepo.On("UpdateTask", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(t *domain.Task) bool {
+			return t.ID == "task801" && t.Status == domain.TaskStatusInProgress
+		})).Return(nil)
+
+		task, err := suite.usecase.TransitionTask(suite.asOwner, "task801", domain.TaskStatusInProgress)
+
+		suite.NoError(err)
+		suite.Equal(domain.TaskStatusInProgress, task.Status)
+	})
+
+	suite.Run("InvalidTransitionListsAllowed", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task802").
+			Return(&domain.Task{ID: "task802", CreatedBy: "user123", Status: domain.TaskStatusCompleted}, nil)
+
+		task, err := suite.usecase.TransitionTask(suite.asOwner, "task802", domain.TaskStatusCancelled)
+
+		var transitionErr *domain.TransitionError
+		suite.Require().ErrorAs(err, &transitionErr)
+		suite.ErrorIs(err, domain.ErrInvalidTransition)
+		suite.Equal([]string{domain.TaskStatusInProgress}, transitionErr.Allowed)
+		suite.Nil(task)
+	})
+
+	suite.Run("RoleNotAllowed", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task803").
+			Return(&domain.Task{ID: "task803", CreatedBy: "user123", Status: domain.TaskStatusCancelled}, nil)
+
+		_, err := suite.usecase.TransitionTask(suite.asOwner, "task803", domain.TaskStatusPending)
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+	})
+
+	suite.Run("AdminRevivesCancelledTask", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task804").
+			Return(&domain.Task{ID: "task804", CreatedBy: "user123", Status: domain.TaskStatusCancelled}, nil)
+		suite.mockRepo.On("UpdateTask", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(t *domain.Task) bool {
+			return t.ID == "task804" && t.Status == domain.TaskStatusPending
+		})).Return(nil)
+
+		task, err := suite.usecase.TransitionTask(suite.asAdmin, "task804", domain.TaskStatusPending)
+
+		suite.NoError(err)
+		suite.Equal(domain.TaskStatusPending, task.Status)
+	})
+
+	suite.Run("UnknownStatus", func() {
+		task, err := suite.usecase.TransitionTask(suite.asOwner, "task801", "archived")
+
+		suite.ErrorIs(err, domain.ErrInvalidStatus)
+		suite.Nil(task)
+	})
+
+	suite.Run("UpdateTaskEnforcesWorkflow", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task805").
+			Return(&domain.Task{ID: "task805", CreatedBy: "user123", Status: domain.TaskStatusCancelled}, nil)
+		task := &domain.Task{ID: "task805", Title: "Revive", Description: "Back to work", DueDate: time.Now().Add(time.Hour), Status: domain.TaskStatusInProgress}
+
+		err := suite.usecase.UpdateTask(suite.asOwner, task)
+
+		var transitionErr *domain.TransitionError
+		suite.Require().ErrorAs(err, &transitionErr)
+		suite.Empty(transitionErr.Allowed)
+	})
+
+	suite.Run("CustomWorkflow", func() {
+		workflow := &domain.Workflow{
+			States:      []string{"open", "closed"},
+			Transitions: []domain.Transition{{From: "open", To: "closed"}},
+		}
+		usecase := NewTaskUsecase(suite.mockRepo, suite.mockProjectRepo, suite.mockHistoryRepo, workflow, 5*time.Second)
+		task := &domain.Task{Title: "Custom", Description: "Workflow", DueDate: time.Now().Add(time.Hour), Status: domain.TaskStatusPending}
+
+		err := usecase.Create(suite.asOwner, task)
+
+		suite.ErrorIs(err, domain.ErrInvalidStatus)
+		suite.Equal("invalid status: must be open, or closed", err.Error())
+	})
+}
+
+// TestHistorySuite tests that task changes are recorded and can be read back
+func (suite *TaskUsecaseTestSuite) TestHistorySuite() {
+	dueDate := time.Date(2024, 7, 23, 12, 0, 0, 0, time.UTC)
+	recorded := func(taskID, action string, changes ...domain.TaskChange) interface{} {
+		return mock.MatchedBy(func(e *domain.TaskHistoryEntry) bool {
+			return e.TaskID == taskID && e.Action == action && e.ActorID == "user123" && !e.CreatedAt.IsZero() && reflect.DeepEqual(changes, e.Changes)
+		})
+	}
+
+	suite.Run("Create", func() {
+		task := &domain.Task{Title: "Write docs", Description: "API docs", DueDate: dueDate, Status: domain.TaskStatusPending}
+		suite.mockRepo.On("AddTask", mock.AnythingOfType("*context.timerCtx"), task).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Task).ID = "task901"
+		}).Return(nil).Once()
+
+		err := suite.usecase.Create(suite.asOwner, task)
+
+		suite.NoError(err)
+		suite.mockHistoryRepo.AssertCalled(suite.T(), "AddEntry", mock.AnythingOfType("*context.timerCtx"), recorded("task901", domain.TaskActionCreated,
+			domain.TaskChange{Field: "title", After: "Write docs"},
+			domain.TaskChange{Field: "description", After: "API docs"},
+			domain.TaskChange{Field: "due_date", After: "2024-07-23T12:00:00Z"},
+			domain.TaskChange{Field: "status", After: domain.TaskStatusPending},
+			domain.TaskChange{Field: "created_by", After: "user123"},
+		))
+	})
+
+	suite.Run("UpdateRecordsChangedFields", func() {
+		existing := &domain.Task{ID: "task902", Title: "Write docs", Description: "API docs", DueDate: dueDate, Status: domain.TaskStatusPending, CreatedBy: "user123"}
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task902").Return(existing, nil)
+		task := &domain.Task{ID: "task902", Title: "Write the docs", Description: "API docs", DueDate: dueDate, Status: domain.TaskStatusInProgress, AssigneeID: "user456"}
+		suite.mockRepo.On("UpdateTask", mock.AnythingOfType("*context.timerCtx"), task).Return(nil)
+
+		err := suite.usecase.UpdateTask(suite.asOwner, task)
+
+		suite.NoError(err)
+		suite.mockHistoryRepo.AssertCalled(suite.T(), "AddEntry", mock.AnythingOfType("*context.timerCtx"), recorded("task902", domain.TaskActionUpdated,
+			domain.TaskChange{Field: "title", Before: "Write docs", After: "Write the docs"},
+			domain.TaskChange{Field: "status", Before: domain.TaskStatusPending, After: domain.TaskStatusInProgress},
+			domain.TaskChange{Field: "assignee_id", After: "user456"},
+		))
+	})
+
+	suite.Run("Transition", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task903").
+			Return(&domain.Task{ID: "task903", CreatedBy: "user123", Status: domain.TaskStatusInProgress}, nil)
+		suite.mockRepo.On("UpdateTask", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("*domain.Task")).Return(nil).Once()
+
+		_, err := suite.usecase.TransitionTask(suite.asOwner, "task903", domain.TaskStatusCompleted)
+
+		suite.NoError(err)
+		suite.mockHistoryRepo.AssertCalled(suite.T(), "AddEntry", mock.AnythingOfType("*context.timerCtx"), recorded("task903", domain.TaskActionTransitioned,
+			domain.TaskChange{Field: "status", Before: domain.TaskStatusInProgress, After: domain.TaskStatusCompleted},
+		))
+	})
+
+	suite.Run("Delete", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task904").
+			Return(&domain.Task{ID: "task904", Title: "Old", CreatedBy: "user123"}, nil)
+		suite.mockRepo.On("DeleteTask", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(t *domain.Task) bool {
+			return t.ID == "task904" && t.DeletedBy == "user123" && !t.DeletedAt.IsZero()
+		})).Return(nil)
+
+		err := suite.usecase.DeleteTask(suite.asOwner, "task904", 0)
+
+		suite.NoError(err)
+		suite.mockHistoryRepo.AssertCalled(suite.T(), "AddEntry", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(e *domain.TaskHistoryEntry) bool {
+			return e.TaskID == "task904" && e.Action == domain.TaskActionDeleted && len(e.Changes) == 1 &&
+				e.Changes[0].Field == "deleted_at" && e.Changes[0].Before == "" && e.Changes[0].After != ""
+		}))
+	})
+
+	suite.Run("GetTaskHistory", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task905").
+			Return(&domain.Task{ID: "task905", CreatedBy: "user123"}, nil)
+		entries := []domain.TaskHistoryEntry{{ID: "h1", TaskID: "task905", Action: domain.TaskActionCreated}}
+		suite.mockHistoryRepo.On("ListEntries", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task905").Return(entries, nil)
+
+		history, err := suite.usecase.GetTaskHistory(suite.asOwner, "task905")
+
+		suite.NoError(err)
+		suite.Equal(entries, history)
+	})
+
+	suite.Run("GetTaskHistoryNotVisible", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task906").
+			Return(&domain.Task{ID: "task906", CreatedBy: "user123"}, nil)
+
+		history, err := suite.usecase.GetTaskHistory(suite.asOther, "task906")
+
+		suite.EqualError(err, "task not found")
+		suite.Nil(history)
+	})
+
+	suite.Run("AdminReadsDeletedTaskHistory", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task907").Return(nil, domain.ErrTaskNotFound)
+		entries := []domain.TaskHistoryEntry{{ID: "h2", TaskID: "task907", Action: domain.TaskActionDeleted}}
+		suite.mockHistoryRepo.On("ListEntries", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task907").Return(entries, nil)
+
+		history, err := suite.usecase.GetTaskHistory(suite.asAdmin, "task907")
+
+		suite.NoError(err)
+		suite.Equal(entries, history)
+	})
+
+	suite.Run("AdminRepositoryError", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task908").Return(nil, errors.New("db down"))
+
+		history, err := suite.usecase.GetTaskHistory(suite.asAdmin, "task908")
+
+		suite.EqualError(err, "db down")
+		suite.Nil(history)
+	})
+}
+
+// TestTrashSuite tests the trash: listing, restoring and purging deleted
+// tasks
+func (suite *TaskUsecaseTestSuite) TestTrashSuite() {
+	deletedAt := time.Date(2024, 7, 23, 12, 0, 0, 0, time.UTC)
+
+	suite.Run("ListDeletedTasks", func() {
+		expectedQuery := domain.TaskQuery{OrgID: domain.DefaultOrganizationID, Deleted: true, SortBy: domain.TaskSortByID, Limit: DefaultTaskPageSize}
+		page := &domain.TaskPage{Tasks: []domain.Task{{ID: "task951", DeletedAt: deletedAt, DeletedBy: "user123"}}, Total: 1}
+		suite.mockRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx"), expectedQuery).Return(page, nil)
+
+		result, err := suite.usecase.ListDeletedTasks(suite.asAdmin, domain.TaskQuery{VisibleTo: "someone"})
+
+		suite.NoError(err)
+		suite.Equal(page, result)
+	})
+
+	suite.Run("ListDeletedTasksForbidden", func() {
+		result, err := suite.usecase.ListDeletedTasks(suite.asOwner, domain.TaskQuery{})
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+		suite.Nil(result)
+	})
+
+	suite.Run("LiveListingExcludesTrash", func() {
+		expectedQuery := domain.TaskQuery{OrgID: domain.DefaultOrganizationID, SortBy: domain.TaskSortByID, Limit: DefaultTaskPageSize}
+		suite.mockProjectRepo.On("ListProjects", mock.AnythingOfType("*context.timerCtx"), domain.ProjectQuery{OrgID: domain.DefaultOrganizationID, IncludeArchived: true}).Return([]domain.Project{}, nil).Once()
+		suite.mockRepo.On("GetAllTasks", mock.AnythingOfType("*context.timerCtx"), expectedQuery).Return(&domain.TaskPage{}, nil).Once()
+
+		_, err := suite.usecase.GetAllTasks(suite.asAdmin, domain.TaskQuery{Deleted: true})
+
+		suite.NoError(err)
+	})
+
+	suite.Run("RestoreTask", func() {
+		trashed := &domain.Task{ID: "task952", Title: "Back", CreatedBy: "user123", DeletedAt: deletedAt, DeletedBy: "user123"}
+		suite.mockRepo.On("RestoreTask", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task952").Return(trashed, nil)
+
+		task, err := suite.usecase.RestoreTask(suite.asAdmin, "task952")
+
+		suite.NoError(err)
+		suite.True(task.DeletedAt.IsZero())
+		suite.Empty(task.DeletedBy)
+		suite.mockHistoryRepo.AssertCalled(suite.T(), "AddEntry", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(e *domain.TaskHistoryEntry) bool {
+			return e.TaskID == "task952" && e.Action == domain.TaskActionRestored &&
+				reflect.DeepEqual([]domain.TaskChange{{Field: "deleted_at", Before: "2024-07-23T12:00:00Z"}}, e.Changes)
+		}))
+	})
+
+	suite.Run("RestoreTaskNotInTrash", func() {
+		suite.mockRepo.On("RestoreTask", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task953").Return(nil, domain.ErrTaskNotFound)
+
+		task, err := suite.usecase.RestoreTask(suite.asAdmin, "task953")
+
+		suite.ErrorIs(err, domain.ErrTaskNotFound)
+		suite.Nil(task)
+	})
+
+	suite.Run("RestoreTaskForbidden", func() {
+		task, err := suite.usecase.RestoreTask(suite.asOwner, "task952")
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+		suite.Nil(task)
+	})
+
+	suite.Run("OrganizationAdminRestores", func() {
+		orgAdmin := withOrganization(suite.asOther, "org1", domain.OrgRoleAdmin)
+		suite.mockRepo.On("RestoreTask", mock.AnythingOfType("*context.timerCtx"), "org1", "task954").Return(&domain.Task{ID: "task954", OrgID: "org1", DeletedAt: deletedAt}, nil)
+
+		task, err := suite.usecase.RestoreTask(orgAdmin, "task954")
+
+		suite.NoError(err)
+		suite.Equal("org1", task.OrgID)
+	})
+
+	suite.Run("PurgeDeletedTasks", func() {
+		before := time.Now()
+		suite.mockRepo.On("PurgeDeletedTasks", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(t time.Time) bool {
+			cutoff := before.Add(-30 * 24 * time.Hour)
+			return !t.Before(cutoff) && t.Before(cutoff.Add(time.Minute))
+		})).Return(int64(4), nil)
+
+		purged, err := suite.usecase.PurgeDeletedTasks(suite.ctx, 30*24*time.Hour)
+
+		suite.NoError(err)
+		suite.Equal(int64(4), purged)
+	})
+
+	suite.Run("PurgeNegativeRetention", func() {
+		_, err := suite.usecase.PurgeDeletedTasks(suite.ctx, -time.Hour)
+
+		suite.Error(err)
+	})
+}
+
+// TestVersionSuite tests optimistic concurrency control with task versions
+func (suite *TaskUsecaseTestSuite) TestVersionSuite() {
+	newTask := func(id string, version int64) *domain.Task {
+		return &domain.Task{ID: id, Title: "Title", Description: "Description", DueDate: time.Now().Add(time.Hour), Status: domain.TaskStatusPending, Version: version}
+	}
+
+	suite.Run("UpdateWithCurrentVersion", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task961").
+			Return(&domain.Task{ID: "task961", CreatedBy: "user123", Status: domain.TaskStatusPending, Version: 3}, nil)
+		task := newTask("task961", 3)
+		suite.mockRepo.On("UpdateTask", mock.AnythingOfType("*context.timerCtx"), task).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Task).Version++
+		}).Return(nil)
+
+		err := suite.usecase.UpdateTask(suite.asOwner, task)
+
+		suite.NoError(err)
+		suite.Equal(int64(4), task.Version)
+	})
+
+	suite.Run("UpdateWithStaleVersion", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task962").
+			Return(&domain.Task{ID: "task962", CreatedBy: "user123", Status: domain.TaskStatusPending, Version: 5}, nil)
+
+		err := suite.usecase.UpdateTask(suite.asOwner, newTask("task962", 4))
+
+		suite.ErrorIs(err, domain.ErrVersionConflict)
+	})
+
+	suite.Run("UpdateWithoutVersionUsesTheOneRead", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task963").
+			Return(&domain.Task{ID: "task963", CreatedBy: "user123", Status: domain.TaskStatusPending, Version: 7}, nil)
+		suite.mockRepo.On("UpdateTask", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(t *domain.Task) bool {
+			return t.ID == "task963" && t.Version == 7
+		})).Return(domain.ErrVersionConflict)
+
+		err := suite.usecase.UpdateTask(suite.asOwner, newTask("task963", 0))
+
+		suite.ErrorIs(err, domain.ErrVersionConflict)
+	})
+
+	suite.Run("DeleteWithStaleVersion", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task964").
+			Return(&domain.Task{ID: "task964", CreatedBy: "user123", Version: 2}, nil)
+
+		err := suite.usecase.DeleteTask(suite.asOwner, "task964", 1)
+
+		suite.ErrorIs(err, domain.ErrVersionConflict)
+	})
+
+	suite.Run("DeleteWithCurrentVersion", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task965").
+			Return(&domain.Task{ID: "task965", CreatedBy: "user123", Version: 2}, nil)
+		suite.mockRepo.On("DeleteTask", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(t *domain.Task) bool {
+			return t.ID == "task965" && t.Version == 2
+		})).Return(nil)
+
+		err := suite.usecase.DeleteTask(suite.asOwner, "task965", 2)
+
+		suite.NoError(err)
+	})
+
+	suite.Run("RestoreBumpsVersion", func() {
+		suite.mockRepo.On("RestoreTask", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task966").
+			Return(&domain.Task{ID: "task966", Version: 4, DeletedAt: time.Now()}, nil)
+
+		task, err := suite.usecase.RestoreTask(suite.asAdmin, "task966")
+
+		suite.NoError(err)
+		suite.Equal(int64(5), task.Version)
+	})
+}
+
+// TestPatchTaskSuite tests the PatchTask method
+func (suite *TaskUsecaseTestSuite) TestPatchTaskSuite() {
+	str := func(s string) *string { return &s }
+	existing := func(id string) *domain.Task {
+		return &domain.Task{ID: id, Title: "Title", Description: "Description", DueDate: time.Now().Add(time.Hour), Status: domain.TaskStatusPending, CreatedBy: "user123", AssigneeID: "user456", Version: 2}
+	}
+
+	suite.Run("Success", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task971").Return(existing("task971"), nil)
+		patch := domain.TaskPatch{Status: str(domain.TaskStatusInProgress), AssigneeID: str("")}
+		suite.mockRepo.On("PatchTask", mock.AnythingOfType("*context.timerCtx"), "", "task971", int64(2), patch).Return(nil)
+
+		task, err := suite.usecase.PatchTask(suite.asOwner, "task971", 2, patch)
+
+		suite.NoError(err)
+		suite.Equal(domain.TaskStatusInProgress, task.Status)
+		suite.Empty(task.AssigneeID)
+		suite.Equal("Title", task.Title)
+		suite.Equal(int64(3), task.Version)
+		suite.mockHistoryRepo.AssertCalled(suite.T(), "AddEntry", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(e *domain.TaskHistoryEntry) bool {
+			return e.TaskID == "task971" && reflect.DeepEqual([]domain.TaskChange{
+				{Field: "status", Before: domain.TaskStatusPending, After: domain.TaskStatusInProgress},
+				{Field: "assignee_id", Before: "user456"},
+			}, e.Changes)
+		}))
+	})
+
+	suite.Run("ClearingRequiredField", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task972").Return(existing("task972"), nil)
+
+		task, err := suite.usecase.PatchTask(suite.asOwner, "task972", 0, domain.TaskPatch{Title: str("")})
+
+		suite.EqualError(err, "title is required")
+		suite.Nil(task)
+	})
+
+	suite.Run("InvalidTransition", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task973").Return(existing("task973"), nil)
+
+		_, err := suite.usecase.PatchTask(suite.asOwner, "task973", 0, domain.TaskPatch{Status: str("done")})
+
+		suite.ErrorIs(err, domain.ErrInvalidStatus)
+	})
+
+	suite.Run("StaleVersion", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task974").Return(existing("task974"), nil)
+
+		_, err := suite.usecase.PatchTask(suite.asOwner, "task974", 1, domain.TaskPatch{Title: str("New")})
+
+		suite.ErrorIs(err, domain.ErrVersionConflict)
+	})
+
+	suite.Run("EmptyPatch", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task975").Return(existing("task975"), nil)
+
+		task, err := suite.usecase.PatchTask(suite.asOwner, "task975", 0, domain.TaskPatch{})
+
+		suite.NoError(err)
+		suite.Equal(int64(2), task.Version)
+	})
+
+	suite.Run("NotVisible", func() {
+		suite.mockRepo.On("GetTaskByID", mock.AnythingOfType("*context.timerCtx"), domain.DefaultOrganizationID, "task976").
+			Return(&domain.Task{ID: "task976", CreatedBy: "someone"}, nil)
+
+		_, err := suite.usecase.PatchTask(suite.asOwner, "task976", 0, domain.TaskPatch{Title: str("New")})
+
+		suite.ErrorIs(err, domain.ErrTaskNotFound)
+	})
+}
+
 // TestContextTimeoutSuite tests context timeout scenarios
 func (suite *TaskUsecaseTestSuite) TestContextTimeoutSuite() {
 	suite.Run("Timeout", func() {
 		// Create usecase with very short timeout
-		usecase := NewTaskUsecase(suite.mockRepo, 1*time.Millisecond)
+		usecase := NewTaskUsecase(suite.mockRepo, suite.mockProjectRepo, suite.mockHistoryRepo, domain.DefaultWorkflow(), 1*time.Millisecond)
 
 		task := &domain.Task{
-			ID:          "task123",
 			Title:       "Test Task",
 			Description: "Test Description",
 			DueDate:     time.Now().Add(24 * time.Hour),
@@ -400,7 +1272,7 @@ func (suite *TaskUsecaseTestSuite) TestContextTimeoutSuite() {
 
 		suite.mockRepo.On("AddTask", mock.AnythingOfType("*context.timerCtx"), task).Return(errors.New("context deadline exceeded"))
 
-		err := usecase.Create(suite.ctx, task)
+		err := usecase.Create(suite.asOwner, task)
 
 		suite.Error(err)
 		suite.Contains(err.Error(), "context deadline exceeded")
@@ -410,4 +1282,4 @@ func (suite *TaskUsecaseTestSuite) TestContextTimeoutSuite() {
 // TestTaskUsecaseSuite runs the test suite
 func TestTaskUsecaseSuite(t *testing.T) {
 	suite.Run(t, new(TaskUsecaseTestSuite))
-} 
\ No newline at end of file
+}
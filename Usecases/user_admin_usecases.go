@@ -0,0 +1,302 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"task_manager/domain"
+	"time"
+)
+
+// Page size limits for GET /users.
+const (
+	DefaultUserPageSize = 20
+	MaxUserPageSize     = 100
+)
+
+// What happens to the tasks a deleted user created. Tasks assigned to the
+// user are unassigned either way.
+const (
+	// DeletedUserTasksReassign hands the tasks over to the admin deleting
+	// the user, in the organizations the admin belongs to. Tasks in other
+	// organizations keep the deleted user as their creator.
+	DeletedUserTasksReassign = "reassign"
+	// DeletedUserTasksDelete moves the tasks to the trash along with the
+	// user, in the organizations the admin belongs to, recording the admin
+	// as the one who deleted them. Tasks in other organizations keep the
+	// deleted user as their creator.
+	DeletedUserTasksDelete = "delete"
+)
+
+// UserAdminUsecase implements the admin-only user management actions.
+type UserAdminUsecase struct {
+	userRepository    domain.IUserRepository
+	taskRepository    domain.ITaskRepository
+	historyRepository domain.ITaskHistoryRepository
+	tokenRepository   domain.ITokenRepository
+	roleRepository    domain.IRoleRepository
+	orgRepository     domain.IOrganizationRepository
+	projectRepository domain.IProjectRepository
+	contextTimeout    time.Duration
+}
+
+func NewUserAdminUsecase(userRepository domain.IUserRepository, taskRepository domain.ITaskRepository, historyRepository domain.ITaskHistoryRepository, tokenRepository domain.ITokenRepository, roleRepository domain.IRoleRepository, orgRepository domain.IOrganizationRepository, projectRepository domain.IProjectRepository, timeout time.Duration) *UserAdminUsecase {
+	return &UserAdminUsecase{
+		userRepository:    userRepository,
+		taskRepository:    taskRepository,
+		historyRepository: historyRepository,
+		tokenRepository:   tokenRepository,
+		roleRepository:    roleRepository,
+		orgRepository:     orgRepository,
+		projectRepository: projectRepository,
+		contextTimeout:    timeout,
+	}
+}
+
+// ListUsers returns one page of users matching query. A zero Limit means
+// DefaultUserPageSize.
+func (au *UserAdminUsecase) ListUsers(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error) {
+	if query.Limit == 0 {
+		query.Limit = DefaultUserPageSize
+	}
+	if query.Limit < 0 || query.Limit > MaxUserPageSize {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidUserQuery, MaxUserPageSize)
+	}
+
+	c, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+	if query.Role != "" {
+		if _, err := resolveRole(c, au.roleRepository, query.Role); errors.Is(err, domain.ErrRoleNotFound) {
+			return nil, fmt.Errorf("%w: unknown role %q", domain.ErrInvalidUserQuery, query.Role)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return au.userRepository.ListUsers(c, query)
+}
+
+// PromoteUser makes the user with the given ID an admin. Only callers
+// holding every admin permission may do so.
+func (au *UserAdminUsecase) PromoteUser(ctx context.Context, id string) error {
+	admin, _ := domain.BuiltInRole(domain.RoleAdmin)
+	if err := ensureCanGrant(ctx, admin); err != nil {
+		return err
+	}
+	c, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+	user, err := au.getUser(c, id)
+	if err != nil {
+		return err
+	}
+	if user.Role == domain.RoleAdmin {
+		return nil
+	}
+	return au.userRepository.UpdateUserRole(c, user.ID, domain.RoleAdmin)
+}
+
+// DemoteUser turns an admin back into a regular user. Access tokens the user
+// already holds still claim the admin role, so they are revoked.
+func (au *UserAdminUsecase) DemoteUser(ctx context.Context, id string) error {
+	c, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+	user, err := au.getUser(c, id)
+	if err != nil {
+		return err
+	}
+	if user.Role != domain.RoleAdmin {
+		return domain.NewError(domain.ErrConflict, "not_admin", "user is not an admin")
+	}
+	if err := au.userRepository.UpdateUserRole(c, user.ID, domain.RoleUser); err != nil {
+		return err
+	}
+	return au.tokenRepository.RevokeUserAccessTokens(c, user.ID, time.Now())
+}
+
+// SetUserRole gives the user with the given ID a built-in or custom role.
+// Callers can only hand out permissions they hold themselves. The user's
+// access tokens name the old role, so they are revoked.
+func (au *UserAdminUsecase) SetUserRole(ctx context.Context, id, roleName string) error {
+	c, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+	role, err := resolveRole(c, au.roleRepository, roleName)
+	if errors.Is(err, domain.ErrRoleNotFound) {
+		return domain.NewValidationError("role", domain.ValidationInvalid, fmt.Sprintf("unknown role %q", roleName))
+	}
+	if err != nil {
+		return err
+	}
+	if err := ensureCanGrant(ctx, role); err != nil {
+		return err
+	}
+	user, err := au.getUser(c, id)
+	if err != nil {
+		return err
+	}
+	if user.Role == role.Name {
+		return nil
+	}
+	if err := au.userRepository.UpdateUserRole(c, user.ID, role.Name); err != nil {
+		return err
+	}
+	return au.tokenRepository.RevokeUserAccessTokens(c, user.ID, time.Now())
+}
+
+// DeactivateUser blocks an account from logging in and signs it out
+// everywhere.
+func (au *UserAdminUsecase) DeactivateUser(ctx context.Context, id string) error {
+	c, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+	user, err := au.getUser(c, id)
+	if err != nil {
+		return err
+	}
+	if user.Deactivated {
+		return nil
+	}
+	if err := au.userRepository.SetUserDeactivated(c, user.ID, true); err != nil {
+		return err
+	}
+	return revokeAllUserTokens(c, au.tokenRepository, user.ID)
+}
+
+// ReactivateUser lets a deactivated account log in again.
+func (au *UserAdminUsecase) ReactivateUser(ctx context.Context, id string) error {
+	c, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+	user, err := au.getUser(c, id)
+	if err != nil {
+		return err
+	}
+	if !user.Deactivated {
+		return nil
+	}
+	return au.userRepository.SetUserDeactivated(c, user.ID, false)
+}
+
+// DeleteUser removes an account on behalf of the authenticated admin.
+// taskPolicy is DeletedUserTasksReassign (the default when empty) or
+// DeletedUserTasksDelete. Admins cannot delete themselves, and the only
+// owner of an organization cannot be deleted until someone else owns it.
+// The account is deactivated before anything else, which fails for the last
+// active admin, so an error part way leaves it deactivated rather than half
+// deleted.
+func (au *UserAdminUsecase) DeleteUser(ctx context.Context, id, taskPolicy string) error {
+	admin, err := currentPrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	if taskPolicy == "" {
+		taskPolicy = DeletedUserTasksReassign
+	}
+	if taskPolicy != DeletedUserTasksReassign && taskPolicy != DeletedUserTasksDelete {
+		return domain.NewValidationError("tasks", domain.ValidationInvalid, fmt.Sprintf("unknown task policy %q; use %q or %q", taskPolicy, DeletedUserTasksReassign, DeletedUserTasksDelete))
+	}
+	if id == admin.UserID {
+		return domain.NewError(domain.ErrConflict, "cannot_delete_self", "admins cannot delete their own account")
+	}
+
+	c, cancel := context.WithTimeout(ctx, au.contextTimeout)
+	defer cancel()
+	user, err := au.getUser(c, id)
+	if err != nil {
+		return err
+	}
+	if err := au.ensureNotLastOwner(c, user.ID); err != nil {
+		return err
+	}
+	if !user.Deactivated {
+		if err := au.userRepository.SetUserDeactivated(c, user.ID, true); err != nil {
+			return err
+		}
+	}
+	orgIDs, err := au.organizationIDs(c, admin.UserID)
+	if err != nil {
+		return err
+	}
+	if taskPolicy == DeletedUserTasksDelete {
+		err = au.deleteTasks(c, user.ID, admin.UserID, orgIDs)
+	} else {
+		err = au.taskRepository.ReassignTasks(c, user.ID, admin.UserID, orgIDs)
+	}
+	if err != nil {
+		return err
+	}
+	if err := au.taskRepository.UnassignTasks(c, user.ID); err != nil {
+		return err
+	}
+	if err := au.orgRepository.RemoveUserMemberships(c, user.ID); err != nil {
+		return err
+	}
+	if err := au.projectRepository.RemoveUserProjectMemberships(c, user.ID); err != nil {
+		return err
+	}
+	if err := revokeAllUserTokens(c, au.tokenRepository, user.ID); err != nil {
+		return err
+	}
+	return au.userRepository.DeleteUser(c, user.ID)
+}
+
+func (au *UserAdminUsecase) getUser(ctx context.Context, id string) (*domain.User, error) {
+	if id == "" {
+		return nil, domain.NewValidationError("id", domain.ValidationRequired, "user ID is required")
+	}
+	return au.userRepository.GetUserByID(ctx, id)
+}
+
+// organizationIDs lists every organization userID belongs to, the default
+// one included.
+func (au *UserAdminUsecase) organizationIDs(ctx context.Context, userID string) ([]string, error) {
+	orgs, err := au.orgRepository.ListOrganizationsForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	orgIDs := []string{domain.DefaultOrganizationID}
+	for _, org := range orgs {
+		orgIDs = append(orgIDs, org.ID)
+	}
+	return orgIDs, nil
+}
+
+// deleteTasks moves the tasks userID created in orgIDs to the trash on
+// behalf of adminID and records their deletion in the task history.
+func (au *UserAdminUsecase) deleteTasks(ctx context.Context, userID, adminID string, orgIDs []string) error {
+	deleted, err := au.taskRepository.DeleteTasksByCreator(ctx, userID, orgIDs, adminID, time.Now())
+	if err != nil {
+		return err
+	}
+	for i := range deleted {
+		before := deleted[i]
+		before.DeletedAt = time.Time{}
+		before.DeletedBy = ""
+		if err := recordTaskHistory(ctx, au.historyRepository, adminID, domain.TaskActionDeleted, &before, &deleted[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureNotLastOwner fails if userID is the only owner of one of their
+// organizations.
+func (au *UserAdminUsecase) ensureNotLastOwner(ctx context.Context, userID string) error {
+	orgs, err := au.orgRepository.ListOrganizationsForUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, org := range orgs {
+		membership, err := au.orgRepository.GetMembership(ctx, org.ID, userID)
+		if err != nil {
+			return err
+		}
+		if membership.Role != domain.OrgRoleOwner {
+			continue
+		}
+		count, err := au.orgRepository.CountMembersWithRole(ctx, org.ID, domain.OrgRoleOwner)
+		if err != nil {
+			return err
+		}
+		if count <= 1 {
+			return fmt.Errorf("%w %q", domain.ErrLastOwner, org.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package usecases
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"task_manager/domain"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+// UserAdminUsecaseTestSuite is a test suite for UserAdminUsecase
+type UserAdminUsecaseTestSuite struct {
+	suite.Suite
+	mockUserRepo    *MockUserRepository
+	mockTaskRepo    *MockTaskRepository
+	mockHistoryRepo *MockTaskHistoryRepository
+	mockTokenRepo   *MockTokenRepository
+	mockRoleRepo    *MockRoleRepository
+	mockOrgRepo     *MockOrganizationRepository
+	mockProjectRepo *MockProjectRepository
+	usecase         *UserAdminUsecase
+	ctx             context.Context
+	asAdmin         context.Context
+}
+
+// SetupSuite runs once before all tests in the suite
+func (suite *UserAdminUsecaseTestSuite) SetupSuite() {
+	suite.ctx = context.Background()
+	suite.asAdmin = withRole(suite.ctx, "admin1", domain.RoleAdmin)
+}
+
+// SetupTest runs before each test
+func (suite *UserAdminUsecaseTestSuite) SetupTest() {
+	suite.mockUserRepo = new(MockUserRepository)
+	suite.mockTaskRepo = new(MockTaskRepository)
+	suite.mockHistoryRepo = new(MockTaskHistoryRepository)
+	suite.mockTokenRepo = new(MockTokenRepository)
+	suite.mockRoleRepo = new(MockRoleRepository)
+	suite.mockOrgRepo = new(MockOrganizationRepository)
+	suite.mockProjectRepo = new(MockProjectRepository)
+	suite.usecase = NewUserAdminUsecase(suite.mockUserRepo, suite.mockTaskRepo, suite.mockHistoryRepo, suite.mockTokenRepo, suite.mockRoleRepo, suite.mockOrgRepo, suite.mockProjectRepo, 5*time.Second)
+}
+
+// TearDownTest runs after each test
+func (suite *UserAdminUsecaseTestSuite) TearDownTest() {
+	suite.mockUserRepo.AssertExpectations(suite.T())
+	suite.mockTaskRepo.AssertExpectations(suite.T())
+	suite.mockHistoryRepo.AssertExpectations(suite.T())
+	suite.mockTokenRepo.AssertExpectations(suite.T())
+	suite.mockRoleRepo.AssertExpectations(suite.T())
+	suite.mockOrgRepo.AssertExpectations(suite.T())
+	suite.mockProjectRepo.AssertExpectations(suite.T())
+}
+
+// TestListUsersSuite tests the ListUsers method
+func (suite *UserAdminUsecaseTestSuite) TestListUsersSuite() {
+	suite.Run("DefaultLimit", func() {
+		page := &domain.UserPage{Users: []domain.User{{ID: "user1"}}, Total: 1}
+		suite.mockUserRepo.On("ListUsers", mock.AnythingOfType("*context.timerCtx"), domain.UserQuery{Search: "ali", Role: "admin", Limit: DefaultUserPageSize}).Return(page, nil)
+
+		result, err := suite.usecase.ListUsers(suite.ctx, domain.UserQuery{Search: "ali", Role: "admin"})
+
+		suite.NoError(err)
+		suite.Equal(page, result)
+	})
+
+	suite.Run("LimitTooLarge", func() {
+		result, err := suite.usecase.ListUsers(suite.ctx, domain.UserQuery{Limit: MaxUserPageSize + 1})
+
+		suite.ErrorIs(err, domain.ErrInvalidUserQuery)
+		suite.Nil(result)
+	})
+
+	suite.Run("CustomRole", func() {
+		page := &domain.UserPage{Users: []domain.User{{ID: "user2", Role: "auditor"}}, Total: 1}
+		suite.mockRoleRepo.On("GetRole", mock.AnythingOfType("*context.timerCtx"), "auditor").Return(&domain.Role{Name: "auditor"}, nil)
+		suite.mockUserRepo.On("ListUsers", mock.AnythingOfType("*context.timerCtx"), domain.UserQuery{Role: "auditor", Limit: DefaultUserPageSize}).Return(page, nil)
+
+		result, err := suite.usecase.ListUsers(suite.ctx, domain.UserQuery{Role: "auditor"})
+
+		suite.NoError(err)
+		suite.Equal(page, result)
+	})
+
+	suite.Run("UnknownRole", func() {
+		suite.mockRoleRepo.On("GetRole", mock.AnythingOfType("*context.timerCtx"), "superuser").Return(nil, domain.ErrRoleNotFound)
+
+		result, err := suite.usecase.ListUsers(suite.ctx, domain.UserQuery{Role: "superuser"})
+
+		suite.ErrorIs(err, domain.ErrInvalidUserQuery)
+		suite.Nil(result)
+	})
+}
+
+// TestPromoteUserSuite tests the PromoteUser method
+func (suite *UserAdminUsecaseTestSuite) TestPromoteUserSuite() {
+	suite.Run("Success", func() {
+		user := &domain.User{ID: "user1", Role: domain.RoleUser}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user1").Return(user, nil)
+		suite.mockUserRepo.On("UpdateUserRole", mock.AnythingOfType("*context.timerCtx"), "user1", domain.RoleAdmin).Return(nil)
+
+		err := suite.usecase.PromoteUser(suite.asAdmin, "user1")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("NotFound", func() {
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "ghost").Return(nil, domain.ErrUserNotFound)
+
+		err := suite.usecase.PromoteUser(suite.asAdmin, "ghost")
+
+		suite.ErrorIs(err, domain.ErrUserNotFound)
+	})
+
+	suite.Run("CallerLacksAdminPermissions", func() {
+		manager := domain.WithPrincipal(suite.ctx, &domain.Principal{UserID: "pm1", Role: "project_manager", Permissions: []string{domain.PermissionUsersPromote}})
+
+		err := suite.usecase.PromoteUser(manager, "user1")
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+	})
+}
+
+// TestSetUserRoleSuite tests the SetUserRole method
+func (suite *UserAdminUsecaseTestSuite) TestSetUserRoleSuite() {
+	auditor := &domain.Role{Name: "auditor", Permissions: []string{domain.PermissionTasksRead, domain.PermissionTasksReadAll}}
+
+	suite.Run("CustomRole", func() {
+		user := &domain.User{ID: "user1", Role: domain.RoleUser}
+		suite.mockRoleRepo.On("GetRole", mock.AnythingOfType("*context.timerCtx"), "auditor").Return(auditor, nil)
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user1").Return(user, nil)
+		suite.mockUserRepo.On("UpdateUserRole", mock.AnythingOfType("*context.timerCtx"), "user1", "auditor").Return(nil)
+		suite.mockTokenRepo.On("RevokeUserAccessTokens", mock.AnythingOfType("*context.timerCtx"), "user1", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := suite.usecase.SetUserRole(suite.asAdmin, "user1", "auditor")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("UnchangedRole", func() {
+		user := &domain.User{ID: "user2", Role: domain.RoleUser}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user2").Return(user, nil)
+
+		err := suite.usecase.SetUserRole(suite.asAdmin, "user2", domain.RoleUser)
+
+		suite.NoError(err)
+	})
+
+	suite.Run("UnknownRole", func() {
+		suite.mockRoleRepo.On("GetRole", mock.AnythingOfType("*context.timerCtx"), "wizard").Return(nil, domain.ErrRoleNotFound)
+
+		err := suite.usecase.SetUserRole(suite.asAdmin, "user1", "wizard")
+
+		suite.Error(err)
+		suite.Equal(`unknown role "wizard"`, err.Error())
+	})
+
+	suite.Run("LastAdmin", func() {
+		admin := &domain.User{ID: "admin1", Role: domain.RoleAdmin}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "admin1").Return(admin, nil)
+		suite.mockUserRepo.On("UpdateUserRole", mock.AnythingOfType("*context.timerCtx"), "admin1", domain.RoleUser).Return(domain.ErrLastAdmin).Once()
+
+		err := suite.usecase.SetUserRole(suite.asAdmin, "admin1", domain.RoleUser)
+
+		suite.ErrorIs(err, domain.ErrLastAdmin)
+	})
+
+	suite.Run("CannotGrantMissingPermissions", func() {
+		manager := domain.WithPrincipal(suite.ctx, &domain.Principal{UserID: "pm1", Role: "project_manager", Permissions: []string{domain.PermissionUsersPromote, domain.PermissionTasksRead}})
+
+		err := suite.usecase.SetUserRole(manager, "user1", domain.RoleAdmin)
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+	})
+}
+
+// TestDemoteUserSuite tests the DemoteUser method
+func (suite *UserAdminUsecaseTestSuite) TestDemoteUserSuite() {
+	suite.Run("Success", func() {
+		admin := &domain.User{ID: "admin2", Role: domain.RoleAdmin}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "admin2").Return(admin, nil)
+		suite.mockUserRepo.On("UpdateUserRole", mock.AnythingOfType("*context.timerCtx"), "admin2", domain.RoleUser).Return(nil)
+		suite.mockTokenRepo.On("RevokeUserAccessTokens", mock.AnythingOfType("*context.timerCtx"), "admin2", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := suite.usecase.DemoteUser(suite.ctx, "admin2")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("LastAdmin", func() {
+		admin := &domain.User{ID: "admin1", Role: domain.RoleAdmin}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "admin1").Return(admin, nil)
+		suite.mockUserRepo.On("UpdateUserRole", mock.AnythingOfType("*context.timerCtx"), "admin1", domain.RoleUser).Return(domain.ErrLastAdmin).Once()
+
+		err := suite.usecase.DemoteUser(suite.ctx, "admin1")
+
+		suite.ErrorIs(err, domain.ErrLastAdmin)
+	})
+
+	suite.Run("NotAnAdmin", func() {
+		user := &domain.User{ID: "user1", Role: domain.RoleUser}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user1").Return(user, nil)
+
+		err := suite.usecase.DemoteUser(suite.ctx, "user1")
+
+		suite.Error(err)
+		suite.Equal("user is not an admin", err.Error())
+	})
+
+	suite.Run("NotFound", func() {
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "ghost").Return(nil, domain.ErrUserNotFound)
+
+		err := suite.usecase.DemoteUser(suite.ctx, "ghost")
+
+		suite.ErrorIs(err, domain.ErrUserNotFound)
+	})
+}
+
+// TestDeactivateUserSuite tests deactivating and reactivating users
+func (suite *UserAdminUsecaseTestSuite) TestDeactivateUserSuite() {
+	suite.Run("Deactivate", func() {
+		user := &domain.User{ID: "user1", Role: domain.RoleUser}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user1").Return(user, nil)
+		suite.mockUserRepo.On("SetUserDeactivated", mock.AnythingOfType("*context.timerCtx"), "user1", true).Return(nil)
+		suite.mockTokenRepo.On("RevokeUserRefreshTokens", mock.AnythingOfType("*context.timerCtx"), "user1").Return(nil)
+		suite.mockTokenRepo.On("RevokeUserAccessTokens", mock.AnythingOfType("*context.timerCtx"), "user1", mock.AnythingOfType("time.Time")).Return(nil)
+
+		err := suite.usecase.DeactivateUser(suite.ctx, "user1")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("DeactivateLastAdmin", func() {
+		admin := &domain.User{ID: "admin1", Role: domain.RoleAdmin}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "admin1").Return(admin, nil)
+		suite.mockUserRepo.On("SetUserDeactivated", mock.AnythingOfType("*context.timerCtx"), "admin1", true).Return(domain.ErrLastAdmin).Once()
+
+		err := suite.usecase.DeactivateUser(suite.ctx, "admin1")
+
+		suite.ErrorIs(err, domain.ErrLastAdmin)
+	})
+
+	suite.Run("Reactivate", func() {
+		user := &domain.User{ID: "user2", Role: domain.RoleUser, Deactivated: true}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user2").Return(user, nil)
+		suite.mockUserRepo.On("SetUserDeactivated", mock.AnythingOfType("*context.timerCtx"), "user2", false).Return(nil)
+
+		err := suite.usecase.ReactivateUser(suite.ctx, "user2")
+
+		suite.NoError(err)
+	})
+}
+
+// TestDeleteUserSuite tests the DeleteUser method
+func (suite *UserAdminUsecaseTestSuite) TestDeleteUserSuite() {
+	suite.Run("ReassignTasks", func() {
+		user := &domain.User{ID: "user1", Role: domain.RoleUser}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user1").Return(user, nil)
+		suite.mockOrgRepo.On("ListOrganizationsForUser", mock.AnythingOfType("*context.timerCtx"), "user1").Return([]domain.Organization{}, nil)
+		suite.mockUserRepo.On("SetUserDeactivated", mock.AnythingOfType("*context.timerCtx"), "user1", true).Return(nil)
+		suite.mockOrgRepo.On("ListOrganizationsForUser", mock.AnythingOfType("*context.timerCtx"), "admin1").Return([]domain.Organization{{ID: "org1", Name: "Engineering"}}, nil)
+		suite.mockTaskRepo.On("ReassignTasks", mock.AnythingOfType("*context.timerCtx"), "user1", "admin1", []string{domain.DefaultOrganizationID, "org1"}).Return(nil)
+		suite.mockTaskRepo.On("UnassignTasks", mock.AnythingOfType("*context.timerCtx"), "user1").Return(nil)
+		suite.mockOrgRepo.On("RemoveUserMemberships", mock.AnythingOfType("*context.timerCtx"), "user1").Return(nil)
+		suite.mockProjectRepo.On("RemoveUserProjectMemberships", mock.AnythingOfType("*context.timerCtx"), "user1").Return(nil)
+		suite.mockTokenRepo.On("RevokeUserRefreshTokens", mock.AnythingOfType("*context.timerCtx"), "user1").Return(nil)
+		suite.mockTokenRepo.On("RevokeUserAccessTokens", mock.AnythingOfType("*context.timerCtx"), "user1", mock.AnythingOfType("time.Time")).Return(nil)
+		suite.mockUserRepo.On("DeleteUser", mock.AnythingOfType("*context.timerCtx"), "user1").Return(nil)
+
+		err := suite.usecase.DeleteUser(suite.asAdmin, "user1", "")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("DeleteTasks", func() {
+		user := &domain.User{ID: "user2", Role: domain.RoleUser}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user2").Return(user, nil)
+		suite.mockOrgRepo.On("ListOrganizationsForUser", mock.AnythingOfType("*context.timerCtx"), "user2").Return([]domain.Organization{}, nil)
+		suite.mockUserRepo.On("SetUserDeactivated", mock.AnythingOfType("*context.timerCtx"), "user2", true).Return(nil)
+		suite.mockOrgRepo.On("ListOrganizationsForUser", mock.AnythingOfType("*context.timerCtx"), "admin1").Return([]domain.Organization{{ID: "org1", Name: "Engineering"}}, nil)
+		deleted := []domain.Task{{ID: "task1", OrgID: "org1", Title: "Report", CreatedBy: "user2", Version: 3, DeletedAt: time.Now(), DeletedBy: "admin1"}}
+		suite.mockTaskRepo.On("DeleteTasksByCreator", mock.AnythingOfType("*context.timerCtx"), "user2", []string{domain.DefaultOrganizationID, "org1"}, "admin1", mock.AnythingOfType("time.Time")).Return(deleted, nil)
+		suite.mockHistoryRepo.On("AddEntry", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(entry *domain.TaskHistoryEntry) bool {
+			return entry.TaskID == "task1" && entry.OrgID == "org1" && entry.Action == domain.TaskActionDeleted && entry.ActorID == "admin1" &&
+				len(entry.Changes) == 1 && entry.Changes[0].Field == "deleted_at" && entry.Changes[0].Before == ""
+		})).Return(nil)
+		suite.mockTaskRepo.On("UnassignTasks", mock.AnythingOfType("*context.timerCtx"), "user2").Return(nil)
+		suite.mockOrgRepo.On("RemoveUserMemberships", mock.AnythingOfType("*context.timerCtx"), "user2").Return(nil)
+		suite.mockProjectRepo.On("RemoveUserProjectMemberships", mock.AnythingOfType("*context.timerCtx"), "user2").Return(nil)
+		suite.mockTokenRepo.On("RevokeUserRefreshTokens", mock.AnythingOfType("*context.timerCtx"), "user2").Return(nil)
+		suite.mockTokenRepo.On("RevokeUserAccessTokens", mock.AnythingOfType("*context.timerCtx"), "user2", mock.AnythingOfType("time.Time")).Return(nil)
+		suite.mockUserRepo.On("DeleteUser", mock.AnythingOfType("*context.timerCtx"), "user2").Return(nil)
+
+		err := suite.usecase.DeleteUser(suite.asAdmin, "user2", DeletedUserTasksDelete)
+
+		suite.NoError(err)
+	})
+
+	suite.Run("LastAdmin", func() {
+		admin := &domain.User{ID: "admin9", Role: domain.RoleAdmin}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "admin9").Return(admin, nil)
+		suite.mockOrgRepo.On("ListOrganizationsForUser", mock.AnythingOfType("*context.timerCtx"), "admin9").Return([]domain.Organization{}, nil)
+		suite.mockUserRepo.On("SetUserDeactivated", mock.AnythingOfType("*context.timerCtx"), "admin9", true).Return(domain.ErrLastAdmin).Once()
+
+		err := suite.usecase.DeleteUser(suite.asAdmin, "admin9", "")
+
+		suite.ErrorIs(err, domain.ErrLastAdmin)
+	})
+
+	suite.Run("LastOwner", func() {
+		user := &domain.User{ID: "user3", Role: domain.RoleUser}
+		orgs := []domain.Organization{{ID: "org1", Name: "Engineering"}, {ID: "org2", Name: "Sales"}}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user3").Return(user, nil)
+		suite.mockOrgRepo.On("ListOrganizationsForUser", mock.AnythingOfType("*context.timerCtx"), "user3").Return(orgs, nil)
+		suite.mockOrgRepo.On("GetMembership", mock.AnythingOfType("*context.timerCtx"), "org1", "user3").Return(&domain.Membership{OrgID: "org1", UserID: "user3", Role: domain.OrgRoleOwner}, nil)
+		suite.mockOrgRepo.On("CountMembersWithRole", mock.AnythingOfType("*context.timerCtx"), "org1", domain.OrgRoleOwner).Return(int64(2), nil)
+		suite.mockOrgRepo.On("GetMembership", mock.AnythingOfType("*context.timerCtx"), "org2", "user3").Return(&domain.Membership{OrgID: "org2", UserID: "user3", Role: domain.OrgRoleOwner}, nil)
+		suite.mockOrgRepo.On("CountMembersWithRole", mock.AnythingOfType("*context.timerCtx"), "org2", domain.OrgRoleOwner).Return(int64(1), nil)
+
+		err := suite.usecase.DeleteUser(suite.asAdmin, "user3", "")
+
+		suite.ErrorIs(err, domain.ErrLastOwner)
+		suite.Equal(`cannot remove the last owner of the organization "Sales"`, err.Error())
+	})
+
+	suite.Run("Self", func() {
+		err := suite.usecase.DeleteUser(suite.asAdmin, "admin1", "")
+
+		suite.Error(err)
+		suite.Equal("admins cannot delete their own account", err.Error())
+	})
+
+	suite.Run("Unauthenticated", func() {
+		err := suite.usecase.DeleteUser(suite.ctx, "user1", "")
+
+		suite.Error(err)
+		suite.Equal("authenticated user is required", err.Error())
+	})
+
+	suite.Run("UnknownPolicy", func() {
+		err := suite.usecase.DeleteUser(suite.asAdmin, "user1", "archive")
+
+		suite.Error(err)
+	})
+}
+
+// TestUserAdminUsecaseSuite runs the test suite
+func TestUserAdminUsecaseSuite(t *testing.T) {
+	suite.Run(t, new(UserAdminUsecaseTestSuite))
+}
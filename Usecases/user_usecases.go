@@ -2,113 +2,553 @@ package usecases
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"strings"
+	"net/mail"
+	"net/url"
 	"task_manager/domain"
 	"time"
 )
 
+// RefreshTokenTTL is how long a refresh token can be exchanged for a new
+// token pair.
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
+var (
+	errInvalidCredentials       = domain.NewError(domain.ErrUnauthorized, "invalid_credentials", "invalid email/username or password")
+	errInvalidRefreshToken      = domain.NewError(domain.ErrUnauthorized, "invalid_refresh_token", "invalid refresh token")
+	errInvalidVerificationToken = domain.NewValidationError("token", domain.ValidationInvalid, "invalid or expired verification token")
+)
+
 type UserUsecase struct {
-	userRepository domain.IUserRepository
-	passwordService domain.IPasswordService
-	jwtService domain.IJWTService
-	contextTimeout time.Duration
+	userRepository           domain.IUserRepository
+	tokenRepository          domain.ITokenRepository
+	orgRepository            domain.IOrganizationRepository
+	resetRepository          domain.IPasswordResetRepository
+	passwordService          domain.IPasswordService
+	jwtService               domain.IJWTService
+	mailSender               domain.IMailSender
+	verifyURL                string
+	requireEmailVerification bool
+	contextTimeout           time.Duration
 }
 
-func NewUserUsecase(userRepository domain.IUserRepository, passwordService domain.IPasswordService, jwtService domain.IJWTService, timeout time.Duration) *UserUsecase {
+// NewUserUsecase creates a UserUsecase. verifyURL is the page linked from
+// verification emails; the token is appended as the "token" query parameter.
+// When requireEmailVerification is set, unverified accounts cannot log in.
+func NewUserUsecase(userRepository domain.IUserRepository, tokenRepository domain.ITokenRepository, orgRepository domain.IOrganizationRepository, resetRepository domain.IPasswordResetRepository, passwordService domain.IPasswordService, jwtService domain.IJWTService, mailSender domain.IMailSender, verifyURL string, requireEmailVerification bool, timeout time.Duration) *UserUsecase {
 	return &UserUsecase{
-		userRepository: userRepository,
-		passwordService: passwordService,
-		jwtService: jwtService,
-		contextTimeout: timeout,
+		userRepository:           userRepository,
+		tokenRepository:          tokenRepository,
+		orgRepository:            orgRepository,
+		resetRepository:          resetRepository,
+		passwordService:          passwordService,
+		jwtService:               jwtService,
+		mailSender:               mailSender,
+		verifyURL:                verifyURL,
+		requireEmailVerification: requireEmailVerification,
+		contextTimeout:           timeout,
 	}
 }
 
-func (uu *UserUsecase) RegisterUser(ctx context.Context, username, email, password string) (string, error) {
+// RegisterUser creates an account and returns it with its ID set. The first account
+// becomes an admin and is verified right away; every other account starts
+// unverified and is sent a verification link.
+func (uu *UserUsecase) RegisterUser(ctx context.Context, username, email, password string) (*domain.User, error) {
 	// Validate input parameters
+	var errs domain.ValidationErrors
 	if username == "" {
-		return "", errors.New("username is required")
+		errs.Add("username", domain.ValidationRequired, "username is required")
 	}
 	if email == "" {
-		return "", errors.New("email is required")
+		errs.Add("email", domain.ValidationRequired, "email is required")
+	} else if !isEmailAddress(email) {
+		errs.Add("email", domain.ValidationInvalid, invalidEmailMessage)
 	}
 	if password == "" {
-		return "", errors.New("password is required")
+		errs.Add("password", domain.ValidationRequired, "password is required")
+	} else if len(password) < 6 {
+		errs.Add("password", domain.ValidationTooShort, "password must be at least 6 characters long")
 	}
-	if len(password) < 6 {
-		return "", errors.New("password must be at least 6 characters long")
+	if err := errs.Err(); err != nil {
+		return nil, err
 	}
-	
-	// Basic email validation
-	if !strings.Contains(email, "@") {
-		return "", errors.New("invalid email format")
-	}
-	
+
 	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
 	defer cancel()
-	if exists, _ := uu.userRepository.UserExistsByEmail(c, email); exists {
-		return "", errors.New("email already registered")
-	}
-	if exists, _ := uu.userRepository.UserExistsByUsername(c, username); exists {
-		return "", errors.New("username already taken")
-	}
-	isEmpty, _ := uu.userRepository.IsUsersCollectionEmpty(c)
-	role := "user"
-	if isEmpty {
-		role = "admin"
+	isEmpty, err := uu.userRepository.IsUsersCollectionEmpty(c)
+	if err != nil {
+		return nil, err
 	}
 	hashed, err := uu.passwordService.HashPassword(password)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	// The repository refuses a taken username or email, so of two
+	// concurrent registrations with the same one only the first succeeds.
+	if isEmpty {
+		admin := &domain.User{
+			Username:      username,
+			Email:         email,
+			Password:      hashed,
+			Role:          domain.RoleAdmin,
+			EmailVerified: true,
+		}
+		err := uu.userRepository.AddFirstAdmin(c, admin)
+		if err == nil {
+			return admin, nil
+		}
+		// Another registration became the first admin in the meantime.
+		if !errors.Is(err, domain.ErrFirstAdminExists) {
+			return nil, err
+		}
 	}
 	user := &domain.User{
 		Username: username,
 		Email:    email,
 		Password: hashed,
-		Role:     role,
+		Role:     domain.RoleUser,
 	}
 	if err := uu.userRepository.AddUser(c, user); err != nil {
-		return "", err
+		return nil, err
 	}
-	return role, nil
+	// The account exists either way; if delivery fails the user can ask for
+	// another link through ResendVerificationEmail.
+	_ = uu.sendVerificationEmail(c, user)
+	return user, nil
 }
 
-func (uu *UserUsecase) LoginUser(ctx context.Context, usernameOrEmail, password string) (string, string, error) {
+// LoginUser checks the credentials and issues an access token together with a
+// refresh token that starts a new token family. It also returns the user.
+func (uu *UserUsecase) LoginUser(ctx context.Context, usernameOrEmail, password string) (*domain.TokenPair, *domain.User, error) {
 	// Validate input parameters
 	if usernameOrEmail == "" {
-		return "", "", errors.New("username or email is required")
+		return nil, nil, domain.NewValidationError("username", domain.ValidationRequired, "username or email is required")
 	}
 	if password == "" {
-		return "", "", errors.New("password is required")
+		return nil, nil, domain.NewValidationError("password", domain.ValidationRequired, "password is required")
 	}
-	
+
 	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
 	defer cancel()
-	var user *domain.User
-	var err error
-	if user, err = uu.userRepository.GetUserByEmail(c, usernameOrEmail); err != nil || user == nil {
+	user, err := uu.userRepository.GetUserByEmail(c, usernameOrEmail)
+	if errors.Is(err, domain.ErrUserNotFound) {
 		user, err = uu.userRepository.GetUserByUsername(c, usernameOrEmail)
-		if err != nil || user == nil {
-			return "", "", errors.New("invalid email/username or password")
-		}
+	}
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, nil, errInvalidCredentials
+	}
+	if err != nil {
+		return nil, nil, err
 	}
 	if !uu.passwordService.CheckPasswordHash(password, user.Password) {
-		return "", "", errors.New("invalid email/username or password")
+		return nil, nil, errInvalidCredentials
+	}
+	if user.Deactivated {
+		return nil, nil, domain.ErrUserDeactivated
+	}
+	if uu.requireEmailVerification && !user.EmailVerified {
+		return nil, nil, domain.ErrEmailNotVerified
 	}
-	token, err := uu.jwtService.GenerateToken(user)
+	tokens, err := uu.issueTokens(c, user, "")
 	if err != nil {
-		return "", "", err
+		return nil, nil, err
 	}
-	return token, user.Role, nil
+	return tokens, user, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new token pair. Each refresh
+// token works once; presenting one that was already exchanged is treated as
+// theft and revokes every token in its family.
+func (uu *UserUsecase) RefreshTokens(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	if refreshToken == "" {
+		return nil, domain.NewValidationError("refresh_token", domain.ValidationRequired, "refresh token is required")
+	}
+
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+	stored, err := uu.tokenRepository.GetRefreshTokenByHash(c, hashToken(refreshToken))
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, errInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored.Revoked {
+		return nil, errInvalidRefreshToken
+	}
+	if !stored.UsedAt.IsZero() {
+		return nil, uu.revokeReusedFamily(c, stored)
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, domain.NewError(domain.ErrUnauthorized, "refresh_token_expired", "refresh token has expired")
+	}
+	consumed, err := uu.tokenRepository.MarkRefreshTokenUsed(c, stored.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !consumed {
+		// Another request exchanged the same token first.
+		return nil, uu.revokeReusedFamily(c, stored)
+	}
+	user, err := uu.userRepository.GetUserByID(c, stored.UserID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, errInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Deactivated {
+		return nil, domain.ErrUserDeactivated
+	}
+	return uu.issueTokens(c, user, stored.FamilyID)
+}
+
+// Logout revokes the caller's access token and, when given, the refresh token
+// family it belongs to. The caller must own the refresh token.
+func (uu *UserUsecase) Logout(ctx context.Context, refreshToken string) error {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return err
+	}
+	if principal.TokenID == "" {
+		return domain.NewError(domain.ErrUnauthorized, "unauthenticated", "access token ID is required")
+	}
+
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+	if err := uu.tokenRepository.RevokeAccessToken(c, principal.TokenID, principal.ExpiresAt); err != nil {
+		return err
+	}
+	if refreshToken == "" {
+		return nil
+	}
+	stored, err := uu.tokenRepository.GetRefreshTokenByHash(c, hashToken(refreshToken))
+	if errors.Is(err, domain.ErrNotFound) || err == nil && stored.UserID != principal.UserID {
+		return domain.NewValidationError("refresh_token", domain.ValidationInvalid, "invalid refresh token")
+	}
+	if err != nil {
+		return err
+	}
+	return uu.tokenRepository.RevokeTokenFamily(c, stored.FamilyID)
+}
+
+// issueTokens creates an access token scoped to the user's active
+// organization and a refresh token for user. An empty familyID starts a new
+// refresh token family. Users who have left their active organization fall
+// back to the default one.
+func (uu *UserUsecase) issueTokens(ctx context.Context, user *domain.User, familyID string) (*domain.TokenPair, error) {
+	membership, err := getMembership(ctx, uu.orgRepository, user.ActiveOrgID, user.ID)
+	if errors.Is(err, domain.ErrOrganizationNotFound) {
+		membership, err = getMembership(ctx, uu.orgRepository, domain.DefaultOrganizationID, user.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := uu.jwtService.GenerateToken(user, membership)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	if familyID == "" {
+		if familyID, err = newOpaqueToken(); err != nil {
+			return nil, err
+		}
+	}
+	now := time.Now()
+	stored := &domain.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		CreatedAt: now,
+		ExpiresAt: now.Add(RefreshTokenTTL),
+	}
+	if err := uu.tokenRepository.AddRefreshToken(ctx, stored); err != nil {
+		return nil, err
+	}
+	return &domain.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func (uu *UserUsecase) revokeReusedFamily(ctx context.Context, stored *domain.RefreshToken) error {
+	if err := uu.tokenRepository.RevokeTokenFamily(ctx, stored.FamilyID); err != nil {
+		return err
+	}
+	return domain.NewError(domain.ErrUnauthorized, "refresh_token_reused", "refresh token reuse detected; please log in again")
+}
+
+// VerifyEmail marks an account verified using the token from a verification
+// email. A token stops working once the account changes its email address.
+func (uu *UserUsecase) VerifyEmail(ctx context.Context, token string) error {
+	if token == "" {
+		return domain.NewValidationError("token", domain.ValidationRequired, "verification token is required")
+	}
+	userID, email, err := uu.jwtService.ParseEmailVerificationToken(token)
+	if err != nil {
+		return errInvalidVerificationToken
+	}
+
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+	user, err := uu.userRepository.GetUserByID(c, userID)
+	if errors.Is(err, domain.ErrUserNotFound) || err == nil && user.Email != email {
+		return errInvalidVerificationToken
+	}
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return nil
+	}
+	return uu.userRepository.MarkEmailVerified(c, user.ID)
+}
+
+// ResendVerificationEmail sends a fresh verification link to email. Unknown
+// and already verified addresses are silently ignored, so the endpoint cannot
+// be used to discover which emails have accounts.
+func (uu *UserUsecase) ResendVerificationEmail(ctx context.Context, email string) error {
+	if email == "" {
+		return domain.NewValidationError("email", domain.ValidationRequired, "email is required")
+	}
+
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+	user, err := uu.userRepository.GetUserByEmail(c, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil || user.EmailVerified {
+		return err
+	}
+	return uu.sendVerificationEmail(c, user)
+}
+
+// MarkEmailVerified lets an admin verify an account without the email link.
+func (uu *UserUsecase) MarkEmailVerified(ctx context.Context, userID string) error {
+	if userID == "" {
+		return domain.NewValidationError("id", domain.ValidationRequired, "user ID is required")
+	}
+
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+	return uu.userRepository.MarkEmailVerified(c, userID)
+}
+
+func (uu *UserUsecase) sendVerificationEmail(ctx context.Context, user *domain.User) error {
+	token, err := uu.jwtService.GenerateEmailVerificationToken(user)
+	if err != nil {
+		return err
+	}
+	return uu.mailSender.Send(ctx, &domain.Mail{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Please confirm your email address by opening the link below within the next 24 hours:\n\n" +
+			tokenLink(uu.verifyURL, token) + "\n\n" +
+			"If you did not create an account, you can ignore this email.\n",
+	})
+}
+
+// GetProfile returns the account of the authenticated user.
+func (uu *UserUsecase) GetProfile(ctx context.Context, userID string) (*domain.User, error) {
+	if userID == "" {
+		return nil, domain.NewError(domain.ErrUnauthorized, "unauthenticated", "authenticated user is required")
+	}
+
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+	return uu.userRepository.GetUserByID(c, userID)
+}
+
+// UpdateProfile changes the username and/or email of the authenticated user;
+// nil leaves a field unchanged. A new email address has to be verified again.
+func (uu *UserUsecase) UpdateProfile(ctx context.Context, userID string, username, email *string) (*domain.User, error) {
+	if username != nil && *username == "" {
+		return nil, domain.NewValidationError("username", domain.ValidationRequired, "username cannot be empty")
+	}
+	if email != nil {
+		if *email == "" {
+			return nil, domain.NewValidationError("email", domain.ValidationRequired, "email cannot be empty")
+		}
+		if err := validateEmail(*email); err != nil {
+			return nil, err
+		}
+	}
+	user, err := uu.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+	emailChanged := email != nil && *email != user.Email
+	if emailChanged {
+		user.Email = *email
+		user.EmailVerified = false
+	}
+	if username != nil {
+		user.Username = *username
+	}
+	if err := uu.userRepository.UpdateProfile(c, user); err != nil {
+		return nil, err
+	}
+	if emailChanged {
+		// As on registration, a failed delivery can be retried through
+		// ResendVerificationEmail.
+		_ = uu.sendVerificationEmail(c, user)
+	}
+	return user, nil
+}
+
+// ChangePassword replaces the password of the authenticated user after
+// checking the current one. Every access and refresh token issued so far is
+// revoked, along with pending password resets, and a new token pair is
+// returned so the caller stays logged in.
+func (uu *UserUsecase) ChangePassword(ctx context.Context, userID, currentPassword, newPassword string) (*domain.TokenPair, error) {
+	var errs domain.ValidationErrors
+	if currentPassword == "" {
+		errs.Add("current_password", domain.ValidationRequired, "current password is required")
+	}
+	if newPassword == "" {
+		errs.Add("new_password", domain.ValidationRequired, "new password is required")
+	} else if len(newPassword) < 6 {
+		errs.Add("new_password", domain.ValidationTooShort, "password must be at least 6 characters long")
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	user, err := uu.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !uu.passwordService.CheckPasswordHash(currentPassword, user.Password) {
+		return nil, domain.NewValidationError("current_password", domain.ValidationInvalid, "current password is incorrect")
+	}
+
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+	hashed, err := uu.passwordService.HashPassword(newPassword)
+	if err != nil {
+		return nil, err
+	}
+	if err := uu.userRepository.UpdatePassword(c, user.ID, hashed); err != nil {
+		return nil, err
+	}
+	if err := uu.resetRepository.DeleteUserPasswordResets(c, user.ID); err != nil {
+		return nil, err
+	}
+	if err := revokeAllUserTokens(c, uu.tokenRepository, user.ID); err != nil {
+		return nil, err
+	}
+	return uu.issueTokens(c, user, "")
+}
+
+// SwitchOrganization makes orgID the caller's active organization and
+// returns a token pair scoped to it. The caller must be a member.
+func (uu *UserUsecase) SwitchOrganization(ctx context.Context, orgID string) (*domain.TokenPair, error) {
+	principal, err := currentPrincipal(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if orgID == "" {
+		return nil, domain.NewValidationError("id", domain.ValidationRequired, "organization ID is required")
+	}
+
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+	if _, err := getMembership(c, uu.orgRepository, orgID, principal.UserID); err != nil {
+		return nil, err
+	}
+	user, err := uu.userRepository.GetUserByID(c, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Deactivated {
+		return nil, domain.ErrUserDeactivated
+	}
+	if err := uu.userRepository.SetActiveOrganization(c, user.ID, orgID); err != nil {
+		return nil, err
+	}
+	user.ActiveOrgID = orgID
+	return uu.issueTokens(c, user, "")
 }
 
 func (uu *UserUsecase) PromoteUserToAdmin(ctx context.Context, identifier string) error {
 	// Validate input parameters
 	if identifier == "" {
-		return errors.New("identifier is required")
+		return domain.NewValidationError("identifier", domain.ValidationRequired, "identifier is required")
+	}
+	admin, _ := domain.BuiltInRole(domain.RoleAdmin)
+	if err := ensureCanGrant(ctx, admin); err != nil {
+		return err
 	}
-	
+
 	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
 	defer cancel()
 	return uu.userRepository.PromoteUserToAdmin(c, identifier)
 }
+
+// PurgeExpiredTokens removes the refresh tokens and revoked access tokens
+// that have expired and returns how many were removed. It is run
+// periodically in the background.
+func (uu *UserUsecase) PurgeExpiredTokens(ctx context.Context) (int64, error) {
+	c, cancel := context.WithTimeout(ctx, uu.contextTimeout)
+	defer cancel()
+	return uu.tokenRepository.PurgeExpiredTokens(c, time.Now())
+}
+
+// revokeAllUserTokens signs the user out everywhere.
+func revokeAllUserTokens(ctx context.Context, tokenRepository domain.ITokenRepository, userID string) error {
+	if err := tokenRepository.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+	return tokenRepository.RevokeUserAccessTokens(ctx, userID, time.Now())
+}
+
+const invalidEmailMessage = "invalid email format"
+
+func validateEmail(email string) error {
+	if !isEmailAddress(email) {
+		return domain.NewValidationError("email", domain.ValidationInvalid, invalidEmailMessage)
+	}
+	return nil
+}
+
+// isEmailAddress accepts a bare address only, e.g. "user@example.com".
+func isEmailAddress(email string) bool {
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Address == email
+}
+
+// newOpaqueToken returns a random, URL-safe token with 256 bits of entropy.
+func newOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 digest under which an opaque token is
+// stored, so a leaked database does not leak usable tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenLink appends token to base as the "token" query parameter.
+func tokenLink(base, token string) string {
+	u, err := url.Parse(base)
+	if err != nil {
+		return base + "?token=" + url.QueryEscape(token)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -3,6 +3,7 @@ package usecases
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -22,6 +23,11 @@ func (m *MockUserRepository) AddUser(ctx context.Context, user *domain.User) err
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) AddFirstAdmin(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
 	args := m.Called(ctx, email)
 	if args.Get(0) == nil {
@@ -43,14 +49,27 @@ func (m *MockUserRepository) IsUsersCollectionEmpty(ctx context.Context) (bool,
 	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockUserRepository) UserExistsByEmail(ctx context.Context, email string) (bool, error) {
-	args := m.Called(ctx, email)
-	return args.Bool(0), args.Error(1)
+func (m *MockUserRepository) GetUserByID(ctx context.Context, id string) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
 }
 
-func (m *MockUserRepository) UserExistsByUsername(ctx context.Context, username string) (bool, error) {
-	args := m.Called(ctx, username)
-	return args.Bool(0), args.Error(1)
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id string, hashedPassword string) error {
+	args := m.Called(ctx, id, hashedPassword)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateProfile(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
 }
 
 func (m *MockUserRepository) PromoteUserToAdmin(ctx context.Context, identifier string) error {
@@ -58,6 +77,91 @@ func (m *MockUserRepository) PromoteUserToAdmin(ctx context.Context, identifier
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) ListUsers(ctx context.Context, query domain.UserQuery) (*domain.UserPage, error) {
+	args := m.Called(ctx, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.UserPage), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateUserRole(ctx context.Context, id, role string) error {
+	args := m.Called(ctx, id, role)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetUserDeactivated(ctx context.Context, id string, deactivated bool) error {
+	args := m.Called(ctx, id, deactivated)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DeleteUser(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CountActiveAdmins(ctx context.Context) (int64, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) SetActiveOrganization(ctx context.Context, id, orgID string) error {
+	args := m.Called(ctx, id, orgID)
+	return args.Error(0)
+}
+
+type MockTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockTokenRepository) AddRefreshToken(ctx context.Context, token *domain.RefreshToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) GetRefreshTokenByHash(ctx context.Context, hash string) (*domain.RefreshToken, error) {
+	args := m.Called(ctx, hash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.RefreshToken), args.Error(1)
+}
+
+func (m *MockTokenRepository) MarkRefreshTokenUsed(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenRepository) RevokeTokenFamily(ctx context.Context, familyID string) error {
+	args := m.Called(ctx, familyID)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) RevokeUserRefreshTokens(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	args := m.Called(ctx, tokenID, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) RevokeUserAccessTokens(ctx context.Context, userID string, issuedBefore time.Time) error {
+	args := m.Called(ctx, userID, issuedBefore)
+	return args.Error(0)
+}
+
+func (m *MockTokenRepository) IsAccessTokenRevoked(ctx context.Context, tokenID, userID string, issuedAt time.Time) (bool, error) {
+	args := m.Called(ctx, tokenID, userID, issuedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockTokenRepository) PurgeExpiredTokens(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 type MockPasswordService struct {
 	mock.Mock
 }
@@ -76,19 +180,33 @@ type MockJWTService struct {
 	mock.Mock
 }
 
-func (m *MockJWTService) GenerateToken(user *domain.User) (string, error) {
+func (m *MockJWTService) GenerateToken(user *domain.User, membership *domain.Membership) (string, error) {
+	args := m.Called(user, membership)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockJWTService) GenerateEmailVerificationToken(user *domain.User) (string, error) {
 	args := m.Called(user)
 	return args.String(0), args.Error(1)
 }
 
+func (m *MockJWTService) ParseEmailVerificationToken(token string) (string, string, error) {
+	args := m.Called(token)
+	return args.String(0), args.String(1), args.Error(2)
+}
+
 // UserUsecaseTestSuite is a test suite for UserUsecase
 type UserUsecaseTestSuite struct {
 	suite.Suite
-	mockUserRepo      *MockUserRepository
+	mockUserRepo        *MockUserRepository
+	mockTokenRepo       *MockTokenRepository
+	mockOrgRepo         *MockOrganizationRepository
+	mockResetRepo       *MockPasswordResetRepository
 	mockPasswordService *MockPasswordService
-	mockJWTService    *MockJWTService
-	usecase           *UserUsecase
-	ctx               context.Context
+	mockJWTService      *MockJWTService
+	mockMailSender      *MockMailSender
+	usecase             *UserUsecase
+	ctx                 context.Context
 }
 
 // SetupSuite runs once before all tests in the suite
@@ -99,16 +217,24 @@ func (suite *UserUsecaseTestSuite) SetupSuite() {
 // SetupTest runs before each test
 func (suite *UserUsecaseTestSuite) SetupTest() {
 	suite.mockUserRepo = new(MockUserRepository)
+	suite.mockTokenRepo = new(MockTokenRepository)
+	suite.mockOrgRepo = new(MockOrganizationRepository)
+	suite.mockResetRepo = new(MockPasswordResetRepository)
 	suite.mockPasswordService = new(MockPasswordService)
 	suite.mockJWTService = new(MockJWTService)
-	suite.usecase = NewUserUsecase(suite.mockUserRepo, suite.mockPasswordService, suite.mockJWTService, 5*time.Second)
+	suite.mockMailSender = new(MockMailSender)
+	suite.usecase = NewUserUsecase(suite.mockUserRepo, suite.mockTokenRepo, suite.mockOrgRepo, suite.mockResetRepo, suite.mockPasswordService, suite.mockJWTService, suite.mockMailSender, "https://app.example.com/verify", false, 5*time.Second)
 }
 
 // TearDownTest runs after each test
 func (suite *UserUsecaseTestSuite) TearDownTest() {
 	suite.mockUserRepo.AssertExpectations(suite.T())
+	suite.mockTokenRepo.AssertExpectations(suite.T())
+	suite.mockOrgRepo.AssertExpectations(suite.T())
+	suite.mockResetRepo.AssertExpectations(suite.T())
 	suite.mockPasswordService.AssertExpectations(suite.T())
 	suite.mockJWTService.AssertExpectations(suite.T())
+	suite.mockMailSender.AssertExpectations(suite.T())
 }
 
 // TestRegisterUserSuite tests the RegisterUser method
@@ -120,45 +246,56 @@ func (suite *UserUsecaseTestSuite) TestRegisterUserSuite() {
 		hashedPassword := "hashed_password"
 
 		// Mock repository calls
-		suite.mockUserRepo.On("UserExistsByEmail", mock.AnythingOfType("*context.timerCtx"), email).Return(false, nil)
-		suite.mockUserRepo.On("UserExistsByUsername", mock.AnythingOfType("*context.timerCtx"), username).Return(false, nil)
-		suite.mockUserRepo.On("IsUsersCollectionEmpty", mock.AnythingOfType("*context.timerCtx")).Return(true, nil)
-		suite.mockPasswordService.On("HashPassword", password).Return(hashedPassword, nil)
-		suite.mockUserRepo.On("AddUser", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("*domain.User")).Return(nil)
+		suite.mockUserRepo.On("IsUsersCollectionEmpty", mock.AnythingOfType("*context.timerCtx")).Return(true, nil).Once()
+		suite.mockPasswordService.On("HashPassword", password).Return(hashedPassword, nil).Once()
+		// The first admin is verified right away and gets no email.
+		suite.mockUserRepo.On("AddFirstAdmin", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(u *domain.User) bool {
+			return u.EmailVerified && u.Role == domain.RoleAdmin
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.User).ID = "new_user_id"
+		}).Return(nil).Once()
 
-		role, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
+		registered, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
 
 		suite.NoError(err)
-		suite.Equal("admin", role)
+		suite.Equal("admin", registered.Role)
+		suite.Equal("new_user_id", registered.ID)
 	})
 
 	suite.Run("Success_RegularUser", func() {
 		// Create new mocks for this specific test to avoid interference
 		mockUserRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockTokenRepository)
 		mockPasswordService := new(MockPasswordService)
 		mockJWTService := new(MockJWTService)
-		usecase := NewUserUsecase(mockUserRepo, mockPasswordService, mockJWTService, 5*time.Second)
-		
+		mockMailSender := new(MockMailSender)
+		usecase := NewUserUsecase(mockUserRepo, mockTokenRepo, new(MockOrganizationRepository), new(MockPasswordResetRepository), mockPasswordService, mockJWTService, mockMailSender, "https://app.example.com/verify", false, 5*time.Second)
+
 		username := "testuser"
 		email := "test@example.com"
 		password := "password123"
 		hashedPassword := "hashed_password"
 
 		// Mock repository calls
-		mockUserRepo.On("UserExistsByEmail", mock.AnythingOfType("*context.timerCtx"), email).Return(false, nil)
-		mockUserRepo.On("UserExistsByUsername", mock.AnythingOfType("*context.timerCtx"), username).Return(false, nil)
 		mockUserRepo.On("IsUsersCollectionEmpty", mock.AnythingOfType("*context.timerCtx")).Return(false, nil)
 		mockPasswordService.On("HashPassword", password).Return(hashedPassword, nil)
-		mockUserRepo.On("AddUser", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("*domain.User")).Return(nil)
+		mockUserRepo.On("AddUser", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(u *domain.User) bool {
+			return !u.EmailVerified
+		})).Return(nil)
+		mockJWTService.On("GenerateEmailVerificationToken", mock.AnythingOfType("*domain.User")).Return("verify_token", nil)
+		mockMailSender.On("Send", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(m *domain.Mail) bool {
+			return m.To == email && strings.Contains(m.Body, "https://app.example.com/verify?token=verify_token")
+		})).Return(nil)
 
-		role, err := usecase.RegisterUser(suite.ctx, username, email, password)
+		registered, err := usecase.RegisterUser(suite.ctx, username, email, password)
 
 		suite.NoError(err)
-		suite.Equal("user", role)
-		
+		suite.Equal("user", registered.Role)
+
 		mockUserRepo.AssertExpectations(suite.T())
 		mockPasswordService.AssertExpectations(suite.T())
 		mockJWTService.AssertExpectations(suite.T())
+		mockMailSender.AssertExpectations(suite.T())
 	})
 
 	suite.Run("EmptyUsername", func() {
@@ -166,11 +303,11 @@ func (suite *UserUsecaseTestSuite) TestRegisterUserSuite() {
 		email := "test@example.com"
 		password := "password123"
 
-		role, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
+		registered, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
 
 		suite.Error(err)
 		suite.Equal("username is required", err.Error())
-		suite.Empty(role)
+		suite.Nil(registered)
 	})
 
 	suite.Run("EmptyEmail", func() {
@@ -178,11 +315,11 @@ func (suite *UserUsecaseTestSuite) TestRegisterUserSuite() {
 		email := ""
 		password := "password123"
 
-		role, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
+		registered, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
 
 		suite.Error(err)
 		suite.Equal("email is required", err.Error())
-		suite.Empty(role)
+		suite.Nil(registered)
 	})
 
 	suite.Run("EmptyPassword", func() {
@@ -190,11 +327,11 @@ func (suite *UserUsecaseTestSuite) TestRegisterUserSuite() {
 		email := "test@example.com"
 		password := ""
 
-		role, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
+		registered, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
 
 		suite.Error(err)
 		suite.Equal("password is required", err.Error())
-		suite.Empty(role)
+		suite.Nil(registered)
 	})
 
 	suite.Run("ShortPassword", func() {
@@ -202,11 +339,11 @@ func (suite *UserUsecaseTestSuite) TestRegisterUserSuite() {
 		email := "test@example.com"
 		password := "12345" // Less than 6 characters
 
-		role, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
+		registered, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
 
 		suite.Error(err)
 		suite.Equal("password must be at least 6 characters long", err.Error())
-		suite.Empty(role)
+		suite.Nil(registered)
 	})
 
 	suite.Run("InvalidEmail", func() {
@@ -214,11 +351,30 @@ func (suite *UserUsecaseTestSuite) TestRegisterUserSuite() {
 		email := "invalid-email" // No @ symbol
 		password := "password123"
 
-		role, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
+		registered, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
 
 		suite.Error(err)
 		suite.Equal("invalid email format", err.Error())
-		suite.Empty(role)
+		suite.Nil(registered)
+	})
+
+	suite.Run("EveryInvalidField", func() {
+		registered, err := suite.usecase.RegisterUser(suite.ctx, "", "invalid-email", "12345")
+
+		var errs domain.ValidationErrors
+		suite.Require().ErrorAs(err, &errs)
+		suite.Len(errs, 3)
+		suite.Equal([]string{"username", "email", "password"}, []string{errs[0].Field, errs[1].Field, errs[2].Field})
+		suite.Equal([]string{domain.ValidationRequired, domain.ValidationInvalid, domain.ValidationTooShort}, []string{errs[0].Code, errs[1].Code, errs[2].Code})
+		suite.Nil(registered)
+	})
+
+	suite.Run("EmailWithDisplayName", func() {
+		registered, err := suite.usecase.RegisterUser(suite.ctx, "testuser", "Test <test@example.com>", "password123")
+
+		suite.Error(err)
+		suite.Equal("invalid email format", err.Error())
+		suite.Nil(registered)
 	})
 
 	suite.Run("EmailAlreadyExists", func() {
@@ -226,13 +382,28 @@ func (suite *UserUsecaseTestSuite) TestRegisterUserSuite() {
 		email := "existing@example.com"
 		password := "password123"
 
-		suite.mockUserRepo.On("UserExistsByEmail", mock.AnythingOfType("*context.timerCtx"), email).Return(true, nil)
+		suite.mockUserRepo.On("IsUsersCollectionEmpty", mock.AnythingOfType("*context.timerCtx")).Return(false, nil).Once()
+		suite.mockPasswordService.On("HashPassword", password).Return("hashed_password", nil).Once()
+		// The repository refuses the taken email; no verification is sent.
+		suite.mockUserRepo.On("AddUser", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(u *domain.User) bool {
+			return u.Email == email
+		})).Return(domain.ErrEmailTaken).Once()
 
-		role, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
+		registered, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
 
 		suite.Error(err)
 		suite.Equal("email already registered", err.Error())
-		suite.Empty(role)
+		suite.ErrorIs(err, domain.ErrConflict)
+		suite.Nil(registered)
+	})
+
+	suite.Run("EmptinessCheckFails", func() {
+		suite.mockUserRepo.On("IsUsersCollectionEmpty", mock.AnythingOfType("*context.timerCtx")).Return(false, errors.New("db down")).Once()
+
+		registered, err := suite.usecase.RegisterUser(suite.ctx, "downuser", "down@example.com", "password123")
+
+		suite.EqualError(err, "db down")
+		suite.Nil(registered)
 	})
 
 	suite.Run("UsernameAlreadyExists", func() {
@@ -240,38 +411,84 @@ func (suite *UserUsecaseTestSuite) TestRegisterUserSuite() {
 		email := "test@example.com"
 		password := "password123"
 
-		suite.mockUserRepo.On("UserExistsByEmail", mock.AnythingOfType("*context.timerCtx"), email).Return(false, nil)
-		suite.mockUserRepo.On("UserExistsByUsername", mock.AnythingOfType("*context.timerCtx"), username).Return(true, nil)
+		suite.mockUserRepo.On("IsUsersCollectionEmpty", mock.AnythingOfType("*context.timerCtx")).Return(false, nil).Once()
+		suite.mockPasswordService.On("HashPassword", password).Return("hashed_password", nil).Once()
+		suite.mockUserRepo.On("AddUser", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(u *domain.User) bool {
+			return u.Username == username
+		})).Return(domain.ErrUsernameTaken).Once()
 
-		role, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
+		registered, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
 
 		suite.Error(err)
 		suite.Equal("username already taken", err.Error())
-		suite.Empty(role)
+		suite.Nil(registered)
+	})
+
+	suite.Run("LostFirstAdminRace", func() {
+		username := "second"
+		email := "second@example.com"
+		password := "password123"
+
+		// Both registrations saw an empty store, but the other one became
+		// the first admin, so this one ends up a regular, unverified user.
+		suite.mockUserRepo.On("IsUsersCollectionEmpty", mock.AnythingOfType("*context.timerCtx")).Return(true, nil).Once()
+		suite.mockPasswordService.On("HashPassword", password).Return("hashed_password", nil).Once()
+		suite.mockUserRepo.On("AddFirstAdmin", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(u *domain.User) bool {
+			return u.Email == email
+		})).Return(domain.ErrFirstAdminExists).Once()
+		suite.mockUserRepo.On("AddUser", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(u *domain.User) bool {
+			return u.Email == email && u.Role == domain.RoleUser && !u.EmailVerified
+		})).Return(nil).Once()
+		suite.mockJWTService.On("GenerateEmailVerificationToken", mock.MatchedBy(func(u *domain.User) bool {
+			return u.Email == email
+		})).Return("verify_token", nil).Once()
+		suite.mockMailSender.On("Send", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(m *domain.Mail) bool {
+			return m.To == email
+		})).Return(nil).Once()
+
+		registered, err := suite.usecase.RegisterUser(suite.ctx, username, email, password)
+
+		suite.NoError(err)
+		suite.Equal(domain.RoleUser, registered.Role)
+		suite.False(registered.EmailVerified)
+	})
+
+	suite.Run("FirstAdminFails", func() {
+		email := "first@example.com"
+		suite.mockUserRepo.On("IsUsersCollectionEmpty", mock.AnythingOfType("*context.timerCtx")).Return(true, nil).Once()
+		suite.mockPasswordService.On("HashPassword", "password123").Return("hashed_password", nil).Once()
+		suite.mockUserRepo.On("AddFirstAdmin", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(u *domain.User) bool {
+			return u.Email == email
+		})).Return(errors.New("db down")).Once()
+
+		registered, err := suite.usecase.RegisterUser(suite.ctx, "first", email, "password123")
+
+		suite.EqualError(err, "db down")
+		suite.Nil(registered)
 	})
 
 	suite.Run("PasswordHashingError", func() {
 		// Create new mocks for this specific test to avoid interference
 		mockUserRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockTokenRepository)
 		mockPasswordService := new(MockPasswordService)
 		mockJWTService := new(MockJWTService)
-		usecase := NewUserUsecase(mockUserRepo, mockPasswordService, mockJWTService, 5*time.Second)
-		
+		mockMailSender := new(MockMailSender)
+		usecase := NewUserUsecase(mockUserRepo, mockTokenRepo, new(MockOrganizationRepository), new(MockPasswordResetRepository), mockPasswordService, mockJWTService, mockMailSender, "https://app.example.com/verify", false, 5*time.Second)
+
 		username := "testuser"
 		email := "test@example.com"
 		password := "password123"
 
-		mockUserRepo.On("UserExistsByEmail", mock.AnythingOfType("*context.timerCtx"), email).Return(false, nil)
-		mockUserRepo.On("UserExistsByUsername", mock.AnythingOfType("*context.timerCtx"), username).Return(false, nil)
 		mockUserRepo.On("IsUsersCollectionEmpty", mock.AnythingOfType("*context.timerCtx")).Return(false, nil)
 		mockPasswordService.On("HashPassword", password).Return("", errors.New("hashing error"))
 
-		role, err := usecase.RegisterUser(suite.ctx, username, email, password)
+		registered, err := usecase.RegisterUser(suite.ctx, username, email, password)
 
 		suite.Error(err)
 		suite.Equal("hashing error", err.Error())
-		suite.Empty(role)
-		
+		suite.Nil(registered)
+
 		mockUserRepo.AssertExpectations(suite.T())
 		mockPasswordService.AssertExpectations(suite.T())
 		mockJWTService.AssertExpectations(suite.T())
@@ -295,13 +512,17 @@ func (suite *UserUsecaseTestSuite) TestLoginUserSuite() {
 
 		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), usernameOrEmail).Return(user, nil)
 		suite.mockPasswordService.On("CheckPasswordHash", password, user.Password).Return(true)
-		suite.mockJWTService.On("GenerateToken", user).Return(token, nil)
+		suite.mockJWTService.On("GenerateToken", user, mock.AnythingOfType("*domain.Membership")).Return(token, nil)
+		suite.mockTokenRepo.On("AddRefreshToken", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(rt *domain.RefreshToken) bool {
+			return rt.UserID == "user123" && rt.FamilyID != "" && rt.TokenHash != "" && rt.ExpiresAt.After(time.Now())
+		})).Return(nil)
 
-		resultToken, role, err := suite.usecase.LoginUser(suite.ctx, usernameOrEmail, password)
+		resultToken, account, err := suite.usecase.LoginUser(suite.ctx, usernameOrEmail, password)
 
 		suite.NoError(err)
-		suite.Equal(token, resultToken)
-		suite.Equal("user", role)
+		suite.Equal(token, resultToken.AccessToken)
+		suite.NotEmpty(resultToken.RefreshToken)
+		suite.Equal("user", account.Role)
 	})
 
 	suite.Run("Success_WithUsername", func() {
@@ -317,55 +538,70 @@ func (suite *UserUsecaseTestSuite) TestLoginUserSuite() {
 			Role:     "admin",
 		}
 
-		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), usernameOrEmail).Return(nil, errors.New("user not found"))
+		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), usernameOrEmail).Return(nil, domain.ErrUserNotFound)
 		suite.mockUserRepo.On("GetUserByUsername", mock.AnythingOfType("*context.timerCtx"), usernameOrEmail).Return(user, nil)
 		suite.mockPasswordService.On("CheckPasswordHash", password, user.Password).Return(true)
-		suite.mockJWTService.On("GenerateToken", user).Return(token, nil)
+		suite.mockJWTService.On("GenerateToken", user, mock.AnythingOfType("*domain.Membership")).Return(token, nil)
+		suite.mockTokenRepo.On("AddRefreshToken", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(rt *domain.RefreshToken) bool {
+			return rt.UserID == "user123" && rt.FamilyID != "" && rt.TokenHash != "" && rt.ExpiresAt.After(time.Now())
+		})).Return(nil)
 
-		resultToken, role, err := suite.usecase.LoginUser(suite.ctx, usernameOrEmail, password)
+		resultToken, account, err := suite.usecase.LoginUser(suite.ctx, usernameOrEmail, password)
 
 		suite.NoError(err)
-		suite.Equal(token, resultToken)
-		suite.Equal("admin", role)
+		suite.Equal(token, resultToken.AccessToken)
+		suite.NotEmpty(resultToken.RefreshToken)
+		suite.Equal("admin", account.Role)
 	})
 
 	suite.Run("EmptyUsernameOrEmail", func() {
 		usernameOrEmail := ""
 		password := "password123"
 
-		resultToken, role, err := suite.usecase.LoginUser(suite.ctx, usernameOrEmail, password)
+		resultToken, account, err := suite.usecase.LoginUser(suite.ctx, usernameOrEmail, password)
 
 		suite.Error(err)
 		suite.Equal("username or email is required", err.Error())
-		suite.Empty(resultToken)
-		suite.Empty(role)
+		suite.Nil(resultToken)
+		suite.Nil(account)
 	})
 
 	suite.Run("EmptyPassword", func() {
 		usernameOrEmail := "testuser"
 		password := ""
 
-		resultToken, role, err := suite.usecase.LoginUser(suite.ctx, usernameOrEmail, password)
+		resultToken, account, err := suite.usecase.LoginUser(suite.ctx, usernameOrEmail, password)
 
 		suite.Error(err)
 		suite.Equal("password is required", err.Error())
-		suite.Empty(resultToken)
-		suite.Empty(role)
+		suite.Nil(resultToken)
+		suite.Nil(account)
 	})
 
 	suite.Run("UserNotFound", func() {
 		usernameOrEmail := "nonexistent"
 		password := "password123"
 
-		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), usernameOrEmail).Return(nil, errors.New("user not found"))
-		suite.mockUserRepo.On("GetUserByUsername", mock.AnythingOfType("*context.timerCtx"), usernameOrEmail).Return(nil, errors.New("user not found"))
+		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), usernameOrEmail).Return(nil, domain.ErrUserNotFound)
+		suite.mockUserRepo.On("GetUserByUsername", mock.AnythingOfType("*context.timerCtx"), usernameOrEmail).Return(nil, domain.ErrUserNotFound)
 
-		resultToken, role, err := suite.usecase.LoginUser(suite.ctx, usernameOrEmail, password)
+		resultToken, account, err := suite.usecase.LoginUser(suite.ctx, usernameOrEmail, password)
 
 		suite.Error(err)
 		suite.Equal("invalid email/username or password", err.Error())
-		suite.Empty(resultToken)
-		suite.Empty(role)
+		suite.ErrorIs(err, domain.ErrUnauthorized)
+		suite.Nil(resultToken)
+		suite.Nil(account)
+	})
+
+	suite.Run("RepositoryError", func() {
+		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), "flaky@example.com").Return(nil, errors.New("db down"))
+
+		resultToken, account, err := suite.usecase.LoginUser(suite.ctx, "flaky@example.com", "password123")
+
+		suite.EqualError(err, "db down")
+		suite.Nil(resultToken)
+		suite.Nil(account)
 	})
 
 	suite.Run("WrongPassword", func() {
@@ -383,21 +619,35 @@ func (suite *UserUsecaseTestSuite) TestLoginUserSuite() {
 		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), usernameOrEmail).Return(user, nil)
 		suite.mockPasswordService.On("CheckPasswordHash", password, user.Password).Return(false)
 
-		resultToken, role, err := suite.usecase.LoginUser(suite.ctx, usernameOrEmail, password)
+		resultToken, account, err := suite.usecase.LoginUser(suite.ctx, usernameOrEmail, password)
 
 		suite.Error(err)
 		suite.Equal("invalid email/username or password", err.Error())
-		suite.Empty(resultToken)
-		suite.Empty(role)
+		suite.Nil(resultToken)
+		suite.Nil(account)
+	})
+
+	suite.Run("DeactivatedUser", func() {
+		user := &domain.User{ID: "user555", Username: "blocked", Email: "blocked@example.com", Password: "hashed_password", Role: "user", Deactivated: true}
+		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), "blocked@example.com").Return(user, nil)
+		suite.mockPasswordService.On("CheckPasswordHash", "password123", user.Password).Return(true)
+
+		tokens, account, err := suite.usecase.LoginUser(suite.ctx, "blocked@example.com", "password123")
+
+		suite.ErrorIs(err, domain.ErrUserDeactivated)
+		suite.Nil(tokens)
+		suite.Nil(account)
 	})
 
 	suite.Run("JWTGenerationError", func() {
 		// Create new mocks for this specific test
 		mockUserRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockTokenRepository)
 		mockPasswordService := new(MockPasswordService)
 		mockJWTService := new(MockJWTService)
-		usecase := NewUserUsecase(mockUserRepo, mockPasswordService, mockJWTService, 5*time.Second)
-		
+		mockMailSender := new(MockMailSender)
+		usecase := NewUserUsecase(mockUserRepo, mockTokenRepo, new(MockOrganizationRepository), new(MockPasswordResetRepository), mockPasswordService, mockJWTService, mockMailSender, "https://app.example.com/verify", false, 5*time.Second)
+
 		usernameOrEmail := "test@example.com"
 		password := "password123"
 
@@ -411,19 +661,479 @@ func (suite *UserUsecaseTestSuite) TestLoginUserSuite() {
 
 		mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), usernameOrEmail).Return(user, nil)
 		mockPasswordService.On("CheckPasswordHash", password, user.Password).Return(true)
-		mockJWTService.On("GenerateToken", user).Return("", errors.New("JWT generation error"))
+		mockJWTService.On("GenerateToken", user, mock.AnythingOfType("*domain.Membership")).Return("", errors.New("JWT generation error"))
 
-		resultToken, role, err := usecase.LoginUser(suite.ctx, usernameOrEmail, password)
+		resultToken, account, err := usecase.LoginUser(suite.ctx, usernameOrEmail, password)
 
 		suite.Error(err)
 		suite.Equal("JWT generation error", err.Error())
-		suite.Empty(resultToken)
-		suite.Empty(role)
-		
+		suite.Nil(resultToken)
+		suite.Nil(account)
+
 		mockUserRepo.AssertExpectations(suite.T())
 		mockPasswordService.AssertExpectations(suite.T())
 		mockJWTService.AssertExpectations(suite.T())
 	})
+
+	suite.Run("RefreshTokenStoreError", func() {
+		mockUserRepo := new(MockUserRepository)
+		mockTokenRepo := new(MockTokenRepository)
+		mockPasswordService := new(MockPasswordService)
+		mockJWTService := new(MockJWTService)
+		mockMailSender := new(MockMailSender)
+		usecase := NewUserUsecase(mockUserRepo, mockTokenRepo, new(MockOrganizationRepository), new(MockPasswordResetRepository), mockPasswordService, mockJWTService, mockMailSender, "https://app.example.com/verify", false, 5*time.Second)
+
+		user := &domain.User{ID: "user123", Username: "testuser", Password: "hashed_password", Role: "user"}
+
+		mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), "testuser").Return(user, nil)
+		mockPasswordService.On("CheckPasswordHash", "password123", user.Password).Return(true)
+		mockJWTService.On("GenerateToken", user, mock.AnythingOfType("*domain.Membership")).Return("jwt_token", nil)
+		mockTokenRepo.On("AddRefreshToken", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("*domain.RefreshToken")).Return(errors.New("db down"))
+
+		resultToken, account, err := usecase.LoginUser(suite.ctx, "testuser", "password123")
+
+		suite.Error(err)
+		suite.Equal("db down", err.Error())
+		suite.Nil(resultToken)
+		suite.Nil(account)
+
+		mockUserRepo.AssertExpectations(suite.T())
+		mockTokenRepo.AssertExpectations(suite.T())
+	})
+}
+
+// TestRefreshTokensSuite tests refresh token rotation
+func (suite *UserUsecaseTestSuite) TestRefreshTokensSuite() {
+	user := &domain.User{ID: "user123", Username: "testuser", Role: "user"}
+
+	suite.Run("Success", func() {
+		stored := &domain.RefreshToken{
+			ID:        "rt1",
+			UserID:    "user123",
+			FamilyID:  "family1",
+			TokenHash: hashToken("refresh-token"),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+
+		suite.mockTokenRepo.On("GetRefreshTokenByHash", mock.AnythingOfType("*context.timerCtx"), hashToken("refresh-token")).Return(stored, nil)
+		suite.mockTokenRepo.On("MarkRefreshTokenUsed", mock.AnythingOfType("*context.timerCtx"), "rt1").Return(true, nil)
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user123").Return(user, nil)
+		suite.mockJWTService.On("GenerateToken", user, mock.AnythingOfType("*domain.Membership")).Return("new_jwt", nil)
+		suite.mockTokenRepo.On("AddRefreshToken", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(rt *domain.RefreshToken) bool {
+			return rt.FamilyID == "family1" && rt.UserID == "user123"
+		})).Return(nil)
+
+		tokens, err := suite.usecase.RefreshTokens(suite.ctx, "refresh-token")
+
+		suite.NoError(err)
+		suite.Equal("new_jwt", tokens.AccessToken)
+		suite.NotEqual("refresh-token", tokens.RefreshToken)
+	})
+
+	suite.Run("EmptyToken", func() {
+		tokens, err := suite.usecase.RefreshTokens(suite.ctx, "")
+
+		suite.Error(err)
+		suite.Equal("refresh token is required", err.Error())
+		suite.Nil(tokens)
+	})
+
+	suite.Run("UnknownToken", func() {
+		suite.mockTokenRepo.On("GetRefreshTokenByHash", mock.AnythingOfType("*context.timerCtx"), hashToken("unknown")).Return(nil, domain.ErrNotFound)
+
+		tokens, err := suite.usecase.RefreshTokens(suite.ctx, "unknown")
+
+		suite.Error(err)
+		suite.Equal("invalid refresh token", err.Error())
+		suite.Nil(tokens)
+	})
+
+	suite.Run("RevokedToken", func() {
+		stored := &domain.RefreshToken{ID: "rt2", UserID: "user123", FamilyID: "family2", ExpiresAt: time.Now().Add(time.Hour), Revoked: true}
+		suite.mockTokenRepo.On("GetRefreshTokenByHash", mock.AnythingOfType("*context.timerCtx"), hashToken("revoked")).Return(stored, nil)
+
+		tokens, err := suite.usecase.RefreshTokens(suite.ctx, "revoked")
+
+		suite.Error(err)
+		suite.Equal("invalid refresh token", err.Error())
+		suite.Nil(tokens)
+	})
+
+	suite.Run("ExpiredToken", func() {
+		stored := &domain.RefreshToken{ID: "rt3", UserID: "user123", FamilyID: "family3", ExpiresAt: time.Now().Add(-time.Minute)}
+		suite.mockTokenRepo.On("GetRefreshTokenByHash", mock.AnythingOfType("*context.timerCtx"), hashToken("expired")).Return(stored, nil)
+
+		tokens, err := suite.usecase.RefreshTokens(suite.ctx, "expired")
+
+		suite.Error(err)
+		suite.Equal("refresh token has expired", err.Error())
+		suite.Nil(tokens)
+	})
+
+	suite.Run("ReuseRevokesFamily", func() {
+		stored := &domain.RefreshToken{
+			ID:        "rt4",
+			UserID:    "user123",
+			FamilyID:  "family4",
+			ExpiresAt: time.Now().Add(time.Hour),
+			UsedAt:    time.Now().Add(-time.Minute),
+		}
+		suite.mockTokenRepo.On("GetRefreshTokenByHash", mock.AnythingOfType("*context.timerCtx"), hashToken("reused")).Return(stored, nil)
+		suite.mockTokenRepo.On("RevokeTokenFamily", mock.AnythingOfType("*context.timerCtx"), "family4").Return(nil)
+
+		tokens, err := suite.usecase.RefreshTokens(suite.ctx, "reused")
+
+		suite.Error(err)
+		suite.Equal("refresh token reuse detected; please log in again", err.Error())
+		suite.Nil(tokens)
+	})
+
+	suite.Run("ConcurrentReuseRevokesFamily", func() {
+		stored := &domain.RefreshToken{ID: "rt5", UserID: "user123", FamilyID: "family5", ExpiresAt: time.Now().Add(time.Hour)}
+		suite.mockTokenRepo.On("GetRefreshTokenByHash", mock.AnythingOfType("*context.timerCtx"), hashToken("raced")).Return(stored, nil)
+		suite.mockTokenRepo.On("MarkRefreshTokenUsed", mock.AnythingOfType("*context.timerCtx"), "rt5").Return(false, nil)
+		suite.mockTokenRepo.On("RevokeTokenFamily", mock.AnythingOfType("*context.timerCtx"), "family5").Return(nil)
+
+		tokens, err := suite.usecase.RefreshTokens(suite.ctx, "raced")
+
+		suite.Error(err)
+		suite.Equal("refresh token reuse detected; please log in again", err.Error())
+		suite.Nil(tokens)
+	})
+}
+
+// TestLogoutSuite tests the Logout method
+func (suite *UserUsecaseTestSuite) TestLogoutSuite() {
+	expiresAt := time.Now().Add(10 * time.Minute)
+	loggedIn := func(tokenID string) context.Context {
+		return domain.WithPrincipal(suite.ctx, &domain.Principal{UserID: "user123", TokenID: tokenID, ExpiresAt: expiresAt})
+	}
+
+	suite.Run("AccessTokenOnly", func() {
+		suite.mockTokenRepo.On("RevokeAccessToken", mock.AnythingOfType("*context.timerCtx"), "jti1", expiresAt).Return(nil)
+
+		err := suite.usecase.Logout(loggedIn("jti1"), "")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("WithRefreshToken", func() {
+		stored := &domain.RefreshToken{ID: "rt1", UserID: "user123", FamilyID: "family1"}
+		suite.mockTokenRepo.On("RevokeAccessToken", mock.AnythingOfType("*context.timerCtx"), "jti2", expiresAt).Return(nil)
+		suite.mockTokenRepo.On("GetRefreshTokenByHash", mock.AnythingOfType("*context.timerCtx"), hashToken("refresh-token")).Return(stored, nil)
+		suite.mockTokenRepo.On("RevokeTokenFamily", mock.AnythingOfType("*context.timerCtx"), "family1").Return(nil)
+
+		err := suite.usecase.Logout(loggedIn("jti2"), "refresh-token")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("RefreshTokenOfAnotherUser", func() {
+		stored := &domain.RefreshToken{ID: "rt9", UserID: "user999", FamilyID: "family9"}
+		suite.mockTokenRepo.On("RevokeAccessToken", mock.AnythingOfType("*context.timerCtx"), "jti3", expiresAt).Return(nil)
+		suite.mockTokenRepo.On("GetRefreshTokenByHash", mock.AnythingOfType("*context.timerCtx"), hashToken("someone-else")).Return(stored, nil)
+
+		err := suite.usecase.Logout(loggedIn("jti3"), "someone-else")
+
+		suite.Error(err)
+		suite.Equal("invalid refresh token", err.Error())
+	})
+
+	suite.Run("MissingTokenID", func() {
+		err := suite.usecase.Logout(loggedIn(""), "")
+
+		suite.Error(err)
+		suite.Equal("access token ID is required", err.Error())
+	})
+}
+
+// TestPurgeExpiredTokensSuite tests the PurgeExpiredTokens method
+func (suite *UserUsecaseTestSuite) TestPurgeExpiredTokensSuite() {
+	suite.Run("Success", func() {
+		suite.mockTokenRepo.On("PurgeExpiredTokens", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(now time.Time) bool {
+			return time.Since(now) < time.Minute
+		})).Return(int64(3), nil).Once()
+
+		purged, err := suite.usecase.PurgeExpiredTokens(suite.ctx)
+
+		suite.NoError(err)
+		suite.Equal(int64(3), purged)
+	})
+
+	suite.Run("RepositoryError", func() {
+		suite.mockTokenRepo.On("PurgeExpiredTokens", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("time.Time")).Return(int64(0), errors.New("db down")).Once()
+
+		purged, err := suite.usecase.PurgeExpiredTokens(suite.ctx)
+
+		suite.Error(err)
+		suite.Zero(purged)
+	})
+}
+
+// TestEmailVerificationSuite tests verifying, resending and requiring email
+// verification
+func (suite *UserUsecaseTestSuite) TestEmailVerificationSuite() {
+	suite.Run("LoginRefusedWhenUnverified", func() {
+		mockUserRepo := new(MockUserRepository)
+		mockPasswordService := new(MockPasswordService)
+		usecase := NewUserUsecase(mockUserRepo, new(MockTokenRepository), new(MockOrganizationRepository), new(MockPasswordResetRepository), mockPasswordService, new(MockJWTService), new(MockMailSender), "https://app.example.com/verify", true, 5*time.Second)
+		user := &domain.User{ID: "user123", Username: "testuser", Email: "test@example.com", Password: "hashed_password", Role: "user"}
+
+		mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), "test@example.com").Return(user, nil)
+		mockPasswordService.On("CheckPasswordHash", "password123", user.Password).Return(true)
+
+		tokens, account, err := usecase.LoginUser(suite.ctx, "test@example.com", "password123")
+
+		suite.ErrorIs(err, domain.ErrEmailNotVerified)
+		suite.Nil(tokens)
+		suite.Nil(account)
+		mockUserRepo.AssertExpectations(suite.T())
+		mockPasswordService.AssertExpectations(suite.T())
+	})
+
+	suite.Run("VerifyEmail_Success", func() {
+		user := &domain.User{ID: "user123", Email: "test@example.com"}
+		suite.mockJWTService.On("ParseEmailVerificationToken", "good-token").Return("user123", "test@example.com", nil)
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user123").Return(user, nil)
+		suite.mockUserRepo.On("MarkEmailVerified", mock.AnythingOfType("*context.timerCtx"), "user123").Return(nil)
+
+		err := suite.usecase.VerifyEmail(suite.ctx, "good-token")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("VerifyEmail_InvalidToken", func() {
+		suite.mockJWTService.On("ParseEmailVerificationToken", "bad-token").Return("", "", errors.New("bad signature"))
+
+		err := suite.usecase.VerifyEmail(suite.ctx, "bad-token")
+
+		suite.Error(err)
+		suite.Equal("invalid or expired verification token", err.Error())
+	})
+
+	suite.Run("VerifyEmail_EmailChanged", func() {
+		user := &domain.User{ID: "user456", Email: "new@example.com"}
+		suite.mockJWTService.On("ParseEmailVerificationToken", "old-token").Return("user456", "old@example.com", nil)
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user456").Return(user, nil)
+
+		err := suite.usecase.VerifyEmail(suite.ctx, "old-token")
+
+		suite.Error(err)
+		suite.Equal("invalid or expired verification token", err.Error())
+	})
+
+	suite.Run("VerifyEmail_EmptyToken", func() {
+		err := suite.usecase.VerifyEmail(suite.ctx, "")
+
+		suite.Error(err)
+		suite.Equal("verification token is required", err.Error())
+	})
+
+	suite.Run("Resend_Unverified", func() {
+		user := &domain.User{ID: "user789", Username: "pending", Email: "pending@example.com"}
+		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), "pending@example.com").Return(user, nil)
+		suite.mockJWTService.On("GenerateEmailVerificationToken", user).Return("fresh-token", nil)
+		suite.mockMailSender.On("Send", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(m *domain.Mail) bool {
+			return m.To == "pending@example.com" && strings.Contains(m.Body, "token=fresh-token")
+		})).Return(nil)
+
+		err := suite.usecase.ResendVerificationEmail(suite.ctx, "pending@example.com")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("Resend_AlreadyVerified", func() {
+		user := &domain.User{ID: "user999", Email: "done@example.com", EmailVerified: true}
+		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), "done@example.com").Return(user, nil)
+
+		err := suite.usecase.ResendVerificationEmail(suite.ctx, "done@example.com")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("Resend_UnknownEmail", func() {
+		suite.mockUserRepo.On("GetUserByEmail", mock.AnythingOfType("*context.timerCtx"), "nobody@example.com").Return(nil, domain.ErrUserNotFound)
+
+		err := suite.usecase.ResendVerificationEmail(suite.ctx, "nobody@example.com")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("AdminOverride", func() {
+		suite.mockUserRepo.On("MarkEmailVerified", mock.AnythingOfType("*context.timerCtx"), "user321").Return(nil)
+
+		err := suite.usecase.MarkEmailVerified(suite.ctx, "user321")
+
+		suite.NoError(err)
+	})
+
+	suite.Run("AdminOverride_UnknownUser", func() {
+		suite.mockUserRepo.On("MarkEmailVerified", mock.AnythingOfType("*context.timerCtx"), "missing").Return(domain.ErrUserNotFound)
+
+		err := suite.usecase.MarkEmailVerified(suite.ctx, "missing")
+
+		suite.Error(err)
+		suite.Equal("user not found", err.Error())
+	})
+}
+
+// TestProfileSuite tests reading and updating the authenticated user's profile
+func (suite *UserUsecaseTestSuite) TestProfileSuite() {
+	strPtr := func(s string) *string { return &s }
+
+	suite.Run("GetProfile_Success", func() {
+		user := &domain.User{ID: "user123", Username: "testuser", Email: "test@example.com"}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user123").Return(user, nil).Once()
+
+		result, err := suite.usecase.GetProfile(suite.ctx, "user123")
+
+		suite.NoError(err)
+		suite.Equal(user, result)
+	})
+
+	suite.Run("GetProfile_NotFound", func() {
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "ghost").Return(nil, domain.ErrUserNotFound)
+
+		result, err := suite.usecase.GetProfile(suite.ctx, "ghost")
+
+		suite.Error(err)
+		suite.Equal("user not found", err.Error())
+		suite.Nil(result)
+	})
+
+	suite.Run("UpdateProfile_Username", func() {
+		user := &domain.User{ID: "user200", Username: "old", Email: "same@example.com", EmailVerified: true}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user200").Return(user, nil).Once()
+		suite.mockUserRepo.On("UpdateProfile", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(u *domain.User) bool {
+			return u.ID == "user200" && u.Username == "renamed"
+		})).Return(nil).Once()
+
+		// Re-sending the current email is not a change.
+		result, err := suite.usecase.UpdateProfile(suite.ctx, "user200", strPtr("renamed"), strPtr("same@example.com"))
+
+		suite.NoError(err)
+		suite.Equal("renamed", result.Username)
+		suite.True(result.EmailVerified)
+	})
+
+	suite.Run("UpdateProfile_EmailNeedsVerification", func() {
+		user := &domain.User{ID: "user201", Username: "mover", Email: "old@example.com", EmailVerified: true}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user201").Return(user, nil).Once()
+		suite.mockUserRepo.On("UpdateProfile", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(u *domain.User) bool {
+			return u.ID == "user201" && u.Email == "new@example.com" && !u.EmailVerified
+		})).Return(nil).Once()
+		suite.mockJWTService.On("GenerateEmailVerificationToken", mock.MatchedBy(func(u *domain.User) bool {
+			return u.Email == "new@example.com"
+		})).Return("verify_token", nil).Once()
+		suite.mockMailSender.On("Send", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(m *domain.Mail) bool {
+			return m.To == "new@example.com"
+		})).Return(nil).Once()
+
+		result, err := suite.usecase.UpdateProfile(suite.ctx, "user201", nil, strPtr("new@example.com"))
+
+		suite.NoError(err)
+		suite.Equal("new@example.com", result.Email)
+		suite.False(result.EmailVerified)
+	})
+
+	suite.Run("UpdateProfile_EmailTaken", func() {
+		user := &domain.User{ID: "user202", Username: "someone", Email: "mine@example.com"}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user202").Return(user, nil).Once()
+		// The repository refuses the taken email.
+		suite.mockUserRepo.On("UpdateProfile", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(u *domain.User) bool {
+			return u.ID == "user202"
+		})).Return(domain.ErrEmailTaken).Once()
+
+		result, err := suite.usecase.UpdateProfile(suite.ctx, "user202", nil, strPtr("taken@example.com"))
+
+		suite.Error(err)
+		suite.Equal("email already registered", err.Error())
+		suite.Nil(result)
+	})
+
+	suite.Run("UpdateProfile_UsernameTaken", func() {
+		user := &domain.User{ID: "user203", Username: "someone", Email: "mine@example.com"}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user203").Return(user, nil).Once()
+		suite.mockUserRepo.On("UpdateProfile", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(u *domain.User) bool {
+			return u.ID == "user203"
+		})).Return(domain.ErrUsernameTaken).Once()
+
+		result, err := suite.usecase.UpdateProfile(suite.ctx, "user203", strPtr("taken"), nil)
+
+		suite.Error(err)
+		suite.Equal("username already taken", err.Error())
+		suite.Nil(result)
+	})
+
+	suite.Run("UpdateProfile_InvalidEmail", func() {
+		result, err := suite.usecase.UpdateProfile(suite.ctx, "user123", nil, strPtr("not-an-email"))
+
+		suite.Error(err)
+		suite.Equal("invalid email format", err.Error())
+		suite.Nil(result)
+	})
+
+	suite.Run("UpdateProfile_EmptyUsername", func() {
+		result, err := suite.usecase.UpdateProfile(suite.ctx, "user123", strPtr(""), nil)
+
+		suite.Error(err)
+		suite.Equal("username cannot be empty", err.Error())
+		suite.Nil(result)
+	})
+}
+
+// TestChangePasswordSuite tests the ChangePassword method
+func (suite *UserUsecaseTestSuite) TestChangePasswordSuite() {
+	suite.Run("Success", func() {
+		user := &domain.User{ID: "user300", Username: "testuser", Password: "old_hash", Role: "user"}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user300").Return(user, nil).Once()
+		suite.mockPasswordService.On("CheckPasswordHash", "oldpassword", "old_hash").Return(true)
+		suite.mockPasswordService.On("HashPassword", "newpassword").Return("new_hash", nil)
+		suite.mockUserRepo.On("UpdatePassword", mock.AnythingOfType("*context.timerCtx"), "user300", "new_hash").Return(nil)
+		suite.mockResetRepo.On("DeleteUserPasswordResets", mock.AnythingOfType("*context.timerCtx"), "user300").Return(nil)
+		suite.mockTokenRepo.On("RevokeUserRefreshTokens", mock.AnythingOfType("*context.timerCtx"), "user300").Return(nil)
+		suite.mockTokenRepo.On("RevokeUserAccessTokens", mock.AnythingOfType("*context.timerCtx"), "user300", mock.MatchedBy(func(cutoff time.Time) bool {
+			return !cutoff.After(time.Now()) && time.Since(cutoff) < 2*time.Second
+		})).Return(nil)
+		suite.mockJWTService.On("GenerateToken", user, mock.AnythingOfType("*domain.Membership")).Return("new_jwt", nil)
+		suite.mockTokenRepo.On("AddRefreshToken", mock.AnythingOfType("*context.timerCtx"), mock.MatchedBy(func(rt *domain.RefreshToken) bool {
+			return rt.UserID == "user300"
+		})).Return(nil)
+
+		tokens, err := suite.usecase.ChangePassword(suite.ctx, "user300", "oldpassword", "newpassword")
+
+		suite.NoError(err)
+		suite.Equal("new_jwt", tokens.AccessToken)
+		suite.NotEmpty(tokens.RefreshToken)
+	})
+
+	suite.Run("WrongCurrentPassword", func() {
+		user := &domain.User{ID: "user301", Password: "old_hash"}
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user301").Return(user, nil).Once()
+		suite.mockPasswordService.On("CheckPasswordHash", "wrongpassword", "old_hash").Return(false)
+
+		tokens, err := suite.usecase.ChangePassword(suite.ctx, "user301", "wrongpassword", "newpassword")
+
+		suite.Error(err)
+		suite.Equal("current password is incorrect", err.Error())
+		suite.Nil(tokens)
+	})
+
+	suite.Run("ShortNewPassword", func() {
+		tokens, err := suite.usecase.ChangePassword(suite.ctx, "user302", "oldpassword", "123")
+
+		suite.Error(err)
+		suite.Equal("password must be at least 6 characters long", err.Error())
+		suite.Nil(tokens)
+	})
+
+	suite.Run("MissingCurrentPassword", func() {
+		tokens, err := suite.usecase.ChangePassword(suite.ctx, "user302", "", "newpassword")
+
+		suite.Error(err)
+		suite.Equal("current password is required", err.Error())
+		suite.Nil(tokens)
+	})
 }
 
 // TestPromoteUserToAdminSuite tests the PromoteUserToAdmin method
@@ -433,7 +1143,7 @@ func (suite *UserUsecaseTestSuite) TestPromoteUserToAdminSuite() {
 
 		suite.mockUserRepo.On("PromoteUserToAdmin", mock.AnythingOfType("*context.timerCtx"), identifier).Return(nil)
 
-		err := suite.usecase.PromoteUserToAdmin(suite.ctx, identifier)
+		err := suite.usecase.PromoteUserToAdmin(withRole(suite.ctx, "admin123", domain.RoleAdmin), identifier)
 
 		suite.NoError(err)
 	})
@@ -441,25 +1151,61 @@ func (suite *UserUsecaseTestSuite) TestPromoteUserToAdminSuite() {
 	suite.Run("EmptyIdentifier", func() {
 		identifier := ""
 
-		err := suite.usecase.PromoteUserToAdmin(suite.ctx, identifier)
+		err := suite.usecase.PromoteUserToAdmin(withRole(suite.ctx, "admin123", domain.RoleAdmin), identifier)
 
 		suite.Error(err)
 		suite.Equal("identifier is required", err.Error())
 	})
 
+	suite.Run("CallerNotAdmin", func() {
+		manager := domain.WithPrincipal(suite.ctx, &domain.Principal{UserID: "pm1", Role: "project_manager", Permissions: []string{domain.PermissionUsersPromote}})
+
+		err := suite.usecase.PromoteUserToAdmin(manager, "testuser")
+
+		suite.ErrorIs(err, domain.ErrPermissionDenied)
+	})
+
 	suite.Run("Error", func() {
 		identifier := "nonexistent"
 
-		suite.mockUserRepo.On("PromoteUserToAdmin", mock.AnythingOfType("*context.timerCtx"), identifier).Return(errors.New("user not found"))
+		suite.mockUserRepo.On("PromoteUserToAdmin", mock.AnythingOfType("*context.timerCtx"), identifier).Return(domain.ErrUserNotFound)
 
-		err := suite.usecase.PromoteUserToAdmin(suite.ctx, identifier)
+		err := suite.usecase.PromoteUserToAdmin(withRole(suite.ctx, "admin123", domain.RoleAdmin), identifier)
 
-		suite.Error(err)
-		suite.Equal("user not found", err.Error())
+		suite.ErrorIs(err, domain.ErrUserNotFound)
+		suite.ErrorIs(err, domain.ErrNotFound)
+	})
+}
+
+// TestSwitchOrganizationSuite tests the SwitchOrganization method
+func (suite *UserUsecaseTestSuite) TestSwitchOrganizationSuite() {
+	suite.Run("Success", func() {
+		user := &domain.User{ID: "user1", Username: "alice", Role: domain.RoleUser}
+		membership := &domain.Membership{OrgID: "org1", UserID: "user1", Role: domain.OrgRoleAdmin}
+		suite.mockOrgRepo.On("GetMembership", mock.AnythingOfType("*context.timerCtx"), "org1", "user1").Return(membership, nil)
+		suite.mockUserRepo.On("GetUserByID", mock.AnythingOfType("*context.timerCtx"), "user1").Return(user, nil)
+		suite.mockUserRepo.On("SetActiveOrganization", mock.AnythingOfType("*context.timerCtx"), "user1", "org1").Return(nil)
+		suite.mockJWTService.On("GenerateToken", user, membership).Return("org_jwt", nil)
+		suite.mockTokenRepo.On("AddRefreshToken", mock.AnythingOfType("*context.timerCtx"), mock.AnythingOfType("*domain.RefreshToken")).Return(nil)
+
+		tokens, err := suite.usecase.SwitchOrganization(withRole(suite.ctx, "user1", domain.RoleUser), "org1")
+
+		suite.NoError(err)
+		suite.Equal("org_jwt", tokens.AccessToken)
+		suite.Equal("org1", user.ActiveOrgID)
+	})
+
+	suite.Run("NotMember", func() {
+		suite.mockOrgRepo.On("GetMembership", mock.AnythingOfType("*context.timerCtx"), "org2", "user2").Return(nil, domain.ErrOrganizationNotFound)
+
+		tokens, err := suite.usecase.SwitchOrganization(withRole(suite.ctx, "user2", domain.RoleUser), "org2")
+
+		suite.ErrorIs(err, domain.ErrOrganizationNotFound)
+		suite.Nil(tokens)
 	})
 }
 
 // TestUserUsecaseSuite runs the test suite
 func TestUserUsecaseSuite(t *testing.T) {
 	suite.Run(t, new(UserUsecaseTestSuite))
-} 
\ No newline at end of file
+}